@@ -16,6 +16,7 @@ package apiutil
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"slices"
@@ -350,6 +351,32 @@ func GetOrganizationByNamespaceRef(ctx context.Context, c client.Reader, namespa
 	}
 }
 
+// ErrLastSuperUser is returned when deleting or demoting the only super user of an organization.
+var ErrLastSuperUser = errors.New("organization must have at least one super user")
+
+// EnsureSuperUserRemains returns a forbidden error unless the organization has another super user than member.
+func EnsureSuperUserRemains(ctx context.Context, c client.Reader, member *dockyardsv1.Member) error {
+	var memberList dockyardsv1.MemberList
+	err := c.List(ctx, &memberList, client.InNamespace(member.Namespace))
+	if err != nil {
+		return err
+	}
+
+	for _, item := range memberList.Items {
+		if item.Name == member.Name {
+			continue
+		}
+
+		if item.Spec.Role == dockyardsv1.RoleSuperUser && item.DeletionTimestamp.IsZero() {
+			return nil
+		}
+	}
+
+	groupResource := dockyardsv1.GroupVersion.WithResource("members").GroupResource()
+
+	return apierrors.NewForbidden(groupResource, member.Name, ErrLastSuperUser)
+}
+
 func getOwner[T client.Object](ctx context.Context, c client.Client, o client.Object, ownerLabel string, owner T) error {
 	labels := o.GetLabels()
 	ownerName := labels[ownerLabel]
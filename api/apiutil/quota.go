@@ -0,0 +1,156 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiutil
+
+import (
+	"context"
+	"slices"
+
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetNodePoolResourceUsage returns the resources used by all replicas of the node pool.
+func GetNodePoolResourceUsage(nodePool *dockyardsv1.NodePool) corev1.ResourceList {
+	replicas := int64(1)
+	if nodePool.Spec.Replicas != nil {
+		replicas = int64(*nodePool.Spec.Replicas)
+	}
+
+	storage := resource.Quantity{}
+
+	quantity, hasStorage := nodePool.Spec.Resources[corev1.ResourceStorage]
+	if hasStorage {
+		storage.Add(quantity)
+	}
+
+	for _, storageResource := range nodePool.Spec.StorageResources {
+		storage.Add(storageResource.Quantity)
+	}
+
+	usage := corev1.ResourceList{
+		dockyardsv1.ResourceNodePool: *resource.NewQuantity(1, resource.DecimalSI),
+		corev1.ResourceCPU:           multiplyQuantity(nodePool.Spec.Resources[corev1.ResourceCPU], replicas),
+		corev1.ResourceMemory:        multiplyQuantity(nodePool.Spec.Resources[corev1.ResourceMemory], replicas),
+		corev1.ResourceStorage:       multiplyQuantity(storage, replicas),
+	}
+
+	return usage
+}
+
+// GetOrganizationResourceUsage returns the resources used by clusters and node pools in the namespace of the
+// organization, objects being deleted are not counted.
+func GetOrganizationResourceUsage(ctx context.Context, c client.Reader, organization *dockyardsv1.Organization) (corev1.ResourceList, error) {
+	usage := corev1.ResourceList{
+		dockyardsv1.ResourceCluster:  *resource.NewQuantity(0, resource.DecimalSI),
+		dockyardsv1.ResourceNodePool: *resource.NewQuantity(0, resource.DecimalSI),
+		corev1.ResourceCPU:           *resource.NewQuantity(0, resource.DecimalSI),
+		corev1.ResourceMemory:        *resource.NewQuantity(0, resource.BinarySI),
+		corev1.ResourceStorage:       *resource.NewQuantity(0, resource.BinarySI),
+	}
+
+	if organization.Spec.NamespaceRef == nil {
+		return usage, nil
+	}
+
+	var clusterList dockyardsv1.ClusterList
+	err := c.List(ctx, &clusterList, client.InNamespace(organization.Spec.NamespaceRef.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cluster := range clusterList.Items {
+		if !cluster.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		AddResourceList(usage, corev1.ResourceList{
+			dockyardsv1.ResourceCluster: *resource.NewQuantity(1, resource.DecimalSI),
+		})
+	}
+
+	var nodePoolList dockyardsv1.NodePoolList
+	err = c.List(ctx, &nodePoolList, client.InNamespace(organization.Spec.NamespaceRef.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, nodePool := range nodePoolList.Items {
+		if !nodePool.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		AddResourceList(usage, GetNodePoolResourceUsage(&nodePool))
+	}
+
+	return usage, nil
+}
+
+// AddResourceList adds the quantities of resources to list.
+func AddResourceList(list, resources corev1.ResourceList) {
+	for resourceName, quantity := range resources {
+		sum, hasResource := list[resourceName]
+		if !hasResource {
+			list[resourceName] = quantity.DeepCopy()
+
+			continue
+		}
+
+		sum.Add(quantity)
+		list[resourceName] = sum
+	}
+}
+
+// SubtractResourceList subtracts the quantities of resources from list.
+func SubtractResourceList(list, resources corev1.ResourceList) {
+	for resourceName, quantity := range resources {
+		difference, hasResource := list[resourceName]
+		if !hasResource {
+			continue
+		}
+
+		difference.Sub(quantity)
+		list[resourceName] = difference
+	}
+}
+
+// ExceededResourceQuotas returns the names of the resources limited by quotas where usage exceeds the limit.
+func ExceededResourceQuotas(quotas, usage corev1.ResourceList) []corev1.ResourceName {
+	var exceeded []corev1.ResourceName
+
+	for resourceName, limit := range quotas {
+		used, hasResource := usage[resourceName]
+		if !hasResource {
+			continue
+		}
+
+		if used.Cmp(limit) > 0 {
+			exceeded = append(exceeded, resourceName)
+		}
+	}
+
+	slices.Sort(exceeded)
+
+	return exceeded
+}
+
+func multiplyQuantity(quantity resource.Quantity, multiplier int64) resource.Quantity {
+	product := quantity.DeepCopy()
+	product.Mul(multiplier)
+
+	return product
+}
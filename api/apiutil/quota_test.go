@@ -0,0 +1,265 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiutil_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func resourceListStrings(list corev1.ResourceList) map[corev1.ResourceName]string {
+	strings := make(map[corev1.ResourceName]string)
+
+	for resourceName, quantity := range list {
+		strings[resourceName] = quantity.String()
+	}
+
+	return strings
+}
+
+func TestGetNodePoolResourceUsage(t *testing.T) {
+	tt := []struct {
+		name     string
+		nodePool dockyardsv1.NodePool
+		expected map[corev1.ResourceName]string
+	}{
+		{
+			name: "test without replicas",
+			nodePool: dockyardsv1.NodePool{
+				Spec: dockyardsv1.NodePoolSpec{
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("2"),
+						corev1.ResourceMemory: resource.MustParse("4Gi"),
+					},
+				},
+			},
+			expected: map[corev1.ResourceName]string{
+				dockyardsv1.ResourceNodePool: "1",
+				corev1.ResourceCPU:           "2",
+				corev1.ResourceMemory:        "4Gi",
+				corev1.ResourceStorage:       "0",
+			},
+		},
+		{
+			name: "test with replicas and storage resources",
+			nodePool: dockyardsv1.NodePool{
+				Spec: dockyardsv1.NodePoolSpec{
+					Replicas: ptr.To(int32(3)),
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU:     resource.MustParse("500m"),
+						corev1.ResourceMemory:  resource.MustParse("2Gi"),
+						corev1.ResourceStorage: resource.MustParse("10Gi"),
+					},
+					StorageResources: []dockyardsv1.NodePoolStorageResource{
+						{
+							Name:     "test",
+							Quantity: resource.MustParse("5Gi"),
+						},
+					},
+				},
+			},
+			expected: map[corev1.ResourceName]string{
+				dockyardsv1.ResourceNodePool: "1",
+				corev1.ResourceCPU:           "1500m",
+				corev1.ResourceMemory:        "6Gi",
+				corev1.ResourceStorage:       "45Gi",
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := apiutil.GetNodePoolResourceUsage(&tc.nodePool)
+			if !cmp.Equal(resourceListStrings(actual), tc.expected) {
+				t.Errorf("diff: %s", cmp.Diff(tc.expected, resourceListStrings(actual)))
+			}
+		})
+	}
+}
+
+func TestGetOrganizationResourceUsage(t *testing.T) {
+	now := metav1.Now()
+
+	organization := dockyardsv1.Organization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+		},
+		Spec: dockyardsv1.OrganizationSpec{
+			NamespaceRef: &corev1.LocalObjectReference{
+				Name: "testing",
+			},
+		},
+	}
+
+	lists := []client.ObjectList{
+		&dockyardsv1.ClusterList{
+			Items: []dockyardsv1.Cluster{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test",
+						Namespace: "testing",
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              "deleted",
+						Namespace:         "testing",
+						DeletionTimestamp: &now,
+						Finalizers: []string{
+							"testing",
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "other",
+						Namespace: "other",
+					},
+				},
+			},
+		},
+		&dockyardsv1.NodePoolList{
+			Items: []dockyardsv1.NodePool{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-control-plane",
+						Namespace: "testing",
+					},
+					Spec: dockyardsv1.NodePoolSpec{
+						Replicas: ptr.To(int32(3)),
+						Resources: corev1.ResourceList{
+							corev1.ResourceCPU:     resource.MustParse("2"),
+							corev1.ResourceMemory:  resource.MustParse("4Gi"),
+							corev1.ResourceStorage: resource.MustParse("20Gi"),
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-worker",
+						Namespace: "testing",
+					},
+					Spec: dockyardsv1.NodePoolSpec{
+						Replicas: ptr.To(int32(2)),
+						Resources: corev1.ResourceList{
+							corev1.ResourceCPU:     resource.MustParse("4"),
+							corev1.ResourceMemory:  resource.MustParse("8Gi"),
+							corev1.ResourceStorage: resource.MustParse("50Gi"),
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "other-worker",
+						Namespace: "other",
+					},
+					Spec: dockyardsv1.NodePoolSpec{
+						Replicas: ptr.To(int32(5)),
+						Resources: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("8"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	_ = dockyardsv1.AddToScheme(scheme.Scheme)
+
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithLists(lists...).Build()
+
+	actual, err := apiutil.GetOrganizationResourceUsage(context.Background(), c, &organization)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[corev1.ResourceName]string{
+		dockyardsv1.ResourceCluster:  "1",
+		dockyardsv1.ResourceNodePool: "2",
+		corev1.ResourceCPU:           "14",
+		corev1.ResourceMemory:        "28Gi",
+		corev1.ResourceStorage:       "160Gi",
+	}
+
+	if !cmp.Equal(resourceListStrings(actual), expected) {
+		t.Errorf("diff: %s", cmp.Diff(expected, resourceListStrings(actual)))
+	}
+}
+
+func TestExceededResourceQuotas(t *testing.T) {
+	tt := []struct {
+		name     string
+		quotas   corev1.ResourceList
+		usage    corev1.ResourceList
+		expected []corev1.ResourceName
+	}{
+		{
+			name: "test without quotas",
+			usage: corev1.ResourceList{
+				dockyardsv1.ResourceCluster: resource.MustParse("10"),
+			},
+		},
+		{
+			name: "test usage at limit",
+			quotas: corev1.ResourceList{
+				dockyardsv1.ResourceCluster: resource.MustParse("2"),
+				corev1.ResourceMemory:       resource.MustParse("8Gi"),
+			},
+			usage: corev1.ResourceList{
+				dockyardsv1.ResourceCluster: resource.MustParse("2"),
+				corev1.ResourceMemory:       resource.MustParse("8Gi"),
+			},
+		},
+		{
+			name: "test usage above limit",
+			quotas: corev1.ResourceList{
+				dockyardsv1.ResourceCluster:  resource.MustParse("2"),
+				dockyardsv1.ResourceNodePool: resource.MustParse("4"),
+				corev1.ResourceCPU:           resource.MustParse("8"),
+				corev1.ResourceMemory:        resource.MustParse("8Gi"),
+			},
+			usage: corev1.ResourceList{
+				dockyardsv1.ResourceCluster:  resource.MustParse("3"),
+				dockyardsv1.ResourceNodePool: resource.MustParse("4"),
+				corev1.ResourceCPU:           resource.MustParse("8500m"),
+				corev1.ResourceMemory:        resource.MustParse("4Gi"),
+			},
+			expected: []corev1.ResourceName{
+				dockyardsv1.ResourceCluster,
+				corev1.ResourceCPU,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := apiutil.ExceededResourceQuotas(tc.quotas, tc.usage)
+			if !cmp.Equal(actual, tc.expected) {
+				t.Errorf("diff: %s", cmp.Diff(tc.expected, actual))
+			}
+		})
+	}
+}
@@ -22,4 +22,5 @@ const (
 	KeyExternalURL     Key = "externalURL"
 	KeyEnvironmentName Key = "environmentName"
 	KeyPublicNamespace Key = "publicNamespace"
+	KeyAllowedOrigins  Key = "allowedOrigins"
 )
@@ -0,0 +1,128 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	APITokenKind = "APIToken"
+
+	// APITokenPrefix is prepended to every api token to tell them apart from signed tokens.
+	APITokenPrefix = "dyt_"
+)
+
+// +kubebuilder:validation:Enum=get;create;update;delete
+type APITokenVerb string
+
+const (
+	APITokenVerbGet    APITokenVerb = "get"
+	APITokenVerbCreate APITokenVerb = "create"
+	APITokenVerbUpdate APITokenVerb = "update"
+	APITokenVerbDelete APITokenVerb = "delete"
+)
+
+// APITokenScope restricts what an api token can be used for, an empty list allows everything the user is
+// allowed to do.
+type APITokenScope struct {
+	Organizations []string       `json:"organizations,omitempty"`
+	Verbs         []APITokenVerb `json:"verbs,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="has(self.userRef) != has(self.serviceAccountRef)",message="exactly one of userRef or serviceAccountRef must be set"
+type APITokenSpec struct {
+	UserRef *corev1.TypedLocalObjectReference `json:"userRef,omitempty"`
+
+	// ServiceAccountRef references the service account the token authenticates as, the namespace is required
+	// since api tokens are cluster scoped.
+	ServiceAccountRef *corev1.TypedObjectReference `json:"serviceAccountRef,omitempty"`
+	DisplayName       string                       `json:"displayName,omitempty"`
+
+	// TokenHash is the hex encoded SHA-256 hash of the token, the token itself is never stored.
+	TokenHash string           `json:"tokenHash"`
+	Duration  *metav1.Duration `json:"duration,omitempty"`
+	Scope     *APITokenScope   `json:"scope,omitempty"`
+
+	// AuthenticationMethods are the methods used to authenticate the subject creating the token as described
+	// in RFC 8176.
+	AuthenticationMethods []string `json:"authenticationMethods,omitempty"`
+}
+
+type APITokenStatus struct {
+	Conditions          []metav1.Condition `json:"conditions,omitempty"`
+	ExpirationTimestamp *metav1.Time       `json:"expirationTimestamp,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="UserName",type=string,JSONPath=".spec.userRef.name"
+// +kubebuilder:printcolumn:name="ServiceAccountName",type=string,JSONPath=".spec.serviceAccountRef.name"
+// +kubebuilder:printcolumn:name="Expiration",type=date,JSONPath=".status.expirationTimestamp"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+type APIToken struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   APITokenSpec   `json:"spec,omitempty"`
+	Status APITokenStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type APITokenList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []APIToken `json:"items"`
+}
+
+func (t *APIToken) GetConditions() []metav1.Condition {
+	return t.Status.Conditions
+}
+
+func (t *APIToken) SetConditions(conditions []metav1.Condition) {
+	t.Status.Conditions = conditions
+}
+
+func (t *APIToken) GetExpiration() *metav1.Time {
+	if t.Spec.Duration == nil {
+		return nil
+	}
+
+	expiration := t.CreationTimestamp.Add(t.Spec.Duration.Duration)
+
+	return &metav1.Time{Time: expiration}
+}
+
+// GetSubject returns the subject the api token authenticates as.
+func (t *APIToken) GetSubject() string {
+	if t.Spec.ServiceAccountRef != nil {
+		return ServiceAccountSubject(ptr.Deref(t.Spec.ServiceAccountRef.Namespace, ""), t.Spec.ServiceAccountRef.Name)
+	}
+
+	if t.Spec.UserRef != nil {
+		return t.Spec.UserRef.Name
+	}
+
+	return ""
+}
+
+func init() {
+	SchemeBuilder.Register(&APIToken{}, &APITokenList{})
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	AuditEventKind = "AuditEvent"
+)
+
+// AuditEventSpec is a single mutating request, audit events are created in the namespace of the organization the
+// request was made to.
+type AuditEventSpec struct {
+	Timestamp     metav1.Time `json:"timestamp"`
+	Subject       string      `json:"subject,omitempty"`
+	Cluster       string      `json:"cluster,omitempty"`
+	Resource      string      `json:"resource,omitempty"`
+	Subresource   string      `json:"subresource,omitempty"`
+	Name          string      `json:"name,omitempty"`
+	Verb          string      `json:"verb"`
+	Method        string      `json:"method"`
+	Path          string      `json:"path"`
+	RequestDigest string      `json:"requestDigest,omitempty"`
+	StatusCode    int         `json:"statusCode"`
+
+	// Duration is how long the audit event is retained before it is deleted.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Subject",type=string,JSONPath=".spec.subject"
+// +kubebuilder:printcolumn:name="Verb",type=string,JSONPath=".spec.verb"
+// +kubebuilder:printcolumn:name="Resource",type=string,JSONPath=".spec.resource"
+// +kubebuilder:printcolumn:name="StatusCode",type=integer,JSONPath=".spec.statusCode"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+type AuditEvent struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AuditEventSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type AuditEventList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []AuditEvent `json:"items"`
+}
+
+func (e *AuditEvent) GetExpiration() *metav1.Time {
+	if e.Spec.Duration == nil {
+		return nil
+	}
+
+	expiration := e.CreationTimestamp.Add(e.Spec.Duration.Duration)
+
+	return &metav1.Time{Time: expiration}
+}
+
+func init() {
+	SchemeBuilder.Register(&AuditEvent{}, &AuditEventList{})
+}
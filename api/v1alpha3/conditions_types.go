@@ -60,8 +60,51 @@ const (
 	MemberAuthorizationInternalErrorReason = "MemberAuthorizationInternalError"
 )
 
+const (
+	OrganizationRoleAuthorizationReadyCondition = "OrganizationRoleAuthorizationReady"
+
+	OrganizationRoleAuthorizationInternalErrorReason = "OrganizationRoleAuthorizationInternalError"
+)
+
 const (
 	UserAuthorizationReadyCondition = "UserAuthorizationReady"
 
 	UserAuthorizationInternalErrorReason = "UserAuthorizationInternalError"
 )
+
+const (
+	ServiceAccountMemberReconcileFailedReason = "ServiceAccountMemberReconcileFailed"
+)
+
+const (
+	LockedOutCondition = "LockedOut"
+
+	TooManyFailedLoginsReason = "TooManyFailedLogins"
+	UnlockedReason            = "Unlocked"
+)
+
+const (
+	ApprovedCondition = "Approved"
+
+	ApprovedReason = "Approved"
+	DeniedReason   = "Denied"
+)
+
+const (
+	InvitationAcceptedCondition = "Accepted"
+
+	InvitationPendingReason  = "Pending"
+	InvitationAcceptedReason = "Accepted"
+	InvitationDeclinedReason = "Declined"
+	InvitationExpiredReason  = "Expired"
+)
+
+const (
+	SentCondition = "Sent"
+
+	SentReason              = "Sent"
+	SendFailedReason        = "SendFailed"
+	RecipientNotFoundReason = "RecipientNotFound"
+	RenderFailedReason      = "RenderFailed"
+	ExpiredReason           = "Expired"
+)
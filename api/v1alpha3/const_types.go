@@ -32,6 +32,7 @@ const (
 	LabelMemberName               = "dockyards.io/member-name"
 	LabelRoleName                 = "dockyards.io/role-name"
 	LabelProviderName             = "dockyards.io/provider-name"
+	LabelServiceAccountName       = "dockyards.io/service-account-name"
 )
 
 const (
@@ -48,6 +49,9 @@ const (
 	AnnotationVoucherCode     = "dockyards.io/voucher-code"
 	AnnotationDefaultRelease  = "dockyards.io/is-default-release"
 
+	// AnnotationOrganizationName is set on the items of lists spanning the namespaces of several organizations.
+	AnnotationOrganizationName = "dockyards.io/organization-name"
+
 	// Deprecated: deployments superseded by workloads
 	AnnotationIgnoreDeployments = "dockyards.io/ignore-deployments"
 	AnnotationSkipRemediation   = "dockyards.io/skip-remediation"
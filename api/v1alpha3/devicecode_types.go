@@ -0,0 +1,92 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DeviceCodeKind = "DeviceCode"
+)
+
+// DeviceCodeSpec describes a pending device authorization, the name of the device code is the SHA-256 hash of
+// the code held by the device.
+type DeviceCodeSpec struct {
+	// Code is the user code entered on the verification page.
+	Code     string           `json:"code"`
+	ClientID string           `json:"clientID,omitempty"`
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+type DeviceCodeStatus struct {
+	Conditions          []metav1.Condition `json:"conditions,omitempty"`
+	ExpirationTimestamp *metav1.Time       `json:"expirationTimestamp,omitempty"`
+
+	// UserRef is the user that approved or denied the device.
+	UserRef *corev1.TypedLocalObjectReference `json:"userRef,omitempty"`
+
+	// AuthenticationMethods are the methods the user authenticated with when approving the device, they are
+	// included in the session created for the device.
+	AuthenticationMethods []string `json:"authenticationMethods,omitempty"`
+
+	LastPollTimestamp *metav1.Time `json:"lastPollTimestamp,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="ClientID",type=string,JSONPath=".spec.clientID"
+// +kubebuilder:printcolumn:name="UserName",type=string,JSONPath=".status.userRef.name"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+type DeviceCode struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DeviceCodeSpec   `json:"spec,omitempty"`
+	Status DeviceCodeStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type DeviceCodeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []DeviceCode `json:"items"`
+}
+
+func (c *DeviceCode) GetConditions() []metav1.Condition {
+	return c.Status.Conditions
+}
+
+func (c *DeviceCode) SetConditions(conditions []metav1.Condition) {
+	c.Status.Conditions = conditions
+}
+
+func (c *DeviceCode) GetExpiration() *metav1.Time {
+	if c.Spec.Duration == nil {
+		return nil
+	}
+
+	expiration := c.CreationTimestamp.Add(c.Spec.Duration.Duration)
+
+	return &metav1.Time{Time: expiration}
+}
+
+func init() {
+	SchemeBuilder.Register(&DeviceCode{}, &DeviceCodeList{})
+}
@@ -26,6 +26,36 @@ const (
 type IdentityProviderSpec struct {
 	DisplayName   *string                 `json:"displayName,omitempty"`
 	OIDCConfigRef *corev1.SecretReference `json:"oidc,omitempty"`
+	SAMLConfigRef *corev1.SecretReference `json:"saml,omitempty"`
+
+	// ClaimMapping selects the claims read from the identity token, unset claims use the standard claim names.
+	ClaimMapping *ClaimMapping `json:"claimMapping,omitempty"`
+
+	// GroupRules are evaluated every time a user logs in using the provider, members created by the rules are
+	// updated or removed when the groups of the user change.
+	GroupRules []GroupRule `json:"groupRules,omitempty"`
+}
+
+// ClaimMapping names the claims holding user information, nested claims are selected using dot-separated paths
+// such as `realm_access.roles`. For SAML providers the claims are the names or friendly names of the assertion
+// attributes, the name id is used as username when the username attribute is missing.
+type ClaimMapping struct {
+	// +kubebuilder:default=preferred_username
+	Username string `json:"username,omitempty"`
+	// +kubebuilder:default=email
+	Email string `json:"email,omitempty"`
+	// +kubebuilder:default=name
+	DisplayName string `json:"displayName,omitempty"`
+	// +kubebuilder:default=groups
+	Groups string `json:"groups,omitempty"`
+}
+
+// GroupRule grants users in a group the role in an organization, the highest role is used when several rules
+// match the same organization.
+type GroupRule struct {
+	Group           string                      `json:"group"`
+	OrganizationRef corev1.LocalObjectReference `json:"organizationRef"`
+	Role            Role                        `json:"role"`
 }
 
 type OIDCConfig struct {
@@ -38,6 +68,44 @@ type OIDCClientConfig struct {
 	ClientID     string `json:"clientID"`
 	RedirectURL  string `json:"redirectURL"`
 	ClientSecret string `json:"clientSecret,omitempty"`
+
+	// Scopes requested in the authorization request, the openid scope is always requested. Defaults to openid,
+	// email and profile.
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Prompt is sent as the prompt parameter of the authorization request, an empty prompt omits the parameter.
+	// Defaults to consent.
+	Prompt *string `json:"prompt,omitempty"`
+
+	// ExtraAuthParams are added to the authorization request, such as audience or acr_values.
+	ExtraAuthParams map[string]string `json:"extraAuthParams,omitempty"`
+
+	// PKCE enables S256 code challenges, required by public clients without a client secret.
+	PKCE bool `json:"pkce,omitempty"`
+}
+
+// SAMLConfig is read from the secret referenced by an identity provider, every key of the secret holds a JSON
+// encoded field.
+type SAMLConfig struct {
+	// EntityID of the service provider, defaults to the metadata url.
+	EntityID string `json:"entityID,omitempty"`
+
+	// MetadataURL is where the service provider metadata is served, such as
+	// https://{DOCKYARDS_DOMAIN}/api/backend/v1/metadata-saml?idp={IDENTITY_PROVIDER_NAME}.
+	MetadataURL string `json:"metadataURL"`
+
+	// ACSURL is the assertion consumer service of the service provider, such as
+	// https://{DOCKYARDS_DOMAIN}/api/backend/v1/acs-saml.
+	ACSURL string `json:"acsURL"`
+
+	// IDPMetadataURL is fetched to get the metadata of the identity provider, unless IDPMetadata is set.
+	IDPMetadataURL *string `json:"idpMetadataURL,omitempty"`
+	IDPMetadata    *string `json:"idpMetadata,omitempty"`
+
+	// Certificate and PrivateKey are PEM encoded and used to sign authentication requests and decrypt
+	// encrypted assertions.
+	Certificate *string `json:"certificate,omitempty"`
+	PrivateKey  *string `json:"privateKey,omitempty"`
 }
 
 type OIDCProviderConfig struct {
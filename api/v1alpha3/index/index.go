@@ -36,6 +36,20 @@ const (
 	ProviderIDField                = ".spec.providerID"
 )
 
+// IndexerFuncs are the functions used to index each field, objects not read from the cache can be matched against
+// the same values. The secret type is left out since its function only accepts secrets.
+var IndexerFuncs = map[string]client.IndexerFunc{
+	EmailField:                     byEmail,
+	MemberReferencesField:          byMemberReferences,
+	OwnerReferencesField:           ByOwnerReferences,
+	UIDField:                       byUID,
+	CredentialReferenceField:       ByCredentialRef,
+	CodeField:                      byCode,
+	WorkloadTemplateReferenceField: ByWorkloadTemplateReference,
+	SelectorField:                  bySelector,
+	ProviderIDField:                ByProviderID,
+}
+
 func ByMemberReferences(ctx context.Context, mgr ctrl.Manager) error {
 	err := mgr.GetFieldIndexer().IndexField(ctx, &v1alpha3.Organization{},
 		MemberReferencesField,
@@ -172,6 +186,8 @@ func byCode(obj client.Object) []string {
 		return []string{t.Spec.Code}
 	case *v1alpha3.VerificationRequest:
 		return []string{t.Spec.Code}
+	case *v1alpha3.DeviceCode:
+		return []string{t.Spec.Code}
 	}
 
 	return nil
@@ -194,6 +210,14 @@ func ByCode(ctx context.Context, mgr ctrl.Manager) error {
 		return err
 	}
 
+	err = mgr.GetFieldIndexer().IndexField(ctx, &v1alpha3.DeviceCode{},
+		CodeField,
+		byCode,
+	)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
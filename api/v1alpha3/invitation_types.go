@@ -16,6 +16,7 @@ package v1alpha3
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -31,11 +32,13 @@ type InvitationSpec struct {
 }
 
 type InvitationStatus struct {
-	ExpirationTimestamp *metav1.Time `json:"expirationTimestamp,omitempty"`
+	ExpirationTimestamp *metav1.Time       `json:"expirationTimestamp,omitempty"`
+	Conditions          []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:printcolumn:name="Email",type=string,JSONPath=".spec.email"
 // +kubebuilder:printcolumn:name="Role",type=string,JSONPath=".spec.role"
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=".status.conditions[?(@.type==\"Accepted\")].reason"
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:printcolumn:name="Duration",type=string,JSONPath=".spec.duration"
 // +kubebuilder:object:root=true
@@ -56,6 +59,29 @@ type InvitationList struct {
 	Items []Invitation `json:"items"`
 }
 
+func (i *Invitation) GetConditions() []metav1.Condition {
+	return i.Status.Conditions
+}
+
+func (i *Invitation) SetConditions(conditions []metav1.Condition) {
+	i.Status.Conditions = conditions
+}
+
+// IsPending returns true unless the invitation has been accepted, declined or has expired.
+func (i *Invitation) IsPending() bool {
+	condition := meta.FindStatusCondition(i.Status.Conditions, InvitationAcceptedCondition)
+	if condition == nil {
+		return true
+	}
+
+	return condition.Reason == InvitationPendingReason
+}
+
+// GetVerificationRequestName returns the name of the verification request delivering the acceptance code.
+func (i *Invitation) GetVerificationRequestName() string {
+	return "invitation-" + string(i.UID)
+}
+
 func (i *Invitation) GetExpiration() *metav1.Time {
 	if i.Spec.Duration == nil {
 		return nil
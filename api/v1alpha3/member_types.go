@@ -29,12 +29,25 @@ const (
 	RoleReader    = "Reader"
 )
 
-// +kubebuilder:validation:Enum=SuperUser;User;Reader
+// Role is either one of the built-in roles SuperUser, User or Reader, or the name of an OrganizationRole in the
+// namespace of the organization.
+// +kubebuilder:validation:MinLength=1
 type Role string
 
+// MemberClusters limits the clusters a member is granted access to, clusters matching either the names or the
+// selector are included.
+type MemberClusters struct {
+	Names    []string              `json:"names,omitempty"`
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
 type MemberSpec struct {
 	Role    Role                             `json:"role"`
 	UserRef corev1.TypedLocalObjectReference `json:"userRef"`
+
+	// Clusters limits the member to a subset of the clusters in the organization, the member is granted access to
+	// all clusters when unset. It is only supported for the User and Reader roles.
+	Clusters *MemberClusters `json:"clusters,omitempty"`
 }
 
 type MemberStatus struct {
@@ -72,6 +85,16 @@ func (m *Member) SetConditions(conditions []metav1.Condition) {
 	m.Status.Conditions = conditions
 }
 
+// GetSubject returns the subject the member is authorized as, service accounts are prefixed to keep them from
+// colliding with users.
+func (m *Member) GetSubject() string {
+	if m.Spec.UserRef.Kind == ServiceAccountKind {
+		return ServiceAccountSubject(m.Namespace, m.Spec.UserRef.Name)
+	}
+
+	return m.Spec.UserRef.Name
+}
+
 func init() {
 	SchemeBuilder.Register(&Member{}, &MemberList{})
 }
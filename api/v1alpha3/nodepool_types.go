@@ -21,7 +21,8 @@ import (
 )
 
 const (
-	NodePoolKind = "NodePool"
+	NodePoolKind                         = "NodePool"
+	ResourceNodePool corev1.ResourceName = "nodepool"
 )
 
 const StorageResourceTypeHostPath = "HostPath"
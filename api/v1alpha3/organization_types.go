@@ -56,6 +56,14 @@ type OrganizationSpec struct {
 
 	NamespaceRef *corev1.LocalObjectReference `json:"namespaceRef,omitempty"`
 	ProviderID   *string                      `json:"providerID,omitempty"`
+
+	// RequireMFA denies members that have not authenticated using multi-factor authentication access to the
+	// organization.
+	RequireMFA bool `json:"requireMFA,omitempty"`
+
+	// ResourceQuotas limits the resources that can be used by the organization, supported resources are
+	// cluster, nodepool, cpu, memory and storage.
+	ResourceQuotas corev1.ResourceList `json:"resourceQuotas,omitempty"`
 }
 
 type OrganizationStatus struct {
@@ -65,6 +73,9 @@ type OrganizationStatus struct {
 	// Deprecated: use spec.namespaceRef
 	NamespaceRef   *corev1.LocalObjectReference `json:"namespaceRef,omitempty"`
 	ResourceQuotas corev1.ResourceList          `json:"resourceQuotas,omitempty"`
+
+	// ResourceUsage is the observed usage of the resources limited by resource quotas.
+	ResourceUsage corev1.ResourceList `json:"resourceUsage,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,87 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	OrganizationRoleKind = "OrganizationRole"
+)
+
+// +kubebuilder:validation:Enum=create;delete;get;list;patch;update;watch
+type Verb string
+
+type OrganizationRoleRule struct {
+	// +kubebuilder:validation:items:Enum=clusters;dnszones;invitations;nodepools;nodes;serviceaccounts;workloads
+	// +kubebuilder:validation:MinItems=1
+	Resources []string `json:"resources"`
+	// +kubebuilder:validation:MinItems=1
+	Verbs []Verb `json:"verbs"`
+}
+
+type OrganizationRoleSpec struct {
+	DisplayName string                 `json:"displayName,omitempty"`
+	Rules       []OrganizationRoleRule `json:"rules,omitempty"`
+}
+
+type OrganizationRoleStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="DisplayName",type=string,JSONPath=".spec.displayName"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+type OrganizationRole struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OrganizationRoleSpec   `json:"spec,omitempty"`
+	Status OrganizationRoleStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type OrganizationRoleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []OrganizationRole `json:"items"`
+}
+
+func (r *OrganizationRole) GetConditions() []metav1.Condition {
+	return r.Status.Conditions
+}
+
+func (r *OrganizationRole) SetConditions(conditions []metav1.Condition) {
+	r.Status.Conditions = conditions
+}
+
+// IsBuiltinRole returns true for the roles that are managed by dockyards rather than defined by an organization
+// using an OrganizationRole.
+func IsBuiltinRole(role Role) bool {
+	switch role {
+	case RoleSuperUser, RoleUser, RoleReader:
+		return true
+	default:
+		return false
+	}
+}
+
+func init() {
+	SchemeBuilder.Register(&OrganizationRole{}, &OrganizationRoleList{})
+}
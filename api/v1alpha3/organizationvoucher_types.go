@@ -28,8 +28,17 @@ type OrganizationVoucherSpec struct {
 	PoolRef *corev1.TypedObjectReference `json:"poolRef"`
 }
 
+type OrganizationVoucherRedemption struct {
+	OrganizationRef corev1.LocalObjectReference `json:"organizationRef"`
+	Timestamp       metav1.Time                 `json:"timestamp"`
+}
+
 type OrganizationVoucherStatus struct {
+	// Redeemed is set when the voucher has been redeemed the maximum number of times.
 	Redeemed bool `json:"redeemed,omitempty"`
+
+	// Redemptions records the organizations created using the voucher.
+	Redemptions []OrganizationVoucherRedemption `json:"redemptions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,68 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	OrganizationVoucherPoolKind = "OrganizationVoucherPool"
+)
+
+type OrganizationVoucherPoolSpec struct {
+	// MaxRedemptions is the number of times each voucher in the pool can be redeemed.
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	MaxRedemptions int32 `json:"maxRedemptions,omitempty"`
+
+	// NotBefore and NotAfter limit when vouchers in the pool can be redeemed.
+	NotBefore *metav1.Time `json:"notBefore,omitempty"`
+	NotAfter  *metav1.Time `json:"notAfter,omitempty"`
+
+	// Duration, ResourceQuotas and Features are assigned to organizations created using vouchers in the pool.
+	Duration       *metav1.Duration    `json:"duration,omitempty"`
+	ResourceQuotas corev1.ResourceList `json:"resourceQuotas,omitempty"`
+	Features       []string            `json:"features,omitempty"`
+}
+
+type OrganizationVoucherPoolStatus struct{}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="MaxRedemptions",type=integer,JSONPath=".spec.maxRedemptions"
+// +kubebuilder:printcolumn:name="NotAfter",type=date,JSONPath=".spec.notAfter"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+type OrganizationVoucherPool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OrganizationVoucherPoolSpec   `json:"spec,omitempty"`
+	Status OrganizationVoucherPoolStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type OrganizationVoucherPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []OrganizationVoucherPool `json:"items,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OrganizationVoucherPool{}, &OrganizationVoucherPoolList{})
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ServiceAccountKind = "ServiceAccount"
+
+	// ServiceAccountSubjectPrefix is prepended to the subject of service accounts to keep them from colliding
+	// with user names.
+	ServiceAccountSubjectPrefix = "dockyards:serviceaccount:"
+)
+
+type ServiceAccountSpec struct {
+	DisplayName string `json:"displayName,omitempty"`
+	Role        Role   `json:"role"`
+}
+
+type ServiceAccountStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Role",type=string,JSONPath=".spec.role"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+type ServiceAccount struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ServiceAccountSpec   `json:"spec,omitempty"`
+	Status ServiceAccountStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type ServiceAccountList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ServiceAccount `json:"items"`
+}
+
+func (s *ServiceAccount) GetConditions() []metav1.Condition {
+	return s.Status.Conditions
+}
+
+func (s *ServiceAccount) SetConditions(conditions []metav1.Condition) {
+	s.Status.Conditions = conditions
+}
+
+// GetSubject returns the subject used for authentication and authorization of the service account.
+func (s *ServiceAccount) GetSubject() string {
+	return ServiceAccountSubject(s.Namespace, s.Name)
+}
+
+func ServiceAccountSubject(namespace, name string) string {
+	return ServiceAccountSubjectPrefix + namespace + ":" + name
+}
+
+func init() {
+	SchemeBuilder.Register(&ServiceAccount{}, &ServiceAccountList{})
+}
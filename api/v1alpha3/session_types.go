@@ -0,0 +1,94 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	SessionKind = "Session"
+)
+
+type SessionSpec struct {
+	UserRef corev1.TypedLocalObjectReference `json:"userRef"`
+
+	// Duration is the idle lifetime of the session, it is extended every time the refresh token is rotated.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// AuthenticationMethods are the methods used when the session was created, they are included in every
+	// access token issued for the session.
+	AuthenticationMethods []string `json:"authenticationMethods,omitempty"`
+}
+
+type SessionStatus struct {
+	Conditions          []metav1.Condition `json:"conditions,omitempty"`
+	ExpirationTimestamp *metav1.Time       `json:"expirationTimestamp,omitempty"`
+
+	// TokenID is the jti of the only refresh token that is currently valid for the session.
+	TokenID              string       `json:"tokenID,omitempty"`
+	LastRefreshTimestamp *metav1.Time `json:"lastRefreshTimestamp,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="UserName",type=string,JSONPath=".spec.userRef.name"
+// +kubebuilder:printcolumn:name="LastRefresh",type=date,JSONPath=".status.lastRefreshTimestamp"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+type Session struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SessionSpec   `json:"spec,omitempty"`
+	Status SessionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type SessionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Session `json:"items"`
+}
+
+func (s *Session) GetConditions() []metav1.Condition {
+	return s.Status.Conditions
+}
+
+func (s *Session) SetConditions(conditions []metav1.Condition) {
+	s.Status.Conditions = conditions
+}
+
+func (s *Session) GetExpiration() *metav1.Time {
+	if s.Spec.Duration == nil {
+		return nil
+	}
+
+	lastActivity := s.CreationTimestamp
+	if s.Status.LastRefreshTimestamp != nil {
+		lastActivity = *s.Status.LastRefreshTimestamp
+	}
+
+	expiration := lastActivity.Add(s.Spec.Duration.Duration)
+
+	return &metav1.Time{Time: expiration}
+}
+
+func init() {
+	SchemeBuilder.Register(&Session{}, &SessionList{})
+}
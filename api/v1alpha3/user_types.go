@@ -15,6 +15,7 @@
 package v1alpha3
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -29,6 +30,15 @@ type UserSpec struct {
 	Phone       string           `json:"phone,omitempty"`
 	Duration    *metav1.Duration `json:"duration,omitempty"`
 	ProviderID  string           `json:"providerID,omitempty"`
+
+	// MFA is set once the user has confirmed the enrollment in multi-factor authentication using a code.
+	MFA *UserMFA `json:"mfa,omitempty"`
+}
+
+type UserMFA struct {
+	// SecretRef references the secret holding the time-based one-time password secret and the hashed
+	// recovery codes.
+	SecretRef corev1.SecretReference `json:"secretRef"`
 }
 
 type UserStatus struct {
@@ -31,18 +31,27 @@ type VerificationRequestSpec struct {
 	BodyText string                           `json:"bodyText"`
 	UserRef  corev1.TypedLocalObjectReference `json:"userRef"`
 	Duration *metav1.Duration                 `json:"duration,omitempty"`
+
+	// Email is the address of the recipient when the user reference does not reference a user, e.g. when
+	// inviting an address that has not signed up yet.
+	Email string `json:"email,omitempty"`
 }
 
 type VerificationRequestStatus struct {
 	ProviderID string                `json:"providerID,omitempty"`
 	ExpirationTimestamp *metav1.Time `json:"expirationTimestamp,omitempty"`
 	Conditions []metav1.Condition    `json:"conditions,omitempty"`
+
+	// DeliveryAttempts is the number of failed attempts to deliver the request, it is used to back off between
+	// attempts.
+	DeliveryAttempts int `json:"deliveryAttempts,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:storageversion
 // +kubebuilder:resource:scope=Cluster
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Sent",type=string,JSONPath=".status.conditions[?(@.type==\"Sent\")].status"
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
 type VerificationRequest struct {
 	metav1.TypeMeta   `json:",inline"`
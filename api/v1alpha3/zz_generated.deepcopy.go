@@ -12,6 +12,250 @@ import (
 	"k8s.io/apiserver/pkg/apis/apiserver/v1beta1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APIToken) DeepCopyInto(out *APIToken) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIToken.
+func (in *APIToken) DeepCopy() *APIToken {
+	if in == nil {
+		return nil
+	}
+	out := new(APIToken)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *APIToken) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APITokenList) DeepCopyInto(out *APITokenList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]APIToken, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APITokenList.
+func (in *APITokenList) DeepCopy() *APITokenList {
+	if in == nil {
+		return nil
+	}
+	out := new(APITokenList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *APITokenList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APITokenScope) DeepCopyInto(out *APITokenScope) {
+	*out = *in
+	if in.Organizations != nil {
+		in, out := &in.Organizations, &out.Organizations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Verbs != nil {
+		in, out := &in.Verbs, &out.Verbs
+		*out = make([]APITokenVerb, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APITokenScope.
+func (in *APITokenScope) DeepCopy() *APITokenScope {
+	if in == nil {
+		return nil
+	}
+	out := new(APITokenScope)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APITokenSpec) DeepCopyInto(out *APITokenSpec) {
+	*out = *in
+	if in.UserRef != nil {
+		in, out := &in.UserRef, &out.UserRef
+		*out = new(v1.TypedLocalObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceAccountRef != nil {
+		in, out := &in.ServiceAccountRef, &out.ServiceAccountRef
+		*out = new(v1.TypedObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Scope != nil {
+		in, out := &in.Scope, &out.Scope
+		*out = new(APITokenScope)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AuthenticationMethods != nil {
+		in, out := &in.AuthenticationMethods, &out.AuthenticationMethods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APITokenSpec.
+func (in *APITokenSpec) DeepCopy() *APITokenSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(APITokenSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APITokenStatus) DeepCopyInto(out *APITokenStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExpirationTimestamp != nil {
+		in, out := &in.ExpirationTimestamp, &out.ExpirationTimestamp
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APITokenStatus.
+func (in *APITokenStatus) DeepCopy() *APITokenStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(APITokenStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditEvent) DeepCopyInto(out *AuditEvent) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditEvent.
+func (in *AuditEvent) DeepCopy() *AuditEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditEvent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AuditEvent) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditEventList) DeepCopyInto(out *AuditEventList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AuditEvent, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditEventList.
+func (in *AuditEventList) DeepCopy() *AuditEventList {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditEventList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AuditEventList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditEventSpec) DeepCopyInto(out *AuditEventSpec) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditEventSpec.
+func (in *AuditEventSpec) DeepCopy() *AuditEventSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditEventSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClaimMapping) DeepCopyInto(out *ClaimMapping) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClaimMapping.
+func (in *ClaimMapping) DeepCopy() *ClaimMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(ClaimMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Cluster) DeepCopyInto(out *Cluster) {
 	*out = *in
@@ -821,26 +1065,26 @@ func (in *DeploymentStatus) DeepCopy() *DeploymentStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Feature) DeepCopyInto(out *Feature) {
+func (in *DeviceCode) DeepCopyInto(out *DeviceCode) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Feature.
-func (in *Feature) DeepCopy() *Feature {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceCode.
+func (in *DeviceCode) DeepCopy() *DeviceCode {
 	if in == nil {
 		return nil
 	}
-	out := new(Feature)
+	out := new(DeviceCode)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Feature) DeepCopyObject() runtime.Object {
+func (in *DeviceCode) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -848,31 +1092,31 @@ func (in *Feature) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *FeatureList) DeepCopyInto(out *FeatureList) {
+func (in *DeviceCodeList) DeepCopyInto(out *DeviceCodeList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]Feature, len(*in))
+		*out = make([]DeviceCode, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FeatureList.
-func (in *FeatureList) DeepCopy() *FeatureList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceCodeList.
+func (in *DeviceCodeList) DeepCopy() *DeviceCodeList {
 	if in == nil {
 		return nil
 	}
-	out := new(FeatureList)
+	out := new(DeviceCodeList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *FeatureList) DeepCopyObject() runtime.Object {
+func (in *DeviceCodeList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -880,56 +1124,86 @@ func (in *FeatureList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *FeatureSpec) DeepCopyInto(out *FeatureSpec) {
+func (in *DeviceCodeSpec) DeepCopyInto(out *DeviceCodeSpec) {
 	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FeatureSpec.
-func (in *FeatureSpec) DeepCopy() *FeatureSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceCodeSpec.
+func (in *DeviceCodeSpec) DeepCopy() *DeviceCodeSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(FeatureSpec)
+	out := new(DeviceCodeSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *FeatureStatus) DeepCopyInto(out *FeatureStatus) {
+func (in *DeviceCodeStatus) DeepCopyInto(out *DeviceCodeStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExpirationTimestamp != nil {
+		in, out := &in.ExpirationTimestamp, &out.ExpirationTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.UserRef != nil {
+		in, out := &in.UserRef, &out.UserRef
+		*out = new(v1.TypedLocalObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AuthenticationMethods != nil {
+		in, out := &in.AuthenticationMethods, &out.AuthenticationMethods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastPollTimestamp != nil {
+		in, out := &in.LastPollTimestamp, &out.LastPollTimestamp
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FeatureStatus.
-func (in *FeatureStatus) DeepCopy() *FeatureStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceCodeStatus.
+func (in *DeviceCodeStatus) DeepCopy() *DeviceCodeStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(FeatureStatus)
+	out := new(DeviceCodeStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HelmDeployment) DeepCopyInto(out *HelmDeployment) {
+func (in *Feature) DeepCopyInto(out *Feature) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	out.Spec = in.Spec
+	out.Status = in.Status
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmDeployment.
-func (in *HelmDeployment) DeepCopy() *HelmDeployment {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Feature.
+func (in *Feature) DeepCopy() *Feature {
 	if in == nil {
 		return nil
 	}
-	out := new(HelmDeployment)
+	out := new(Feature)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HelmDeployment) DeepCopyObject() runtime.Object {
+func (in *Feature) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -937,21 +1211,126 @@ func (in *HelmDeployment) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HelmDeploymentList) DeepCopyInto(out *HelmDeploymentList) {
+func (in *FeatureList) DeepCopyInto(out *FeatureList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]HelmDeployment, len(*in))
+		*out = make([]Feature, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmDeploymentList.
-func (in *HelmDeploymentList) DeepCopy() *HelmDeploymentList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FeatureList.
+func (in *FeatureList) DeepCopy() *FeatureList {
+	if in == nil {
+		return nil
+	}
+	out := new(FeatureList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FeatureList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FeatureSpec) DeepCopyInto(out *FeatureSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FeatureSpec.
+func (in *FeatureSpec) DeepCopy() *FeatureSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FeatureSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FeatureStatus) DeepCopyInto(out *FeatureStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FeatureStatus.
+func (in *FeatureStatus) DeepCopy() *FeatureStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FeatureStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupRule) DeepCopyInto(out *GroupRule) {
+	*out = *in
+	out.OrganizationRef = in.OrganizationRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupRule.
+func (in *GroupRule) DeepCopy() *GroupRule {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmDeployment) DeepCopyInto(out *HelmDeployment) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmDeployment.
+func (in *HelmDeployment) DeepCopy() *HelmDeployment {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmDeployment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HelmDeployment) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmDeploymentList) DeepCopyInto(out *HelmDeploymentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HelmDeployment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmDeploymentList.
+func (in *HelmDeploymentList) DeepCopy() *HelmDeploymentList {
 	if in == nil {
 		return nil
 	}
@@ -1081,6 +1460,21 @@ func (in *IdentityProviderSpec) DeepCopyInto(out *IdentityProviderSpec) {
 		*out = new(v1.SecretReference)
 		**out = **in
 	}
+	if in.SAMLConfigRef != nil {
+		in, out := &in.SAMLConfigRef, &out.SAMLConfigRef
+		*out = new(v1.SecretReference)
+		**out = **in
+	}
+	if in.ClaimMapping != nil {
+		in, out := &in.ClaimMapping, &out.ClaimMapping
+		*out = new(ClaimMapping)
+		**out = **in
+	}
+	if in.GroupRules != nil {
+		in, out := &in.GroupRules, &out.GroupRules
+		*out = make([]GroupRule, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityProviderSpec.
@@ -1184,6 +1578,13 @@ func (in *InvitationStatus) DeepCopyInto(out *InvitationStatus) {
 		in, out := &in.ExpirationTimestamp, &out.ExpirationTimestamp
 		*out = (*in).DeepCopy()
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvitationStatus.
@@ -1335,6 +1736,31 @@ func (in *Member) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberClusters) DeepCopyInto(out *MemberClusters) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberClusters.
+func (in *MemberClusters) DeepCopy() *MemberClusters {
+	if in == nil {
+		return nil
+	}
+	out := new(MemberClusters)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MemberList) DeepCopyInto(out *MemberList) {
 	*out = *in
@@ -1371,6 +1797,11 @@ func (in *MemberList) DeepCopyObject() runtime.Object {
 func (in *MemberSpec) DeepCopyInto(out *MemberSpec) {
 	*out = *in
 	in.UserRef.DeepCopyInto(&out.UserRef)
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = new(MemberClusters)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberSpec.
@@ -1714,6 +2145,23 @@ func (in *NodeStatus) DeepCopy() *NodeStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OIDCClientConfig) DeepCopyInto(out *OIDCClientConfig) {
 	*out = *in
+	if in.Scopes != nil {
+		in, out := &in.Scopes, &out.Scopes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Prompt != nil {
+		in, out := &in.Prompt, &out.Prompt
+		*out = new(string)
+		**out = **in
+	}
+	if in.ExtraAuthParams != nil {
+		in, out := &in.ExtraAuthParams, &out.ExtraAuthParams
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCClientConfig.
@@ -1729,7 +2177,7 @@ func (in *OIDCClientConfig) DeepCopy() *OIDCClientConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OIDCConfig) DeepCopyInto(out *OIDCConfig) {
 	*out = *in
-	out.ClientConfig = in.ClientConfig
+	in.ClientConfig.DeepCopyInto(&out.ClientConfig)
 	if in.ProviderDiscoveryURL != nil {
 		in, out := &in.ProviderDiscoveryURL, &out.ProviderDiscoveryURL
 		*out = new(string)
@@ -1847,6 +2295,134 @@ func (in *OrganizationMemberReference) DeepCopy() *OrganizationMemberReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrganizationRole) DeepCopyInto(out *OrganizationRole) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationRole.
+func (in *OrganizationRole) DeepCopy() *OrganizationRole {
+	if in == nil {
+		return nil
+	}
+	out := new(OrganizationRole)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OrganizationRole) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrganizationRoleList) DeepCopyInto(out *OrganizationRoleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OrganizationRole, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationRoleList.
+func (in *OrganizationRoleList) DeepCopy() *OrganizationRoleList {
+	if in == nil {
+		return nil
+	}
+	out := new(OrganizationRoleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OrganizationRoleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrganizationRoleRule) DeepCopyInto(out *OrganizationRoleRule) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Verbs != nil {
+		in, out := &in.Verbs, &out.Verbs
+		*out = make([]Verb, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationRoleRule.
+func (in *OrganizationRoleRule) DeepCopy() *OrganizationRoleRule {
+	if in == nil {
+		return nil
+	}
+	out := new(OrganizationRoleRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrganizationRoleSpec) DeepCopyInto(out *OrganizationRoleSpec) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]OrganizationRoleRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationRoleSpec.
+func (in *OrganizationRoleSpec) DeepCopy() *OrganizationRoleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OrganizationRoleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrganizationRoleStatus) DeepCopyInto(out *OrganizationRoleStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationRoleStatus.
+func (in *OrganizationRoleStatus) DeepCopy() *OrganizationRoleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OrganizationRoleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OrganizationSpec) DeepCopyInto(out *OrganizationSpec) {
 	*out = *in
@@ -1882,6 +2458,13 @@ func (in *OrganizationSpec) DeepCopyInto(out *OrganizationSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.ResourceQuotas != nil {
+		in, out := &in.ResourceQuotas, &out.ResourceQuotas
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationSpec.
@@ -1920,6 +2503,13 @@ func (in *OrganizationStatus) DeepCopyInto(out *OrganizationStatus) {
 			(*out)[key] = val.DeepCopy()
 		}
 	}
+	if in.ResourceUsage != nil {
+		in, out := &in.ResourceUsage, &out.ResourceUsage
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationStatus.
@@ -1938,7 +2528,7 @@ func (in *OrganizationVoucher) DeepCopyInto(out *OrganizationVoucher) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationVoucher.
@@ -1991,6 +2581,137 @@ func (in *OrganizationVoucherList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrganizationVoucherPool) DeepCopyInto(out *OrganizationVoucherPool) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationVoucherPool.
+func (in *OrganizationVoucherPool) DeepCopy() *OrganizationVoucherPool {
+	if in == nil {
+		return nil
+	}
+	out := new(OrganizationVoucherPool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OrganizationVoucherPool) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrganizationVoucherPoolList) DeepCopyInto(out *OrganizationVoucherPoolList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OrganizationVoucherPool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationVoucherPoolList.
+func (in *OrganizationVoucherPoolList) DeepCopy() *OrganizationVoucherPoolList {
+	if in == nil {
+		return nil
+	}
+	out := new(OrganizationVoucherPoolList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OrganizationVoucherPoolList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrganizationVoucherPoolSpec) DeepCopyInto(out *OrganizationVoucherPoolSpec) {
+	*out = *in
+	if in.NotBefore != nil {
+		in, out := &in.NotBefore, &out.NotBefore
+		*out = (*in).DeepCopy()
+	}
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ResourceQuotas != nil {
+		in, out := &in.ResourceQuotas, &out.ResourceQuotas
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Features != nil {
+		in, out := &in.Features, &out.Features
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationVoucherPoolSpec.
+func (in *OrganizationVoucherPoolSpec) DeepCopy() *OrganizationVoucherPoolSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OrganizationVoucherPoolSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrganizationVoucherPoolStatus) DeepCopyInto(out *OrganizationVoucherPoolStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationVoucherPoolStatus.
+func (in *OrganizationVoucherPoolStatus) DeepCopy() *OrganizationVoucherPoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OrganizationVoucherPoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrganizationVoucherRedemption) DeepCopyInto(out *OrganizationVoucherRedemption) {
+	*out = *in
+	out.OrganizationRef = in.OrganizationRef
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationVoucherRedemption.
+func (in *OrganizationVoucherRedemption) DeepCopy() *OrganizationVoucherRedemption {
+	if in == nil {
+		return nil
+	}
+	out := new(OrganizationVoucherRedemption)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OrganizationVoucherSpec) DeepCopyInto(out *OrganizationVoucherSpec) {
 	*out = *in
@@ -2014,6 +2735,13 @@ func (in *OrganizationVoucherSpec) DeepCopy() *OrganizationVoucherSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OrganizationVoucherStatus) DeepCopyInto(out *OrganizationVoucherStatus) {
 	*out = *in
+	if in.Redemptions != nil {
+		in, out := &in.Redemptions, &out.Redemptions
+		*out = make([]OrganizationVoucherRedemption, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationVoucherStatus.
@@ -2130,6 +2858,252 @@ func (in *ReleaseStatus) DeepCopy() *ReleaseStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SAMLConfig) DeepCopyInto(out *SAMLConfig) {
+	*out = *in
+	if in.IDPMetadataURL != nil {
+		in, out := &in.IDPMetadataURL, &out.IDPMetadataURL
+		*out = new(string)
+		**out = **in
+	}
+	if in.IDPMetadata != nil {
+		in, out := &in.IDPMetadata, &out.IDPMetadata
+		*out = new(string)
+		**out = **in
+	}
+	if in.Certificate != nil {
+		in, out := &in.Certificate, &out.Certificate
+		*out = new(string)
+		**out = **in
+	}
+	if in.PrivateKey != nil {
+		in, out := &in.PrivateKey, &out.PrivateKey
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SAMLConfig.
+func (in *SAMLConfig) DeepCopy() *SAMLConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SAMLConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceAccount) DeepCopyInto(out *ServiceAccount) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceAccount.
+func (in *ServiceAccount) DeepCopy() *ServiceAccount {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceAccount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServiceAccount) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceAccountList) DeepCopyInto(out *ServiceAccountList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ServiceAccount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceAccountList.
+func (in *ServiceAccountList) DeepCopy() *ServiceAccountList {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceAccountList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServiceAccountList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceAccountSpec) DeepCopyInto(out *ServiceAccountSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceAccountSpec.
+func (in *ServiceAccountSpec) DeepCopy() *ServiceAccountSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceAccountSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceAccountStatus) DeepCopyInto(out *ServiceAccountStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceAccountStatus.
+func (in *ServiceAccountStatus) DeepCopy() *ServiceAccountStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceAccountStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Session) DeepCopyInto(out *Session) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Session.
+func (in *Session) DeepCopy() *Session {
+	if in == nil {
+		return nil
+	}
+	out := new(Session)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Session) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SessionList) DeepCopyInto(out *SessionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Session, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SessionList.
+func (in *SessionList) DeepCopy() *SessionList {
+	if in == nil {
+		return nil
+	}
+	out := new(SessionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SessionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SessionSpec) DeepCopyInto(out *SessionSpec) {
+	*out = *in
+	in.UserRef.DeepCopyInto(&out.UserRef)
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.AuthenticationMethods != nil {
+		in, out := &in.AuthenticationMethods, &out.AuthenticationMethods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SessionSpec.
+func (in *SessionSpec) DeepCopy() *SessionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SessionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SessionStatus) DeepCopyInto(out *SessionStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExpirationTimestamp != nil {
+		in, out := &in.ExpirationTimestamp, &out.ExpirationTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.LastRefreshTimestamp != nil {
+		in, out := &in.LastRefreshTimestamp, &out.LastRefreshTimestamp
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SessionStatus.
+func (in *SessionStatus) DeepCopy() *SessionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SessionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *User) DeepCopyInto(out *User) {
 	*out = *in
@@ -2189,6 +3163,22 @@ func (in *UserList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserMFA) DeepCopyInto(out *UserMFA) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserMFA.
+func (in *UserMFA) DeepCopy() *UserMFA {
+	if in == nil {
+		return nil
+	}
+	out := new(UserMFA)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UserSpec) DeepCopyInto(out *UserSpec) {
 	*out = *in
@@ -2197,6 +3187,11 @@ func (in *UserSpec) DeepCopyInto(out *UserSpec) {
 		*out = new(metav1.Duration)
 		**out = **in
 	}
+	if in.MFA != nil {
+		in, out := &in.MFA, &out.MFA
+		*out = new(UserMFA)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserSpec.
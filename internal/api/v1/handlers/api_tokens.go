@@ -0,0 +1,257 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"time"
+
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=dockyards.io,resources=apitokens,verbs=create;delete;get;list;patch;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=apitokens/status,verbs=patch
+
+type APITokenOptions struct {
+	DisplayName   *string   `json:"display_name,omitempty"`
+	Duration      *string   `json:"duration,omitempty"`
+	Organizations *[]string `json:"organizations,omitempty"`
+	Verbs         *[]string `json:"verbs,omitempty"`
+}
+
+type APIToken struct {
+	CreatedAt     time.Time  `json:"created_at"`
+	DisplayName   *string    `json:"display_name,omitempty"`
+	Duration      *string    `json:"duration,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Organizations *[]string  `json:"organizations,omitempty"`
+	Token         *string    `json:"token,omitempty"`
+	Verbs         *[]string  `json:"verbs,omitempty"`
+}
+
+func toAPIToken(apiToken *dockyardsv1.APIToken) APIToken {
+	result := APIToken{
+		CreatedAt: apiToken.CreationTimestamp.Time,
+		ID:        string(apiToken.UID),
+		Name:      apiToken.Name,
+	}
+
+	if apiToken.Spec.DisplayName != "" {
+		result.DisplayName = &apiToken.Spec.DisplayName
+	}
+
+	if apiToken.Spec.Duration != nil {
+		result.Duration = ptr.To(apiToken.Spec.Duration.String())
+		result.ExpiresAt = &apiToken.GetExpiration().Time
+	}
+
+	scope := apiToken.Spec.Scope
+	if scope != nil {
+		if len(scope.Organizations) > 0 {
+			result.Organizations = &scope.Organizations
+		}
+
+		if len(scope.Verbs) > 0 {
+			verbs := make([]string, len(scope.Verbs))
+			for i, verb := range scope.Verbs {
+				verbs[i] = string(verb)
+			}
+
+			result.Verbs = &verbs
+		}
+	}
+
+	return result
+}
+
+// errNotWithAPIToken is returned when an api token is used to manage api tokens, a token must never be able
+// to create tokens with a wider scope than its own.
+var errNotWithAPIToken = errors.New("api tokens cannot be managed using an api token")
+
+// apiTokenNameAttempts is how many random names are tried before giving up when creating an api token.
+const apiTokenNameAttempts = 5
+
+// newAPIToken returns an api token without a subject, name or hash, those are set by createAPIToken.
+func newAPIToken(request *APITokenOptions) (*dockyardsv1.APIToken, error) {
+	apiToken := dockyardsv1.APIToken{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{},
+		},
+	}
+
+	if request.DisplayName != nil {
+		apiToken.Spec.DisplayName = *request.DisplayName
+	}
+
+	if request.Duration != nil {
+		duration, err := time.ParseDuration(*request.Duration)
+		if err != nil || duration <= 0 {
+			fieldErrors := field.ErrorList{
+				field.Invalid(field.NewPath("duration"), *request.Duration, "must be a positive duration"),
+			}
+
+			return nil, apierrors.NewInvalid(dockyardsv1.GroupVersion.WithKind(dockyardsv1.APITokenKind).GroupKind(), "", fieldErrors)
+		}
+
+		apiToken.Spec.Duration = &metav1.Duration{
+			Duration: duration,
+		}
+	}
+
+	if request.Organizations != nil || request.Verbs != nil {
+		apiToken.Spec.Scope = &dockyardsv1.APITokenScope{}
+
+		if request.Organizations != nil {
+			apiToken.Spec.Scope.Organizations = *request.Organizations
+		}
+
+		if request.Verbs != nil {
+			for _, verb := range *request.Verbs {
+				apiToken.Spec.Scope.Verbs = append(apiToken.Spec.Scope.Verbs, dockyardsv1.APITokenVerb(verb))
+			}
+		}
+	}
+
+	return &apiToken, nil
+}
+
+// createAPIToken creates the api token with a random name starting with the prefix. The name is part of the token
+// so it is generated here rather than by the api server to be able to store the hash when creating the resource, a
+// name already in use is retried with a new one.
+func (h *handler) createAPIToken(ctx context.Context, apiToken *dockyardsv1.APIToken, prefix string) (*APIToken, error) {
+	// The token inherits the authentication methods of the request creating it, organizations requiring
+	// multi-factor authentication only accept tokens created by subjects that used a second factor.
+	claims, err := middleware.ClaimsFrom(ctx)
+	if err == nil {
+		apiToken.Spec.AuthenticationMethods = claims.AuthenticationMethods
+	}
+
+	var token string
+
+	for attempt := 1; ; attempt++ {
+		name := prefix + "-" + utilrand.String(5)
+		token = dockyardsv1.APITokenPrefix + name + "." + rand.Text()
+
+		apiToken.Name = name
+		apiToken.Spec.TokenHash = middleware.HashAPIToken(token)
+
+		err := h.Create(ctx, apiToken)
+		if apierrors.IsAlreadyExists(err) && attempt < apiTokenNameAttempts {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		break
+	}
+
+	if apiToken.Spec.Duration != nil {
+		patch := client.MergeFrom(apiToken.DeepCopy())
+
+		apiToken.Status.ExpirationTimestamp = apiToken.GetExpiration()
+
+		err := h.Status().Patch(ctx, apiToken, patch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := toAPIToken(apiToken)
+	result.Token = &token
+
+	return &result, nil
+}
+
+func (h *handler) CreateUserAPIToken(ctx context.Context, user *dockyardsv1.User, request *APITokenOptions) (*APIToken, error) {
+	if middleware.APITokenFrom(ctx) != nil {
+		return nil, apierrors.NewForbidden(dockyardsv1.GroupVersion.WithResource("apitokens").GroupResource(), "", errNotWithAPIToken)
+	}
+
+	apiToken, err := newAPIToken(request)
+	if err != nil {
+		return nil, err
+	}
+
+	apiToken.Labels[dockyardsv1.LabelUserName] = user.Name
+	apiToken.OwnerReferences = []metav1.OwnerReference{
+		{
+			APIVersion: dockyardsv1.GroupVersion.String(),
+			Kind:       dockyardsv1.UserKind,
+			Name:       user.Name,
+			UID:        user.UID,
+		},
+	}
+	apiToken.Spec.UserRef = &corev1.TypedLocalObjectReference{
+		APIGroup: &dockyardsv1.GroupVersion.Group,
+		Kind:     dockyardsv1.UserKind,
+		Name:     user.Name,
+	}
+
+	return h.createAPIToken(ctx, apiToken, user.Name)
+}
+
+func (h *handler) ListUserAPITokens(ctx context.Context, user *dockyardsv1.User) (*[]APIToken, error) {
+	matchingLabels := client.MatchingLabels{
+		dockyardsv1.LabelUserName: user.Name,
+	}
+
+	var apiTokenList dockyardsv1.APITokenList
+	err := h.List(ctx, &apiTokenList, matchingLabels)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]APIToken, len(apiTokenList.Items))
+
+	for i, item := range apiTokenList.Items {
+		result[i] = toAPIToken(&item)
+	}
+
+	return &result, nil
+}
+
+func (h *handler) DeleteUserAPIToken(ctx context.Context, user *dockyardsv1.User, apiTokenName string) error {
+	// An api token can be used to revoke itself but no other tokens.
+	apiToken := middleware.APITokenFrom(ctx)
+	if apiToken != nil && apiToken.Name != apiTokenName {
+		return apierrors.NewForbidden(dockyardsv1.GroupVersion.WithResource("apitokens").GroupResource(), apiTokenName, errNotWithAPIToken)
+	}
+
+	var existing dockyardsv1.APIToken
+	err := h.Get(ctx, client.ObjectKey{Name: apiTokenName}, &existing)
+	if err != nil {
+		return err
+	}
+
+	if existing.Spec.UserRef == nil || existing.Spec.UserRef.Name != user.Name {
+		return apierrors.NewNotFound(dockyardsv1.GroupVersion.WithResource("apitokens").GroupResource(), apiTokenName)
+	}
+
+	return h.Delete(ctx, &existing)
+}
@@ -0,0 +1,216 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"testing"
+
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/handlers"
+	"k8s.io/utils/ptr"
+)
+
+func TestUserAPITokens_Create(t *testing.T) {
+	mgr := testEnvironment.GetManager()
+
+	organization := testEnvironment.MustCreateOrganization(t)
+	user := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleUser)
+	otherUser := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleReader)
+
+	userToken := MustSignToken(t, user.Name)
+
+	var apiToken handlers.APIToken
+
+	t.Run("test as user", func(t *testing.T) {
+		request := handlers.APITokenOptions{
+			DisplayName: ptr.To("ci"),
+			Duration:    ptr.To("24h"),
+			Verbs:       &[]string{"get"},
+		}
+
+		b, err := json.Marshal(&request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u := url.URL{
+			Path: path.Join("/v1/users", user.Name, "tokens"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+		}
+
+		b, err = io.ReadAll(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = json.Unmarshal(b, &apiToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if apiToken.Token == nil {
+			t.Fatal("expected token in response")
+		}
+
+		if apiToken.ExpiresAt == nil {
+			t.Error("expected expiration in response")
+		}
+	})
+
+	t.Run("test using token", func(t *testing.T) {
+		mgr.GetCache().WaitForCacheSync(ctx)
+
+		u := url.URL{
+			Path: "/v1/whoami",
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+*apiToken.Token)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, statusCode)
+		}
+	})
+
+	t.Run("test token out of scope", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "clusters"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBufferString(`{"name":"test"}`))
+
+		r.Header.Add("Authorization", "Bearer "+*apiToken.Token)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+
+	t.Run("test as other user", func(t *testing.T) {
+		otherUserToken := MustSignToken(t, otherUser.Name)
+
+		u := url.URL{
+			Path: path.Join("/v1/users", user.Name, "tokens"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBufferString(`{}`))
+
+		r.Header.Add("Authorization", "Bearer "+otherUserToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+
+	t.Run("test invalid duration", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/users", user.Name, "tokens"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBufferString(`{"duration":"-1h"}`))
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnprocessableEntity {
+			t.Errorf("expected status code %d, got %d", http.StatusUnprocessableEntity, statusCode)
+		}
+	})
+}
+
+func TestUserAPITokens_Delete(t *testing.T) {
+	mgr := testEnvironment.GetManager()
+
+	organization := testEnvironment.MustCreateOrganization(t)
+	user := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleUser)
+
+	userToken := MustSignToken(t, user.Name)
+
+	u := url.URL{
+		Path: path.Join("/v1/users", user.Name, "tokens"),
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBufferString(`{}`))
+
+	r.Header.Add("Authorization", "Bearer "+userToken)
+
+	mux.ServeHTTP(w, r)
+
+	statusCode := w.Result().StatusCode
+	if statusCode != http.StatusCreated {
+		t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+	}
+
+	var apiToken handlers.APIToken
+	err := json.NewDecoder(w.Result().Body).Decode(&apiToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mgr.GetCache().WaitForCacheSync(ctx)
+
+	t.Run("test as user", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/users", user.Name, "tokens", apiToken.Name),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusAccepted {
+			t.Errorf("expected status code %d, got %d", http.StatusAccepted, statusCode)
+		}
+	})
+}
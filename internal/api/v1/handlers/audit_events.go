@@ -0,0 +1,163 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	"github.com/sudoswedenab/dockyards-backend/internal/audit"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=dockyards.io,resources=auditevents,verbs=get;list;watch
+
+// ListOrganizationAuditEvents lists the audit events of an organization, the events are filtered using the query
+// parameters subject, resource, verb, since, until and limit. The events are read from the audit event resources in
+// the namespace of the organization which are retained for the configured duration.
+func (h *handler) ListOrganizationAuditEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := middleware.LoggerFrom(ctx).With("resource", "auditevents")
+
+	organizationName := r.PathValue("organizationName")
+	if organizationName == "" {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	var organization dockyardsv1.Organization
+	err := h.Get(ctx, client.ObjectKey{Name: organizationName}, &organization)
+	if client.IgnoreNotFound(err) != nil {
+		logger.Error("error getting organization", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if apierrors.IsNotFound(err) {
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	if organization.Spec.NamespaceRef == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	subject, err := middleware.SubjectFrom(ctx)
+	if err != nil {
+		logger.Error("error getting subject from context", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	resourceAttributes := authorizationv1.ResourceAttributes{
+		Group:     dockyardsv1.GroupVersion.Group,
+		Namespace: organization.Spec.NamespaceRef.Name,
+		Resource:  "auditevents",
+		Verb:      "list",
+	}
+
+	allowed, err := apiutil.IsSubjectAllowed(ctx, h.Client, subject, &resourceAttributes)
+	if err != nil {
+		logger.Error("error reviewing subject", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if !allowed {
+		logger.Debug("subject is not allowed to list resource", "subject", subject, "organization", organization.Name)
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	query := r.URL.Query()
+
+	filter := audit.Filter{
+		Organization: organization.Name,
+		Subject:      query.Get("subject"),
+		Resource:     query.Get("resource"),
+		Verb:         query.Get("verb"),
+	}
+
+	for key, value := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if !query.Has(key) {
+			continue
+		}
+
+		*value, err = time.Parse(time.RFC3339, query.Get(key))
+		if err != nil {
+			logger.Debug("error parsing time", "key", key, "err", err)
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	if query.Has("limit") {
+		filter.Limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil || filter.Limit < 1 {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	var auditEventList dockyardsv1.AuditEventList
+	err = h.List(ctx, &auditEventList, client.InNamespace(organization.Spec.NamespaceRef.Name))
+	if err != nil {
+		logger.Error("error listing audit events", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	events := make([]audit.Event, len(auditEventList.Items))
+	for i := range auditEventList.Items {
+		events[i] = audit.EventFromResource(organization.Name, &auditEventList.Items[i])
+	}
+
+	response := filter.Apply(events)
+
+	b, err := json.Marshal(response)
+	if err != nil {
+		logger.Error("error marshalling response", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(b)
+	if err != nil {
+		logger.Error("error writing response", "err", err)
+
+		return
+	}
+}
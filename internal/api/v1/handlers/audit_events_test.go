@@ -0,0 +1,223 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	apitypes "github.com/sudoswedenab/dockyards-api/pkg/types"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/audit"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestOrganizationAuditEvents_List(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("no kubebuilder assets configured")
+	}
+
+	organization := testEnvironment.MustCreateOrganization(t)
+
+	superUser := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleSuperUser)
+	user := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleUser)
+	reader := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleReader)
+
+	superUserToken := MustSignToken(t, superUser.Name)
+	userToken := MustSignToken(t, user.Name)
+	readerToken := MustSignToken(t, reader.Name)
+
+	options := apitypes.InvitationOptions{
+		Email: "audit@dockyards.dev",
+		Role:  string(dockyardsv1.RoleReader),
+	}
+
+	b, err := json.Marshal(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := sha256.Sum256(b)
+
+	invitationPath := path.Join("/v1/orgs", organization.Name, "invitations")
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, invitationPath, bytes.NewBuffer(b))
+
+	r.Header.Add("Authorization", "Bearer "+superUserToken)
+	r.Header.Add("Content-Type", "application/json")
+
+	mux.ServeHTTP(w, r)
+
+	statusCode := w.Result().StatusCode
+	if statusCode != http.StatusCreated {
+		t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+	}
+
+	u := url.URL{
+		Path: path.Join("/v1/orgs", organization.Name, "audit-events"),
+	}
+
+	// The audit event is written to the resource sink in the background.
+	err = wait.PollUntilContextTimeout(t.Context(), time.Millisecond*200, time.Second*5, true, func(ctx context.Context) (bool, error) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+superUserToken)
+
+		mux.ServeHTTP(w, r)
+
+		var events []audit.Event
+		err := json.Unmarshal(w.Body.Bytes(), &events)
+		if err != nil {
+			return false, nil
+		}
+
+		return len(events) > 0, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test as super user", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+superUserToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, statusCode)
+		}
+
+		b, err := io.ReadAll(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual []audit.Event
+		err = json.Unmarshal(b, &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []audit.Event{
+			{
+				Subject:       superUser.Name,
+				Organization:  organization.Name,
+				Resource:      "invitations",
+				Verb:          "create",
+				Method:        http.MethodPost,
+				Path:          invitationPath,
+				RequestDigest: "sha256:" + hex.EncodeToString(digest[:]),
+				StatusCode:    http.StatusCreated,
+			},
+		}
+
+		ignoreTimestamp := cmpopts.IgnoreFields(audit.Event{}, "Timestamp")
+
+		if !cmp.Equal(actual, expected, ignoreTimestamp) {
+			t.Errorf("diff: %s", cmp.Diff(expected, actual, ignoreTimestamp))
+		}
+	})
+
+	t.Run("test filter", func(t *testing.T) {
+		q := url.Values{
+			"verb": []string{"delete"},
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path+"?"+q.Encode(), nil)
+
+		r.Header.Add("Authorization", "Bearer "+superUserToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, statusCode)
+		}
+
+		b, err := io.ReadAll(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(b) != "[]" {
+			t.Errorf("expected empty list, got %s", b)
+		}
+	})
+
+	t.Run("test invalid since", func(t *testing.T) {
+		q := url.Values{
+			"since": []string{"yesterday"},
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path+"?"+q.Encode(), nil)
+
+		r.Header.Add("Authorization", "Bearer "+superUserToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, statusCode)
+		}
+	})
+
+	t.Run("test as user", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+
+	t.Run("test as reader", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+readerToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+}
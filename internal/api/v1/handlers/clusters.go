@@ -26,8 +26,10 @@ import (
 	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
 	"github.com/sudoswedenab/dockyards-backend/api/config"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
 	"github.com/sudoswedenab/dockyards-backend/pkg/util/name"
 	"gopkg.in/yaml.v3"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -422,6 +424,20 @@ func (h *handler) DeleteOrganizationCluster(ctx context.Context, organization *d
 	return nil
 }
 
+// isClusterAllowed returns true if the subject is allowed to get the cluster, members may be limited to a subset of
+// the clusters in an organization.
+func (h *handler) isClusterAllowed(ctx context.Context, subject string, cluster *dockyardsv1.Cluster) (bool, error) {
+	resourceAttributes := authorizationv1.ResourceAttributes{
+		Group:     dockyardsv1.GroupVersion.Group,
+		Name:      cluster.Name,
+		Namespace: cluster.Namespace,
+		Resource:  "clusters",
+		Verb:      "get",
+	}
+
+	return apiutil.IsSubjectAllowed(ctx, h.Client, subject, &resourceAttributes)
+}
+
 func (h *handler) ListOrganizationClusters(ctx context.Context, organization *dockyardsv1.Organization) (*[]types.Cluster, error) {
 	var clusterList dockyardsv1.ClusterList
 	err := h.List(ctx, &clusterList, client.InNamespace(organization.Spec.NamespaceRef.Name))
@@ -429,9 +445,43 @@ func (h *handler) ListOrganizationClusters(ctx context.Context, organization *do
 		return nil, err
 	}
 
-	response := make([]types.Cluster, len(clusterList.Items))
+	subject, err := middleware.SubjectFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceAttributes := authorizationv1.ResourceAttributes{
+		Group:     dockyardsv1.GroupVersion.Group,
+		Namespace: organization.Spec.NamespaceRef.Name,
+		Resource:  "clusters",
+		Verb:      "get",
+	}
+
+	allowed, err := apiutil.IsSubjectAllowed(ctx, h.Client, subject, &resourceAttributes)
+	if err != nil {
+		return nil, err
+	}
+
+	items := clusterList.Items
+
+	if !allowed {
+		items = []dockyardsv1.Cluster{}
+
+		for _, item := range clusterList.Items {
+			allowed, err := h.isClusterAllowed(ctx, subject, &item)
+			if err != nil {
+				return nil, err
+			}
+
+			if allowed {
+				items = append(items, item)
+			}
+		}
+	}
+
+	response := make([]types.Cluster, len(items))
 
-	for i, item := range clusterList.Items {
+	for i, item := range items {
 		cluster := types.Cluster{
 			CreatedAt: item.CreationTimestamp.Time,
 			ID:        string(item.UID),
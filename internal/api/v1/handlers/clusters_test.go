@@ -28,6 +28,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/sudoswedenab/dockyards-api/pkg/types"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/pkg/authorization"
 	"github.com/sudoswedenab/dockyards-backend/pkg/testing/testingutil"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -1891,3 +1892,182 @@ func mustMarshalJSON(value any) []byte {
 	}
 	return bytes
 }
+
+func TestOrganizationClusters_Restricted(t *testing.T) {
+	c := testEnvironment.GetClient()
+
+	organization := testEnvironment.MustCreateOrganization(t)
+
+	user := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleUser)
+	userToken := MustSignToken(t, user.Name)
+
+	allowedCluster := dockyardsv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "allowed",
+			Namespace: organization.Spec.NamespaceRef.Name,
+		},
+	}
+
+	otherCluster := dockyardsv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "other",
+			Namespace: organization.Spec.NamespaceRef.Name,
+		},
+	}
+
+	for _, cluster := range []*dockyardsv1.Cluster{&allowedCluster, &otherCluster} {
+		err := c.Create(ctx, cluster)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var member dockyardsv1.Member
+	err := c.Get(ctx, client.ObjectKey{Name: user.Name, Namespace: organization.Spec.NamespaceRef.Name}, &member)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patch := client.MergeFrom(member.DeepCopy())
+
+	member.Spec.Clusters = &dockyardsv1.MemberClusters{
+		Names: []string{
+			allowedCluster.Name,
+		},
+	}
+
+	err = c.Patch(ctx, &member, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = authorization.ReconcileMemberAuthorization(ctx, c, &member)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test listing clusters", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "clusters"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, statusCode)
+		}
+
+		b, err := io.ReadAll(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual []types.Cluster
+		err = json.Unmarshal(b, &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(actual) != 1 || actual[0].Name != allowedCluster.Name {
+			t.Errorf("expected only cluster %s, got %v", allowedCluster.Name, actual)
+		}
+	})
+
+	t.Run("test getting allowed cluster", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "clusters", allowedCluster.Name),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, statusCode)
+		}
+	})
+
+	t.Run("test getting other cluster", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "clusters", otherCluster.Name),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+
+	t.Run("test creating kubeconfig for other cluster", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "clusters", otherCluster.Name, "kubeconfig"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBufferString("{}"))
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+
+	// Credentials are authorized against clusters in the whole organization, permissions to get some of the
+	// clusters must not grant access to them.
+	t.Run("test listing credentials", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "credentials"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+
+	t.Run("test getting credential named as allowed cluster", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "credentials", allowedCluster.Name),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+}
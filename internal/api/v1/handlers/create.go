@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/sudoswedenab/dockyards-api/pkg/types"
 	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
@@ -123,6 +124,21 @@ func CreateClusterResource[T1, T2 any](h *handler, resource string, f CreateClus
 			return
 		}
 
+		allowed, err = h.isClusterAllowed(ctx, subject, &cluster)
+		if err != nil {
+			logger.Error("error reviewing subject", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if !allowed {
+			logger.Debug("subject is not allowed to access cluster", "subject", subject, "cluster", cluster.Name)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
 		b, err := io.ReadAll(r.Body)
 		if err != nil {
 			logger.Error("error reading request body", "err", err)
@@ -438,6 +454,17 @@ func CreateGlobalResource[T1, T2 any](resource string, f CreateGlobalResourceFun
 			return
 		}
 
+		if apierrors.IsTooManyRequests(err) {
+			retryAfter, ok := apierrors.SuggestsClientDelay(err)
+			if ok {
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			}
+
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
 		if apierrors.IsUnauthorized(err) {
 			logger.Error("error creating global resource", "err", err)
 			w.WriteHeader(http.StatusUnauthorized)
@@ -475,3 +502,352 @@ func CreateGlobalResource[T1, T2 any](resource string, f CreateGlobalResourceFun
 		}
 	}
 }
+
+type CreateUserResourceFunc[T1, T2 any] func(context.Context, *dockyardsv1.User, *T1) (*T2, error)
+
+func CreateUserResource[T1, T2 any](h *handler, resource string, f CreateUserResourceFunc[T1, T2]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		logger := middleware.LoggerFrom(ctx).With("resource", resource)
+
+		userName := r.PathValue("userName")
+		if userName == "" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		var user dockyardsv1.User
+		err := h.Get(ctx, client.ObjectKey{Name: userName}, &user)
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error("error getting user", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if apierrors.IsNotFound(err) {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		subject, err := middleware.SubjectFrom(ctx)
+		if err != nil {
+			logger.Error("error getting subject from context", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		// Resources belonging to a user are authorized against the user itself.
+		resourceAttributes := authorizationv1.ResourceAttributes{
+			Group:    dockyardsv1.GroupVersion.Group,
+			Name:     user.Name,
+			Resource: "users",
+			Verb:     "patch",
+		}
+
+		allowed, err := apiutil.IsSubjectAllowed(ctx, h.Client, subject, &resourceAttributes)
+		if err != nil {
+			logger.Error("error reviewing subject", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if !allowed {
+			logger.Debug("subject is not allowed to create resource", "subject", subject, "user", user.Name)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			logger.Error("error reading request body", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		var request T1
+		err = json.Unmarshal(b, &request)
+		if err != nil {
+			logger.Error("error unmarshalling request", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		response, err := f(ctx, &user, &request)
+		if apierrors.IsForbidden(err) {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		if apiutil.IgnoreClientError(err) != nil {
+			logger.Error("error creating resource", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) {
+			w.WriteHeader(http.StatusConflict)
+
+			return
+		}
+
+		if apierrors.IsInvalid(err) {
+			statusError, ok := err.(*apierrors.StatusError)
+			if !ok {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+
+				return
+			}
+
+			if statusError.ErrStatus.Details == nil {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+
+				return
+			}
+
+			var response types.UnprocessableEntityErrors
+
+			for _, cause := range statusError.ErrStatus.Details.Causes {
+				response.Errors = append(response.Errors, fmt.Sprintf("%s: %s", cause.Field, cause.Message))
+			}
+
+			b, err := json.Marshal(response)
+			if err != nil {
+				logger.Error("error marhalling response", "err", err)
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, err = w.Write(b)
+			if err != nil {
+				logger.Error("error writing response", "err", err)
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			return
+		}
+
+		b, err = json.Marshal(response)
+		if err != nil {
+			logger.Error("error marshalling response", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_, err = w.Write(b)
+		if err != nil {
+			logger.Error("error writing response", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+	}
+}
+
+type CreateServiceAccountResourceFunc[T1, T2 any] func(context.Context, *dockyardsv1.ServiceAccount, *T1) (*T2, error)
+
+func CreateServiceAccountResource[T1, T2 any](h *handler, resource string, f CreateServiceAccountResourceFunc[T1, T2]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		logger := middleware.LoggerFrom(ctx).With("resource", resource)
+
+		organizationName := r.PathValue("organizationName")
+		if organizationName == "" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		serviceAccountName := r.PathValue("serviceAccountName")
+		if serviceAccountName == "" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		var organization dockyardsv1.Organization
+		err := h.Get(ctx, client.ObjectKey{Name: organizationName}, &organization)
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error("error getting organization", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if apierrors.IsNotFound(err) {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if organization.Spec.NamespaceRef == nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		subject, err := middleware.SubjectFrom(ctx)
+		if err != nil {
+			logger.Error("error getting subject from context", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		resourceAttributes := authorizationv1.ResourceAttributes{
+			Group:     dockyardsv1.GroupVersion.Group,
+			Namespace: organization.Spec.NamespaceRef.Name,
+			Resource:  resource,
+			Verb:      "create",
+		}
+
+		allowed, err := apiutil.IsSubjectAllowed(ctx, h.Client, subject, &resourceAttributes)
+		if err != nil {
+			logger.Error("error reviewing subject", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if !allowed {
+			logger.Debug("subject is not allowed to create resource", "subject", subject, "organization", organization.Name)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		objectKey := client.ObjectKey{
+			Name:      serviceAccountName,
+			Namespace: organization.Spec.NamespaceRef.Name,
+		}
+
+		var serviceAccount dockyardsv1.ServiceAccount
+		err = h.Get(ctx, objectKey, &serviceAccount)
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error("error getting service account", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if apierrors.IsNotFound(err) {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			logger.Error("error reading request body", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		var request T1
+		err = json.Unmarshal(b, &request)
+		if err != nil {
+			logger.Error("error unmarshalling request", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		response, err := f(ctx, &serviceAccount, &request)
+		if apierrors.IsForbidden(err) {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		if apiutil.IgnoreClientError(err) != nil {
+			logger.Error("error creating resource", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) {
+			w.WriteHeader(http.StatusConflict)
+
+			return
+		}
+
+		if apierrors.IsInvalid(err) {
+			statusError, ok := err.(*apierrors.StatusError)
+			if !ok {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+
+				return
+			}
+
+			if statusError.ErrStatus.Details == nil {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+
+				return
+			}
+
+			var response types.UnprocessableEntityErrors
+
+			for _, cause := range statusError.ErrStatus.Details.Causes {
+				response.Errors = append(response.Errors, fmt.Sprintf("%s: %s", cause.Field, cause.Message))
+			}
+
+			b, err := json.Marshal(response)
+			if err != nil {
+				logger.Error("error marhalling response", "err", err)
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, err = w.Write(b)
+			if err != nil {
+				logger.Error("error writing response", "err", err)
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			return
+		}
+
+		b, bytes := any(*response).([]byte)
+		if !bytes {
+			b, err = json.Marshal(response)
+			if err != nil {
+				logger.Error("error marshalling response", "err", err)
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_, err = w.Write(b)
+		if err != nil {
+			logger.Error("error writing response", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+	}
+}
@@ -17,6 +17,7 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"strings"
 
 	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
@@ -126,6 +127,21 @@ func DeleteClusterResource(h *handler, resource string, f DeleteClusterResourceF
 			return
 		}
 
+		allowed, err = h.isClusterAllowed(ctx, subject, &cluster)
+		if err != nil {
+			logger.Error("error reviewing subject", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if !allowed {
+			logger.Debug("subject is not allowed to access cluster", "subject", subject, "cluster", cluster.Name)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
 		err = f(ctx, &cluster, resourceName)
 		if client.IgnoreNotFound(err) != nil {
 			logger.Error("error deleting resource", "err", err)
@@ -219,6 +235,12 @@ func DeleteOrganizationResource(h *handler, resource string, f DeleteOrganizatio
 		}
 
 		err = f(ctx, &organization, resourceName)
+		if apierrors.IsForbidden(err) {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
 		if client.IgnoreNotFound(err) != nil {
 			logger.Error("error deleting resource", "err", err)
 			w.WriteHeader(http.StatusInternalServerError)
@@ -259,11 +281,15 @@ func DeleteGlobalResource(h *handler, resource string, f DeleteGlobalResourceFun
 			return
 		}
 
+		// The resource may include a subresource, such as users/lockout.
+		resource, subresource, _ := strings.Cut(resource, "/")
+
 		resourceAttributes := authorizationv1.ResourceAttributes{
-			Group:    dockyardsv1.GroupVersion.Group,
-			Resource: resource,
-			Name:     resourceName,
-			Verb:     "delete",
+			Group:       dockyardsv1.GroupVersion.Group,
+			Resource:    resource,
+			Subresource: subresource,
+			Name:        resourceName,
+			Verb:        "delete",
 		}
 
 		allowed, err := apiutil.IsSubjectAllowed(ctx, h.Client, subject, &resourceAttributes)
@@ -298,3 +324,272 @@ func DeleteGlobalResource(h *handler, resource string, f DeleteGlobalResourceFun
 		w.WriteHeader(http.StatusAccepted)
 	}
 }
+
+// DeleteSubjectResource deletes a global resource owned by the subject, the ownership is verified by the
+// delete function instead of a subject access review.
+func DeleteSubjectResource(resource string, f DeleteGlobalResourceFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		logger := middleware.LoggerFrom(ctx).With("resource", resource)
+
+		resourceName := r.PathValue("resourceName")
+		if resourceName == "" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		err := f(ctx, resourceName)
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error("error deleting resource", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if apierrors.IsNotFound(err) {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+type DeleteNamelessResourceFunc func(context.Context) error
+
+func DeleteNamelessResource(resource string, f DeleteNamelessResourceFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		logger := middleware.LoggerFrom(ctx).With("resource", resource)
+
+		err := f(ctx)
+		if apierrors.IsForbidden(err) {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		if err != nil {
+			logger.Error("error deleting resource", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+type DeleteUserResourceFunc func(context.Context, *dockyardsv1.User, string) error
+
+func DeleteUserResource(h *handler, resource string, f DeleteUserResourceFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		logger := middleware.LoggerFrom(ctx).With("resource", resource)
+
+		resourceName := r.PathValue("resourceName")
+		if resourceName == "" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		userName := r.PathValue("userName")
+		if userName == "" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		var user dockyardsv1.User
+		err := h.Get(ctx, client.ObjectKey{Name: userName}, &user)
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error("error getting user", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if apierrors.IsNotFound(err) {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		subject, err := middleware.SubjectFrom(ctx)
+		if err != nil {
+			logger.Error("error getting subject from context", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		// Resources belonging to a user are authorized against the user itself.
+		resourceAttributes := authorizationv1.ResourceAttributes{
+			Group:    dockyardsv1.GroupVersion.Group,
+			Name:     user.Name,
+			Resource: "users",
+			Verb:     "patch",
+		}
+
+		allowed, err := apiutil.IsSubjectAllowed(ctx, h.Client, subject, &resourceAttributes)
+		if err != nil {
+			logger.Error("error reviewing subject", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if !allowed {
+			logger.Debug("subject is not allowed to delete resource", "subject", subject, "user", user.Name)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		err = f(ctx, &user, resourceName)
+		if apierrors.IsForbidden(err) {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error("error deleting resource", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if apierrors.IsNotFound(err) {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+type DeleteServiceAccountResourceFunc func(context.Context, *dockyardsv1.ServiceAccount, string) error
+
+func DeleteServiceAccountResource(h *handler, resource string, f DeleteServiceAccountResourceFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		logger := middleware.LoggerFrom(ctx).With("resource", resource)
+
+		organizationName := r.PathValue("organizationName")
+		if organizationName == "" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		serviceAccountName := r.PathValue("serviceAccountName")
+		if serviceAccountName == "" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		resourceName := r.PathValue("resourceName")
+		if resourceName == "" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		var organization dockyardsv1.Organization
+		err := h.Get(ctx, client.ObjectKey{Name: organizationName}, &organization)
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error("eror getting organization", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if apierrors.IsNotFound(err) {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if organization.Spec.NamespaceRef == nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		subject, err := middleware.SubjectFrom(ctx)
+		if err != nil {
+			logger.Error("error getting subject from context", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		resourceAttributes := authorizationv1.ResourceAttributes{
+			Group:     dockyardsv1.GroupVersion.Group,
+			Namespace: organization.Spec.NamespaceRef.Name,
+			Resource:  resource,
+			Verb:      "delete",
+		}
+
+		allowed, err := apiutil.IsSubjectAllowed(ctx, h.Client, subject, &resourceAttributes)
+		if err != nil {
+			logger.Error("error reviewing subject", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if !allowed {
+			logger.Debug("subject is not allowed to delete resource", "subject", subject, "organization", organization.Name)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		objectKey := client.ObjectKey{
+			Name:      serviceAccountName,
+			Namespace: organization.Spec.NamespaceRef.Name,
+		}
+
+		var serviceAccount dockyardsv1.ServiceAccount
+		err = h.Get(ctx, objectKey, &serviceAccount)
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error("error getting service account", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if apierrors.IsNotFound(err) {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		err = f(ctx, &serviceAccount, resourceName)
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error("error deleting resource", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if apierrors.IsNotFound(err) {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
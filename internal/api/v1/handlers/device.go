@@ -0,0 +1,384 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
+	"github.com/sudoswedenab/dockyards-backend/api/config"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/api/v1alpha3/index"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=dockyards.io,resources=devicecodes,verbs=create;delete;get;list;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=devicecodes/status,verbs=patch
+
+const (
+	deviceCodeDuration = time.Minute * 10
+	deviceCodeInterval = time.Second * 5
+
+	// userCodeAlphabet leaves out vowels and easily confused characters as recommended by RFC 8628.
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength   = 8
+)
+
+// errDeviceNotWithAPIToken is returned when an api token is used to approve a device, the device would
+// otherwise be issued a session without the scope of the api token.
+var errDeviceNotWithAPIToken = errors.New("devices cannot be approved using an api token")
+
+// Errors returned by the device token endpoint as described in RFC 8628.
+const (
+	deviceErrorAuthorizationPending = "authorization_pending"
+	deviceErrorSlowDown             = "slow_down"
+	deviceErrorAccessDenied         = "access_denied"
+	deviceErrorExpiredToken         = "expired_token"
+	deviceErrorInvalidGrant         = "invalid_grant"
+)
+
+type DeviceCodeOptions struct {
+	ClientID *string `json:"client_id,omitempty"`
+}
+
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type DeviceTokenOptions struct {
+	DeviceCode string `json:"device_code"`
+}
+
+type DeviceVerificationOptions struct {
+	UserCode string `json:"user_code"`
+	Deny     *bool  `json:"deny,omitempty"`
+}
+
+type DeviceTokenError struct {
+	Error string `json:"error"`
+}
+
+func hashDeviceCode(deviceCode string) string {
+	hash := sha256.Sum256([]byte(deviceCode))
+
+	return hex.EncodeToString(hash[:])
+}
+
+func generateUserCode() (string, error) {
+	b := make([]byte, userCodeLength)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(userCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+
+		b[i] = userCodeAlphabet[n.Int64()]
+	}
+
+	return string(b), nil
+}
+
+// normalizeUserCode removes the formatting of a user code, codes are accepted in any case and with or without
+// separators.
+func normalizeUserCode(userCode string) string {
+	userCode = strings.ToUpper(userCode)
+
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(userCodeAlphabet, r) {
+			return r
+		}
+
+		return -1
+	}, userCode)
+}
+
+func formatUserCode(userCode string) string {
+	return userCode[:userCodeLength/2] + "-" + userCode[userCodeLength/2:]
+}
+
+// CreateGlobalDeviceCode starts a device authorization, the device polls the token endpoint using the device
+// code while the user approves the device using the user code.
+func (h *handler) CreateGlobalDeviceCode(ctx context.Context, request *DeviceCodeOptions) (*DeviceAuthorization, error) {
+	deviceCode := rand.Text()
+
+	userCode, err := generateUserCode()
+	if err != nil {
+		return nil, err
+	}
+
+	object := dockyardsv1.DeviceCode{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: hashDeviceCode(deviceCode),
+		},
+		Spec: dockyardsv1.DeviceCodeSpec{
+			Code: userCode,
+			Duration: &metav1.Duration{
+				Duration: deviceCodeDuration,
+			},
+		},
+	}
+
+	if request.ClientID != nil {
+		object.Spec.ClientID = *request.ClientID
+	}
+
+	err = h.Create(ctx, &object)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := client.MergeFrom(object.DeepCopy())
+
+	object.Status.ExpirationTimestamp = object.GetExpiration()
+
+	err = h.Status().Patch(ctx, &object, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	externalURL := h.Config.GetValueOrDefault(config.KeyExternalURL, "")
+	verificationURI := strings.TrimSuffix(externalURL, "/") + "/device"
+
+	response := DeviceAuthorization{
+		DeviceCode:              deviceCode,
+		UserCode:                formatUserCode(userCode),
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?" + url.Values{"user_code": []string{formatUserCode(userCode)}}.Encode(),
+		ExpiresIn:               int(deviceCodeDuration.Seconds()),
+		Interval:                int(deviceCodeInterval.Seconds()),
+	}
+
+	return &response, nil
+}
+
+// UpdateGlobalDeviceVerification approves or denies the device with the user code on behalf of the subject.
+func (h *handler) UpdateGlobalDeviceVerification(ctx context.Context, request *DeviceVerificationOptions) error {
+	if middleware.APITokenFrom(ctx) != nil {
+		return apierrors.NewForbidden(dockyardsv1.GroupVersion.WithResource("devicecodes").GroupResource(), "", errDeviceNotWithAPIToken)
+	}
+
+	subject, err := middleware.SubjectFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	var user dockyardsv1.User
+	err = h.Get(ctx, client.ObjectKey{Name: subject}, &user)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return apierrors.NewUnauthorized("only users can approve devices")
+		}
+
+		return err
+	}
+
+	var deviceCodeList dockyardsv1.DeviceCodeList
+	err = h.List(ctx, &deviceCodeList, client.MatchingFields{
+		index.CodeField: normalizeUserCode(request.UserCode),
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(deviceCodeList.Items) != 1 {
+		return apierrors.NewUnauthorized("could not find device code")
+	}
+
+	deviceCodeName := deviceCodeList.Items[0].Name
+
+	var authenticationMethods []string
+	claims, err := middleware.ClaimsFrom(ctx)
+	if err == nil {
+		authenticationMethods = claims.AuthenticationMethods
+	}
+
+	condition := metav1.Condition{
+		Type:    dockyardsv1.ApprovedCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  dockyardsv1.ApprovedReason,
+		Message: "approved by " + user.Name,
+	}
+
+	if request.Deny != nil && *request.Deny {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = dockyardsv1.DeniedReason
+		condition.Message = "denied by " + user.Name
+	}
+
+	// The device code is read directly from the api server since the device updates it while polling.
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var deviceCode dockyardsv1.DeviceCode
+		err := h.apiReader.Get(ctx, client.ObjectKey{Name: deviceCodeName}, &deviceCode)
+		if apierrors.IsNotFound(err) {
+			return apierrors.NewUnauthorized("could not find device code")
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if apiutil.HasExpired(&deviceCode) {
+			return apierrors.NewUnauthorized("device code has expired")
+		}
+
+		if meta.FindStatusCondition(deviceCode.Status.Conditions, dockyardsv1.ApprovedCondition) != nil {
+			return apierrors.NewUnauthorized("device code has already been verified")
+		}
+
+		patch := client.MergeFromWithOptions(deviceCode.DeepCopy(), client.MergeFromWithOptimisticLock{})
+
+		deviceCode.Status.UserRef = &corev1.TypedLocalObjectReference{
+			APIGroup: &dockyardsv1.GroupVersion.Group,
+			Kind:     dockyardsv1.UserKind,
+			Name:     user.Name,
+		}
+		deviceCode.Status.AuthenticationMethods = authenticationMethods
+
+		meta.SetStatusCondition(&deviceCode.Status.Conditions, condition)
+
+		return h.Status().Patch(ctx, &deviceCode, patch)
+	})
+}
+
+func writeDeviceTokenError(w http.ResponseWriter, deviceError string) error {
+	b, err := json.Marshal(DeviceTokenError{Error: deviceError})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusBadRequest)
+
+	_, err = w.Write(b)
+
+	return err
+}
+
+// CreateDeviceToken is polled by the device until the user has approved or denied it, errors are returned as
+// described in RFC 8628 to let the device tell pending authorizations from failed ones.
+func (h *handler) CreateDeviceToken(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	var request DeviceTokenOptions
+	err = json.Unmarshal(b, &request)
+	if err != nil {
+		return apierrors.NewBadRequest("could not unmarshal request")
+	}
+
+	// The device code is read directly from the api server to see approvals made by other replicas.
+	var deviceCode dockyardsv1.DeviceCode
+	err = h.apiReader.Get(ctx, client.ObjectKey{Name: hashDeviceCode(request.DeviceCode)}, &deviceCode)
+	if apierrors.IsNotFound(err) {
+		return writeDeviceTokenError(w, deviceErrorInvalidGrant)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !deviceCode.DeletionTimestamp.IsZero() || apiutil.HasExpired(&deviceCode) {
+		return writeDeviceTokenError(w, deviceErrorExpiredToken)
+	}
+
+	condition := meta.FindStatusCondition(deviceCode.Status.Conditions, dockyardsv1.ApprovedCondition)
+	if condition == nil {
+		lastPoll := deviceCode.Status.LastPollTimestamp
+		if lastPoll != nil && time.Since(lastPoll.Time) < deviceCodeInterval {
+			return writeDeviceTokenError(w, deviceErrorSlowDown)
+		}
+
+		patch := client.MergeFrom(deviceCode.DeepCopy())
+
+		deviceCode.Status.LastPollTimestamp = &metav1.Time{Time: time.Now()}
+
+		err := h.Status().Patch(ctx, &deviceCode, patch)
+		if err != nil {
+			return err
+		}
+
+		return writeDeviceTokenError(w, deviceErrorAuthorizationPending)
+	}
+
+	// The device code is deleted before any tokens are issued to make sure it is only exchanged once.
+	err = h.Delete(ctx, &deviceCode, client.Preconditions{UID: &deviceCode.UID, ResourceVersion: &deviceCode.ResourceVersion})
+	if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+		return writeDeviceTokenError(w, deviceErrorInvalidGrant)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if condition.Status != metav1.ConditionTrue || deviceCode.Status.UserRef == nil {
+		return writeDeviceTokenError(w, deviceErrorAccessDenied)
+	}
+
+	var user dockyardsv1.User
+	err = h.Get(ctx, client.ObjectKey{Name: deviceCode.Status.UserRef.Name}, &user)
+	if apierrors.IsNotFound(err) {
+		return writeDeviceTokenError(w, deviceErrorAccessDenied)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	session, err := h.createSession(ctx, &user, deviceCode.Status.AuthenticationMethods...)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := h.generateTokens(ctx, &user, session)
+	if err != nil {
+		return err
+	}
+
+	b, err = json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(b)
+
+	return err
+}
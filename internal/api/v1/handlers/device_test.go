@@ -0,0 +1,225 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/sudoswedenab/dockyards-api/pkg/types"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/handlers"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
+)
+
+func mustCreateDeviceCode(t *testing.T) *handlers.DeviceAuthorization {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v1/device/code", bytes.NewBufferString(`{"client_id":"dyctl"}`))
+
+	mux.ServeHTTP(w, r)
+
+	statusCode := w.Result().StatusCode
+	if statusCode != http.StatusCreated {
+		t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+	}
+
+	var deviceAuthorization handlers.DeviceAuthorization
+	err := json.Unmarshal(w.Body.Bytes(), &deviceAuthorization)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &deviceAuthorization
+}
+
+func postDeviceToken(t *testing.T, deviceCode string) *httptest.ResponseRecorder {
+	options := handlers.DeviceTokenOptions{
+		DeviceCode: deviceCode,
+	}
+
+	b, err := json.Marshal(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v1/device/token", bytes.NewBuffer(b))
+
+	mux.ServeHTTP(w, r)
+
+	return w
+}
+
+func expectDeviceTokenError(t *testing.T, w *httptest.ResponseRecorder, expected string) {
+	statusCode := w.Result().StatusCode
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, statusCode)
+	}
+
+	var actual handlers.DeviceTokenError
+	err := json.Unmarshal(w.Body.Bytes(), &actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual.Error != expected {
+		t.Fatalf("expected error %s, got %s", expected, actual.Error)
+	}
+}
+
+// mustVerifyDevice retries the verification until the device code has been observed by the cache.
+func mustVerifyDevice(t *testing.T, token string, options handlers.DeviceVerificationOptions) {
+	b, err := json.Marshal(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = wait.PollUntilContextTimeout(ctx, time.Millisecond*200, time.Second*5, true, func(ctx context.Context) (bool, error) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/device/verify", bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+token)
+
+		mux.ServeHTTP(w, r)
+
+		return w.Result().StatusCode == http.StatusAccepted, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeviceAuthorization(t *testing.T) {
+	organization := testEnvironment.MustCreateOrganization(t)
+	user := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleUser)
+	userToken := MustSignToken(t, user.Name)
+
+	t.Run("test approve", func(t *testing.T) {
+		deviceAuthorization := mustCreateDeviceCode(t)
+
+		w := postDeviceToken(t, deviceAuthorization.DeviceCode)
+		expectDeviceTokenError(t, w, "authorization_pending")
+
+		w = postDeviceToken(t, deviceAuthorization.DeviceCode)
+		expectDeviceTokenError(t, w, "slow_down")
+
+		mustVerifyDevice(t, userToken, handlers.DeviceVerificationOptions{
+			UserCode: deviceAuthorization.UserCode,
+		})
+
+		w = postDeviceToken(t, deviceAuthorization.DeviceCode)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, statusCode)
+		}
+
+		var tokens types.Tokens
+		err := json.Unmarshal(w.Body.Bytes(), &tokens)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+			t.Fatal("expected access and refresh tokens")
+		}
+
+		w = postDeviceToken(t, deviceAuthorization.DeviceCode)
+		expectDeviceTokenError(t, w, "invalid_grant")
+	})
+
+	t.Run("test deny", func(t *testing.T) {
+		deviceAuthorization := mustCreateDeviceCode(t)
+
+		mustVerifyDevice(t, userToken, handlers.DeviceVerificationOptions{
+			UserCode: deviceAuthorization.UserCode,
+			Deny:     ptr.To(true),
+		})
+
+		w := postDeviceToken(t, deviceAuthorization.DeviceCode)
+		expectDeviceTokenError(t, w, "access_denied")
+	})
+
+	t.Run("test approve with api token", func(t *testing.T) {
+		deviceAuthorization := mustCreateDeviceCode(t)
+
+		request := handlers.APITokenOptions{
+			DisplayName: ptr.To("device"),
+			Duration:    ptr.To("1h"),
+		}
+
+		b, err := json.Marshal(&request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, path.Join("/v1/users", user.Name, "tokens"), bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+		}
+
+		var apiToken handlers.APIToken
+		err = json.Unmarshal(w.Body.Bytes(), &apiToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		options := handlers.DeviceVerificationOptions{
+			UserCode: deviceAuthorization.UserCode,
+		}
+
+		b, err = json.Marshal(&options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The api token is retried until it has been observed by the cache.
+		err = wait.PollUntilContextTimeout(ctx, time.Millisecond*200, time.Second*5, true, func(ctx context.Context) (bool, error) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/v1/device/verify", bytes.NewBuffer(b))
+
+			r.Header.Add("Authorization", "Bearer "+*apiToken.Token)
+
+			mux.ServeHTTP(w, r)
+
+			return w.Result().StatusCode == http.StatusForbidden, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w = postDeviceToken(t, deviceAuthorization.DeviceCode)
+		expectDeviceTokenError(t, w, "authorization_pending")
+	})
+
+	t.Run("test invalid device code", func(t *testing.T) {
+		w := postDeviceToken(t, "invalid")
+		expectDeviceTokenError(t, w, "invalid_grant")
+	})
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=core,resources=events,verbs=list
+
+// Event is a Kubernetes event regarding a resource.
+type Event struct {
+	Type      string    `json:"type"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+	Kind      string    `json:"kind"`
+	Name      string    `json:"name"`
+	Count     int32     `json:"count"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ListClusterEvents lists the events regarding the cluster, oldest first. Events are read directly from the
+// API server since they are not cached.
+func (h *handler) ListClusterEvents(ctx context.Context, cluster *dockyardsv1.Cluster) (*[]Event, error) {
+	matchingFields := client.MatchingFields{
+		"involvedObject.uid": string(cluster.UID),
+	}
+
+	var eventList corev1.EventList
+	err := h.apiReader.List(ctx, &eventList, matchingFields, client.InNamespace(cluster.Namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Event, len(eventList.Items))
+	for i, item := range eventList.Items {
+		result[i] = Event{
+			Type:      item.Type,
+			Reason:    item.Reason,
+			Message:   item.Message,
+			Kind:      item.InvolvedObject.Kind,
+			Name:      item.InvolvedObject.Name,
+			Count:     eventCount(&item),
+			Timestamp: eventTimestamp(&item),
+		}
+	}
+
+	slices.SortStableFunc(result, func(a, b Event) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+
+	return &result, nil
+}
+
+// eventCount returns the number of occurrences of an event, events created using the events API keep the count in
+// the series.
+func eventCount(event *corev1.Event) int32 {
+	if event.Series != nil {
+		return event.Series.Count
+	}
+
+	if event.Count == 0 {
+		return 1
+	}
+
+	return event.Count
+}
+
+// eventTimestamp returns the time of the last occurrence of an event.
+func eventTimestamp(event *corev1.Event) time.Time {
+	if event.Series != nil {
+		return event.Series.LastObservedTime.Time
+	}
+
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+
+	return event.EventTime.Time
+}
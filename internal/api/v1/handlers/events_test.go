@@ -0,0 +1,148 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/handlers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterEvents_List(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("no kubebuilder assets configured")
+	}
+
+	c := testEnvironment.GetClient()
+
+	organization := testEnvironment.MustCreateOrganization(t)
+	otherOrganization := testEnvironment.MustCreateOrganization(t)
+
+	reader := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleReader)
+	otherUser := testEnvironment.MustGetOrganizationUser(t, otherOrganization, dockyardsv1.RoleSuperUser)
+
+	readerToken := MustSignToken(t, reader.Name)
+	otherToken := MustSignToken(t, otherUser.Name)
+
+	cluster := dockyardsv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    organization.Spec.NamespaceRef.Name,
+		},
+	}
+
+	err := c.Create(ctx, &cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timestamp := metav1.NewTime(time.Now().Truncate(time.Second))
+
+	event := corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: cluster.Name + "-",
+			Namespace:    cluster.Namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: dockyardsv1.GroupVersion.String(),
+			Kind:       dockyardsv1.ClusterKind,
+			Name:       cluster.Name,
+			Namespace:  cluster.Namespace,
+			UID:        cluster.UID,
+		},
+		Type:           corev1.EventTypeNormal,
+		Reason:         "UpgradesAvailable",
+		Message:        "Upgrades available to v1.31.0",
+		Count:          2,
+		FirstTimestamp: timestamp,
+		LastTimestamp:  timestamp,
+	}
+
+	err = c.Create(ctx, &event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := url.URL{
+		Path: path.Join("/v1/orgs", organization.Name, "clusters", cluster.Name, "events"),
+	}
+
+	t.Run("test as reader", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+readerToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, statusCode)
+		}
+
+		b, err := io.ReadAll(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual []handlers.Event
+		err = json.Unmarshal(b, &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []handlers.Event{
+			{
+				Type:      corev1.EventTypeNormal,
+				Reason:    "UpgradesAvailable",
+				Message:   "Upgrades available to v1.31.0",
+				Kind:      dockyardsv1.ClusterKind,
+				Name:      cluster.Name,
+				Count:     2,
+				Timestamp: timestamp.Time,
+			},
+		}
+
+		if !cmp.Equal(actual, expected) {
+			t.Errorf("diff: %s", cmp.Diff(expected, actual))
+		}
+	})
+
+	t.Run("test as other organization", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+otherToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+}
@@ -127,6 +127,21 @@ func GetClusterResource[T any](h *handler, resource string, f GetClusterResource
 			return
 		}
 
+		allowed, err = h.isClusterAllowed(ctx, subject, &cluster)
+		if err != nil {
+			logger.Error("error reviewing subject", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if !allowed {
+			logger.Debug("subject is not allowed to access cluster", "subject", subject, "cluster", cluster.Name)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
 		response, err := f(ctx, &cluster, resourceName)
 		if client.IgnoreNotFound(err) != nil {
 			logger.Error("error getting resource", "err", err)
@@ -163,6 +178,16 @@ func GetClusterResource[T any](h *handler, resource string, f GetClusterResource
 type GetOrganizationResourceFunc[T any] func(context.Context, *dockyardsv1.Organization, string) (*T, error)
 
 func GetOrganizationResource[T any](h *handler, resource string, f GetOrganizationResourceFunc[T]) http.HandlerFunc {
+	return getOrganizationResource(h, resource, false, f)
+}
+
+// GetOrganizationNamedResource authorizes getting the named resource rather than any resource of the kind in the
+// organization, members limited to a subset of the clusters are only allowed to get some of them.
+func GetOrganizationNamedResource[T any](h *handler, resource string, f GetOrganizationResourceFunc[T]) http.HandlerFunc {
+	return getOrganizationResource(h, resource, true, f)
+}
+
+func getOrganizationResource[T any](h *handler, resource string, named bool, f GetOrganizationResourceFunc[T]) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
@@ -218,6 +243,10 @@ func GetOrganizationResource[T any](h *handler, resource string, f GetOrganizati
 			Verb:      "get",
 		}
 
+		if named {
+			resourceAttributes.Name = resourceName
+		}
+
 		allowed, err := apiutil.IsSubjectAllowed(ctx, h.Client, subject, &resourceAttributes)
 		if err != nil {
 			logger.Error("error reviewing subject", "err", err)
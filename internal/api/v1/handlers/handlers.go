@@ -15,13 +15,15 @@
 package handlers
 
 import (
-	"crypto/ecdsa"
 	"errors"
 	"log/slog"
 	"net/http"
 
 	"github.com/sudoswedenab/dockyards-backend/api/config"
 	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	"github.com/sudoswedenab/dockyards-backend/internal/audit"
+	"github.com/sudoswedenab/dockyards-backend/internal/metrics"
+	utiljwt "github.com/sudoswedenab/dockyards-backend/pkg/util/jwt"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -30,22 +32,30 @@ import (
 type handler struct {
 	client.Client
 
-	logger               *slog.Logger
-	systemNamespace      string
-	jwtAccessPrivateKey  *ecdsa.PrivateKey
-	jwtRefreshPrivateKey *ecdsa.PrivateKey
-	jwtAccessPublicKey   *ecdsa.PublicKey
-	jwtRefreshPublicKey  *ecdsa.PublicKey
+	apiReader       client.Reader
+	logger          *slog.Logger
+	systemNamespace string
+	jwtKeySet       *utiljwt.KeySet
 	Config 	             *config.ConfigManager
+
+	metrics             *metrics.PrometheusMetrics
+	loginEmailLimiter   *loginLimiter
+	loginAddressLimiter *loginLimiter
+	trustForwardedFor   bool
+	allowedOrigins      []string
+	auditRecorder       *audit.Recorder
+	samlMetadata        *samlMetadataCache
 }
 
 type HandlerOption func(*handler)
 
 func WithManager(mgr ctrl.Manager) HandlerOption {
 	controllerClient := mgr.GetClient()
+	apiReader := mgr.GetAPIReader()
 
 	return func(h *handler) {
 		h.Client = controllerClient
+		h.apiReader = apiReader
 	}
 }
 
@@ -55,15 +65,9 @@ func WithSystemNamespace(namespace string) HandlerOption {
 	}
 }
 
-func WithJWTPrivateKeys(accessKey, refreshKey *ecdsa.PrivateKey) HandlerOption {
-	accessPublicKey := accessKey.PublicKey
-	refreshPublicKey := refreshKey.PublicKey
-
+func WithJWTKeySet(keySet *utiljwt.KeySet) HandlerOption {
 	return func(h *handler) {
-		h.jwtAccessPrivateKey = accessKey
-		h.jwtRefreshPrivateKey = refreshKey
-		h.jwtAccessPublicKey = &accessPublicKey
-		h.jwtRefreshPublicKey = &refreshPublicKey
+		h.jwtKeySet = keySet
 	}
 }
 
@@ -79,6 +83,35 @@ func WithConfigManager(config *config.ConfigManager) HandlerOption {
 	}
 }
 
+func WithPrometheusMetrics(prometheusMetrics *metrics.PrometheusMetrics) HandlerOption {
+	return func(h *handler) {
+		h.metrics = prometheusMetrics
+	}
+}
+
+// WithTrustForwardedFor makes the client address of requests be taken from the X-Forwarded-For header, it
+// must only be enabled when running behind a reverse proxy that sets the header.
+func WithTrustForwardedFor(trustForwardedFor bool) HandlerOption {
+	return func(h *handler) {
+		h.trustForwardedFor = trustForwardedFor
+	}
+}
+
+// WithAllowedOrigins sets the origins allowed as single sign-on callbacks in addition to the allowed origins of
+// the config manager.
+func WithAllowedOrigins(allowedOrigins []string) HandlerOption {
+	return func(h *handler) {
+		h.allowedOrigins = allowedOrigins
+	}
+}
+
+// WithAuditRecorder records every mutating request as an audit event.
+func WithAuditRecorder(auditRecorder *audit.Recorder) HandlerOption {
+	return func(h *handler) {
+		h.auditRecorder = auditRecorder
+	}
+}
+
 func RegisterRoutes(mux *http.ServeMux, handlerOptions ...HandlerOption) error {
 	var h handler
 
@@ -90,9 +123,27 @@ func RegisterRoutes(mux *http.ServeMux, handlerOptions ...HandlerOption) error {
 		h.logger.Warn("using empty namespace")
 	}
 
-	logger := middleware.NewLogger(h.logger).Handler
-	requireAuth := middleware.NewRequireAuth(h.jwtAccessPublicKey).Handler
-	requireRefresh := middleware.NewRequireAuth(h.jwtRefreshPublicKey).Handler
+	h.loginEmailLimiter = newLoginLimiter(emailFreeAttempts)
+	h.loginAddressLimiter = newLoginLimiter(addressFreeAttempts)
+	h.samlMetadata = newSAMLMetadataCache()
+
+	loggerHandler := middleware.NewLogger(h.logger).Handler
+	auditHandler := middleware.NewAudit(h.auditRecorder).Handler
+
+	// Every route is audited, the audit middleware only records requests with mutating methods.
+	logger := func(next http.Handler) http.Handler {
+		return loggerHandler(auditHandler(next))
+	}
+
+	// Routes with passwords, codes, tokens or credentials in the body are audited without request digests.
+	credentialAuditHandler := middleware.NewAudit(h.auditRecorder, middleware.WithoutRequestDigest()).Handler
+	credentialLogger := func(next http.Handler) http.Handler {
+		return loggerHandler(credentialAuditHandler(next))
+	}
+
+	clientIP := middleware.NewClientIP(h.trustForwardedFor).Handler
+	requireAuth := middleware.NewRequireAuth(h.jwtKeySet.AccessTokenKeyfunc, middleware.WithAPITokens(h.Client), middleware.WithOrganizationMFA(h.Client)).Handler
+	requireRefresh := middleware.NewRequireAuth(h.jwtKeySet.RefreshTokenKeyfunc).Handler
 	contentJSON := middleware.NewContentType("application/json").Handler
 	contentYAML := middleware.NewContentType("application/yaml").Handler
 
@@ -102,14 +153,64 @@ func RegisterRoutes(mux *http.ServeMux, handlerOptions ...HandlerOption) error {
 	}
 
 	mux.Handle("POST /v1/login",
+		credentialLogger(
+			clientIP(
+				contentJSON(
+					validateJSON.WithSchema("#login")(CreateGlobalResource("users", h.CreateGlobalTokens)),
+				),
+			),
+		),
+	)
+
+	mux.Handle("POST /v1/login/mfa",
+		credentialLogger(
+			clientIP(
+				contentJSON(
+					validateJSON.WithSchema("#loginMFA")(CreateGlobalResource("users", h.CreateGlobalMFATokens)),
+				),
+			),
+		),
+	)
+
+	mux.Handle("POST /v1/login/sso",
+		credentialLogger(
+			contentJSON(
+				validateJSON.WithSchema("#loginSSO")(CreateGlobalResource("sessions", h.CreateGlobalSSOTokens)),
+			),
+		),
+	)
+
+	mux.Handle("POST /v1/device/code",
 		logger(
 			contentJSON(
-				validateJSON.WithSchema("#login")(CreateGlobalResource("users", h.CreateGlobalTokens)),
+				validateJSON.WithSchema("#createDeviceCode")(CreateGlobalResource("devicecodes", h.CreateGlobalDeviceCode)),
 			),
 		),
 	)
 
-	mux.Handle("POST /v1/refresh", logger(requireRefresh(contentJSON(GetNamelessResource(h.GetGlobalTokens)))))
+	mux.Handle("POST /v1/device/token",
+		credentialLogger(
+			contentJSON(
+				validateJSON.WithSchema("#deviceToken")(unprotectedRoute(h.CreateDeviceToken)),
+			),
+		),
+	)
+
+	mux.Handle("POST /v1/device/verify",
+		credentialLogger(
+			requireAuth(
+				validateJSON.WithSchema("#verifyDevice")(UpdateNamelessResource(&h, "devicecodes", h.UpdateGlobalDeviceVerification)),
+			),
+		),
+	)
+
+	mux.Handle("POST /v1/refresh", credentialLogger(requireRefresh(contentJSON(GetNamelessResource(h.GetGlobalTokens)))))
+
+	mux.Handle("GET /.well-known/jwks.json", logger(contentJSON(UnprotectedResource(h.GetJWKS))))
+
+	mux.Handle("GET /v1/sessions", logger(requireAuth(contentJSON(ListGlobalResource("sessions", h.ListGlobalSessions)))))
+	mux.Handle("DELETE /v1/sessions", logger(requireAuth(DeleteNamelessResource("sessions", h.DeleteGlobalSessions))))
+	mux.Handle("DELETE /v1/sessions/{resourceName}", logger(requireAuth(DeleteSubjectResource("sessions", h.DeleteGlobalSession))))
 
 	mux.Handle("GET /v1/cluster-options", logger(requireAuth(GetNamelessResource(h.GetClusterOptions))))
 
@@ -128,6 +229,16 @@ func RegisterRoutes(mux *http.ServeMux, handlerOptions ...HandlerOption) error {
 		),
 	)
 
+	mux.Handle("POST /v1/organization-vouchers",
+		logger(
+			requireAuth(
+				contentJSON(
+					validateJSON.WithSchema("#createOrganizationVouchers")(CreateGlobalResource("organizationvouchers", h.CreateGlobalOrganizationVouchers)),
+				),
+			),
+		),
+	)
+
 	mux.Handle("POST /v1/orgs/{organizationName}/clusters",
 		logger(
 			requireAuth(
@@ -141,7 +252,7 @@ func RegisterRoutes(mux *http.ServeMux, handlerOptions ...HandlerOption) error {
 	mux.Handle("GET /v1/whoami", logger(requireAuth(contentJSON(GetNamelessResource(h.GetWhoami)))))
 
 	mux.Handle("POST /v1/orgs/{organizationName}/credentials",
-		logger(
+		credentialLogger(
 			requireAuth(
 				contentJSON(
 					validateJSON.WithSchema("#createCredential")(CreateOrganizationResource(&h, "clusters", h.CreateOrganizationCredential)),
@@ -157,7 +268,7 @@ func RegisterRoutes(mux *http.ServeMux, handlerOptions ...HandlerOption) error {
 	mux.Handle("GET /v1/orgs/{organizationName}/credentials/{resourceName}", logger(requireAuth(contentJSON(GetOrganizationResource(&h, "clusters", h.GetOrganizationCredential)))))
 
 	mux.Handle("PATCH /v1/orgs/{organizationName}/credentials/{resourceName}",
-		logger(
+		credentialLogger(
 			requireAuth(
 				contentJSON(
 					validateJSON.WithSchema("#updateCredential")(UpdateOrganizationResource(&h, "clusters", h.UpdateOrganizationCredential)),
@@ -207,8 +318,8 @@ func RegisterRoutes(mux *http.ServeMux, handlerOptions ...HandlerOption) error {
 	mux.Handle("GET /v1/orgs/{organizationName}/clusters/{clusterName}/node-pools", logger(requireAuth(contentJSON(ListClusterResource(&h, "nodepools", h.ListClusterNodePools)))))
 	mux.Handle("PATCH /v1/orgs/{organizationName}/clusters/{clusterName}/node-pools/{resourceName}", logger(requireAuth(UpdateClusterResource(&h, "nodepools", h.UpdateClusterNodePool))))
 
-	mux.Handle("GET /v1/orgs/{organizationName}/clusters", logger(requireAuth(contentJSON(ListOrganizationResource(&h, "clusters", h.ListOrganizationClusters)))))
-	mux.Handle("GET /v1/orgs/{organizationName}/clusters/{resourceName}", logger(requireAuth(contentJSON(GetOrganizationResource(&h, "clusters", h.GetOrganizationCluster)))))
+	mux.Handle("GET /v1/orgs/{organizationName}/clusters", logger(requireAuth(contentJSON(ListOrganizationFilteredResource(&h, "clusters", h.ListOrganizationClusters)))))
+	mux.Handle("GET /v1/orgs/{organizationName}/clusters/{resourceName}", logger(requireAuth(contentJSON(GetOrganizationNamedResource(&h, "clusters", h.GetOrganizationCluster)))))
 
 	mux.Handle("POST /v1/orgs/{organizationName}/clusters/{clusterName}/kubeconfig", logger(requireAuth(contentYAML(CreateClusterResource(&h, "clusters", h.CreateClusterKubeconfig)))))
 
@@ -224,23 +335,123 @@ func RegisterRoutes(mux *http.ServeMux, handlerOptions ...HandlerOption) error {
 
 	mux.Handle("DELETE /v1/orgs/{organizationName}/invitations/{resourceName}", logger(requireAuth(DeleteOrganizationResource(&h, "invitations", h.DeleteOrganizationInvitation))))
 	mux.Handle("GET /v1/orgs/{organizationName}/invitations", logger(requireAuth(contentJSON(ListOrganizationResource(&h, "invitations", h.ListOrganizationInvitations)))))
+	mux.Handle("POST /v1/orgs/{organizationName}/invitations/{resourceName}/resend", logger(requireAuth(UpdateOrganizationResource(&h, "invitations", h.ResendOrganizationInvitation))))
 
 	mux.Handle("GET /v1/invitations", logger(requireAuth(contentJSON(ListGlobalResource("invitations", h.ListGlobalInvitations)))))
 	mux.Handle("DELETE /v1/invitations/{resourceName}", logger(requireAuth(contentJSON(DeleteGlobalResource(&h, "invitations", h.DeleteGlobalInvitation)))))
 	mux.Handle("PATCH /v1/invitations/{resourceName}", logger(requireAuth(contentJSON(UpdateGlobalResource(&h, "invitations", h.UpdateGlobalInvitation)))))
 
+	mux.Handle("POST /v1/invitations/accept",
+		credentialLogger(
+			contentJSON(
+				validateJSON.WithSchema("#acceptInvitation")(CreateGlobalResource("invitations", h.AcceptInvitation)),
+			),
+		),
+	)
+
+	mux.Handle("POST /v1/invitations/decline",
+		credentialLogger(
+			validateJSON.WithSchema("#declineInvitation")(UpdateNamelessResource(&h, "invitations", h.DeclineInvitation)),
+		),
+	)
+
+	mux.Handle("GET /v1/orgs/{organizationName}/audit-events", logger(requireAuth(contentJSON(http.HandlerFunc(h.ListOrganizationAuditEvents)))))
+
+	mux.Handle("GET /v1/orgs/{organizationName}/clusters/{clusterName}/events", logger(requireAuth(contentJSON(ListClusterResource(&h, "clusters", h.ListClusterEvents)))))
+
 	mux.Handle("GET /v1/orgs/{organizationName}/clusters/{clusterName}/nodes", logger(requireAuth(contentJSON(ListClusterResource(&h, "nodes", h.ListClusterNodes)))))
 	mux.Handle("GET /v1/orgs/{organizationName}/clusters/{clusterName}/nodes/{resourceName}", logger(requireAuth(contentJSON(GetClusterResource(&h, "nodes", h.GetClusterNode)))))
 
-	mux.Handle("POST /v1/users", logger(CreateGlobalResource("users", h.CreateGlobalUser)))
-	mux.Handle("PUT /v1/users/{resourceName}", logger(requireAuth(UpdateGlobalResource(&h, "users", h.UpdateGlobalUser))))
+	mux.Handle("POST /v1/users", credentialLogger(CreateGlobalResource("users", h.CreateGlobalUser)))
+	mux.Handle("PUT /v1/users/{resourceName}", credentialLogger(requireAuth(UpdateGlobalResource(&h, "users", h.UpdateGlobalUser))))
 
 	mux.Handle("GET /v1/orgs/{organizationName}/members", logger(requireAuth(contentJSON(ListOrganizationResource(&h, "members", h.ListOrganizationMembers)))))
 	mux.Handle("DELETE /v1/orgs/{organizationName}/members/{resourceName}", logger(requireAuth(contentJSON(DeleteOrganizationResource(&h, "members", h.DeleteOrganizationMember)))))
 
-	mux.Handle("POST /v1/users/{resourceName}/password", logger(requireAuth(UpdateGlobalResource(&h, "users", h.UpdateUserPassword))))
-	mux.Handle("POST /v1/verify",
+	mux.Handle("POST /v1/orgs/{organizationName}/members",
+		logger(
+			requireAuth(
+				contentJSON(
+					validateJSON.WithSchema("#createMember")(CreateOrganizationResource(&h, "members", h.CreateOrganizationMember)),
+				),
+			),
+		),
+	)
+
+	mux.Handle("PATCH /v1/orgs/{organizationName}/members/{resourceName}",
+		logger(
+			requireAuth(
+				validateJSON.WithSchema("#updateMember")(UpdateOrganizationResource(&h, "members", h.UpdateOrganizationMember)),
+			),
+		),
+	)
+
+	mux.Handle("POST /v1/users/{userName}/tokens",
+		logger(
+			requireAuth(
+				contentJSON(
+					validateJSON.WithSchema("#createAPIToken")(CreateUserResource(&h, "apitokens", h.CreateUserAPIToken)),
+				),
+			),
+		),
+	)
+
+	mux.Handle("GET /v1/users/{userName}/tokens", logger(requireAuth(contentJSON(ListUserResource(&h, "apitokens", h.ListUserAPITokens)))))
+	mux.Handle("DELETE /v1/users/{userName}/tokens/{resourceName}", logger(requireAuth(DeleteUserResource(&h, "apitokens", h.DeleteUserAPIToken))))
+
+	mux.Handle("DELETE /v1/users/{resourceName}/lockout", logger(requireAuth(DeleteGlobalResource(&h, "users/lockout", h.DeleteUserLockout))))
+
+	mux.Handle("POST /v1/users/{userName}/mfa", logger(requireAuth(contentJSON(CreateUserResource(&h, "users", h.CreateUserMFA)))))
+
+	mux.Handle("POST /v1/users/{userName}/mfa/confirm",
+		credentialLogger(
+			requireAuth(
+				contentJSON(
+					validateJSON.WithSchema("#mfaCode")(CreateUserResource(&h, "users", h.CreateUserMFAConfirmation)),
+				),
+			),
+		),
+	)
+
+	mux.Handle("POST /v1/users/{userName}/mfa/deactivate",
+		credentialLogger(
+			requireAuth(
+				contentJSON(
+					validateJSON.WithSchema("#mfaCode")(CreateUserResource(&h, "users", h.CreateUserMFADeactivation)),
+				),
+			),
+		),
+	)
+
+	mux.Handle("POST /v1/orgs/{organizationName}/service-accounts",
 		logger(
+			requireAuth(
+				contentJSON(
+					validateJSON.WithSchema("#createServiceAccount")(CreateOrganizationResource(&h, "serviceaccounts", h.CreateOrganizationServiceAccount)),
+				),
+			),
+		),
+	)
+
+	mux.Handle("GET /v1/orgs/{organizationName}/service-accounts", logger(requireAuth(contentJSON(ListOrganizationResource(&h, "serviceaccounts", h.ListOrganizationServiceAccounts)))))
+	mux.Handle("DELETE /v1/orgs/{organizationName}/service-accounts/{resourceName}", logger(requireAuth(DeleteOrganizationResource(&h, "serviceaccounts", h.DeleteOrganizationServiceAccount))))
+
+	mux.Handle("POST /v1/orgs/{organizationName}/service-accounts/{serviceAccountName}/tokens",
+		logger(
+			requireAuth(
+				contentJSON(
+					validateJSON.WithSchema("#createAPIToken")(CreateServiceAccountResource(&h, "serviceaccounts", h.CreateServiceAccountAPIToken)),
+				),
+			),
+		),
+	)
+
+	mux.Handle("GET /v1/orgs/{organizationName}/service-accounts/{serviceAccountName}/tokens", logger(requireAuth(contentJSON(ListServiceAccountResource(&h, "serviceaccounts", h.ListServiceAccountAPITokens)))))
+	mux.Handle("DELETE /v1/orgs/{organizationName}/service-accounts/{serviceAccountName}/tokens/{resourceName}", logger(requireAuth(DeleteServiceAccountResource(&h, "serviceaccounts", h.DeleteServiceAccountAPIToken))))
+
+	mux.Handle("POST /v1/users/{resourceName}/password", credentialLogger(requireAuth(UpdateGlobalResource(&h, "users", h.UpdateUserPassword))))
+	mux.Handle("POST /v1/verify",
+		credentialLogger(
 			contentJSON(
 				validateJSON.WithSchema("#verifyOptions")(UpdateNamelessResource(&h, "verificationrequests", h.UpdateGlobalVerificationRequest)),
 			),
@@ -254,7 +465,7 @@ func RegisterRoutes(mux *http.ServeMux, handlerOptions ...HandlerOption) error {
 	)
 
 	mux.Handle("POST /v1/reset-password",
-		logger(
+		credentialLogger(
 			validateJSON.WithSchema("#resetPasswordOptions")(UpdateNamelessResource(&h, "verificationrequests", h.ResetPassword)),
 		),
 	)
@@ -263,6 +474,9 @@ func RegisterRoutes(mux *http.ServeMux, handlerOptions ...HandlerOption) error {
 
 	mux.Handle("GET /v1/login-sso", logger(unprotectedRoute(h.LoginOIDC)))
 	mux.Handle("GET /v1/callback-sso", logger(unprotectedRoute(h.Callback)))
+	mux.Handle("GET /v1/login-saml", logger(unprotectedRoute(h.LoginSAML)))
+	mux.Handle("GET /v1/metadata-saml", logger(unprotectedRoute(h.GetSAMLMetadata)))
+	mux.Handle("POST /v1/acs-saml", credentialLogger(unprotectedRoute(h.AssertionConsumerService)))
 
 	return nil
 }
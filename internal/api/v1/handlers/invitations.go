@@ -16,24 +16,47 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	mathrand "math/rand/v2"
 	"time"
 
+	"github.com/fluxcd/pkg/runtime/conditions"
 	"github.com/sudoswedenab/dockyards-api/pkg/types"
 	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
 	"github.com/sudoswedenab/dockyards-backend/api/v1alpha3/index"
 	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	"golang.org/x/crypto/bcrypt"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// +kubebuilder:rbac:groups=dockyards.io,resources=invitations,verbs=create;delete;get;list;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=invitations,verbs=create;delete;get;list;patch;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=invitations/status,verbs=patch
 // +kubebuilder:rbac:groups=dockyards.io,resources=members,verbs=create;get;list;watch
 // +kubebuilder:rbac:groups=dockyards.io,resources=organizations,verbs=get;list;watch
-// +kubebuilder:rbac:groups=dockyards.io,resources=users,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=users,verbs=create;get;list;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=users/status,verbs=patch
+// +kubebuilder:rbac:groups=dockyards.io,resources=verificationrequests,verbs=delete;get;list;watch
+
+var errInvitationNotPending = errors.New("invitation is no longer pending")
+
+// InvitationAcceptOptions accepts the invitation with the code sent to the invited address, the password is
+// required when the address does not belong to an existing user.
+type InvitationAcceptOptions struct {
+	Code        string  `json:"code"`
+	DisplayName *string `json:"display_name,omitempty"`
+	Password    *string `json:"password,omitempty"`
+}
+
+type InvitationDeclineOptions struct {
+	Code string `json:"code"`
+}
 
 func (h *handler) CreateOrganizationInvitation(ctx context.Context, organization *dockyardsv1.Organization, request *types.InvitationOptions) (*types.Invitation, error) {
 	invitation := dockyardsv1.Invitation{
@@ -102,6 +125,11 @@ func (h *handler) DeleteOrganizationInvitation(ctx context.Context, organization
 		return err
 	}
 
+	err = h.deleteInvitationVerificationRequest(ctx, &invitation)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -112,10 +140,14 @@ func (h *handler) ListOrganizationInvitations(ctx context.Context, organization
 		return nil, err
 	}
 
-	result := make([]types.Invitation, len(invitationList.Items))
+	result := []types.Invitation{}
 
-	for i, item := range invitationList.Items {
-		result[i] = types.Invitation{
+	for _, item := range invitationList.Items {
+		if !item.IsPending() {
+			continue
+		}
+
+		invitation := types.Invitation{
 			CreatedAt: item.CreationTimestamp.Time,
 			Email:     &item.Spec.Email,
 			ID:        string(item.UID),
@@ -124,14 +156,44 @@ func (h *handler) ListOrganizationInvitations(ctx context.Context, organization
 		}
 
 		if item.Spec.Duration != nil {
-			result[i].Duration = ptr.To(item.Spec.Duration.String())
-			result[i].ExpiresAt = &item.GetExpiration().Time
+			invitation.Duration = ptr.To(item.Spec.Duration.String())
+			invitation.ExpiresAt = &item.GetExpiration().Time
 		}
+
+		result = append(result, invitation)
 	}
 
 	return &result, nil
 }
 
+// ResendOrganizationInvitation resends the invitation by replacing the acceptance code, the previous code can no
+// longer be used to accept the invitation.
+func (h *handler) ResendOrganizationInvitation(ctx context.Context, organization *dockyardsv1.Organization, invitationName string, _ *types.InvitationOptions) error {
+	objectKey := client.ObjectKey{
+		Name:      invitationName,
+		Namespace: organization.Spec.NamespaceRef.Name,
+	}
+
+	var invitation dockyardsv1.Invitation
+	err := h.Get(ctx, objectKey, &invitation)
+	if err != nil {
+		return err
+	}
+
+	if !invitation.IsPending() || apiutil.HasExpired(&invitation) {
+		return apierrors.NewForbidden(dockyardsv1.GroupVersion.WithResource("invitations").GroupResource(), invitation.Name, errInvitationNotPending)
+	}
+
+	// The invitation reconciler creates a new verification request with a new code as soon as the current one
+	// has been deleted.
+	err = h.deleteInvitationVerificationRequest(ctx, &invitation)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (h *handler) ListGlobalInvitations(ctx context.Context) (*[]types.Invitation, error) {
 	subject, err := middleware.SubjectFrom(ctx)
 	if err != nil {
@@ -157,7 +219,7 @@ func (h *handler) ListGlobalInvitations(ctx context.Context) (*[]types.Invitatio
 	response := []types.Invitation{}
 
 	for _, item := range invitationList.Items {
-		if apiutil.HasExpired(&item) {
+		if !item.IsPending() || apiutil.HasExpired(&item) {
 			continue
 		}
 
@@ -188,6 +250,7 @@ func (h *handler) ListGlobalInvitations(ctx context.Context) (*[]types.Invitatio
 	return &response, nil
 }
 
+// DeleteGlobalInvitation declines the pending invitation to the organization for the subject.
 func (h *handler) DeleteGlobalInvitation(ctx context.Context, invitationName string) error {
 	var organization dockyardsv1.Organization
 	err := h.Get(ctx, client.ObjectKey{Name: invitationName}, &organization)
@@ -206,25 +269,12 @@ func (h *handler) DeleteGlobalInvitation(ctx context.Context, invitationName str
 		return err
 	}
 
-	matchingFields := client.MatchingFields{
-		index.EmailField: user.Spec.Email,
-	}
-
-	var invitationList dockyardsv1.InvitationList
-	err = h.List(ctx, &invitationList, matchingFields, client.InNamespace(organization.Spec.NamespaceRef.Name))
+	invitation, err := h.getPendingInvitation(ctx, &organization, user.Spec.Email)
 	if err != nil {
 		return err
 	}
 
-	if len(invitationList.Items) != 1 {
-		statusError := apierrors.NewUnauthorized("unexpected invitations count")
-
-		return statusError
-	}
-
-	invitation := invitationList.Items[0]
-
-	err = h.Delete(ctx, &invitation)
+	err = h.setInvitationCondition(ctx, invitation, metav1.ConditionFalse, dockyardsv1.InvitationDeclinedReason, "Declined by "+user.Name)
 	if err != nil {
 		return err
 	}
@@ -232,9 +282,8 @@ func (h *handler) DeleteGlobalInvitation(ctx context.Context, invitationName str
 	return nil
 }
 
+// UpdateGlobalInvitation accepts the pending invitation to the organization for the subject.
 func (h *handler) UpdateGlobalInvitation(ctx context.Context, organizationName string, _ *types.InvitationOptions) error {
-	logger := middleware.LoggerFrom(ctx)
-
 	objectKey := client.ObjectKey{
 		Name: organizationName,
 	}
@@ -256,29 +305,228 @@ func (h *handler) UpdateGlobalInvitation(ctx context.Context, organizationName s
 		return err
 	}
 
+	invitation, err := h.getPendingInvitation(ctx, &organization, user.Spec.Email)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.acceptInvitation(ctx, &organization, invitation, &user)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AcceptInvitation accepts an invitation using the code sent to the invited address, a user is
+// signed up with the address when there is no existing user.
+func (h *handler) AcceptInvitation(ctx context.Context, request *InvitationAcceptOptions) (*types.Member, error) {
+	time.Sleep(time.Duration(mathrand.Float64() * float64(time.Second))) // Harden against brute force attacks.
+
+	organization, invitation, err := h.getInvitationByCode(ctx, request.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	var userList dockyardsv1.UserList
+	err = h.List(ctx, &userList, client.MatchingFields{
+		index.EmailField: invitation.Spec.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var user *dockyardsv1.User
+
+	switch len(userList.Items) {
+	case 0:
+		user, err = h.createInvitedUser(ctx, invitation, request)
+		if err != nil {
+			return nil, err
+		}
+	case 1:
+		user = &userList.Items[0]
+	default:
+		return nil, errors.New("unexpected multiple users with the same email")
+	}
+
+	member, err := h.acceptInvitation(ctx, organization, invitation, user)
+	if err != nil {
+		return nil, err
+	}
+
+	response := types.Member{
+		CreatedAt: member.CreationTimestamp.Time,
+		Email:     &user.Spec.Email,
+		ID:        string(member.UID),
+		Name:      member.Name,
+		Role:      ptr.To(string(member.Spec.Role)),
+	}
+
+	return &response, nil
+}
+
+// getInvitationByCode returns the pending invitation with the acceptance code and the organization it belongs to.
+func (h *handler) getInvitationByCode(ctx context.Context, code string) (*dockyardsv1.Organization, *dockyardsv1.Invitation, error) {
+	var verificationRequestList dockyardsv1.VerificationRequestList
+	err := h.List(ctx, &verificationRequestList, client.MatchingFields{
+		index.CodeField: code,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(verificationRequestList.Items) != 1 {
+		return nil, nil, apierrors.NewUnauthorized("could not find verification request")
+	}
+
+	verificationRequest := verificationRequestList.Items[0]
+
+	if verificationRequest.Spec.UserRef.Kind != dockyardsv1.InvitationKind {
+		return nil, nil, apierrors.NewUnauthorized("verification request does not reference an invitation")
+	}
+
+	var organization dockyardsv1.Organization
+	err = h.Get(ctx, client.ObjectKey{Name: verificationRequest.Labels[dockyardsv1.LabelOrganizationName]}, &organization)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, apierrors.NewUnauthorized("could not find organization")
+		}
+
+		return nil, nil, err
+	}
+
+	if organization.Spec.NamespaceRef == nil {
+		return nil, nil, apierrors.NewUnauthorized("organization has no namespace")
+	}
+
+	objectKey := client.ObjectKey{
+		Name:      verificationRequest.Spec.UserRef.Name,
+		Namespace: organization.Spec.NamespaceRef.Name,
+	}
+
+	var invitation dockyardsv1.Invitation
+	err = h.Get(ctx, objectKey, &invitation)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, apierrors.NewUnauthorized("could not find invitation")
+		}
+
+		return nil, nil, err
+	}
+
+	if !invitation.IsPending() || apiutil.HasExpired(&invitation) {
+		return nil, nil, apierrors.NewUnauthorized(errInvitationNotPending.Error())
+	}
+
+	return &organization, &invitation, nil
+}
+
+// DeclineInvitation declines an invitation using the code sent to the invited address.
+func (h *handler) DeclineInvitation(ctx context.Context, request *InvitationDeclineOptions) error {
+	time.Sleep(time.Duration(mathrand.Float64() * float64(time.Second))) // Harden against brute force attacks.
+
+	_, invitation, err := h.getInvitationByCode(ctx, request.Code)
+	if err != nil {
+		return err
+	}
+
+	err = h.setInvitationCondition(ctx, invitation, metav1.ConditionFalse, dockyardsv1.InvitationDeclinedReason, "Declined by code")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// createInvitedUser signs up a user with the invited address, the address is verified by the invitation code.
+func (h *handler) createInvitedUser(ctx context.Context, invitation *dockyardsv1.Invitation, request *InvitationAcceptOptions) (*dockyardsv1.User, error) {
+	if request.Password == nil || *request.Password == "" {
+		required := field.Required(field.NewPath("password"), "password is required to sign up")
+
+		return nil, apierrors.NewInvalid(dockyardsv1.GroupVersion.WithKind(dockyardsv1.UserKind).GroupKind(), invitation.Spec.Email, field.ErrorList{required})
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(*request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := dockyardsv1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "dockyards-",
+		},
+		Spec: dockyardsv1.UserSpec{
+			Email:      invitation.Spec.Email,
+			Password:   string(passwordHash),
+			ProviderID: dockyardsv1.ProviderPrefixDockyards,
+		},
+	}
+
+	if request.DisplayName != nil {
+		user.Spec.DisplayName = *request.DisplayName
+	}
+
+	err = h.Create(ctx, &user)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := client.MergeFrom(user.DeepCopy())
+
+	meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+		Type:    dockyardsv1.ReadyCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  dockyardsv1.VerificationReasonVerified,
+		Message: "Verified by invitation",
+	})
+
+	err = h.Status().Patch(ctx, &user, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (h *handler) getPendingInvitation(ctx context.Context, organization *dockyardsv1.Organization, email string) (*dockyardsv1.Invitation, error) {
 	matchingFields := client.MatchingFields{
-		index.EmailField: user.Spec.Email,
+		index.EmailField: email,
 	}
 
 	var invitationList dockyardsv1.InvitationList
-	err = h.List(ctx, &invitationList, matchingFields, client.InNamespace(organization.Spec.NamespaceRef.Name))
+	err := h.List(ctx, &invitationList, matchingFields, client.InNamespace(organization.Spec.NamespaceRef.Name))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if len(invitationList.Items) != 1 {
+	pending := []dockyardsv1.Invitation{}
+
+	for _, item := range invitationList.Items {
+		if !item.IsPending() || apiutil.HasExpired(&item) {
+			continue
+		}
+
+		pending = append(pending, item)
+	}
+
+	if len(pending) != 1 {
 		statusError := apierrors.NewUnauthorized("unexpected invitations count")
 
-		return statusError
+		return nil, statusError
 	}
 
-	invitation := invitationList.Items[0]
+	return &pending[0], nil
+}
+
+func (h *handler) acceptInvitation(ctx context.Context, organization *dockyardsv1.Organization, invitation *dockyardsv1.Invitation, user *dockyardsv1.User) (*dockyardsv1.Member, error) {
+	logger := middleware.LoggerFrom(ctx)
 
 	member := dockyardsv1.Member{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      user.Name,
+			Name: user.Name,
 			Labels: map[string]string{
-				dockyardsv1.LabelOrganizationName: organizationName,
+				dockyardsv1.LabelOrganizationName: organization.Name,
 				dockyardsv1.LabelRoleName:         string(invitation.Spec.Role),
 				dockyardsv1.LabelUserName:         user.Name,
 			},
@@ -294,17 +542,76 @@ func (h *handler) UpdateGlobalInvitation(ctx context.Context, organizationName s
 		},
 	}
 
-	err = h.Create(ctx, &member)
+	err := h.Create(ctx, &member)
+	if apierrors.IsAlreadyExists(err) {
+		err = h.getAcceptedMember(ctx, &member)
+	}
+
 	if err != nil {
 		logger.Error("error creating member", "err", err)
 
-		return err
+		return nil, err
 	}
 
-	err = h.Delete(ctx, &invitation)
+	err = h.setInvitationCondition(ctx, invitation, metav1.ConditionTrue, dockyardsv1.InvitationAcceptedReason, "Accepted by "+user.Name)
 	if err != nil {
-		logger.Error("error deleting invitation", "err", err)
+		logger.Error("error accepting invitation", "err", err)
+
+		return nil, err
+	}
+
+	err = h.deleteInvitationVerificationRequest(ctx, invitation)
+	if err != nil {
+		logger.Error("error deleting invitation verification request", "err", err)
+
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// getAcceptedMember replaces the member with the existing member of the same user and role, a member left by a
+// previous acceptance that failed before the invitation was marked as accepted is reused.
+func (h *handler) getAcceptedMember(ctx context.Context, member *dockyardsv1.Member) error {
+	var existing dockyardsv1.Member
+	err := h.apiReader.Get(ctx, client.ObjectKeyFromObject(member), &existing)
+	if err != nil {
+		return err
+	}
+
+	if existing.Spec.UserRef.Name != member.Spec.UserRef.Name || existing.Spec.Role != member.Spec.Role {
+		groupResource := dockyardsv1.GroupVersion.WithResource("members").GroupResource()
+
+		return apierrors.NewAlreadyExists(groupResource, member.Name)
+	}
+
+	*member = existing
+
+	return nil
+}
+
+func (h *handler) setInvitationCondition(ctx context.Context, invitation *dockyardsv1.Invitation, status metav1.ConditionStatus, reason, message string) error {
+	patch := client.MergeFrom(invitation.DeepCopy())
+
+	switch status {
+	case metav1.ConditionTrue:
+		conditions.MarkTrue(invitation, dockyardsv1.InvitationAcceptedCondition, reason, "%s", message)
+	default:
+		conditions.MarkFalse(invitation, dockyardsv1.InvitationAcceptedCondition, reason, "%s", message)
+	}
+
+	return h.Status().Patch(ctx, invitation, patch)
+}
+
+func (h *handler) deleteInvitationVerificationRequest(ctx context.Context, invitation *dockyardsv1.Invitation) error {
+	verificationRequest := dockyardsv1.VerificationRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: invitation.GetVerificationRequestName(),
+		},
+	}
 
+	err := h.Delete(ctx, &verificationRequest)
+	if client.IgnoreNotFound(err) != nil {
 		return err
 	}
 
@@ -26,12 +26,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fluxcd/pkg/runtime/conditions"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	apitypes "github.com/sudoswedenab/dockyards-api/pkg/types"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/handlers"
 	"github.com/sudoswedenab/dockyards-backend/pkg/testing/testingutil"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
@@ -836,6 +839,17 @@ func TestGlobalInvitations_Delete(t *testing.T) {
 		if statusCode != http.StatusAccepted {
 			t.Fatalf("expected status code %d, got %d", http.StatusAccepted, statusCode)
 		}
+
+		var actual dockyardsv1.Invitation
+		err = c.Get(ctx, client.ObjectKeyFromObject(&invitation), &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		condition := conditions.Get(&actual, dockyardsv1.InvitationAcceptedCondition)
+		if condition == nil || condition.Reason != dockyardsv1.InvitationDeclinedReason {
+			t.Errorf("expected invitation to be declined, got %v", condition)
+		}
 	})
 }
 
@@ -958,8 +972,8 @@ func TestGlobalInvitations_Update(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if actualInvitation.DeletionTimestamp.IsZero() {
-			t.Error("expected actual invitation deletion timestamp, got zero")
+		if !conditions.IsTrue(&actualInvitation, dockyardsv1.InvitationAcceptedCondition) {
+			t.Error("expected invitation to be accepted")
 		}
 
 		var actualMembers dockyardsv1.MemberList
@@ -1071,3 +1085,420 @@ func TestGlobalInvitations_Update(t *testing.T) {
 		}
 	})
 }
+
+func mustCreatePendingInvitation(t *testing.T, organization *dockyardsv1.Organization, email, code string) dockyardsv1.Invitation {
+	t.Helper()
+
+	mgr := testEnvironment.GetManager()
+	c := testEnvironment.GetClient()
+
+	invitation := dockyardsv1.Invitation{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "pending-",
+			Namespace:    organization.Spec.NamespaceRef.Name,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: dockyardsv1.GroupVersion.String(),
+					Kind:       dockyardsv1.OrganizationKind,
+					Name:       organization.Name,
+					UID:        organization.UID,
+				},
+			},
+		},
+		Spec: dockyardsv1.InvitationSpec{
+			Email: email,
+			Role:  dockyardsv1.RoleReader,
+		},
+	}
+
+	err := c.Create(ctx, &invitation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verificationRequest := dockyardsv1.VerificationRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: invitation.GetVerificationRequestName(),
+			Labels: map[string]string{
+				dockyardsv1.LabelOrganizationName: organization.Name,
+			},
+		},
+		Spec: dockyardsv1.VerificationRequestSpec{
+			Code:     code,
+			Email:    email,
+			Subject:  "Invitation",
+			BodyText: code,
+			UserRef: corev1.TypedLocalObjectReference{
+				APIGroup: &dockyardsv1.GroupVersion.Group,
+				Kind:     dockyardsv1.InvitationKind,
+				Name:     invitation.Name,
+			},
+		},
+	}
+
+	err = c.Create(ctx, &verificationRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = testingutil.RetryUntilFound(ctx, mgr.GetClient(), &invitation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = testingutil.RetryUntilFound(ctx, mgr.GetClient(), &verificationRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return invitation
+}
+
+func TestGlobalInvitations_Accept(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("no kubebuilder assets configured")
+	}
+
+	mgr := testEnvironment.GetManager()
+	c := testEnvironment.GetClient()
+
+	organization := testEnvironment.MustCreateOrganization(t)
+
+	t.Run("test sign up", func(t *testing.T) {
+		invitation := mustCreatePendingInvitation(t, organization, "sign-up@dockyards.dev", "test-sign-up-code")
+
+		options := handlers.InvitationAcceptOptions{
+			Code:     "test-sign-up-code",
+			Password: ptr.To("password"),
+		}
+
+		b, err := json.Marshal(&options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/invitations/accept", bytes.NewBuffer(b))
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+		}
+
+		var userList dockyardsv1.UserList
+		err = c.List(ctx, &userList)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var user *dockyardsv1.User
+
+		for _, item := range userList.Items {
+			if item.Spec.Email == invitation.Spec.Email {
+				user = &item
+			}
+		}
+
+		if user == nil {
+			t.Fatal("expected user to be signed up")
+		}
+
+		if !conditions.IsTrue(user, dockyardsv1.ReadyCondition) {
+			t.Error("expected user to be ready")
+		}
+
+		var member dockyardsv1.Member
+		err = c.Get(ctx, client.ObjectKey{Name: user.Name, Namespace: organization.Spec.NamespaceRef.Name}, &member)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if member.Spec.Role != invitation.Spec.Role {
+			t.Errorf("expected role %s, got %s", invitation.Spec.Role, member.Spec.Role)
+		}
+
+		var actual dockyardsv1.Invitation
+		err = c.Get(ctx, client.ObjectKeyFromObject(&invitation), &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !conditions.IsTrue(&actual, dockyardsv1.InvitationAcceptedCondition) {
+			t.Error("expected invitation to be accepted")
+		}
+	})
+
+	t.Run("test sign up without password", func(t *testing.T) {
+		mustCreatePendingInvitation(t, organization, "no-password@dockyards.dev", "test-no-password-code")
+
+		options := handlers.InvitationAcceptOptions{
+			Code: "test-no-password-code",
+		}
+
+		b, err := json.Marshal(&options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/invitations/accept", bytes.NewBuffer(b))
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnprocessableEntity, statusCode)
+		}
+	})
+
+	t.Run("test existing user", func(t *testing.T) {
+		existingUser := dockyardsv1.User{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+			},
+			Spec: dockyardsv1.UserSpec{
+				Email: "existing-accept@dockyards.dev",
+			},
+		}
+
+		err := c.Create(ctx, &existingUser)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = testingutil.RetryUntilFound(ctx, mgr.GetClient(), &existingUser)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mustCreatePendingInvitation(t, organization, existingUser.Spec.Email, "test-existing-code")
+
+		options := handlers.InvitationAcceptOptions{
+			Code: "test-existing-code",
+		}
+
+		b, err := json.Marshal(&options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/invitations/accept", bytes.NewBuffer(b))
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+		}
+
+		var member dockyardsv1.Member
+		err = c.Get(ctx, client.ObjectKey{Name: existingUser.Name, Namespace: organization.Spec.NamespaceRef.Name}, &member)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("test existing member", func(t *testing.T) {
+		existingUser := dockyardsv1.User{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+			},
+			Spec: dockyardsv1.UserSpec{
+				Email: "existing-member@dockyards.dev",
+			},
+		}
+
+		err := c.Create(ctx, &existingUser)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = testingutil.RetryUntilFound(ctx, mgr.GetClient(), &existingUser)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		invitation := mustCreatePendingInvitation(t, organization, existingUser.Spec.Email, "test-existing-member-code")
+
+		// The member is left by a previous acceptance that failed before the invitation was marked as accepted.
+		existingMember := dockyardsv1.Member{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      existingUser.Name,
+				Namespace: organization.Spec.NamespaceRef.Name,
+			},
+			Spec: dockyardsv1.MemberSpec{
+				Role: invitation.Spec.Role,
+				UserRef: corev1.TypedLocalObjectReference{
+					APIGroup: &dockyardsv1.GroupVersion.Group,
+					Kind:     dockyardsv1.UserKind,
+					Name:     existingUser.Name,
+				},
+			},
+		}
+
+		err = c.Create(ctx, &existingMember)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		options := handlers.InvitationAcceptOptions{
+			Code: "test-existing-member-code",
+		}
+
+		b, err := json.Marshal(&options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/invitations/accept", bytes.NewBuffer(b))
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+		}
+
+		var actual dockyardsv1.Invitation
+		err = c.Get(ctx, client.ObjectKeyFromObject(&invitation), &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !conditions.IsTrue(&actual, dockyardsv1.InvitationAcceptedCondition) {
+			t.Error("expected invitation to be accepted")
+		}
+	})
+
+	t.Run("test invalid code", func(t *testing.T) {
+		options := handlers.InvitationAcceptOptions{
+			Code:     "test-invalid-code",
+			Password: ptr.To("password"),
+		}
+
+		b, err := json.Marshal(&options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/invitations/accept", bytes.NewBuffer(b))
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+}
+
+func TestGlobalInvitations_Decline(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("no kubebuilder assets configured")
+	}
+
+	c := testEnvironment.GetClient()
+
+	organization := testEnvironment.MustCreateOrganization(t)
+
+	t.Run("test decline by code", func(t *testing.T) {
+		invitation := mustCreatePendingInvitation(t, organization, "decline@dockyards.dev", "test-decline-code")
+
+		options := handlers.InvitationDeclineOptions{
+			Code: "test-decline-code",
+		}
+
+		b, err := json.Marshal(&options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/invitations/decline", bytes.NewBuffer(b))
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusAccepted {
+			t.Fatalf("expected status code %d, got %d", http.StatusAccepted, statusCode)
+		}
+
+		var actual dockyardsv1.Invitation
+		err = c.Get(ctx, client.ObjectKeyFromObject(&invitation), &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		condition := conditions.Get(&actual, dockyardsv1.InvitationAcceptedCondition)
+		if condition == nil || condition.Reason != dockyardsv1.InvitationDeclinedReason {
+			t.Errorf("expected invitation to be declined, got %v", condition)
+		}
+	})
+}
+
+func TestOrganizationInvitations_Resend(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("no kubebuilder assets configured")
+	}
+
+	c := testEnvironment.GetClient()
+
+	organization := testEnvironment.MustCreateOrganization(t)
+
+	superUser := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleSuperUser)
+	reader := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleReader)
+
+	superUserToken := MustSignToken(t, superUser.Name)
+	readerToken := MustSignToken(t, reader.Name)
+
+	t.Run("test as super user", func(t *testing.T) {
+		invitation := mustCreatePendingInvitation(t, organization, "resend@dockyards.dev", "test-resend-code")
+
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "invitations", invitation.Name, "resend"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBufferString("{}"))
+
+		r.Header.Add("Authorization", "Bearer "+superUserToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusAccepted {
+			t.Fatalf("expected status code %d, got %d", http.StatusAccepted, statusCode)
+		}
+
+		var verificationRequest dockyardsv1.VerificationRequest
+		err := c.Get(ctx, client.ObjectKey{Name: invitation.GetVerificationRequestName()}, &verificationRequest)
+		if !apierrors.IsNotFound(err) {
+			t.Errorf("expected previous verification request to be deleted, got %v", err)
+		}
+	})
+
+	t.Run("test as reader", func(t *testing.T) {
+		invitation := mustCreatePendingInvitation(t, organization, "resend-reader@dockyards.dev", "test-resend-reader-code")
+
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "invitations", invitation.Name, "resend"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBufferString("{}"))
+
+		r.Header.Add("Authorization", "Bearer "+readerToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+}
@@ -16,24 +16,52 @@ package handlers
 
 import (
 	"context"
+	"crypto/rand"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/sudoswedenab/dockyards-api/pkg/types"
+	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
 	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	utiljwt "github.com/sudoswedenab/dockyards-backend/pkg/util/jwt"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+const (
+	accessTokenDuration  = time.Minute * 30
+	refreshTokenDuration = time.Hour * 2
+)
+
 // +kubebuilder:rbac:groups=dockyards.io,resources=users,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=sessions,verbs=create;delete;get;list;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=sessions/status,verbs=patch
 
 func (h *handler) GetGlobalTokens(ctx context.Context) (*types.Tokens, error) {
+	logger := middleware.LoggerFrom(ctx)
+
 	subject, err := middleware.SubjectFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	claims, err := middleware.ClaimsFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.SessionID == "" {
+		return nil, apierrors.NewUnauthorized("refresh token is not part of a session")
+	}
+
+	// Tokens with an audience are signed for other purposes, such as single sign-on exchange codes.
+	if len(claims.Audience) > 0 {
+		return nil, apierrors.NewUnauthorized("unexpected audience")
+	}
+
 	var user dockyardsv1.User
 	err = h.Get(ctx, client.ObjectKey{Name: subject}, &user)
 	if err != nil {
@@ -48,7 +76,38 @@ func (h *handler) GetGlobalTokens(ctx context.Context) (*types.Tokens, error) {
 		return nil, apierrors.NewUnauthorized("user deleted")
 	}
 
-	response, err := h.generateTokens(&user)
+	// The session is read directly from the api server, a stale cache would otherwise make a
+	// legitimate refresh look like a reused token.
+	var session dockyardsv1.Session
+	err = h.apiReader.Get(ctx, client.ObjectKey{Name: claims.SessionID}, &session)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, apierrors.NewUnauthorized("session not found")
+		}
+
+		return nil, err
+	}
+
+	if session.Spec.UserRef.Name != user.Name {
+		return nil, apierrors.NewUnauthorized("session does not belong to user")
+	}
+
+	if !session.DeletionTimestamp.IsZero() || apiutil.HasExpired(&session) {
+		return nil, apierrors.NewUnauthorized("session expired")
+	}
+
+	if claims.ID != session.Status.TokenID {
+		logger.Warn("refresh token reuse detected, revoking session", "session", session.Name, "subject", subject)
+
+		err := h.Delete(ctx, &session)
+		if client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+
+		return nil, apierrors.NewUnauthorized("refresh token has already been used")
+	}
+
+	response, err := h.generateTokens(ctx, &user, &session)
 	if err != nil {
 		return nil, err
 	}
@@ -56,25 +115,89 @@ func (h *handler) GetGlobalTokens(ctx context.Context) (*types.Tokens, error) {
 	return response, nil
 }
 
-func (h *handler) generateTokens(user *dockyardsv1.User) (*types.Tokens, error) {
-	claims := jwt.RegisteredClaims{
-		Subject:   user.Name,
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute * 30)),
+func (h *handler) createSession(ctx context.Context, user *dockyardsv1.User, authenticationMethods ...string) (*dockyardsv1.Session, error) {
+	session := dockyardsv1.Session{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: user.Name + "-",
+			Labels: map[string]string{
+				dockyardsv1.LabelUserName: user.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: dockyardsv1.GroupVersion.String(),
+					Kind:       dockyardsv1.UserKind,
+					Name:       user.Name,
+					UID:        user.UID,
+				},
+			},
+		},
+		Spec: dockyardsv1.SessionSpec{
+			UserRef: corev1.TypedLocalObjectReference{
+				APIGroup: &dockyardsv1.GroupVersion.Group,
+				Kind:     dockyardsv1.UserKind,
+				Name:     user.Name,
+			},
+			Duration: &metav1.Duration{
+				Duration: refreshTokenDuration,
+			},
+			AuthenticationMethods: authenticationMethods,
+		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
-	signedAccessToken, err := token.SignedString(h.jwtAccessPrivateKey)
+	err := h.Create(ctx, &session)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshTokenClaims := jwt.RegisteredClaims{
-		Subject:   user.Name,
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 2)),
+	return &session, nil
+}
+
+// generateTokens signs a new pair of tokens for the session and rotates the session to the new refresh
+// token, any refresh token issued previously for the session is considered used from here on.
+func (h *handler) generateTokens(ctx context.Context, user *dockyardsv1.User, session *dockyardsv1.Session) (*types.Tokens, error) {
+	now := time.Now()
+
+	claims := middleware.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.Name,
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenDuration)),
+		},
+		SessionID:             session.Name,
+		AuthenticationMethods: session.Spec.AuthenticationMethods,
+	}
+
+	signedAccessToken, err := h.jwtKeySet.SignAccessToken(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenID := rand.Text()
+
+	refreshTokenClaims := middleware.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.Name,
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(refreshTokenDuration)),
+		},
+		SessionID: session.Name,
+	}
+
+	signedRefreshToken, err := h.jwtKeySet.SignRefreshToken(refreshTokenClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := client.MergeFromWithOptions(session.DeepCopy(), client.MergeFromWithOptimisticLock{})
+
+	session.Status.TokenID = tokenID
+	session.Status.LastRefreshTimestamp = &metav1.Time{Time: now}
+	session.Status.ExpirationTimestamp = session.GetExpiration()
+
+	err = h.Status().Patch(ctx, session, patch)
+	if apierrors.IsConflict(err) {
+		return nil, apierrors.NewUnauthorized("session was rotated concurrently")
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodES256, refreshTokenClaims)
-	signedRefreshToken, err := refreshToken.SignedString(h.jwtRefreshPrivateKey)
 	if err != nil {
 		return nil, err
 	}
@@ -86,3 +209,7 @@ func (h *handler) generateTokens(user *dockyardsv1.User) (*types.Tokens, error)
 
 	return &tokens, nil
 }
+
+func (h *handler) GetJWKS(ctx context.Context) (*utiljwt.JSONWebKeySet, error) {
+	return h.jwtKeySet.AccessTokenJWKS()
+}
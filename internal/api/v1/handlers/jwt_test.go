@@ -26,6 +26,8 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/sudoswedenab/dockyards-api/pkg/types"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	utiljwt "github.com/sudoswedenab/dockyards-backend/pkg/util/jwt"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -98,6 +100,73 @@ func TestGlobalTokens_Get(t *testing.T) {
 		}
 	})
 
+	t.Run("test reused refresh token", func(t *testing.T) {
+		user := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleUser)
+		session := MustCreateSession(t, user.Name)
+
+		userToken, err := SignSessionToken(user.Name, session.Name, session.Status.TokenID, refreshKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u := url.URL{
+			Path: "/v1/refresh",
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, statusCode)
+		}
+
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodPost, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode = w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+
+		var actual dockyardsv1.Session
+		err = c.Get(ctx, client.ObjectKeyFromObject(session), &actual)
+		if !apierrors.IsNotFound(err) {
+			t.Errorf("expected session to be revoked, got %v", err)
+		}
+	})
+
+	t.Run("test refresh token without session", func(t *testing.T) {
+		userToken, err := SignToken(superUser.Name, refreshKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u := url.URL{
+			Path: "/v1/refresh",
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+
 	t.Run("test access token", func(t *testing.T) {
 		superUserToken := MustSignToken(t, superUser.Name)
 
@@ -178,3 +247,40 @@ func TestGlobalTokens_Get(t *testing.T) {
 		t.Logf("b: %s", string(b))
 	})
 }
+
+func TestJWKS_Get(t *testing.T) {
+	u := url.URL{
+		Path: "/.well-known/jwks.json",
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+	mux.ServeHTTP(w, r)
+
+	statusCode := w.Result().StatusCode
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, statusCode)
+	}
+
+	b, err := io.ReadAll(w.Result().Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual utiljwt.JSONWebKeySet
+	err = json.Unmarshal(b, &actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(actual.Keys) == 0 {
+		t.Fatal("expected at least one key")
+	}
+
+	for _, key := range actual.Keys {
+		if key.KeyType != "EC" || key.Curve != "P-256" || key.KeyID == "" {
+			t.Errorf("unexpected key %v", key)
+		}
+	}
+}
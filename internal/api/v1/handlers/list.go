@@ -120,6 +120,21 @@ func ListClusterResource[T any](h *handler, resource string, f ListClusterResour
 			return
 		}
 
+		allowed, err = h.isClusterAllowed(ctx, subject, &cluster)
+		if err != nil {
+			logger.Error("error reviewing subject", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if !allowed {
+			logger.Debug("subject is not allowed to access cluster", "subject", subject, "cluster", cluster.Name)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
 		response, err := f(ctx, &cluster)
 		if err != nil {
 			logger.Error("error listing resource", "err", err)
@@ -149,6 +164,17 @@ func ListClusterResource[T any](h *handler, resource string, f ListClusterResour
 type ListOrganizationResourceFunc[T any] func(context.Context, *dockyardsv1.Organization) (*[]T, error)
 
 func ListOrganizationResource[T any](h *handler, resource string, f ListOrganizationResourceFunc[T]) http.HandlerFunc {
+	return listOrganizationResource(h, resource, "get", f)
+}
+
+// ListOrganizationFilteredResource authorizes listing rather than getting the resource in the organization, the
+// function is responsible for leaving out items the subject is not allowed to get. Members limited to a subset of
+// the clusters are allowed to list clusters but only to get some of them.
+func ListOrganizationFilteredResource[T any](h *handler, resource string, f ListOrganizationResourceFunc[T]) http.HandlerFunc {
+	return listOrganizationResource(h, resource, "list", f)
+}
+
+func listOrganizationResource[T any](h *handler, resource, verb string, f ListOrganizationResourceFunc[T]) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
@@ -194,7 +220,7 @@ func ListOrganizationResource[T any](h *handler, resource string, f ListOrganiza
 			Group:     dockyardsv1.GroupVersion.Group,
 			Namespace: organization.Spec.NamespaceRef.Name,
 			Resource:  resource,
-			Verb:      "get",
+			Verb:      verb,
 		}
 
 		allowed, err := apiutil.IsSubjectAllowed(ctx, h.Client, subject, &resourceAttributes)
@@ -271,3 +297,209 @@ func ListGlobalResource[T any](resource string, f ListGlobalResourceFunc[T]) htt
 		}
 	}
 }
+
+type ListUserResourceFunc[T any] func(context.Context, *dockyardsv1.User) (*[]T, error)
+
+func ListUserResource[T any](h *handler, resource string, f ListUserResourceFunc[T]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		logger := middleware.LoggerFrom(ctx).With("resource", resource)
+
+		userName := r.PathValue("userName")
+		if userName == "" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		var user dockyardsv1.User
+		err := h.Get(ctx, client.ObjectKey{Name: userName}, &user)
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error("error getting user", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if apierrors.IsNotFound(err) {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		subject, err := middleware.SubjectFrom(ctx)
+		if err != nil {
+			logger.Error("error getting subject from context", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		// Resources belonging to a user are authorized against the user itself.
+		resourceAttributes := authorizationv1.ResourceAttributes{
+			Group:    dockyardsv1.GroupVersion.Group,
+			Name:     user.Name,
+			Resource: "users",
+			Verb:     "get",
+		}
+
+		allowed, err := apiutil.IsSubjectAllowed(ctx, h.Client, subject, &resourceAttributes)
+		if err != nil {
+			logger.Error("error reviewing subject", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if !allowed {
+			logger.Debug("subject is not allowed to list resource", "subject", subject, "user", user.Name)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		response, err := f(ctx, &user)
+		if err != nil {
+			logger.Error("error listing resource", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		b, err := json.Marshal(response)
+		if err != nil {
+			logger.Error("error marshalling response", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(b)
+		if err != nil {
+			logger.Error("error writing response", "err", err)
+
+			return
+		}
+	}
+}
+
+type ListServiceAccountResourceFunc[T any] func(context.Context, *dockyardsv1.ServiceAccount) (*[]T, error)
+
+func ListServiceAccountResource[T any](h *handler, resource string, f ListServiceAccountResourceFunc[T]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		logger := middleware.LoggerFrom(ctx).With("resource", resource)
+
+		organizationName := r.PathValue("organizationName")
+		if organizationName == "" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		serviceAccountName := r.PathValue("serviceAccountName")
+		if serviceAccountName == "" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		var organization dockyardsv1.Organization
+		err := h.Get(ctx, client.ObjectKey{Name: organizationName}, &organization)
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error("error getting organization", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if apierrors.IsNotFound(err) {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if organization.Spec.NamespaceRef == nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		subject, err := middleware.SubjectFrom(ctx)
+		if err != nil {
+			logger.Error("error getting subject from context", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		resourceAttributes := authorizationv1.ResourceAttributes{
+			Group:     dockyardsv1.GroupVersion.Group,
+			Namespace: organization.Spec.NamespaceRef.Name,
+			Resource:  resource,
+			Verb:      "list",
+		}
+
+		allowed, err := apiutil.IsSubjectAllowed(ctx, h.Client, subject, &resourceAttributes)
+		if err != nil {
+			logger.Error("error reviewing subject", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if !allowed {
+			logger.Debug("subject is not allowed to list resource", "subject", subject, "organization", organization.Name)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		objectKey := client.ObjectKey{
+			Name:      serviceAccountName,
+			Namespace: organization.Spec.NamespaceRef.Name,
+		}
+
+		var serviceAccount dockyardsv1.ServiceAccount
+		err = h.Get(ctx, objectKey, &serviceAccount)
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error("error getting service account", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if apierrors.IsNotFound(err) {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		response, err := f(ctx, &serviceAccount)
+		if err != nil {
+			logger.Error("error listing resource", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		b, err := json.Marshal(response)
+		if err != nil {
+			logger.Error("error marshalling response", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(b)
+		if err != nil {
+			logger.Error("error writing response", "err", err)
+
+			return
+		}
+	}
+}
@@ -16,31 +16,53 @@ package handlers
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/sudoswedenab/dockyards-api/pkg/types"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
 	"github.com/sudoswedenab/dockyards-backend/api/v1alpha3/index"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
 	"golang.org/x/crypto/bcrypt"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func (h *handler) CreateGlobalTokens(ctx context.Context, request *types.LoginOptions) (*types.Tokens, error) {
+// LoginResponse contains either the tokens of a new session or, when the user has enrolled in multi-factor
+// authentication, a short-lived token to exchange for tokens together with a one-time code.
+type LoginResponse struct {
+	AccessToken  string  `json:"AccessToken,omitempty"`
+	RefreshToken string  `json:"RefreshToken,omitempty"`
+	MFARequired  bool    `json:"MFARequired,omitempty"`
+	MFAToken     *string `json:"MFAToken,omitempty"`
+}
+
+// +kubebuilder:rbac:groups=dockyards.io,resources=users/status,verbs=patch
+
+func (h *handler) CreateGlobalTokens(ctx context.Context, request *types.LoginOptions) (*LoginResponse, error) {
+	err := h.checkLoginAttempts(ctx, request.Email)
+	if err != nil {
+		return nil, err
+	}
+
 	matchingFields := client.MatchingFields{
 		index.EmailField: request.Email,
 		index.ProviderIDField: dockyardsv1.ProviderPrefixDockyards,
 	}
 
 	var userList dockyardsv1.UserList
-	err := h.List(ctx, &userList, matchingFields)
+	err = h.List(ctx, &userList, matchingFields)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(userList.Items) == 0 {
-		return nil, apierrors.NewUnauthorized("user not found")
+		return nil, h.loginFailed(ctx, request.Email, nil, "user_not_found")
 	}
 
 	if len(userList.Items) > 1 {
@@ -54,15 +76,167 @@ func (h *handler) CreateGlobalTokens(ctx context.Context, request *types.LoginOp
 		return nil, apierrors.NewUnauthorized("user does not have ready condition")
 	}
 
+	err = h.checkLockout(&user)
+	if err != nil {
+		return nil, err
+	}
+
 	err = bcrypt.CompareHashAndPassword([]byte(user.Spec.Password), []byte(request.Password))
 	if err != nil {
-		return nil, apierrors.NewUnauthorized("error comparing password to hash")
+		return nil, h.loginFailed(ctx, request.Email, &user, "invalid_password")
+	}
+
+	if user.Spec.MFA != nil {
+		mfaToken, err := h.signMFAToken(&user)
+		if err != nil {
+			return nil, apierrors.NewInternalError(err)
+		}
+
+		response := LoginResponse{
+			MFARequired: true,
+			MFAToken:    &mfaToken,
+		}
+
+		return &response, nil
+	}
+
+	err = h.loginSucceeded(ctx, request.Email, &user)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := h.createSession(ctx, &user, middleware.AuthenticationMethodPassword)
+	if err != nil {
+		return nil, apierrors.NewInternalError(err)
 	}
 
-	tokens, err := h.generateTokens(&user)
+	tokens, err := h.generateTokens(ctx, &user, session)
 	if err != nil {
 		return nil, apierrors.NewInternalError(err)
 	}
 
-	return tokens, nil
+	response := LoginResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}
+
+	return &response, nil
+}
+
+func (h *handler) checkLoginAttempts(ctx context.Context, email string) error {
+	retryAfter := max(
+		h.loginEmailLimiter.retryAfter(strings.ToLower(email)),
+		h.loginAddressLimiter.retryAfter(middleware.ClientIPFrom(ctx)),
+	)
+
+	if retryAfter > 0 {
+		h.metrics.IncLoginFailure("throttled")
+
+		return apierrors.NewTooManyRequests("too many failed login attempts", int(math.Ceil(retryAfter.Seconds())))
+	}
+
+	return nil
+}
+
+func lockoutRemaining(user *dockyardsv1.User) time.Duration {
+	condition := meta.FindStatusCondition(user.Status.Conditions, dockyardsv1.LockedOutCondition)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		return 0
+	}
+
+	return time.Until(condition.LastTransitionTime.Add(lockoutDuration))
+}
+
+func (h *handler) checkLockout(user *dockyardsv1.User) error {
+	remaining := lockoutRemaining(user)
+	if remaining > 0 {
+		h.metrics.IncLoginFailure("locked_out")
+
+		return apierrors.NewTooManyRequests("user is locked out", int(math.Ceil(remaining.Seconds())))
+	}
+
+	return nil
+}
+
+// loginFailed records a failed attempt and locks out the user once the number of failed attempts for the
+// email address reaches the threshold, the returned error is meant to be returned to the client. The failed
+// attempts are forgotten once the lockout is applied so that the user gets the full threshold of attempts when
+// the lockout expires.
+func (h *handler) loginFailed(ctx context.Context, email string, user *dockyardsv1.User, reason string) error {
+	h.metrics.IncLoginFailure(reason)
+
+	failures := h.loginEmailLimiter.fail(strings.ToLower(email))
+	h.loginAddressLimiter.fail(middleware.ClientIPFrom(ctx))
+
+	if user != nil && failures >= lockoutThreshold && lockoutRemaining(user) <= 0 {
+		message := fmt.Sprintf("locked out after %d failed login attempts", failures)
+
+		err := h.setLockedOut(ctx, user.Name, metav1.ConditionTrue, dockyardsv1.TooManyFailedLoginsReason, message)
+		if err != nil {
+			return err
+		}
+
+		h.loginEmailLimiter.reset(strings.ToLower(email))
+
+		h.metrics.IncLockout()
+	}
+
+	return apierrors.NewUnauthorized(reason)
+}
+
+func (h *handler) loginSucceeded(ctx context.Context, email string, user *dockyardsv1.User) error {
+	h.loginEmailLimiter.reset(strings.ToLower(email))
+
+	if meta.IsStatusConditionTrue(user.Status.Conditions, dockyardsv1.LockedOutCondition) {
+		return h.setLockedOut(ctx, user.Name, metav1.ConditionFalse, dockyardsv1.UnlockedReason, "lockout expired")
+	}
+
+	return nil
+}
+
+// setLockedOut sets the locked out condition of the user, the condition is replaced rather than updated to
+// have the transition time reflect when the user was last locked out.
+func (h *handler) setLockedOut(ctx context.Context, userName string, status metav1.ConditionStatus, reason, message string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var user dockyardsv1.User
+		err := h.apiReader.Get(ctx, client.ObjectKey{Name: userName}, &user)
+		if err != nil {
+			return err
+		}
+
+		patch := client.MergeFromWithOptions(user.DeepCopy(), client.MergeFromWithOptimisticLock{})
+
+		meta.RemoveStatusCondition(&user.Status.Conditions, dockyardsv1.LockedOutCondition)
+		meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+			Type:    dockyardsv1.LockedOutCondition,
+			Status:  status,
+			Reason:  reason,
+			Message: message,
+		})
+
+		return h.Status().Patch(ctx, &user, patch)
+	})
+}
+
+// DeleteUserLockout unlocks a user before the lockout expires, it requires permission to delete the lockout
+// subresource of users which is not granted to any dockyards role.
+func (h *handler) DeleteUserLockout(ctx context.Context, userName string) error {
+	var user dockyardsv1.User
+	err := h.Get(ctx, client.ObjectKey{Name: userName}, &user)
+	if err != nil {
+		return err
+	}
+
+	h.loginEmailLimiter.reset(strings.ToLower(user.Spec.Email))
+
+	if !meta.IsStatusConditionTrue(user.Status.Conditions, dockyardsv1.LockedOutCondition) {
+		return nil
+	}
+
+	subject, err := middleware.SubjectFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	return h.setLockedOut(ctx, user.Name, metav1.ConditionFalse, dockyardsv1.UnlockedReason, "unlocked by "+subject)
 }
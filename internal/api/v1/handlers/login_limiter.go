@@ -0,0 +1,112 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// emailFreeAttempts and addressFreeAttempts are the number of failed attempts allowed before backing off,
+	// client addresses are allowed more attempts since they can be shared by many users.
+	emailFreeAttempts   = 3
+	addressFreeAttempts = 20
+
+	loginBackoffBase   = time.Second
+	loginBackoffMax    = time.Minute * 15
+	loginAttemptMaxAge = time.Hour
+
+	// lockoutThreshold is the number of failed attempts for an email address before the user is locked out.
+	lockoutThreshold = 10
+	lockoutDuration  = time.Minute * 30
+)
+
+type loginAttempt struct {
+	failures    int
+	lastFailure time.Time
+}
+
+// loginLimiter tracks failed login attempts by key, such as email address or client address, and backs off
+// exponentially. The attempts are kept in memory so every replica keeps its own count.
+type loginLimiter struct {
+	mu           sync.Mutex
+	freeAttempts int
+	attempts     map[string]*loginAttempt
+	now          func() time.Time
+}
+
+func newLoginLimiter(freeAttempts int) *loginLimiter {
+	l := loginLimiter{
+		freeAttempts: freeAttempts,
+		attempts:     make(map[string]*loginAttempt),
+		now:          time.Now,
+	}
+
+	return &l
+}
+
+// retryAfter returns how long to wait before the next attempt is allowed for the key.
+func (l *loginLimiter) retryAfter(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	attempt, found := l.attempts[key]
+	if !found || attempt.failures < l.freeAttempts {
+		return 0
+	}
+
+	backoff := loginBackoffBase << min(attempt.failures-l.freeAttempts, 20)
+	backoff = min(backoff, loginBackoffMax)
+
+	return attempt.lastFailure.Add(backoff).Sub(l.now())
+}
+
+// fail records a failed attempt for the key and returns the number of consecutive failures, empty keys
+// are not tracked.
+func (l *loginLimiter) fail(key string) int {
+	if key == "" {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	for k, attempt := range l.attempts {
+		if now.Sub(attempt.lastFailure) > loginAttemptMaxAge {
+			delete(l.attempts, k)
+		}
+	}
+
+	attempt, found := l.attempts[key]
+	if !found {
+		attempt = &loginAttempt{}
+		l.attempts[key] = attempt
+	}
+
+	attempt.failures++
+	attempt.lastFailure = now
+
+	return attempt.failures
+}
+
+func (l *loginLimiter) reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.attempts, key)
+}
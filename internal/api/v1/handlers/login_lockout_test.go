@@ -0,0 +1,180 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"testing"
+
+	"github.com/sudoswedenab/dockyards-api/pkg/types"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/pkg/testing/testingutil"
+	"golang.org/x/crypto/bcrypt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func mustCreateLoginUser(t *testing.T, hash []byte) *dockyardsv1.User {
+	c := testEnvironment.GetClient()
+
+	user := dockyardsv1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+		},
+		Spec: dockyardsv1.UserSpec{
+			Password:   string(hash),
+			ProviderID: dockyardsv1.ProviderPrefixDockyards,
+		},
+	}
+
+	err := c.Create(ctx, &user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patch := client.MergeFrom(user.DeepCopy())
+
+	user.Spec.Email = user.Name + "@dockyards.dev"
+
+	err = c.Patch(ctx, &user, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patch = client.MergeFrom(user.DeepCopy())
+
+	user.Status.Conditions = []metav1.Condition{
+		{
+			Type:               dockyardsv1.ReadyCondition,
+			Status:             metav1.ConditionTrue,
+			Reason:             "testing",
+			LastTransitionTime: metav1.Now(),
+		},
+	}
+
+	err = c.Status().Patch(ctx, &user, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = testingutil.RetryUntilFound(ctx, testEnvironment.GetManager().GetClient(), &user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &user
+}
+
+func postLogin(t *testing.T, remoteAddr, email, password string) *http.Response {
+	options := types.LoginOptions{
+		Email:    email,
+		Password: password,
+	}
+
+	b, err := json.Marshal(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := url.URL{
+		Path: path.Join("/v1/login"),
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBuffer(b))
+	r.RemoteAddr = remoteAddr
+
+	mux.ServeHTTP(w, r)
+
+	return w.Result()
+}
+
+func TestGlobalTokens_Lockout(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), 10)
+	if err != nil {
+		t.Fatalf("unexpected error hashing string 'password'")
+	}
+
+	t.Run("test backoff", func(t *testing.T) {
+		user := mustCreateLoginUser(t, hash)
+
+		for range 3 {
+			response := postLogin(t, "198.51.100.1:1234", user.Spec.Email, "invalid")
+			if response.StatusCode != http.StatusUnauthorized {
+				t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, response.StatusCode)
+			}
+		}
+
+		response := postLogin(t, "198.51.100.2:1234", user.Spec.Email, "password")
+		if response.StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("expected status code %d, got %d", http.StatusTooManyRequests, response.StatusCode)
+		}
+
+		if response.Header.Get("Retry-After") == "" {
+			t.Fatal("expected retry-after header")
+		}
+	})
+
+	t.Run("test locked out", func(t *testing.T) {
+		user := mustCreateLoginUser(t, hash)
+
+		c := testEnvironment.GetClient()
+
+		patch := client.MergeFrom(user.DeepCopy())
+
+		user.Status.Conditions = append(user.Status.Conditions, metav1.Condition{
+			Type:               dockyardsv1.LockedOutCondition,
+			Status:             metav1.ConditionTrue,
+			Reason:             dockyardsv1.TooManyFailedLoginsReason,
+			LastTransitionTime: metav1.Now(),
+		})
+
+		err := c.Status().Patch(ctx, user, patch)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = testingutil.RetryUntilFound(ctx, testEnvironment.GetManager().GetClient(), user)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		response := postLogin(t, "198.51.100.3:1234", user.Spec.Email, "password")
+		if response.StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("expected status code %d, got %d", http.StatusTooManyRequests, response.StatusCode)
+		}
+
+		u := url.URL{
+			Path: path.Join("/v1/users", user.Name, "lockout"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(t, user.Name))
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+}
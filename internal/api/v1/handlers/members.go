@@ -18,13 +18,20 @@ import (
 	"context"
 
 	"github.com/sudoswedenab/dockyards-api/pkg/types"
+	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/api/v1alpha3/index"
 	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// +kubebuilder:rbac:groups=dockyards.io,resources=members,verbs=delete;get;list;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=members,verbs=create;delete;get;list;patch;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=serviceaccounts,verbs=get;list;watch
 // +kubebuilder:rbac:groups=dockyards.io,resources=users,verbs=get;list;watch
 
 func (h *handler) ListOrganizationMembers(ctx context.Context, organization *dockyardsv1.Organization) (*[]types.Member, error) {
@@ -37,19 +44,27 @@ func (h *handler) ListOrganizationMembers(ctx context.Context, organization *doc
 	response := make([]types.Member, len(memberList.Items))
 
 	var user dockyardsv1.User
+	var serviceAccount dockyardsv1.ServiceAccount
 	for i, member := range memberList.Items {
+		var object client.Object = &user
+
 		key := client.ObjectKey{
 			Name: member.Spec.UserRef.Name,
 		}
 
-		err := h.Get(ctx, key, &user)
+		if member.Spec.UserRef.Kind == dockyardsv1.ServiceAccountKind {
+			object = &serviceAccount
+			key.Namespace = member.Namespace
+		}
+
+		err := h.Get(ctx, key, object)
 		if err != nil {
 			return nil, err
 		}
 
 		response[i] = types.Member{
 			CreatedAt: organization.CreationTimestamp.Time,
-			ID:        string(user.UID),
+			ID:        string(object.GetUID()),
 			Name:      member.Name,
 			Role:      ptr.To(string(member.Spec.Role)),
 		}
@@ -79,6 +94,13 @@ func (h *handler) DeleteOrganizationMember(ctx context.Context, organization *do
 		return err
 	}
 
+	if member.Spec.Role == dockyardsv1.RoleSuperUser {
+		err := apiutil.EnsureSuperUserRemains(ctx, h.apiReader, &member)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = h.Delete(ctx, &member)
 	if err != nil {
 		return err
@@ -86,3 +108,128 @@ func (h *handler) DeleteOrganizationMember(ctx context.Context, organization *do
 
 	return nil
 }
+
+type MemberOptions struct {
+	Name  *string `json:"name,omitempty"`
+	Email *string `json:"email,omitempty"`
+	Role  string  `json:"role"`
+}
+
+type MemberRoleOptions struct {
+	Role string `json:"role"`
+}
+
+func (h *handler) CreateOrganizationMember(ctx context.Context, organization *dockyardsv1.Organization, request *MemberOptions) (*types.Member, error) {
+	qualifiedKind := dockyardsv1.GroupVersion.WithKind(dockyardsv1.MemberKind).GroupKind()
+
+	var user dockyardsv1.User
+
+	switch {
+	case request.Name != nil:
+		err := h.Get(ctx, client.ObjectKey{Name: *request.Name}, &user)
+		if apierrors.IsNotFound(err) {
+			notFound := field.NotFound(field.NewPath("name"), *request.Name)
+
+			return nil, apierrors.NewInvalid(qualifiedKind, *request.Name, field.ErrorList{notFound})
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	case request.Email != nil:
+		var userList dockyardsv1.UserList
+		err := h.List(ctx, &userList, client.MatchingFields{index.EmailField: *request.Email})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(userList.Items) != 1 {
+			notFound := field.NotFound(field.NewPath("email"), *request.Email)
+
+			return nil, apierrors.NewInvalid(qualifiedKind, "", field.ErrorList{notFound})
+		}
+
+		user = userList.Items[0]
+	default:
+		required := field.Required(field.NewPath("name"), "name or email must be set")
+
+		return nil, apierrors.NewInvalid(qualifiedKind, "", field.ErrorList{required})
+	}
+
+	member := dockyardsv1.Member{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: user.Name,
+			Labels: map[string]string{
+				dockyardsv1.LabelOrganizationName: organization.Name,
+				dockyardsv1.LabelRoleName:         request.Role,
+				dockyardsv1.LabelUserName:         user.Name,
+			},
+			Namespace: organization.Spec.NamespaceRef.Name,
+		},
+		Spec: dockyardsv1.MemberSpec{
+			Role: dockyardsv1.Role(request.Role),
+			UserRef: corev1.TypedLocalObjectReference{
+				APIGroup: &dockyardsv1.GroupVersion.Group,
+				Kind:     dockyardsv1.UserKind,
+				Name:     user.Name,
+			},
+		},
+	}
+
+	err := h.Create(ctx, &member)
+	if err != nil {
+		return nil, err
+	}
+
+	response := types.Member{
+		CreatedAt: member.CreationTimestamp.Time,
+		Email:     &user.Spec.Email,
+		ID:        string(user.UID),
+		Name:      member.Name,
+		Role:      ptr.To(string(member.Spec.Role)),
+	}
+
+	return &response, nil
+}
+
+func (h *handler) UpdateOrganizationMember(ctx context.Context, organization *dockyardsv1.Organization, memberName string, request *MemberRoleOptions) error {
+	key := client.ObjectKey{
+		Name:      memberName,
+		Namespace: organization.Spec.NamespaceRef.Name,
+	}
+
+	var member dockyardsv1.Member
+	err := h.Get(ctx, key, &member)
+	if err != nil {
+		return err
+	}
+
+	role := dockyardsv1.Role(request.Role)
+
+	if member.Spec.Role == role {
+		return nil
+	}
+
+	if member.Spec.Role == dockyardsv1.RoleSuperUser {
+		err := apiutil.EnsureSuperUserRemains(ctx, h.apiReader, &member)
+		if err != nil {
+			return err
+		}
+	}
+
+	patch := client.MergeFrom(member.DeepCopy())
+
+	if member.Labels == nil {
+		member.Labels = make(map[string]string)
+	}
+
+	member.Labels[dockyardsv1.LabelRoleName] = string(role)
+	member.Spec.Role = role
+
+	err = h.Patch(ctx, &member, patch)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -15,6 +15,7 @@
 package handlers_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -27,6 +28,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/sudoswedenab/dockyards-api/pkg/types"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/handlers"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
@@ -414,3 +416,207 @@ func TestOrganizationMembers_DeleteSelf(t *testing.T) {
 		}
 	})
 }
+
+func TestOrganizationMembers_Create(t *testing.T) {
+	c := testEnvironment.GetClient()
+
+	organization := testEnvironment.MustCreateOrganization(t)
+
+	superUser := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleSuperUser)
+	user := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleUser)
+
+	superUserToken := MustSignToken(t, superUser.Name)
+	userToken := MustSignToken(t, user.Name)
+
+	otherUser := mustCreateLoginUser(t, nil)
+
+	t.Run("test as user", func(t *testing.T) {
+		request := handlers.MemberOptions{
+			Name: &otherUser.Name,
+			Role: string(dockyardsv1.RoleReader),
+		}
+
+		b, err := json.Marshal(&request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "members"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+
+	t.Run("test as super user", func(t *testing.T) {
+		request := handlers.MemberOptions{
+			Email: &otherUser.Spec.Email,
+			Role:  string(dockyardsv1.RoleReader),
+		}
+
+		b, err := json.Marshal(&request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "members"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+superUserToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+		}
+
+		var actual dockyardsv1.Member
+		err = c.Get(ctx, client.ObjectKey{Name: otherUser.Name, Namespace: organization.Spec.NamespaceRef.Name}, &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual.Spec.Role != dockyardsv1.RoleReader {
+			t.Errorf("expected role %s, got %s", dockyardsv1.RoleReader, actual.Spec.Role)
+		}
+	})
+
+	t.Run("test unknown user", func(t *testing.T) {
+		request := handlers.MemberOptions{
+			Name: ptr.To("unknown"),
+			Role: string(dockyardsv1.RoleReader),
+		}
+
+		b, err := json.Marshal(&request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "members"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+superUserToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnprocessableEntity, statusCode)
+		}
+	})
+}
+
+func TestOrganizationMembers_Update(t *testing.T) {
+	c := testEnvironment.GetClient()
+
+	organization := testEnvironment.MustCreateOrganization(t)
+
+	superUser := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleSuperUser)
+	user := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleUser)
+	reader := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleReader)
+
+	superUserToken := MustSignToken(t, superUser.Name)
+	userToken := MustSignToken(t, user.Name)
+
+	patchMember := func(t *testing.T, token, memberName string, role dockyardsv1.Role) int {
+		request := handlers.MemberRoleOptions{
+			Role: string(role),
+		}
+
+		b, err := json.Marshal(&request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "members", memberName),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPatch, u.Path, bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+token)
+
+		mux.ServeHTTP(w, r)
+
+		return w.Result().StatusCode
+	}
+
+	t.Run("test as user", func(t *testing.T) {
+		statusCode := patchMember(t, userToken, reader.Name, dockyardsv1.RoleUser)
+		if statusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+
+	t.Run("test as super user", func(t *testing.T) {
+		statusCode := patchMember(t, superUserToken, reader.Name, dockyardsv1.RoleUser)
+		if statusCode != http.StatusAccepted {
+			t.Fatalf("expected status code %d, got %d", http.StatusAccepted, statusCode)
+		}
+
+		var actual dockyardsv1.Member
+		err := c.Get(ctx, client.ObjectKey{Name: reader.Name, Namespace: organization.Spec.NamespaceRef.Name}, &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := map[string]string{
+			dockyardsv1.LabelOrganizationName: organization.Name,
+			dockyardsv1.LabelRoleName:         string(dockyardsv1.RoleUser),
+			dockyardsv1.LabelUserName:         reader.Name,
+		}
+
+		if actual.Spec.Role != dockyardsv1.RoleUser {
+			t.Errorf("expected role %s, got %s", dockyardsv1.RoleUser, actual.Spec.Role)
+		}
+
+		if !cmp.Equal(actual.Labels, expected) {
+			t.Errorf("diff: %s", cmp.Diff(expected, actual.Labels))
+		}
+	})
+
+	t.Run("test demoting last super user", func(t *testing.T) {
+		statusCode := patchMember(t, superUserToken, superUser.Name, dockyardsv1.RoleUser)
+		if statusCode != http.StatusForbidden {
+			t.Fatalf("expected status code %d, got %d", http.StatusForbidden, statusCode)
+		}
+	})
+
+	t.Run("test deleting last super user", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "members", "@me"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+superUserToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusForbidden {
+			t.Fatalf("expected status code %d, got %d", http.StatusForbidden, statusCode)
+		}
+	})
+}
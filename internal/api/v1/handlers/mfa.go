@@ -0,0 +1,449 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sudoswedenab/dockyards-api/pkg/types"
+	"github.com/sudoswedenab/dockyards-backend/api/config"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	"github.com/sudoswedenab/dockyards-backend/pkg/util/totp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	mfaTokenDuration = time.Minute * 5
+
+	// mfaAudience is the audience of tokens issued by the first step of a login, they are signed using the
+	// refresh token key but never belong to a session so they cannot be used to refresh.
+	mfaAudience = "dockyards:mfa"
+
+	mfaSecretKey        = "secret"
+	mfaRecoveryCodesKey = "recoveryCodes"
+	mfaCounterKey       = "counter"
+
+	recoveryCodeCount = 10
+)
+
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=create;delete;get;list;patch;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=users,verbs=get;list;patch;watch
+
+type MFAEnrollment struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	Secret        string   `json:"secret"`
+	URI           string   `json:"uri"`
+}
+
+// MFACodeOptions holds the second factor required to confirm or deactivate multi-factor authentication.
+type MFACodeOptions struct {
+	Code         *string `json:"code,omitempty"`
+	RecoveryCode *string `json:"recovery_code,omitempty"`
+}
+
+type MFAStatus struct {
+	Enabled bool `json:"enabled"`
+}
+
+type LoginMFAOptions struct {
+	Code         *string `json:"code,omitempty"`
+	MFAToken     string  `json:"mfa_token"`
+	RecoveryCode *string `json:"recovery_code,omitempty"`
+}
+
+var (
+	errMFANotWithAPIToken = errors.New("multi-factor authentication cannot be managed using an api token")
+	errMFANotPending      = errors.New("user has no pending multi-factor authentication enrollment")
+	errMFANotEnrolled     = errors.New("user has not enrolled in multi-factor authentication")
+)
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateUserMFA starts the enrollment of a user in multi-factor authentication, the user is enrolled once the
+// enrollment is confirmed using a code. Enrolling again before confirming replaces the secret and recovery codes.
+func (h *handler) CreateUserMFA(ctx context.Context, user *dockyardsv1.User, _ *struct{}) (*MFAEnrollment, error) {
+	err := h.checkUserMFA(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Spec.MFA != nil {
+		return nil, apierrors.NewAlreadyExists(dockyardsv1.GroupVersion.WithResource("users").GroupResource(), user.Name)
+	}
+
+	secret := totp.GenerateSecret()
+
+	recoveryCodes := make([]string, recoveryCodeCount)
+	hashedRecoveryCodes := make([]string, recoveryCodeCount)
+
+	for i := range recoveryCodes {
+		recoveryCodes[i] = rand.Text()
+		hashedRecoveryCodes[i] = hashRecoveryCode(recoveryCodes[i])
+	}
+
+	mfaSecret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mfaSecretName(user),
+			Namespace: h.systemNamespace,
+			Labels: map[string]string{
+				dockyardsv1.LabelUserName: user.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: dockyardsv1.GroupVersion.String(),
+					Kind:       dockyardsv1.UserKind,
+					Name:       user.Name,
+					UID:        user.UID,
+				},
+			},
+		},
+		Data: map[string][]byte{
+			mfaSecretKey:        []byte(secret),
+			mfaRecoveryCodesKey: []byte(strings.Join(hashedRecoveryCodes, "\n")),
+		},
+	}
+
+	err = h.Create(ctx, &mfaSecret)
+	if apierrors.IsAlreadyExists(err) {
+		// The secret of a pending enrollment is replaced, the counter is removed by the merge patch since a nil
+		// value is marshalled as null.
+		mfaSecret.Data[mfaCounterKey] = nil
+
+		err = h.Patch(ctx, &mfaSecret, client.Merge)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	issuer := h.Config.GetValueOrDefault(config.KeyEnvironmentName, "dockyards")
+
+	response := MFAEnrollment{
+		RecoveryCodes: recoveryCodes,
+		Secret:        secret,
+		URI:           totp.URI(issuer, user.Spec.Email, secret),
+	}
+
+	return &response, nil
+}
+
+// CreateUserMFAConfirmation enrolls the user in multi-factor authentication once a code generated from the
+// pending enrollment is valid, logins require a second factor from then on.
+func (h *handler) CreateUserMFAConfirmation(ctx context.Context, user *dockyardsv1.User, request *MFACodeOptions) (*MFAStatus, error) {
+	err := h.checkUserMFA(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Spec.MFA != nil {
+		return nil, apierrors.NewAlreadyExists(dockyardsv1.GroupVersion.WithResource("users").GroupResource(), user.Name)
+	}
+
+	objectKey := client.ObjectKey{
+		Name:      mfaSecretName(user),
+		Namespace: h.systemNamespace,
+	}
+
+	var mfaSecret corev1.Secret
+	err = h.apiReader.Get(ctx, objectKey, &mfaSecret)
+	if apierrors.IsNotFound(err) {
+		return nil, apierrors.NewConflict(dockyardsv1.GroupVersion.WithResource("users").GroupResource(), user.Name, errMFANotPending)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.useMFACode(ctx, user, &mfaSecret, request.Code, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := client.MergeFrom(user.DeepCopy())
+
+	user.Spec.MFA = &dockyardsv1.UserMFA{
+		SecretRef: corev1.SecretReference{
+			Name:      mfaSecret.Name,
+			Namespace: mfaSecret.Namespace,
+		},
+	}
+
+	err = h.Patch(ctx, user, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	response := MFAStatus{
+		Enabled: true,
+	}
+
+	return &response, nil
+}
+
+// CreateUserMFADeactivation removes multi-factor authentication from the user, it requires a code or an unused
+// recovery code so that a stolen access token alone cannot remove the second factor. Users reset their second
+// factor by deactivating it and enrolling again.
+func (h *handler) CreateUserMFADeactivation(ctx context.Context, user *dockyardsv1.User, request *MFACodeOptions) (*MFAStatus, error) {
+	err := h.checkUserMFA(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Spec.MFA == nil {
+		return nil, apierrors.NewConflict(dockyardsv1.GroupVersion.WithResource("users").GroupResource(), user.Name, errMFANotEnrolled)
+	}
+
+	objectKey := client.ObjectKey{
+		Name:      user.Spec.MFA.SecretRef.Name,
+		Namespace: user.Spec.MFA.SecretRef.Namespace,
+	}
+
+	var mfaSecret corev1.Secret
+	err = h.apiReader.Get(ctx, objectKey, &mfaSecret)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, err
+	}
+
+	// A user whose secret is missing can never complete a login using a second factor, the enrollment is
+	// removed without a code so that the user can enroll again.
+	if err == nil {
+		err = h.useMFACode(ctx, user, &mfaSecret, request.Code, request.RecoveryCode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	patch := client.MergeFrom(user.DeepCopy())
+
+	user.Spec.MFA = nil
+
+	err = h.Patch(ctx, user, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.Delete(ctx, &mfaSecret)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, err
+	}
+
+	response := MFAStatus{
+		Enabled: false,
+	}
+
+	return &response, nil
+}
+
+// checkUserMFA returns an error unless the user manages multi-factor authentication using dockyards and the
+// request was made using a signed token.
+func (h *handler) checkUserMFA(ctx context.Context, user *dockyardsv1.User) error {
+	if middleware.APITokenFrom(ctx) != nil {
+		return apierrors.NewForbidden(dockyardsv1.GroupVersion.WithResource("users").GroupResource(), user.Name, errMFANotWithAPIToken)
+	}
+
+	if user.Spec.ProviderID != dockyardsv1.ProviderPrefixDockyards {
+		fieldErrors := field.ErrorList{
+			field.Forbidden(field.NewPath("providerID"), "multi-factor authentication is managed by the identity provider"),
+		}
+
+		return apierrors.NewInvalid(dockyardsv1.GroupVersion.WithKind(dockyardsv1.UserKind).GroupKind(), user.Name, fieldErrors)
+	}
+
+	return nil
+}
+
+// useMFACode validates a code or a recovery code against the secret and records it as used, the secret is
+// patched using an optimistic lock to prevent concurrent use of the same code.
+func (h *handler) useMFACode(ctx context.Context, user *dockyardsv1.User, mfaSecret *corev1.Secret, code, recoveryCode *string) error {
+	patch := client.MergeFromWithOptions(mfaSecret.DeepCopy(), client.MergeFromWithOptimisticLock{})
+
+	var fieldErrors field.ErrorList
+
+	switch {
+	case code != nil:
+		counter, valid := totp.Validate(string(mfaSecret.Data[mfaSecretKey]), *code, time.Now())
+
+		lastCounter, _ := strconv.ParseUint(string(mfaSecret.Data[mfaCounterKey]), 10, 64)
+		if !valid || counter <= lastCounter {
+			fieldErrors = append(fieldErrors, field.Invalid(field.NewPath("code"), *code, "invalid code"))
+
+			break
+		}
+
+		mfaSecret.Data[mfaCounterKey] = []byte(strconv.FormatUint(counter, 10))
+	case recoveryCode != nil:
+		hashedRecoveryCodes, ok := useRecoveryCode(mfaSecret, *recoveryCode)
+		if !ok {
+			fieldErrors = append(fieldErrors, field.Invalid(field.NewPath("recovery_code"), "", "invalid recovery code"))
+
+			break
+		}
+
+		mfaSecret.Data[mfaRecoveryCodesKey] = hashedRecoveryCodes
+	default:
+		fieldErrors = append(fieldErrors, field.Required(field.NewPath("code"), "code is required"))
+	}
+
+	if len(fieldErrors) > 0 {
+		return apierrors.NewInvalid(dockyardsv1.GroupVersion.WithKind(dockyardsv1.UserKind).GroupKind(), user.Name, fieldErrors)
+	}
+
+	err := h.Patch(ctx, mfaSecret, patch)
+	if apierrors.IsConflict(err) {
+		return apierrors.NewConflict(dockyardsv1.GroupVersion.WithResource("users").GroupResource(), user.Name, errors.New("code was used concurrently"))
+	}
+
+	return err
+}
+
+// useRecoveryCode returns the hashed recovery codes of the secret without the recovery code, or false if the
+// recovery code is not valid.
+func useRecoveryCode(mfaSecret *corev1.Secret, recoveryCode string) ([]byte, bool) {
+	hash := hashRecoveryCode(recoveryCode)
+
+	hashedRecoveryCodes := strings.Split(string(mfaSecret.Data[mfaRecoveryCodesKey]), "\n")
+
+	i := slices.IndexFunc(hashedRecoveryCodes, func(hashedRecoveryCode string) bool {
+		return subtle.ConstantTimeCompare([]byte(hashedRecoveryCode), []byte(hash)) == 1
+	})
+	if i == -1 {
+		return nil, false
+	}
+
+	hashedRecoveryCodes = slices.Delete(hashedRecoveryCodes, i, i+1)
+
+	return []byte(strings.Join(hashedRecoveryCodes, "\n")), true
+}
+
+func mfaSecretName(user *dockyardsv1.User) string {
+	return "mfa-" + user.Name
+}
+
+func (h *handler) signMFAToken(user *dockyardsv1.User) (string, error) {
+	claims := middleware.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.Name,
+			Audience:  jwt.ClaimStrings{mfaAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(mfaTokenDuration)),
+		},
+		AuthenticationMethods: []string{
+			middleware.AuthenticationMethodPassword,
+		},
+	}
+
+	return h.jwtKeySet.SignRefreshToken(claims)
+}
+
+// CreateGlobalMFATokens completes a login for users enrolled in multi-factor authentication by exchanging the
+// token from the first step together with a one-time code or an unused recovery code for a new session.
+func (h *handler) CreateGlobalMFATokens(ctx context.Context, request *LoginMFAOptions) (*types.Tokens, error) {
+	var claims middleware.Claims
+	_, err := jwt.ParseWithClaims(request.MFAToken, &claims, h.jwtKeySet.RefreshTokenKeyfunc, jwt.WithAudience(mfaAudience), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, apierrors.NewUnauthorized("invalid mfa token")
+	}
+
+	var user dockyardsv1.User
+	err = h.Get(ctx, client.ObjectKey{Name: claims.Subject}, &user)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, apierrors.NewUnauthorized("user not found")
+		}
+
+		return nil, err
+	}
+
+	condition := meta.FindStatusCondition(user.Status.Conditions, dockyardsv1.ReadyCondition)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		return nil, apierrors.NewUnauthorized("user does not have ready condition")
+	}
+
+	if user.Spec.MFA == nil {
+		return nil, apierrors.NewUnauthorized("user has not enrolled in multi-factor authentication")
+	}
+
+	err = h.checkLoginAttempts(ctx, user.Spec.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.checkLockout(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	// The secret is read directly from the api server, a stale cache would otherwise allow replaying codes
+	// and recovery codes.
+	objectKey := client.ObjectKey{
+		Name:      user.Spec.MFA.SecretRef.Name,
+		Namespace: user.Spec.MFA.SecretRef.Namespace,
+	}
+
+	var mfaSecret corev1.Secret
+	err = h.apiReader.Get(ctx, objectKey, &mfaSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.useMFACode(ctx, &user, &mfaSecret, request.Code, request.RecoveryCode)
+	if apierrors.IsInvalid(err) {
+		return nil, h.loginFailed(ctx, user.Spec.Email, &user, "invalid_code")
+	}
+
+	if apierrors.IsConflict(err) {
+		return nil, apierrors.NewUnauthorized("code was used concurrently")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.loginSucceeded(ctx, user.Spec.Email, &user)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := h.createSession(ctx, &user, middleware.AuthenticationMethodPassword, middleware.AuthenticationMethodOTP)
+	if err != nil {
+		return nil, apierrors.NewInternalError(err)
+	}
+
+	tokens, err := h.generateTokens(ctx, &user, session)
+	if err != nil {
+		return nil, apierrors.NewInternalError(err)
+	}
+
+	return tokens, nil
+}
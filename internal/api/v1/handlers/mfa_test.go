@@ -0,0 +1,336 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/sudoswedenab/dockyards-api/pkg/types"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/handlers"
+	"github.com/sudoswedenab/dockyards-backend/pkg/util/totp"
+	"golang.org/x/crypto/bcrypt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestUserMFA_Create(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mgr := testEnvironment.GetManager()
+	c := testEnvironment.GetClient()
+
+	organization := testEnvironment.MustCreateOrganization(t)
+	user := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleUser)
+
+	patch := client.MergeFrom(user.DeepCopy())
+
+	user.Spec.Email = user.Name + "@dockyards.dev"
+	user.Spec.Password = string(hash)
+	user.Spec.ProviderID = dockyardsv1.ProviderPrefixDockyards
+
+	err = c.Patch(ctx, user, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patch = client.MergeFrom(user.DeepCopy())
+
+	user.Status.Conditions = []metav1.Condition{
+		{
+			Type:               dockyardsv1.ReadyCondition,
+			Status:             metav1.ConditionTrue,
+			Reason:             "testing",
+			LastTransitionTime: metav1.Now(),
+		},
+	}
+
+	err = c.Status().Patch(ctx, user, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userToken := MustSignToken(t, user.Name)
+
+	var enrollment handlers.MFAEnrollment
+
+	t.Run("test enroll", func(t *testing.T) {
+		mgr.GetCache().WaitForCacheSync(ctx)
+
+		u := url.URL{
+			Path: path.Join("/v1/users", user.Name, "mfa"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBufferString(`{}`))
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+		}
+
+		err := json.NewDecoder(w.Result().Body).Decode(&enrollment)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(enrollment.RecoveryCodes) == 0 {
+			t.Error("expected recovery codes in response")
+		}
+	})
+
+	t.Run("test enroll again before confirming", func(t *testing.T) {
+		previous := enrollment.Secret
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, path.Join("/v1/users", user.Name, "mfa"), bytes.NewBufferString(`{}`))
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+		}
+
+		err := json.NewDecoder(w.Result().Body).Decode(&enrollment)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if enrollment.Secret == previous {
+			t.Error("expected secret to be replaced")
+		}
+	})
+
+	t.Run("test confirm invalid code", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, path.Join("/v1/users", user.Name, "mfa", "confirm"), bytes.NewBufferString(`{"code":"000000"}`))
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnprocessableEntity, statusCode)
+		}
+	})
+
+	t.Run("test confirm", func(t *testing.T) {
+		code, err := totp.GenerateCode(enrollment.Secret, totp.Counter(time.Now())-1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		options := handlers.MFACodeOptions{
+			Code: &code,
+		}
+
+		b, err := json.Marshal(&options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, path.Join("/v1/users", user.Name, "mfa", "confirm"), bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+		}
+	})
+
+	var loginResponse handlers.LoginResponse
+
+	t.Run("test login", func(t *testing.T) {
+		mgr.GetCache().WaitForCacheSync(ctx)
+
+		options := types.LoginOptions{
+			Email:    user.Spec.Email,
+			Password: "password",
+		}
+
+		b, err := json.Marshal(&options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/login", bytes.NewBuffer(b))
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+		}
+
+		err = json.NewDecoder(w.Result().Body).Decode(&loginResponse)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !loginResponse.MFARequired || loginResponse.MFAToken == nil {
+			t.Fatal("expected mfa to be required")
+		}
+
+		if loginResponse.AccessToken != "" {
+			t.Error("expected no access token before second factor")
+		}
+	})
+
+	code, err := totp.GenerateCode(enrollment.Secret, totp.Counter(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test code", func(t *testing.T) {
+		options := handlers.LoginMFAOptions{
+			Code:     &code,
+			MFAToken: *loginResponse.MFAToken,
+		}
+
+		b, err := json.Marshal(&options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/login/mfa", bytes.NewBuffer(b))
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+		}
+	})
+
+	t.Run("test reused code", func(t *testing.T) {
+		options := handlers.LoginMFAOptions{
+			Code:     &code,
+			MFAToken: *loginResponse.MFAToken,
+		}
+
+		b, err := json.Marshal(&options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/login/mfa", bytes.NewBuffer(b))
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+
+	t.Run("test recovery code", func(t *testing.T) {
+		for _, expected := range []int{http.StatusCreated, http.StatusUnauthorized} {
+			options := handlers.LoginMFAOptions{
+				MFAToken:     *loginResponse.MFAToken,
+				RecoveryCode: ptr.To(enrollment.RecoveryCodes[0]),
+			}
+
+			b, err := json.Marshal(&options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/v1/login/mfa", bytes.NewBuffer(b))
+
+			mux.ServeHTTP(w, r)
+
+			statusCode := w.Result().StatusCode
+			if statusCode != expected {
+				t.Fatalf("expected status code %d, got %d", expected, statusCode)
+			}
+		}
+	})
+
+	t.Run("test mfa token as refresh token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/refresh", nil)
+
+		r.Header.Add("Authorization", "Bearer "+*loginResponse.MFAToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+
+	t.Run("test deactivate", func(t *testing.T) {
+		mgr.GetCache().WaitForCacheSync(ctx)
+
+		options := handlers.MFACodeOptions{
+			RecoveryCode: ptr.To(enrollment.RecoveryCodes[1]),
+		}
+
+		b, err := json.Marshal(&options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, path.Join("/v1/users", user.Name, "mfa", "deactivate"), bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+		}
+
+		var actual dockyardsv1.User
+		err = c.Get(ctx, client.ObjectKeyFromObject(user), &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual.Spec.MFA != nil {
+			t.Error("expected user to not be enrolled in multi-factor authentication")
+		}
+	})
+}
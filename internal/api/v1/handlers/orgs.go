@@ -22,10 +22,8 @@ import (
 
 	"github.com/sudoswedenab/dockyards-api/pkg/types"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
-	"github.com/sudoswedenab/dockyards-backend/api/v1alpha3/index"
 	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
 	corev1 "k8s.io/api/core/v1"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
@@ -36,6 +34,42 @@ import (
 // +kubebuilder:rbac:groups=dockyards.io,resources=members,verbs=create;get;list;watch
 // +kubebuilder:rbac:groups=dockyards.io,resources=organizations,verbs=create;delete;get;list;watch
 
+type ResourceQuota struct {
+	Limit *string `json:"limit,omitempty"`
+	Used  string  `json:"used"`
+}
+
+type Organization struct {
+	types.Organization
+	ResourceQuotas map[string]ResourceQuota `json:"resource_quotas,omitempty"`
+}
+
+func toResourceQuotas(organization *dockyardsv1.Organization) map[string]ResourceQuota {
+	if len(organization.Spec.ResourceQuotas) == 0 && len(organization.Status.ResourceUsage) == 0 {
+		return nil
+	}
+
+	resourceQuotas := make(map[string]ResourceQuota)
+
+	for resourceName, quantity := range organization.Status.ResourceUsage {
+		resourceQuotas[string(resourceName)] = ResourceQuota{
+			Used: quantity.String(),
+		}
+	}
+
+	for resourceName, quantity := range organization.Spec.ResourceQuotas {
+		resourceQuota, hasUsage := resourceQuotas[string(resourceName)]
+		if !hasUsage {
+			resourceQuota.Used = "0"
+		}
+
+		resourceQuota.Limit = ptr.To(quantity.String())
+		resourceQuotas[string(resourceName)] = resourceQuota
+	}
+
+	return resourceQuotas
+}
+
 func (h *handler) ListGlobalOrganizations(ctx context.Context) (*[]types.Organization, error) {
 	logger := middleware.LoggerFrom(ctx)
 
@@ -118,6 +152,16 @@ func (h *handler) CreateGlobalOrganization(ctx context.Context, request *types.O
 		return nil, err
 	}
 
+	var organizationVoucher *dockyardsv1.OrganizationVoucher
+	var organizationVoucherPool *dockyardsv1.OrganizationVoucherPool
+
+	if request.VoucherCode != nil {
+		organizationVoucher, organizationVoucherPool, err = h.getRedeemableOrganizationVoucher(ctx, *request.VoucherCode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	namespace := corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: "dockyards-",
@@ -159,33 +203,17 @@ func (h *handler) CreateGlobalOrganization(ctx context.Context, request *types.O
 		}
 	}
 
-	if request.VoucherCode != nil {
-		matchingFields := client.MatchingFields{
-			index.CodeField: *request.VoucherCode,
-		}
-
-		var organizationVoucherList dockyardsv1.OrganizationVoucherList
-		err := h.List(ctx, &organizationVoucherList, matchingFields, client.InNamespace(h.systemNamespace))
-		if err != nil {
-			return nil, err
-		}
-
-		if len(organizationVoucherList.Items) != 1 {
-			statusError := apierrors.NewInvalid(dockyardsv1.GroupVersion.WithKind(dockyardsv1.OrganizationKind).GroupKind(), "", nil)
-
-			return nil, statusError
+	if organizationVoucher != nil {
+		organization.Annotations = map[string]string{
+			dockyardsv1.AnnotationVoucherCode: organizationVoucher.Spec.Code,
 		}
 
-		organizationVoucher := organizationVoucherList.Items[0]
-
-		if organizationVoucher.Status.Redeemed {
-			statusError := apierrors.NewInvalid(dockyardsv1.GroupVersion.WithKind(dockyardsv1.OrganizationKind).GroupKind(), "", nil)
-
-			return nil, statusError
-		}
+		if organizationVoucherPool != nil {
+			if organizationVoucherPool.Spec.Duration != nil {
+				organization.Spec.Duration = organizationVoucherPool.Spec.Duration.DeepCopy()
+			}
 
-		organization.Annotations = map[string]string{
-			dockyardsv1.AnnotationVoucherCode: organizationVoucher.Spec.Code,
+			organization.Spec.ResourceQuotas = organizationVoucherPool.Spec.ResourceQuotas.DeepCopy()
 		}
 	}
 
@@ -194,6 +222,17 @@ func (h *handler) CreateGlobalOrganization(ctx context.Context, request *types.O
 		return nil, err
 	}
 
+	// The voucher is redeemed once the organization exists, the organization is removed when the voucher cannot be
+	// redeemed so that no organization is created without a redemption.
+	if organizationVoucher != nil {
+		err := h.redeemOrganizationVoucher(ctx, organizationVoucher, organizationVoucherPool, organization.Name)
+		if err != nil {
+			h.deleteUnredeemedOrganization(ctx, &organization, &namespace)
+
+			return nil, err
+		}
+	}
+
 	patch := client.MergeFrom(namespace.DeepCopy())
 
 	namespace.OwnerReferences = []metav1.OwnerReference{
@@ -214,6 +253,22 @@ func (h *handler) CreateGlobalOrganization(ctx context.Context, request *types.O
 		return nil, err
 	}
 
+	if organizationVoucherPool != nil {
+		for _, featureName := range organizationVoucherPool.Spec.Features {
+			feature := dockyardsv1.Feature{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      featureName,
+					Namespace: namespace.Name,
+				},
+			}
+
+			err := h.Create(ctx, &feature)
+			if client.IgnoreAlreadyExists(err) != nil {
+				return nil, err
+			}
+		}
+	}
+
 	response := types.Organization{
 		CreatedAt: organization.CreationTimestamp.Time,
 		ID:        string(organization.UID),
@@ -252,6 +307,22 @@ func (h *handler) CreateGlobalOrganization(ctx context.Context, request *types.O
 	return &response, nil
 }
 
+// deleteUnredeemedOrganization deletes an organization and its namespace created with a voucher that could not be
+// redeemed, errors are logged since the error of the redemption is returned.
+func (h *handler) deleteUnredeemedOrganization(ctx context.Context, organization *dockyardsv1.Organization, namespace *corev1.Namespace) {
+	logger := middleware.LoggerFrom(ctx)
+
+	err := h.Delete(ctx, organization)
+	if client.IgnoreNotFound(err) != nil {
+		logger.Error("error deleting unredeemed organization", "organization", organization.Name, "err", err)
+	}
+
+	err = h.Delete(ctx, namespace)
+	if client.IgnoreNotFound(err) != nil {
+		logger.Error("error deleting unredeemed organization namespace", "namespace", namespace.Name, "err", err)
+	}
+}
+
 func (h *handler) DeleteGlobalOrganization(ctx context.Context, resourceName string) error {
 	var organization dockyardsv1.Organization
 	err := h.Get(ctx, client.ObjectKey{Name: resourceName}, &organization)
@@ -267,7 +338,7 @@ func (h *handler) DeleteGlobalOrganization(ctx context.Context, resourceName str
 	return nil
 }
 
-func (h *handler) GetGlobalOrganization(ctx context.Context, organizationName string) (*types.Organization, error) {
+func (h *handler) GetGlobalOrganization(ctx context.Context, organizationName string) (*Organization, error) {
 	objectKey := client.ObjectKey{
 		Name: organizationName,
 	}
@@ -317,7 +388,10 @@ func (h *handler) GetGlobalOrganization(ctx context.Context, organizationName st
 		response.CredentialReferenceName = &credentialReferenceName
 	}
 
-	return &response, nil
+	return &Organization{
+		Organization:   response,
+		ResourceQuotas: toResourceQuotas(&organization),
+	}, nil
 }
 
 func (h *handler) UpdateGlobalOrganization(ctx context.Context, organizationName string, request *types.OrganizationOptions) error {
@@ -30,9 +30,11 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	apitypes "github.com/sudoswedenab/dockyards-api/pkg/types"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/handlers"
 	"github.com/sudoswedenab/dockyards-backend/pkg/testing/testingutil"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
@@ -1247,6 +1249,86 @@ func TestGlobalOrganizations_Get(t *testing.T) {
 			t.Errorf("diff: %s", cmp.Diff(expected, actual))
 		}
 	})
+
+	t.Run("test resource quotas", func(t *testing.T) {
+		otherOrganization := testEnvironment.MustCreateOrganization(t)
+
+		otherUser := testEnvironment.MustGetOrganizationUser(t, otherOrganization, dockyardsv1.RoleReader)
+
+		otherUserToken := MustSignToken(t, otherUser.Name)
+
+		patch := client.MergeFrom(otherOrganization.DeepCopy())
+
+		otherOrganization.Spec.ResourceQuotas = corev1.ResourceList{
+			dockyardsv1.ResourceCluster: resource.MustParse("3"),
+			corev1.ResourceCPU:          resource.MustParse("16"),
+		}
+
+		err := c.Patch(ctx, otherOrganization, patch)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		otherOrganization.Status.ResourceUsage = corev1.ResourceList{
+			dockyardsv1.ResourceCluster: resource.MustParse("1"),
+			corev1.ResourceMemory:       resource.MustParse("8Gi"),
+		}
+
+		err = c.Status().Patch(ctx, otherOrganization, patch)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = testingutil.RetryUntilFound(ctx, mgr.GetClient(), otherOrganization)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u := url.URL{
+			Path: path.Join("/v1/orgs", otherOrganization.Name),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+otherUserToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, statusCode)
+		}
+
+		b, err := io.ReadAll(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual handlers.Organization
+		err = json.Unmarshal(b, &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := map[string]handlers.ResourceQuota{
+			"cluster": {
+				Limit: ptr.To("3"),
+				Used:  "1",
+			},
+			"cpu": {
+				Limit: ptr.To("16"),
+				Used:  "0",
+			},
+			"memory": {
+				Used: "8Gi",
+			},
+		}
+
+		if !cmp.Equal(actual.ResourceQuotas, expected) {
+			t.Errorf("diff: %s", cmp.Diff(expected, actual.ResourceQuotas))
+		}
+	})
 }
 
 func TestGlobalOrganizations_Update(t *testing.T) {
@@ -0,0 +1,145 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/sudoswedenab/dockyards-api/pkg/types"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/api/v1alpha3/index"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestCreateGlobalOrganizationVoucher(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = dockyardsv1.AddToScheme(scheme)
+
+	user := dockyardsv1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+		},
+	}
+
+	organizationVoucher := dockyardsv1.OrganizationVoucher{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "dockyards-system",
+		},
+		Spec: dockyardsv1.OrganizationVoucherSpec{
+			Code: "TEST-123",
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		interceptors  interceptor.Funcs
+		expectError   bool
+		organizations int
+		redemptions   int
+	}{
+		{
+			name:          "test redeemed",
+			organizations: 1,
+			redemptions:   1,
+		},
+		{
+			name: "test redemption failure",
+			interceptors: interceptor.Funcs{
+				SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+					_, isVoucher := obj.(*dockyardsv1.OrganizationVoucher)
+					if isVoucher {
+						return apierrors.NewServiceUnavailable("testing")
+					}
+
+					return c.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+				},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(&user, &organizationVoucher).
+				WithStatusSubresource(&organizationVoucher).
+				WithIndex(&dockyardsv1.OrganizationVoucher{}, index.CodeField, index.IndexerFuncs[index.CodeField]).
+				WithInterceptorFuncs(tc.interceptors).
+				Build()
+
+			h := handler{
+				Client:          c,
+				apiReader:       c,
+				logger:          slog.New(slog.DiscardHandler),
+				systemNamespace: organizationVoucher.Namespace,
+			}
+
+			ctx := middleware.ContextWithSubject(t.Context(), user.Name)
+			ctx = middleware.ContextWithLogger(ctx, h.logger)
+
+			options := types.OrganizationOptions{
+				VoucherCode: ptr.To(organizationVoucher.Spec.Code),
+			}
+
+			_, err := h.CreateGlobalOrganization(ctx, &options)
+			if tc.expectError != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tc.expectError, err)
+			}
+
+			var organizationList dockyardsv1.OrganizationList
+			err = c.List(t.Context(), &organizationList)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(organizationList.Items) != tc.organizations {
+				t.Errorf("expected %d organizations, got %d", tc.organizations, len(organizationList.Items))
+			}
+
+			var namespaceList corev1.NamespaceList
+			err = c.List(t.Context(), &namespaceList)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(namespaceList.Items) != tc.organizations {
+				t.Errorf("expected %d namespaces, got %d", tc.organizations, len(namespaceList.Items))
+			}
+
+			var actual dockyardsv1.OrganizationVoucher
+			err = c.Get(t.Context(), client.ObjectKeyFromObject(&organizationVoucher), &actual)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(actual.Status.Redemptions) != tc.redemptions {
+				t.Errorf("expected %d redemptions, got %d", tc.redemptions, len(actual.Status.Redemptions))
+			}
+		})
+	}
+}
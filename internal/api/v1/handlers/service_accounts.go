@@ -0,0 +1,175 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"time"
+
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=dockyards.io,resources=serviceaccounts,verbs=create;delete;get;list;watch
+
+type ServiceAccountOptions struct {
+	DisplayName *string `json:"display_name,omitempty"`
+	Name        string  `json:"name"`
+	Role        string  `json:"role"`
+}
+
+type ServiceAccount struct {
+	CreatedAt   time.Time `json:"created_at"`
+	DisplayName *string   `json:"display_name,omitempty"`
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Role        string    `json:"role"`
+}
+
+func toServiceAccount(serviceAccount *dockyardsv1.ServiceAccount) ServiceAccount {
+	result := ServiceAccount{
+		CreatedAt: serviceAccount.CreationTimestamp.Time,
+		ID:        string(serviceAccount.UID),
+		Name:      serviceAccount.Name,
+		Role:      string(serviceAccount.Spec.Role),
+	}
+
+	if serviceAccount.Spec.DisplayName != "" {
+		result.DisplayName = &serviceAccount.Spec.DisplayName
+	}
+
+	return result
+}
+
+func (h *handler) CreateOrganizationServiceAccount(ctx context.Context, organization *dockyardsv1.Organization, request *ServiceAccountOptions) (*ServiceAccount, error) {
+	serviceAccount := dockyardsv1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      request.Name,
+			Namespace: organization.Spec.NamespaceRef.Name,
+			Labels: map[string]string{
+				dockyardsv1.LabelOrganizationName: organization.Name,
+				dockyardsv1.LabelRoleName:         request.Role,
+			},
+		},
+		Spec: dockyardsv1.ServiceAccountSpec{
+			Role: dockyardsv1.Role(request.Role),
+		},
+	}
+
+	if request.DisplayName != nil {
+		serviceAccount.Spec.DisplayName = *request.DisplayName
+	}
+
+	err := h.Create(ctx, &serviceAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	result := toServiceAccount(&serviceAccount)
+
+	return &result, nil
+}
+
+func (h *handler) ListOrganizationServiceAccounts(ctx context.Context, organization *dockyardsv1.Organization) (*[]ServiceAccount, error) {
+	var serviceAccountList dockyardsv1.ServiceAccountList
+	err := h.List(ctx, &serviceAccountList, client.InNamespace(organization.Spec.NamespaceRef.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ServiceAccount, len(serviceAccountList.Items))
+
+	for i, item := range serviceAccountList.Items {
+		result[i] = toServiceAccount(&item)
+	}
+
+	return &result, nil
+}
+
+func (h *handler) DeleteOrganizationServiceAccount(ctx context.Context, organization *dockyardsv1.Organization, serviceAccountName string) error {
+	objectKey := client.ObjectKey{
+		Name:      serviceAccountName,
+		Namespace: organization.Spec.NamespaceRef.Name,
+	}
+
+	var serviceAccount dockyardsv1.ServiceAccount
+	err := h.Get(ctx, objectKey, &serviceAccount)
+	if err != nil {
+		return err
+	}
+
+	return h.Delete(ctx, &serviceAccount)
+}
+
+func (h *handler) CreateServiceAccountAPIToken(ctx context.Context, serviceAccount *dockyardsv1.ServiceAccount, request *APITokenOptions) (*APIToken, error) {
+	if middleware.APITokenFrom(ctx) != nil {
+		return nil, apierrors.NewForbidden(dockyardsv1.GroupVersion.WithResource("apitokens").GroupResource(), "", errNotWithAPIToken)
+	}
+
+	apiToken, err := newAPIToken(request)
+	if err != nil {
+		return nil, err
+	}
+
+	apiToken.Labels[dockyardsv1.LabelServiceAccountName] = serviceAccount.Name
+	apiToken.Labels[dockyardsv1.LabelNamespaceName] = serviceAccount.Namespace
+	apiToken.Spec.ServiceAccountRef = &corev1.TypedObjectReference{
+		APIGroup:  &dockyardsv1.GroupVersion.Group,
+		Kind:      dockyardsv1.ServiceAccountKind,
+		Name:      serviceAccount.Name,
+		Namespace: &serviceAccount.Namespace,
+	}
+
+	return h.createAPIToken(ctx, apiToken, serviceAccount.Name)
+}
+
+func (h *handler) ListServiceAccountAPITokens(ctx context.Context, serviceAccount *dockyardsv1.ServiceAccount) (*[]APIToken, error) {
+	matchingLabels := client.MatchingLabels{
+		dockyardsv1.LabelServiceAccountName: serviceAccount.Name,
+		dockyardsv1.LabelNamespaceName:      serviceAccount.Namespace,
+	}
+
+	var apiTokenList dockyardsv1.APITokenList
+	err := h.List(ctx, &apiTokenList, matchingLabels)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]APIToken, len(apiTokenList.Items))
+
+	for i, item := range apiTokenList.Items {
+		result[i] = toAPIToken(&item)
+	}
+
+	return &result, nil
+}
+
+func (h *handler) DeleteServiceAccountAPIToken(ctx context.Context, serviceAccount *dockyardsv1.ServiceAccount, apiTokenName string) error {
+	var apiToken dockyardsv1.APIToken
+	err := h.Get(ctx, client.ObjectKey{Name: apiTokenName}, &apiToken)
+	if err != nil {
+		return err
+	}
+
+	if apiToken.GetSubject() != serviceAccount.GetSubject() {
+		return apierrors.NewNotFound(dockyardsv1.GroupVersion.WithResource("apitokens").GroupResource(), apiTokenName)
+	}
+
+	return h.Delete(ctx, &apiToken)
+}
@@ -0,0 +1,274 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"testing"
+
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/handlers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestOrganizationServiceAccounts_Create(t *testing.T) {
+	organization := testEnvironment.MustCreateOrganization(t)
+	superUser := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleSuperUser)
+	reader := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleReader)
+
+	superUserToken := MustSignToken(t, superUser.Name)
+	readerToken := MustSignToken(t, reader.Name)
+
+	c := testEnvironment.GetClient()
+
+	t.Run("test as super user", func(t *testing.T) {
+		request := handlers.ServiceAccountOptions{
+			DisplayName: ptr.To("Automation"),
+			Name:        "automation",
+			Role:        dockyardsv1.RoleUser,
+		}
+
+		b, err := json.Marshal(&request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "service-accounts"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+superUserToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+		}
+
+		var serviceAccount dockyardsv1.ServiceAccount
+		err = c.Get(ctx, client.ObjectKey{Name: "automation", Namespace: organization.Spec.NamespaceRef.Name}, &serviceAccount)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if serviceAccount.Spec.Role != dockyardsv1.RoleUser {
+			t.Errorf("expected role %s, got %s", dockyardsv1.RoleUser, serviceAccount.Spec.Role)
+		}
+	})
+
+	t.Run("test as reader", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "service-accounts"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBufferString(`{"name":"reader","role":"Reader"}`))
+
+		r.Header.Add("Authorization", "Bearer "+readerToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+}
+
+func TestServiceAccountAPITokens_Create(t *testing.T) {
+	mgr := testEnvironment.GetManager()
+
+	organization := testEnvironment.MustCreateOrganization(t)
+	superUser := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleSuperUser)
+	reader := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleReader)
+
+	superUserToken := MustSignToken(t, superUser.Name)
+	readerToken := MustSignToken(t, reader.Name)
+
+	c := testEnvironment.GetClient()
+
+	serviceAccount := dockyardsv1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: organization.Spec.NamespaceRef.Name,
+		},
+		Spec: dockyardsv1.ServiceAccountSpec{
+			Role: dockyardsv1.RoleReader,
+		},
+	}
+
+	err := c.Create(ctx, &serviceAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mgr.GetCache().WaitForCacheSync(ctx)
+
+	t.Run("test as super user", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "service-accounts", serviceAccount.Name, "tokens"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBufferString(`{"display_name":"ci"}`))
+
+		r.Header.Add("Authorization", "Bearer "+superUserToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+		}
+
+		b, err := io.ReadAll(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var response handlers.APIToken
+		err = json.Unmarshal(b, &response)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if response.Token == nil {
+			t.Fatal("expected token in response")
+		}
+
+		var apiToken dockyardsv1.APIToken
+		err = c.Get(ctx, client.ObjectKey{Name: response.Name}, &apiToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := dockyardsv1.ServiceAccountSubject(serviceAccount.Namespace, serviceAccount.Name)
+		if apiToken.GetSubject() != expected {
+			t.Errorf("expected subject %s, got %s", expected, apiToken.GetSubject())
+		}
+	})
+
+	t.Run("test as reader", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "service-accounts", serviceAccount.Name, "tokens"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBufferString(`{}`))
+
+		r.Header.Add("Authorization", "Bearer "+readerToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+}
+
+func TestServiceAccountAPITokens_List(t *testing.T) {
+	mgr := testEnvironment.GetManager()
+
+	organization := testEnvironment.MustCreateOrganization(t)
+	reader := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleReader)
+
+	readerToken := MustSignToken(t, reader.Name)
+
+	c := testEnvironment.GetClient()
+
+	serviceAccount := dockyardsv1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: organization.Spec.NamespaceRef.Name,
+		},
+		Spec: dockyardsv1.ServiceAccountSpec{
+			Role: dockyardsv1.RoleReader,
+		},
+	}
+
+	err := c.Create(ctx, &serviceAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mgr.GetCache().WaitForCacheSync(ctx)
+
+	t.Run("test as reader", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "service-accounts", serviceAccount.Name, "tokens"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+readerToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, statusCode)
+		}
+	})
+
+	t.Run("test missing organization", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/orgs", "missing", "service-accounts", serviceAccount.Name, "tokens"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+readerToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+
+	t.Run("test missing service account", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/orgs", organization.Name, "service-accounts", "missing", "tokens"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+readerToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+}
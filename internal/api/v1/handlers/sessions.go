@@ -0,0 +1,144 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// errSessionRequired is returned when revoking the other sessions of the subject with a token not issued for a
+// session, such as an api token, since every session would be revoked.
+var errSessionRequired = errors.New("revoking other sessions requires a token issued for a session")
+
+type Session struct {
+	CreatedAt time.Time  `json:"created_at"`
+	Current   bool       `json:"current"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+func (h *handler) ListGlobalSessions(ctx context.Context) (*[]Session, error) {
+	subject, err := middleware.SubjectFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := middleware.ClaimsFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matchingLabels := client.MatchingLabels{
+		dockyardsv1.LabelUserName: subject,
+	}
+
+	var sessionList dockyardsv1.SessionList
+	err = h.List(ctx, &sessionList, matchingLabels)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, len(sessionList.Items))
+
+	for i, item := range sessionList.Items {
+		session := Session{
+			CreatedAt: item.CreationTimestamp.Time,
+			Current:   item.Name == claims.SessionID,
+			ID:        string(item.UID),
+			Name:      item.Name,
+		}
+
+		if item.Status.ExpirationTimestamp != nil {
+			session.ExpiresAt = &item.Status.ExpirationTimestamp.Time
+		}
+
+		if item.Status.LastRefreshTimestamp != nil {
+			session.UpdatedAt = &item.Status.LastRefreshTimestamp.Time
+		}
+
+		sessions[i] = session
+	}
+
+	return &sessions, nil
+}
+
+// DeleteGlobalSessions revokes all sessions of the subject except the one used to make the request, the request
+// must be made with a token issued for a session.
+func (h *handler) DeleteGlobalSessions(ctx context.Context) error {
+	subject, err := middleware.SubjectFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := middleware.ClaimsFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	if claims.SessionID == "" || middleware.APITokenFrom(ctx) != nil {
+		return apierrors.NewForbidden(dockyardsv1.GroupVersion.WithResource("sessions").GroupResource(), "", errSessionRequired)
+	}
+
+	matchingLabels := client.MatchingLabels{
+		dockyardsv1.LabelUserName: subject,
+	}
+
+	var sessionList dockyardsv1.SessionList
+	err = h.List(ctx, &sessionList, matchingLabels)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessionList.Items {
+		if session.Name == claims.SessionID {
+			continue
+		}
+
+		err := h.Delete(ctx, &session)
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *handler) DeleteGlobalSession(ctx context.Context, sessionName string) error {
+	subject, err := middleware.SubjectFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	var session dockyardsv1.Session
+	err = h.Get(ctx, client.ObjectKey{Name: sessionName}, &session)
+	if err != nil {
+		return err
+	}
+
+	if session.Spec.UserRef.Name != subject {
+		return apierrors.NewNotFound(dockyardsv1.GroupVersion.WithResource("sessions").GroupResource(), sessionName)
+	}
+
+	return h.Delete(ctx, &session)
+}
@@ -0,0 +1,219 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"testing"
+
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/handlers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestGlobalSessions_List(t *testing.T) {
+	organization := testEnvironment.MustCreateOrganization(t)
+	user := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleUser)
+	otherUser := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleReader)
+
+	current := MustCreateSession(t, user.Name)
+	other := MustCreateSession(t, user.Name)
+	_ = MustCreateSession(t, otherUser.Name)
+
+	userToken, err := SignSessionToken(user.Name, current.Name, "", accessKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mgr := testEnvironment.GetManager()
+	mgr.GetCache().WaitForCacheSync(ctx)
+
+	t.Run("test as user", func(t *testing.T) {
+		u := url.URL{
+			Path: "/v1/sessions",
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, statusCode)
+		}
+
+		b, err := io.ReadAll(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual []handlers.Session
+		err = json.Unmarshal(b, &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(actual) != 2 {
+			t.Fatalf("expected 2 sessions, got %d", len(actual))
+		}
+
+		for _, session := range actual {
+			switch session.Name {
+			case current.Name:
+				if !session.Current {
+					t.Errorf("expected session %s to be current", session.Name)
+				}
+			case other.Name:
+				if session.Current {
+					t.Errorf("expected session %s not to be current", session.Name)
+				}
+			default:
+				t.Errorf("unexpected session %s", session.Name)
+			}
+		}
+	})
+}
+
+func TestGlobalSessions_Delete(t *testing.T) {
+	mgr := testEnvironment.GetManager()
+	c := mgr.GetClient()
+
+	organization := testEnvironment.MustCreateOrganization(t)
+	user := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleUser)
+	otherUser := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleReader)
+
+	current := MustCreateSession(t, user.Name)
+
+	userToken, err := SignSessionToken(user.Name, current.Name, "", accessKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test other user session", func(t *testing.T) {
+		session := MustCreateSession(t, otherUser.Name)
+
+		mgr.GetCache().WaitForCacheSync(ctx)
+
+		u := url.URL{
+			Path: path.Join("/v1/sessions", session.Name),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, statusCode)
+		}
+	})
+
+	t.Run("test own session", func(t *testing.T) {
+		session := MustCreateSession(t, user.Name)
+
+		mgr.GetCache().WaitForCacheSync(ctx)
+
+		u := url.URL{
+			Path: path.Join("/v1/sessions", session.Name),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusAccepted {
+			t.Fatalf("expected status code %d, got %d", http.StatusAccepted, statusCode)
+		}
+
+		var actual dockyardsv1.Session
+		err := c.Get(ctx, client.ObjectKeyFromObject(session), &actual)
+		if !apierrors.IsNotFound(err) {
+			t.Errorf("expected session to be deleted, got %v", err)
+		}
+	})
+
+	t.Run("test other sessions", func(t *testing.T) {
+		session := MustCreateSession(t, user.Name)
+
+		mgr.GetCache().WaitForCacheSync(ctx)
+
+		u := url.URL{
+			Path: "/v1/sessions",
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusAccepted {
+			t.Fatalf("expected status code %d, got %d", http.StatusAccepted, statusCode)
+		}
+
+		var actual dockyardsv1.Session
+		err := c.Get(ctx, client.ObjectKeyFromObject(session), &actual)
+		if !apierrors.IsNotFound(err) {
+			t.Errorf("expected session to be deleted, got %v", err)
+		}
+
+		err = c.Get(ctx, client.ObjectKeyFromObject(current), &actual)
+		if err != nil {
+			t.Errorf("expected current session to be kept, got %v", err)
+		}
+	})
+
+	t.Run("test other sessions without session", func(t *testing.T) {
+		u := url.URL{
+			Path: "/v1/sessions",
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(t, user.Name))
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusForbidden {
+			t.Fatalf("expected status code %d, got %d", http.StatusForbidden, statusCode)
+		}
+
+		var actual dockyardsv1.Session
+		err := c.Get(ctx, client.ObjectKeyFromObject(current), &actual)
+		if err != nil {
+			t.Errorf("expected current session to be kept, got %v", err)
+		}
+	})
+}
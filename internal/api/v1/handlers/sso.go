@@ -15,61 +15,102 @@
 package handlers
 
 import (
+	"cmp"
 	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/sudoswedenab/dockyards-backend/api/config"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/api/v1alpha3/index"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
 	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/oauth2"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func (h *handler) config(ctx context.Context, providerName string) (*oauth2.Config, *oidc.Provider, error) {
+func (h *handler) getIdentityProvider(ctx context.Context, providerName string) (*dockyardsv1.IdentityProvider, error) {
 	publicNamespace := h.Config.GetValueOrDefault(config.KeyPublicNamespace, "dockyards-public")
 
 	var identityProvider dockyardsv1.IdentityProvider
 	err := h.Get(ctx, client.ObjectKey{Name: providerName, Namespace: publicNamespace}, &identityProvider)
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not get provider: %w", err)
+		return nil, fmt.Errorf("could not get provider: %w", err)
 	}
 
+	return &identityProvider, nil
+}
+
+func (h *handler) config(ctx context.Context, identityProvider *dockyardsv1.IdentityProvider) (*oauth2.Config, *oidc.Provider, *dockyardsv1.OIDCClientConfig, error) {
 	configRef := identityProvider.Spec.OIDCConfigRef
 	if configRef == nil {
-		return nil, nil, errors.New("oidc config ref was is not set")
+		return nil, nil, nil, errors.New("oidc config ref was is not set")
 	}
 
 	oidcConfig, _, err := h.getOIDCConfig(ctx, *configRef)
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not get oidc config: %w", err)
+		return nil, nil, nil, fmt.Errorf("could not get oidc config: %w", err)
 	}
 
 	provider, err := h.getOIDCProvider(ctx, oidcConfig)
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not get oidc provider: %w", err)
+		return nil, nil, nil, fmt.Errorf("could not get oidc provider: %w", err)
 	}
 
 	client := oidcConfig.ClientConfig
+
+	scopes := []string{oidc.ScopeOpenID, "email", "profile"}
+	if len(client.Scopes) > 0 {
+		scopes = client.Scopes
+		if !slices.Contains(scopes, oidc.ScopeOpenID) {
+			scopes = append([]string{oidc.ScopeOpenID}, scopes...)
+		}
+	}
+
 	config := oauth2.Config{
 		ClientID:     client.ClientID,
 		ClientSecret: client.ClientSecret,
 		Endpoint:     provider.Endpoint(),
 		RedirectURL:  client.RedirectURL,
-		Scopes:       []string{"openid", "email", "profile"},
+		Scopes:       scopes,
 	}
 
-	return &config, provider, nil
+	return &config, provider, &client, nil
+}
+
+// authCodeOptions returns the options of the authorization request, the nonce and state parameters are set
+// by the caller.
+func authCodeOptions(clientConfig *dockyardsv1.OIDCClientConfig, verifier string) []oauth2.AuthCodeOption {
+	var options []oauth2.AuthCodeOption
+
+	prompt := ptr.Deref(clientConfig.Prompt, "consent")
+	if prompt != "" {
+		options = append(options, oauth2.SetAuthURLParam("prompt", prompt))
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(clientConfig.ExtraAuthParams)) {
+		options = append(options, oauth2.SetAuthURLParam(key, clientConfig.ExtraAuthParams[key]))
+	}
+
+	if verifier != "" {
+		options = append(options, oauth2.S256ChallengeOption(verifier))
+	}
+
+	return options
 }
 
 func (h *handler) LoginOIDC(w http.ResponseWriter, r *http.Request) error {
@@ -78,12 +119,21 @@ func (h *handler) LoginOIDC(w http.ResponseWriter, r *http.Request) error {
 	providerName := r.URL.Query().Get("idp")
 	callbackURL := r.URL.Query().Get("callbackURL")
 
-	config, _, err := h.config(ctx, providerName)
+	if !h.isAllowedCallbackURL(callbackURL) {
+		return apierrors.NewBadRequest("callback url is not allowed")
+	}
+
+	identityProvider, err := h.getIdentityProvider(ctx, providerName)
 	if err != nil {
 		return apierrors.NewInternalError(err)
 	}
 
-	state, err := makeState(providerName, callbackURL)
+	config, _, clientConfig, err := h.config(ctx, identityProvider)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	state, err := h.signState(providerName, callbackURL, "")
 	if err != nil {
 		return apierrors.NewInternalError(fmt.Errorf("could not make state: %w", err))
 	}
@@ -96,11 +146,16 @@ func (h *handler) LoginOIDC(w http.ResponseWriter, r *http.Request) error {
 	setCallbackCookie(w, r, "state", state)
 	setCallbackCookie(w, r, "nonce", string(nonce))
 
-	url := config.AuthCodeURL(
-		state,
-		oauth2.SetAuthURLParam("nonce", fmt.Sprintf("%x", sha256.Sum256(nonce))),
-		oauth2.SetAuthURLParam("prompt", "consent"), // HARDCODE ApprovalForce for now
-	)
+	var verifier string
+	if clientConfig.PKCE {
+		verifier = oauth2.GenerateVerifier()
+		setCallbackCookie(w, r, "verifier", verifier)
+	}
+
+	options := authCodeOptions(clientConfig, verifier)
+	options = append(options, oauth2.SetAuthURLParam("nonce", fmt.Sprintf("%x", sha256.Sum256(nonce))))
+
+	url := config.AuthCodeURL(state, options...)
 
 	// http://localhost/api/backend/v1/callback-sso
 
@@ -110,25 +165,82 @@ func (h *handler) LoginOIDC(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
-func decodeState(data string) (*stateStruct, error) {
-	stateJSON, err := base64.URLEncoding.DecodeString(data)
-	if err != nil {
-		return nil, fmt.Errorf("could not decode state: %w", err)
+type Claims struct {
+	Email    string
+	Username string
+	Name     string
+	Groups   []string
+}
+
+// mapClaims reads the user information from the raw claims using the claim mapping of the identity provider.
+func mapClaims(identityProvider *dockyardsv1.IdentityProvider, raw map[string]any) Claims {
+	claimMapping := dockyardsv1.ClaimMapping{}
+	if identityProvider.Spec.ClaimMapping != nil {
+		claimMapping = *identityProvider.Spec.ClaimMapping
 	}
 
-	var state stateStruct
-	err = json.Unmarshal(stateJSON, &state)
-	if err != nil {
-		return nil, err
+	claims := Claims{
+		Email:    claimString(raw, cmp.Or(claimMapping.Email, "email")),
+		Username: claimString(raw, cmp.Or(claimMapping.Username, "preferred_username")),
+		Name:     claimString(raw, cmp.Or(claimMapping.DisplayName, "name")),
+		Groups:   claimStrings(raw, cmp.Or(claimMapping.Groups, "groups")),
 	}
 
-	return &state, nil
+	return claims
 }
 
-type Claims struct {
-	Email string `json:"email"`
-	PreferredUsername string `json:"preferred_username"`
-	Name string `json:"name"`
+func lookupClaim(raw map[string]any, name string) (any, bool) {
+	var value any = raw
+
+	for key := range strings.SplitSeq(name, ".") {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		value, ok = obj[key]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+func claimString(raw map[string]any, name string) string {
+	value, found := lookupClaim(raw, name)
+	if !found {
+		return ""
+	}
+
+	s, _ := value.(string)
+
+	return s
+}
+
+// claimStrings returns the values of a claim, providers sending a single group as a string are supported.
+func claimStrings(raw map[string]any, name string) []string {
+	value, found := lookupClaim(raw, name)
+	if !found {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if ok {
+				values = append(values, s)
+			}
+		}
+
+		return values
+	default:
+		return nil
+	}
 }
 
 func (h *handler) Callback(w http.ResponseWriter, r *http.Request) error {
@@ -156,17 +268,36 @@ func (h *handler) Callback(w http.ResponseWriter, r *http.Request) error {
 		return apierrors.NewBadRequest("state cookie did not match query parameter")
 	}
 
-	state, err := decodeState(queryState)
+	state, err := h.parseState(queryState)
+	if err != nil {
+		return apierrors.NewBadRequest("invalid state")
+	}
+
+	if !h.isAllowedCallbackURL(state.CallbackURL) {
+		return apierrors.NewBadRequest("callback url is not allowed")
+	}
+
+	identityProvider, err := h.getIdentityProvider(ctx, state.IDP)
 	if err != nil {
 		return apierrors.NewInternalError(err)
 	}
 
-	config, provider, err := h.config(ctx, state.IDP)
+	config, provider, clientConfig, err := h.config(ctx, identityProvider)
 	if err != nil {
 		return apierrors.NewInternalError(fmt.Errorf("could not get config: %w", err))
 	}
 
-	oauth2Token, err := config.Exchange(ctx, code)
+	var exchangeOptions []oauth2.AuthCodeOption
+	if clientConfig.PKCE {
+		verifierCookie, err := r.Cookie("verifier")
+		if err != nil {
+			return apierrors.NewBadRequest("verifier cookie not found")
+		}
+
+		exchangeOptions = append(exchangeOptions, oauth2.VerifierOption(verifierCookie.Value))
+	}
+
+	oauth2Token, err := config.Exchange(ctx, code, exchangeOptions...)
 	if err != nil {
 		return apierrors.NewInternalError(fmt.Errorf("could not exchange tokens: %w", err))
 	}
@@ -189,46 +320,99 @@ func (h *handler) Callback(w http.ResponseWriter, r *http.Request) error {
 		return apierrors.NewInternalError(errors.New("token nonce did not match cookie nonce"))
 	}
 
-	var claims Claims
-	err = token.Claims(&claims)
+	var raw map[string]any
+	err = token.Claims(&raw)
 	if err != nil {
 		return apierrors.NewInternalError(fmt.Errorf("could not parse claims: %w", err))
 	}
 
-	user, err := h.getOrCreateUser(ctx, state.IDP, claims)
+	claims := mapClaims(identityProvider, raw)
+	if claims.Username == "" {
+		return apierrors.NewInternalError(errors.New("username claim was empty"))
+	}
+
+	return h.completeLogin(w, r, identityProvider, state, claims)
+}
+
+// completeLogin provisions the user and its group members and redirects the user to the callback with a code
+// to exchange for the tokens of a new session.
+func (h *handler) completeLogin(w http.ResponseWriter, r *http.Request, identityProvider *dockyardsv1.IdentityProvider, state *stateClaims, claims Claims) error {
+	ctx := r.Context()
+
+	user, err := h.getOrCreateUser(ctx, identityProvider.Name, claims)
 	if err != nil {
 		return apierrors.NewInternalError(fmt.Errorf("could not get or create user: %w", err))
 	}
 
-	tokens, err := h.generateTokens(user)
+	err = h.syncGroupMembers(ctx, identityProvider, user, claims.Groups)
+	if err != nil {
+		return apierrors.NewInternalError(fmt.Errorf("could not sync group members: %w", err))
+	}
+
+	session, err := h.createSession(ctx, user, middleware.AuthenticationMethodFederated)
+	if err != nil {
+		return apierrors.NewInternalError(fmt.Errorf("could not create session: %w", err))
+	}
+
+	exchangeCode, err := h.signExchangeCode(ctx, user, session)
 	if err != nil {
-		return apierrors.NewInternalError(fmt.Errorf("could not generate tokens: %w", err))
+		return apierrors.NewInternalError(fmt.Errorf("could not sign exchange code: %w", err))
 	}
 
-	url := state.CallbackURL + "/" + tokens.RefreshToken
-	http.Redirect(w, r, url, http.StatusFound)
+	redirectURL, err := withExchangeCode(state.CallbackURL, exchangeCode)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
 
 	return nil
 }
 
+// getOrCreateUser returns the user with the provider id of the claims, creating it when missing. The username
+// claim is only stored in the provider id as claims such as email addresses are not valid object names.
 func (h *handler) getOrCreateUser(ctx context.Context, providerName string, claims Claims) (*dockyardsv1.User, error) {
-	name := providerName + "-" + claims.PreferredUsername
+	providerID := providerName + "://" + claims.Username
+
+	matchingFields := client.MatchingFields{
+		index.ProviderIDField: providerID,
+	}
+
+	var userList dockyardsv1.UserList
+	err := h.List(ctx, &userList, matchingFields)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(userList.Items) > 1 {
+		return nil, errors.New("provider id matched multiple users")
+	}
+
+	if len(userList.Items) == 1 {
+		user := userList.Items[0]
 
-	var user dockyardsv1.User
-	err := h.Get(ctx, client.ObjectKey{Name: name}, &user)
-	if err == nil {
-		if !strings.HasPrefix(user.Spec.ProviderID, providerName + "://") {
-			return nil, errors.New("user provider id was invalid")
-		}
 		if user.Labels[dockyardsv1.LabelProviderName] != providerName {
 			return nil, errors.New("user provider name was invalid")
 		}
 
-		return &user, nil
-	}
+		email := cmp.Or(claims.Email, user.Spec.Email)
+		displayName := cmp.Or(claims.Name, user.Spec.DisplayName)
 
-	if !apierrors.IsNotFound(err) {
-		return nil, err
+		if user.Spec.Email == email && user.Spec.DisplayName == displayName {
+			return &user, nil
+		}
+
+		patch := client.MergeFrom(user.DeepCopy())
+
+		user.Spec.Email = email
+		user.Spec.DisplayName = displayName
+
+		err := h.Patch(ctx, &user, patch)
+		if err != nil {
+			return nil, fmt.Errorf("could not patch user: %w", err)
+		}
+
+		return &user, nil
 	}
 
 	password, err := randomPassword() // Should be impossible to guess.
@@ -236,9 +420,9 @@ func (h *handler) getOrCreateUser(ctx context.Context, providerName string, clai
 		return nil, fmt.Errorf("could not create random password: %w", err)
 	}
 
-	user = dockyardsv1.User{
+	user := dockyardsv1.User{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: name,
+			Name: ssoUserName(providerName, claims.Username),
 			Labels: map[string]string{
 				dockyardsv1.LabelProviderName: providerName,
 			},
@@ -247,7 +431,7 @@ func (h *handler) getOrCreateUser(ctx context.Context, providerName string, clai
 			DisplayName: claims.Name,
 			Password: password,
 			Email: claims.Email,
-			ProviderID: providerName + "://" + claims.PreferredUsername,
+			ProviderID: providerID,
 		},
 		Status: dockyardsv1.UserStatus{
 			Conditions: []metav1.Condition{
@@ -255,7 +439,7 @@ func (h *handler) getOrCreateUser(ctx context.Context, providerName string, clai
 					Type: dockyardsv1.ReadyCondition,
 					Status: metav1.ConditionTrue,
 					Reason: dockyardsv1.VerificationReasonVerified,
-					Message: "Verified by OIDC",
+					Message: "Verified by identity provider",
 					LastTransitionTime: metav1.Now(),
 				},
 			},
@@ -269,6 +453,14 @@ func (h *handler) getOrCreateUser(ctx context.Context, providerName string, clai
 	return &user, nil
 }
 
+// ssoUserName returns the name of a user created by an identity provider, the name is derived from a hash of
+// the username so that concurrent logins create the same user.
+func ssoUserName(providerName, username string) string {
+	sum := sha256.Sum256([]byte(username))
+
+	return providerName + "-" + hex.EncodeToString(sum[:8])
+}
+
 func makeNonce() ([]byte, error) {
 	src := make([]byte, 18)
 	count, err := rand.Read(src)
@@ -285,31 +477,6 @@ func makeNonce() ([]byte, error) {
 	return dst, nil
 }
 
-type stateStruct struct {
-	CSRF        string `json:"state"`
-	IDP         string `json:"idp"`
-	CallbackURL string `json:"callbackURL"`
-}
-func makeState(identityProviderName string, callbackURL string) (string, error) {
-	src := make([]byte, 18)
-	_, err := rand.Read(src)
-	if err != nil {
-		return "", err
-	}
-
-	csrf := base64.URLEncoding.EncodeToString(src)
-	stateJSON, err := json.Marshal(stateStruct{
-		CSRF: csrf,
-		IDP: identityProviderName,
-		CallbackURL: callbackURL,
-	})
-	if err != nil {
-		return "", err
-	}
-
-	return base64.URLEncoding.EncodeToString(stateJSON), nil
-}
-
 func setCallbackCookie(w http.ResponseWriter, r *http.Request, name, value string) {
 	c := &http.Cookie{
 		Name:     name,
@@ -363,25 +530,10 @@ func (h *handler) getOIDCConfig(ctx context.Context, ref corev1.SecretReference)
 		return dockyardsv1.OIDCConfig{}, corev1.Secret{}, err
 	}
 
-	obj := make(map[string]any, len(secret.Data))
-	for key, value := range secret.Data {
-		var v any
-		err = json.Unmarshal(value, &v);
-		if err != nil {
-			return dockyardsv1.OIDCConfig{}, corev1.Secret{}, fmt.Errorf("could not unmarshal oidc config key '%s': %w", key, err)
-		}
-		obj[key] = v
-	}
-
-	payload, err := json.Marshal(obj)
-	if err != nil {
-		return dockyardsv1.OIDCConfig{}, corev1.Secret{}, err
-	}
-
 	var oidcConfig dockyardsv1.OIDCConfig
-	err = json.Unmarshal(payload, &oidcConfig)
+	err = decodeSecretConfig(&secret, &oidcConfig)
 	if err != nil {
-		return dockyardsv1.OIDCConfig{}, corev1.Secret{}, err
+		return dockyardsv1.OIDCConfig{}, corev1.Secret{}, fmt.Errorf("could not decode oidc config: %w", err)
 	}
 
 	// nolint:nestif // extracting this to its own validating function would make this block more complex
@@ -413,6 +565,26 @@ func (h *handler) getOIDCConfig(ctx context.Context, ref corev1.SecretReference)
 	return oidcConfig, secret, nil
 }
 
+// decodeSecretConfig decodes a config where every key of the secret holds a JSON encoded field.
+func decodeSecretConfig(secret *corev1.Secret, v any) error {
+	obj := make(map[string]any, len(secret.Data))
+	for key, value := range secret.Data {
+		var field any
+		err := json.Unmarshal(value, &field)
+		if err != nil {
+			return fmt.Errorf("could not unmarshal key '%s': %w", key, err)
+		}
+		obj[key] = field
+	}
+
+	payload, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(payload, v)
+}
+
 func randomPassword() (string, error) {
 	bytes := make([]byte, 64)
 	_, err := rand.Read(bytes)
@@ -0,0 +1,196 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+)
+
+func TestMapClaims(t *testing.T) {
+	testCases := []struct {
+		name             string
+		identityProvider dockyardsv1.IdentityProvider
+		raw              map[string]any
+		expected         Claims
+	}{
+		{
+			name: "test default mapping",
+			raw: map[string]any{
+				"email":              "test@dockyards.dev",
+				"preferred_username": "test",
+				"name":               "Test User",
+				"groups":             []any{"admins", "developers"},
+			},
+			expected: Claims{
+				Email:    "test@dockyards.dev",
+				Username: "test",
+				Name:     "Test User",
+				Groups:   []string{"admins", "developers"},
+			},
+		},
+		{
+			name: "test custom mapping",
+			identityProvider: dockyardsv1.IdentityProvider{
+				Spec: dockyardsv1.IdentityProviderSpec{
+					ClaimMapping: &dockyardsv1.ClaimMapping{
+						Email:    "mail",
+						Username: "upn",
+						Groups:   "realm_access.roles",
+					},
+				},
+			},
+			raw: map[string]any{
+				"mail": "test@dockyards.dev",
+				"upn":  "test",
+				"name": "Test User",
+				"realm_access": map[string]any{
+					"roles": []any{"admins"},
+				},
+			},
+			expected: Claims{
+				Email:    "test@dockyards.dev",
+				Username: "test",
+				Name:     "Test User",
+				Groups:   []string{"admins"},
+			},
+		},
+		{
+			name: "test missing claims",
+			raw:  map[string]any{},
+		},
+		{
+			name: "test unexpected types",
+			raw: map[string]any{
+				"email":  true,
+				"groups": 1,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := mapClaims(&tc.identityProvider, tc.raw)
+			if !cmp.Equal(actual, tc.expected) {
+				t.Errorf("diff: %s", cmp.Diff(tc.expected, actual))
+			}
+		})
+	}
+}
+
+func TestLookupClaim(t *testing.T) {
+	raw := map[string]any{
+		"email": "test@dockyards.dev",
+		"realm_access": map[string]any{
+			"roles": []any{"admins"},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		claim         string
+		expected      any
+		expectedFound bool
+	}{
+		{
+			name:          "test top level",
+			claim:         "email",
+			expected:      "test@dockyards.dev",
+			expectedFound: true,
+		},
+		{
+			name:          "test nested",
+			claim:         "realm_access.roles",
+			expected:      []any{"admins"},
+			expectedFound: true,
+		},
+		{
+			name:  "test missing",
+			claim: "groups",
+		},
+		{
+			name:  "test missing nested",
+			claim: "realm_access.groups",
+		},
+		{
+			name:  "test through non-object",
+			claim: "email.domain",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, found := lookupClaim(raw, tc.claim)
+			if found != tc.expectedFound {
+				t.Fatalf("expected found %t, got %t", tc.expectedFound, found)
+			}
+
+			if !cmp.Equal(actual, tc.expected) {
+				t.Errorf("diff: %s", cmp.Diff(tc.expected, actual))
+			}
+		})
+	}
+}
+
+func TestClaimStrings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      map[string]any
+		expected []string
+	}{
+		{
+			name: "test list",
+			raw: map[string]any{
+				"groups": []any{"admins", "developers"},
+			},
+			expected: []string{"admins", "developers"},
+		},
+		{
+			name: "test single string",
+			raw: map[string]any{
+				"groups": "admins",
+			},
+			expected: []string{"admins"},
+		},
+		{
+			name: "test non-string items",
+			raw: map[string]any{
+				"groups": []any{"admins", 1, map[string]any{}},
+			},
+			expected: []string{"admins"},
+		},
+		{
+			name: "test unexpected type",
+			raw: map[string]any{
+				"groups": true,
+			},
+		},
+		{
+			name: "test missing",
+			raw:  map[string]any{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := claimStrings(tc.raw, "groups")
+			if !cmp.Equal(actual, tc.expected) {
+				t.Errorf("diff: %s", cmp.Diff(tc.expected, actual))
+			}
+		})
+	}
+}
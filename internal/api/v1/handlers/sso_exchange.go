@@ -0,0 +1,219 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sudoswedenab/dockyards-api/pkg/types"
+	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
+	"github.com/sudoswedenab/dockyards-backend/api/config"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	ssoStateAudience = "dockyards:sso-state"
+	ssoCodeAudience  = "dockyards:sso-code"
+
+	ssoStateDuration     = time.Hour
+	exchangeCodeDuration = time.Minute
+)
+
+type LoginSSOOptions struct {
+	Code string `json:"code"`
+}
+
+type stateClaims struct {
+	jwt.RegisteredClaims
+
+	IDP         string `json:"idp"`
+	CallbackURL string `json:"callbackURL"`
+
+	// RequestID is the id of the SAML authentication request the state was sent with.
+	RequestID string `json:"requestID,omitempty"`
+}
+
+// isAllowedCallbackURL returns true if the origin of the callback url is allowed, either by the allowed origins
+// of the handler or the config manager, or by being the external url.
+func (h *handler) isAllowedCallbackURL(callbackURL string) bool {
+	u, err := url.Parse(callbackURL)
+	if err != nil || u.Host == "" || u.User != nil {
+		return false
+	}
+
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return false
+	}
+
+	origin := u.Scheme + "://" + u.Host
+
+	allowedOrigins := slices.Concat(h.allowedOrigins, h.Config.GetStringSliceOrDefault(config.KeyAllowedOrigins, nil))
+
+	externalURL, err := url.Parse(h.Config.GetValueOrDefault(config.KeyExternalURL, ""))
+	if err == nil && externalURL.Host != "" {
+		allowedOrigins = append(allowedOrigins, externalURL.Scheme+"://"+externalURL.Host)
+	}
+
+	return slices.ContainsFunc(allowedOrigins, func(allowedOrigin string) bool {
+		return strings.EqualFold(strings.TrimSuffix(allowedOrigin, "/"), origin)
+	})
+}
+
+// signState signs the state parameter, the signature keeps the callback url from being replaced after the
+// allow-list has been checked.
+func (h *handler) signState(identityProviderName, callbackURL, requestID string) (string, error) {
+	claims := stateClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        rand.Text(),
+			Audience:  jwt.ClaimStrings{ssoStateAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ssoStateDuration)),
+		},
+		IDP:         identityProviderName,
+		CallbackURL: callbackURL,
+		RequestID:   requestID,
+	}
+
+	return h.jwtKeySet.SignRefreshToken(claims)
+}
+
+func (h *handler) parseState(state string) (*stateClaims, error) {
+	var claims stateClaims
+	_, err := jwt.ParseWithClaims(state, &claims, h.jwtKeySet.RefreshTokenKeyfunc, jwt.WithAudience(ssoStateAudience), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+// exchangeCodeTokenID returns the token id stored in the session for an exchange code, the id is derived from
+// the code so that it never matches the id of a refresh token.
+func exchangeCodeTokenID(codeID string) string {
+	sum := sha256.Sum256([]byte(ssoCodeAudience + ":" + codeID))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// signExchangeCode signs a short-lived code for the session and rotates the session to it, the code is
+// exchanged for tokens exactly once.
+func (h *handler) signExchangeCode(ctx context.Context, user *dockyardsv1.User, session *dockyardsv1.Session) (string, error) {
+	codeID := rand.Text()
+
+	claims := middleware.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.Name,
+			ID:        codeID,
+			Audience:  jwt.ClaimStrings{ssoCodeAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(exchangeCodeDuration)),
+		},
+		SessionID: session.Name,
+	}
+
+	signedCode, err := h.jwtKeySet.SignRefreshToken(claims)
+	if err != nil {
+		return "", err
+	}
+
+	patch := client.MergeFromWithOptions(session.DeepCopy(), client.MergeFromWithOptimisticLock{})
+
+	session.Status.TokenID = exchangeCodeTokenID(codeID)
+	session.Status.LastRefreshTimestamp = &metav1.Time{Time: time.Now()}
+	session.Status.ExpirationTimestamp = session.GetExpiration()
+
+	err = h.Status().Patch(ctx, session, patch)
+	if err != nil {
+		return "", err
+	}
+
+	return signedCode, nil
+}
+
+func withExchangeCode(callbackURL, code string) (string, error) {
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	query.Set("code", code)
+
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// CreateGlobalSSOTokens exchanges the code from a single sign-on callback for the tokens of the session.
+func (h *handler) CreateGlobalSSOTokens(ctx context.Context, request *LoginSSOOptions) (*types.Tokens, error) {
+	logger := middleware.LoggerFrom(ctx)
+
+	var claims middleware.Claims
+	_, err := jwt.ParseWithClaims(request.Code, &claims, h.jwtKeySet.RefreshTokenKeyfunc, jwt.WithAudience(ssoCodeAudience), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, apierrors.NewUnauthorized("invalid exchange code")
+	}
+
+	var user dockyardsv1.User
+	err = h.Get(ctx, client.ObjectKey{Name: claims.Subject}, &user)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, apierrors.NewUnauthorized("user not found")
+		}
+
+		return nil, err
+	}
+
+	var session dockyardsv1.Session
+	err = h.apiReader.Get(ctx, client.ObjectKey{Name: claims.SessionID}, &session)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, apierrors.NewUnauthorized("session not found")
+		}
+
+		return nil, err
+	}
+
+	if session.Spec.UserRef.Name != user.Name {
+		return nil, apierrors.NewUnauthorized("session does not belong to user")
+	}
+
+	if !session.DeletionTimestamp.IsZero() || apiutil.HasExpired(&session) {
+		return nil, apierrors.NewUnauthorized("session expired")
+	}
+
+	if exchangeCodeTokenID(claims.ID) != session.Status.TokenID {
+		logger.Warn("exchange code reuse detected, revoking session", "session", session.Name, "subject", user.Name)
+
+		err := h.Delete(ctx, &session)
+		if client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+
+		return nil, apierrors.NewUnauthorized("exchange code has already been used")
+	}
+
+	return h.generateTokens(ctx, &user, &session)
+}
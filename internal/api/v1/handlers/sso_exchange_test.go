@@ -0,0 +1,166 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/handlers"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func mustSignExchangeCode(t *testing.T, session *dockyardsv1.Session) string {
+	c := testEnvironment.GetClient()
+
+	codeID := rand.Text()
+
+	patch := client.MergeFrom(session.DeepCopy())
+
+	sum := sha256.Sum256([]byte("dockyards:sso-code:" + codeID))
+	session.Status.TokenID = hex.EncodeToString(sum[:])
+
+	err := c.Status().Patch(t.Context(), session, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := middleware.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   session.Spec.UserRef.Name,
+			ID:        codeID,
+			Audience:  jwt.ClaimStrings{"dockyards:sso-code"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+		SessionID: session.Name,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	signedToken, err := token.SignedString(refreshKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signedToken
+}
+
+func postLoginSSO(t *testing.T, code string) *http.Response {
+	options := handlers.LoginSSOOptions{
+		Code: code,
+	}
+
+	b, err := json.Marshal(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := url.URL{
+		Path: path.Join("/v1/login/sso"),
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewBuffer(b))
+
+	mux.ServeHTTP(w, r)
+
+	return w.Result()
+}
+
+func TestGlobalSSOTokens_Create(t *testing.T) {
+	organization := testEnvironment.MustCreateOrganization(t)
+	user := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleReader)
+
+	t.Run("test exchange code", func(t *testing.T) {
+		session := MustCreateSession(t, user.Name)
+		code := mustSignExchangeCode(t, session)
+
+		response := postLoginSSO(t, code)
+		if response.StatusCode != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, response.StatusCode)
+		}
+
+		response = postLoginSSO(t, code)
+		if response.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, response.StatusCode)
+		}
+	})
+
+	t.Run("test refresh token", func(t *testing.T) {
+		session := MustCreateSession(t, user.Name)
+
+		refreshToken, err := SignSessionToken(user.Name, session.Name, session.Status.TokenID, refreshKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		response := postLoginSSO(t, refreshToken)
+		if response.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, response.StatusCode)
+		}
+	})
+
+	t.Run("test exchange code as refresh token", func(t *testing.T) {
+		session := MustCreateSession(t, user.Name)
+		code := mustSignExchangeCode(t, session)
+
+		u := url.URL{
+			Path: path.Join("/v1/refresh"),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, u.Path, nil)
+
+		r.Header.Add("Authorization", "Bearer "+code)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+
+	t.Run("test disallowed callback url", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/login-sso"),
+			RawQuery: url.Values{
+				"idp":         []string{"test"},
+				"callbackURL": []string{"https://attacker.example.com/callback"},
+			}.Encode(),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.String(), nil)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, statusCode)
+		}
+	})
+}
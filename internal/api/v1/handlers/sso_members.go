@@ -0,0 +1,164 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"slices"
+
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=dockyards.io,resources=identityproviders,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=members,verbs=create;delete;get;list;patch;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=organizations,verbs=get;list;watch
+
+var rolePriority = map[dockyardsv1.Role]int{
+	dockyardsv1.RoleReader:    1,
+	dockyardsv1.RoleUser:      2,
+	dockyardsv1.RoleSuperUser: 3,
+}
+
+// groupRoles returns the role granted in each organization by the group rules matching any of the groups.
+func groupRoles(groupRules []dockyardsv1.GroupRule, groups []string) map[string]dockyardsv1.Role {
+	roles := make(map[string]dockyardsv1.Role)
+
+	for _, groupRule := range groupRules {
+		if !slices.Contains(groups, groupRule.Group) {
+			continue
+		}
+
+		organizationName := groupRule.OrganizationRef.Name
+
+		if rolePriority[groupRule.Role] > rolePriority[roles[organizationName]] {
+			roles[organizationName] = groupRule.Role
+		}
+	}
+
+	return roles
+}
+
+// syncGroupMembers creates, updates and deletes the members of the user managed by the identity provider to
+// match the group rules. Members not created by the identity provider are left unchanged, as are members the
+// admission webhook refuses to delete or demote such as the last super user of an organization.
+func (h *handler) syncGroupMembers(ctx context.Context, identityProvider *dockyardsv1.IdentityProvider, user *dockyardsv1.User, groups []string) error {
+	roles := groupRoles(identityProvider.Spec.GroupRules, groups)
+
+	matchingLabels := client.MatchingLabels{
+		dockyardsv1.LabelProviderName: identityProvider.Name,
+		dockyardsv1.LabelUserName:     user.Name,
+	}
+
+	var memberList dockyardsv1.MemberList
+	err := h.List(ctx, &memberList, matchingLabels)
+	if err != nil {
+		return err
+	}
+
+	for i := range memberList.Items {
+		member := &memberList.Items[i]
+
+		organizationName := member.Labels[dockyardsv1.LabelOrganizationName]
+
+		role, found := roles[organizationName]
+		if !found {
+			err := h.Delete(ctx, member)
+			if apierrors.IsForbidden(err) {
+				h.logger.Warn("ignoring group rule removing member", "organization", organizationName, "member", member.Name, "err", err)
+
+				continue
+			}
+
+			if client.IgnoreNotFound(err) != nil {
+				return err
+			}
+
+			continue
+		}
+
+		delete(roles, organizationName)
+
+		if member.Spec.Role == role {
+			continue
+		}
+
+		patch := client.MergeFrom(member.DeepCopy())
+
+		member.Spec.Role = role
+		member.Labels[dockyardsv1.LabelRoleName] = string(role)
+
+		err := h.Patch(ctx, member, patch)
+		if apierrors.IsForbidden(err) {
+			h.logger.Warn("ignoring group rule changing member role", "organization", organizationName, "member", member.Name, "role", role, "err", err)
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for organizationName, role := range roles {
+		var organization dockyardsv1.Organization
+		err := h.Get(ctx, client.ObjectKey{Name: organizationName}, &organization)
+		if apierrors.IsNotFound(err) {
+			h.logger.Warn("ignoring group rule for missing organization", "organization", organizationName)
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if organization.Spec.NamespaceRef == nil {
+			continue
+		}
+
+		member := dockyardsv1.Member{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      user.Name,
+				Namespace: organization.Spec.NamespaceRef.Name,
+				Labels: map[string]string{
+					dockyardsv1.LabelOrganizationName: organization.Name,
+					dockyardsv1.LabelProviderName:     identityProvider.Name,
+					dockyardsv1.LabelRoleName:         string(role),
+					dockyardsv1.LabelUserName:         user.Name,
+				},
+			},
+			Spec: dockyardsv1.MemberSpec{
+				Role: role,
+				UserRef: corev1.TypedLocalObjectReference{
+					APIGroup: &dockyardsv1.GroupVersion.Group,
+					Kind:     dockyardsv1.UserKind,
+					Name:     user.Name,
+				},
+			},
+		}
+
+		// An existing member was added by other means and takes precedence over the group rules.
+		err = h.Create(ctx, &member)
+		if client.IgnoreAlreadyExists(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,299 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestGroupRoles(t *testing.T) {
+	groupRules := []dockyardsv1.GroupRule{
+		{
+			Group:           "developers",
+			OrganizationRef: corev1.LocalObjectReference{Name: "test"},
+			Role:            dockyardsv1.RoleUser,
+		},
+		{
+			Group:           "admins",
+			OrganizationRef: corev1.LocalObjectReference{Name: "test"},
+			Role:            dockyardsv1.RoleSuperUser,
+		},
+		{
+			Group:           "auditors",
+			OrganizationRef: corev1.LocalObjectReference{Name: "test"},
+			Role:            dockyardsv1.RoleReader,
+		},
+		{
+			Group:           "auditors",
+			OrganizationRef: corev1.LocalObjectReference{Name: "other"},
+			Role:            dockyardsv1.RoleReader,
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		groups   []string
+		expected map[string]dockyardsv1.Role
+	}{
+		{
+			name:     "test no groups",
+			expected: map[string]dockyardsv1.Role{},
+		},
+		{
+			name:     "test unknown group",
+			groups:   []string{"unknown"},
+			expected: map[string]dockyardsv1.Role{},
+		},
+		{
+			name:   "test single group",
+			groups: []string{"developers"},
+			expected: map[string]dockyardsv1.Role{
+				"test": dockyardsv1.RoleUser,
+			},
+		},
+		{
+			name:   "test highest role",
+			groups: []string{"auditors", "admins", "developers"},
+			expected: map[string]dockyardsv1.Role{
+				"test":  dockyardsv1.RoleSuperUser,
+				"other": dockyardsv1.RoleReader,
+			},
+		},
+		{
+			name:   "test lower role after higher",
+			groups: []string{"developers", "auditors"},
+			expected: map[string]dockyardsv1.Role{
+				"test":  dockyardsv1.RoleUser,
+				"other": dockyardsv1.RoleReader,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := groupRoles(groupRules, tc.groups)
+			if !cmp.Equal(actual, tc.expected) {
+				t.Errorf("diff: %s", cmp.Diff(tc.expected, actual))
+			}
+		})
+	}
+}
+
+func newGroupMember(organizationName, providerName string, role dockyardsv1.Role) *dockyardsv1.Member {
+	member := dockyardsv1.Member{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: organizationName,
+			Labels: map[string]string{
+				dockyardsv1.LabelOrganizationName: organizationName,
+				dockyardsv1.LabelRoleName:         string(role),
+				dockyardsv1.LabelUserName:         "test",
+			},
+		},
+		Spec: dockyardsv1.MemberSpec{
+			Role: role,
+			UserRef: corev1.TypedLocalObjectReference{
+				APIGroup: &dockyardsv1.GroupVersion.Group,
+				Kind:     dockyardsv1.UserKind,
+				Name:     "test",
+			},
+		},
+	}
+
+	if providerName != "" {
+		member.Labels[dockyardsv1.LabelProviderName] = providerName
+	}
+
+	return &member
+}
+
+func TestSyncGroupMembers(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = dockyardsv1.AddToScheme(scheme)
+
+	var organizations []client.Object
+	for _, name := range []string{"test", "other"} {
+		organization := dockyardsv1.Organization{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: dockyardsv1.OrganizationSpec{
+				NamespaceRef: &corev1.LocalObjectReference{
+					Name: name,
+				},
+			},
+		}
+
+		organizations = append(organizations, &organization)
+	}
+
+	identityProvider := dockyardsv1.IdentityProvider{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "sso",
+		},
+		Spec: dockyardsv1.IdentityProviderSpec{
+			GroupRules: []dockyardsv1.GroupRule{
+				{
+					Group:           "developers",
+					OrganizationRef: corev1.LocalObjectReference{Name: "test"},
+					Role:            dockyardsv1.RoleUser,
+				},
+				{
+					Group:           "admins",
+					OrganizationRef: corev1.LocalObjectReference{Name: "test"},
+					Role:            dockyardsv1.RoleSuperUser,
+				},
+				{
+					Group:           "missing",
+					OrganizationRef: corev1.LocalObjectReference{Name: "missing"},
+					Role:            dockyardsv1.RoleReader,
+				},
+			},
+		},
+	}
+
+	user := dockyardsv1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+		},
+	}
+
+	forbidden := apierrors.NewForbidden(dockyardsv1.GroupVersion.WithResource("members").GroupResource(), "test", nil)
+
+	testCases := []struct {
+		name         string
+		groups       []string
+		existing     []client.Object
+		interceptors interceptor.Funcs
+		expected     map[string]dockyardsv1.Role
+	}{
+		{
+			name:   "test create member",
+			groups: []string{"developers"},
+			expected: map[string]dockyardsv1.Role{
+				"test": dockyardsv1.RoleUser,
+			},
+		},
+		{
+			name:   "test update role",
+			groups: []string{"admins"},
+			existing: []client.Object{
+				newGroupMember("test", identityProvider.Name, dockyardsv1.RoleUser),
+			},
+			expected: map[string]dockyardsv1.Role{
+				"test": dockyardsv1.RoleSuperUser,
+			},
+		},
+		{
+			name: "test delete member",
+			existing: []client.Object{
+				newGroupMember("test", identityProvider.Name, dockyardsv1.RoleUser),
+			},
+			expected: map[string]dockyardsv1.Role{},
+		},
+		{
+			name: "test member added by other means",
+			existing: []client.Object{
+				newGroupMember("test", "", dockyardsv1.RoleReader),
+			},
+			groups: []string{"admins"},
+			expected: map[string]dockyardsv1.Role{
+				"test": dockyardsv1.RoleReader,
+			},
+		},
+		{
+			name:     "test missing organization",
+			groups:   []string{"missing"},
+			expected: map[string]dockyardsv1.Role{},
+		},
+		{
+			name: "test delete denied",
+			existing: []client.Object{
+				newGroupMember("test", identityProvider.Name, dockyardsv1.RoleSuperUser),
+			},
+			interceptors: interceptor.Funcs{
+				Delete: func(context.Context, client.WithWatch, client.Object, ...client.DeleteOption) error {
+					return forbidden
+				},
+			},
+			expected: map[string]dockyardsv1.Role{
+				"test": dockyardsv1.RoleSuperUser,
+			},
+		},
+		{
+			name:   "test demotion denied",
+			groups: []string{"developers"},
+			existing: []client.Object{
+				newGroupMember("test", identityProvider.Name, dockyardsv1.RoleSuperUser),
+			},
+			interceptors: interceptor.Funcs{
+				Patch: func(context.Context, client.WithWatch, client.Object, client.Patch, ...client.PatchOption) error {
+					return forbidden
+				},
+			},
+			expected: map[string]dockyardsv1.Role{
+				"test": dockyardsv1.RoleSuperUser,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(organizations...).
+				WithObjects(tc.existing...).
+				WithInterceptorFuncs(tc.interceptors).
+				Build()
+
+			h := handler{
+				Client: c,
+				logger: slog.New(slog.DiscardHandler),
+			}
+
+			err := h.syncGroupMembers(t.Context(), &identityProvider, &user, tc.groups)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var memberList dockyardsv1.MemberList
+			err = c.List(t.Context(), &memberList)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := make(map[string]dockyardsv1.Role)
+			for _, member := range memberList.Items {
+				actual[member.Namespace] = member.Spec.Role
+			}
+
+			if !cmp.Equal(actual, tc.expected) {
+				t.Errorf("diff: %s", cmp.Diff(tc.expected, actual))
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/crewjam/saml"
+	dsig "github.com/russellhaering/goxmldsig"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func (h *handler) getSAMLConfig(ctx context.Context, ref corev1.SecretReference) (*dockyardsv1.SAMLConfig, error) {
+	var secret corev1.Secret
+	err := h.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: ref.Namespace}, &secret)
+	if err != nil {
+		return nil, err
+	}
+
+	var samlConfig dockyardsv1.SAMLConfig
+	err = decodeSecretConfig(&secret, &samlConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode saml config: %w", err)
+	}
+
+	if samlConfig.MetadataURL == "" || samlConfig.ACSURL == "" {
+		return nil, errors.New("invalid saml config: metadataURL and acsURL must be set")
+	}
+
+	if samlConfig.IDPMetadata == nil && samlConfig.IDPMetadataURL == nil {
+		return nil, errors.New("invalid saml config: either idpMetadata or idpMetadataURL must be set")
+	}
+
+	return &samlConfig, nil
+}
+
+func parseIDPMetadata(data []byte) (*saml.EntityDescriptor, error) {
+	var entityDescriptor saml.EntityDescriptor
+	err := xml.Unmarshal(data, &entityDescriptor)
+	if err == nil {
+		return &entityDescriptor, nil
+	}
+
+	// Federations publish the metadata of many entities in a single document, the first entity is used.
+	var entitiesDescriptor saml.EntitiesDescriptor
+	if xml.Unmarshal(data, &entitiesDescriptor) == nil && len(entitiesDescriptor.EntityDescriptors) > 0 {
+		return &entitiesDescriptor.EntityDescriptors[0], nil
+	}
+
+	return nil, err
+}
+
+func (h *handler) samlServiceProvider(ctx context.Context, identityProvider *dockyardsv1.IdentityProvider) (*saml.ServiceProvider, error) {
+	configRef := identityProvider.Spec.SAMLConfigRef
+	if configRef == nil {
+		return nil, errors.New("saml config ref is not set")
+	}
+
+	samlConfig, err := h.getSAMLConfig(ctx, *configRef)
+	if err != nil {
+		return nil, fmt.Errorf("could not get saml config: %w", err)
+	}
+
+	metadataURL, err := url.Parse(samlConfig.MetadataURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse metadata url: %w", err)
+	}
+
+	acsURL, err := url.Parse(samlConfig.ACSURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse acs url: %w", err)
+	}
+
+	var idpMetadata *saml.EntityDescriptor
+	if samlConfig.IDPMetadata != nil {
+		idpMetadata, err = parseIDPMetadata([]byte(*samlConfig.IDPMetadata))
+	} else {
+		idpMetadata, err = h.samlMetadata.get(ctx, *samlConfig.IDPMetadataURL)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not get identity provider metadata: %w", err)
+	}
+
+	sp := saml.ServiceProvider{
+		EntityID:          samlConfig.EntityID,
+		MetadataURL:       *metadataURL,
+		AcsURL:            *acsURL,
+		IDPMetadata:       idpMetadata,
+		AuthnNameIDFormat: saml.UnspecifiedNameIDFormat,
+	}
+
+	if samlConfig.Certificate != nil && samlConfig.PrivateKey != nil {
+		keyPair, err := tls.X509KeyPair([]byte(*samlConfig.Certificate), []byte(*samlConfig.PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("could not load key pair: %w", err)
+		}
+
+		certificate, err := x509.ParseCertificate(keyPair.Certificate[0])
+		if err != nil {
+			return nil, fmt.Errorf("could not parse certificate: %w", err)
+		}
+
+		signer, ok := keyPair.PrivateKey.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("private key is not a signer")
+		}
+
+		sp.Key = signer
+		sp.Certificate = certificate
+
+		switch signer.(type) {
+		case *rsa.PrivateKey:
+			sp.SignatureMethod = dsig.RSASHA256SignatureMethod
+		case *ecdsa.PrivateKey:
+			sp.SignatureMethod = dsig.ECDSASHA256SignatureMethod
+		}
+	}
+
+	return &sp, nil
+}
+
+// samlAttributes returns the attributes of the assertion by both name and friendly name, attributes with a single
+// value are returned as strings.
+func samlAttributes(assertion *saml.Assertion) map[string]any {
+	raw := make(map[string]any)
+
+	for _, attributeStatement := range assertion.AttributeStatements {
+		for _, attribute := range attributeStatement.Attributes {
+			var value any
+			if len(attribute.Values) == 1 {
+				value = attribute.Values[0].Value
+			} else {
+				values := make([]any, len(attribute.Values))
+				for i, attributeValue := range attribute.Values {
+					values[i] = attributeValue.Value
+				}
+
+				value = values
+			}
+
+			raw[attribute.Name] = value
+
+			if attribute.FriendlyName != "" {
+				raw[attribute.FriendlyName] = value
+			}
+		}
+	}
+
+	return raw
+}
+
+func (h *handler) LoginSAML(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	providerName := r.URL.Query().Get("idp")
+	callbackURL := r.URL.Query().Get("callbackURL")
+
+	if !h.isAllowedCallbackURL(callbackURL) {
+		return apierrors.NewBadRequest("callback url is not allowed")
+	}
+
+	identityProvider, err := h.getIdentityProvider(ctx, providerName)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	sp, err := h.samlServiceProvider(ctx, identityProvider)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	ssoURL := sp.GetSSOBindingLocation(saml.HTTPRedirectBinding)
+	if ssoURL == "" {
+		return apierrors.NewInternalError(errors.New("identity provider does not support the http redirect binding"))
+	}
+
+	authnRequest, err := sp.MakeAuthenticationRequest(ssoURL, saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		return apierrors.NewInternalError(fmt.Errorf("could not make authentication request: %w", err))
+	}
+
+	// The state is sent as relay state since cookies are not included in the cross-site post to the assertion
+	// consumer service.
+	state, err := h.signState(providerName, callbackURL, authnRequest.ID)
+	if err != nil {
+		return apierrors.NewInternalError(fmt.Errorf("could not make state: %w", err))
+	}
+
+	redirectURL, err := authnRequest.Redirect(state, sp)
+	if err != nil {
+		return apierrors.NewInternalError(fmt.Errorf("could not make redirect url: %w", err))
+	}
+
+	http.Redirect(w, r, redirectURL.String(), http.StatusSeeOther)
+
+	return nil
+}
+
+func (h *handler) GetSAMLMetadata(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	providerName := r.URL.Query().Get("idp")
+
+	identityProvider, err := h.getIdentityProvider(ctx, providerName)
+	if err != nil {
+		return apierrors.NewNotFound(dockyardsv1.GroupVersion.WithResource("identityproviders").GroupResource(), providerName)
+	}
+
+	sp, err := h.samlServiceProvider(ctx, identityProvider)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	b, err := xml.MarshalIndent(sp.Metadata(), "", "  ")
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+
+	_, err = w.Write(b)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AssertionConsumerService validates the signed assertion posted by the identity provider and completes the
+// login of the user.
+func (h *handler) AssertionConsumerService(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	logger := middleware.LoggerFrom(ctx)
+
+	err := r.ParseForm()
+	if err != nil {
+		return apierrors.NewBadRequest("could not parse form")
+	}
+
+	state, err := h.parseState(r.PostForm.Get("RelayState"))
+	if err != nil || state.RequestID == "" {
+		return apierrors.NewBadRequest("invalid relay state")
+	}
+
+	if !h.isAllowedCallbackURL(state.CallbackURL) {
+		return apierrors.NewBadRequest("callback url is not allowed")
+	}
+
+	identityProvider, err := h.getIdentityProvider(ctx, state.IDP)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	sp, err := h.samlServiceProvider(ctx, identityProvider)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	assertion, err := sp.ParseResponse(r, []string{state.RequestID})
+	if err != nil {
+		var invalidResponseError *saml.InvalidResponseError
+		if errors.As(err, &invalidResponseError) {
+			logger.Debug("invalid saml response", "err", invalidResponseError.PrivateErr)
+		}
+
+		return apierrors.NewUnauthorized("invalid saml response")
+	}
+
+	claims := mapClaims(identityProvider, samlAttributes(assertion))
+	if claims.Username == "" && assertion.Subject != nil && assertion.Subject.NameID != nil {
+		claims.Username = assertion.Subject.NameID.Value
+	}
+
+	if claims.Username == "" {
+		return apierrors.NewInternalError(errors.New("username attribute was empty"))
+	}
+
+	return h.completeLogin(w, r, identityProvider, state, claims)
+}
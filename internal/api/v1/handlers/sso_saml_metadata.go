@@ -0,0 +1,110 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/crewjam/saml"
+)
+
+const (
+	// maxMetadataSize limits the size of identity provider metadata read from a metadata url.
+	maxMetadataSize = 1 << 20
+
+	samlMetadataTTL     = time.Hour
+	samlMetadataTimeout = time.Second * 10
+)
+
+type samlMetadataEntry struct {
+	entityDescriptor *saml.EntityDescriptor
+	expires          time.Time
+}
+
+// samlMetadataCache keeps identity provider metadata fetched from metadata urls, the metadata is needed by every
+// SAML request and rarely changes.
+type samlMetadataCache struct {
+	mu      sync.Mutex
+	client  *http.Client
+	entries map[string]samlMetadataEntry
+	now     func() time.Time
+}
+
+func newSAMLMetadataCache() *samlMetadataCache {
+	c := samlMetadataCache{
+		client: &http.Client{
+			Timeout: samlMetadataTimeout,
+		},
+		entries: make(map[string]samlMetadataEntry),
+		now:     time.Now,
+	}
+
+	return &c
+}
+
+// get returns the metadata of the url, metadata older than the ttl is fetched again.
+func (c *samlMetadataCache) get(ctx context.Context, metadataURL string) (*saml.EntityDescriptor, error) {
+	c.mu.Lock()
+	entry, found := c.entries[metadataURL]
+	c.mu.Unlock()
+
+	if found && c.now().Before(entry.expires) {
+		return entry.entityDescriptor, nil
+	}
+
+	entityDescriptor, err := c.fetch(ctx, metadataURL)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[metadataURL] = samlMetadataEntry{
+		entityDescriptor: entityDescriptor,
+		expires:          c.now().Add(samlMetadataTTL),
+	}
+	c.mu.Unlock()
+
+	return entityDescriptor, nil
+}
+
+func (c *samlMetadataCache) fetch(ctx context.Context, metadataURL string) (*saml.EntityDescriptor, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxMetadataSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseIDPMetadata(data)
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSAMLMetadataCache(t *testing.T) {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		_, _ = w.Write([]byte(`<EntityDescriptor xmlns="urn:oasis:names:tc:SAML:2.0:metadata" entityID="https://idp.dockyards.dev"/>`))
+	}))
+
+	defer server.Close()
+
+	now := time.Now()
+
+	c := newSAMLMetadataCache()
+	c.now = func() time.Time {
+		return now
+	}
+
+	for _, tc := range []struct {
+		name     string
+		advance  time.Duration
+		expected int
+	}{
+		{
+			name:     "test first request",
+			expected: 1,
+		},
+		{
+			name:     "test cached",
+			advance:  samlMetadataTTL - time.Second,
+			expected: 1,
+		},
+		{
+			name:     "test expired",
+			advance:  time.Second,
+			expected: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			now = now.Add(tc.advance)
+
+			entityDescriptor, err := c.get(t.Context(), server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if entityDescriptor.EntityID != "https://idp.dockyards.dev" {
+				t.Errorf("unexpected entity id %s", entityDescriptor.EntityID)
+			}
+
+			if requests != tc.expected {
+				t.Errorf("expected %d requests, got %d", tc.expected, requests)
+			}
+		})
+	}
+
+	t.Run("test error not cached", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+
+		defer failing.Close()
+
+		_, err := c.get(t.Context(), failing.URL)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+
+		_, found := c.entries[failing.URL]
+		if found {
+			t.Error("expected failed request to not be cached")
+		}
+	})
+}
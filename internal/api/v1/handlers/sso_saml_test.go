@@ -0,0 +1,144 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/crewjam/saml"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/pkg/testing/testingutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testIDPMetadata = `<EntityDescriptor xmlns="urn:oasis:names:tc:SAML:2.0:metadata" entityID="https://idp.dockyards.dev/metadata">
+  <IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://idp.dockyards.dev/sso"/>
+  </IDPSSODescriptor>
+</EntityDescriptor>`
+
+func TestSAML(t *testing.T) {
+	c := testEnvironment.GetClient()
+
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "saml-",
+			Namespace:    testEnvironment.GetDockyardsNamespace(),
+		},
+		Data: map[string][]byte{},
+	}
+
+	samlConfig := map[string]string{
+		"metadataURL": "https://dockyards.dev/api/backend/v1/metadata-saml",
+		"acsURL":      "https://dockyards.dev/api/backend/v1/acs-saml",
+		"idpMetadata": testIDPMetadata,
+	}
+
+	for key, value := range samlConfig {
+		b, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		secret.Data[key] = b
+	}
+
+	err := c.Create(ctx, &secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identityProvider := dockyardsv1.IdentityProvider{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "saml-",
+		},
+		Spec: dockyardsv1.IdentityProviderSpec{
+			SAMLConfigRef: &corev1.SecretReference{
+				Name:      secret.Name,
+				Namespace: secret.Namespace,
+			},
+		},
+	}
+
+	err = c.Create(ctx, &identityProvider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = testingutil.RetryUntilFound(ctx, testEnvironment.GetManager().GetClient(), &identityProvider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test metadata", func(t *testing.T) {
+		u := url.URL{
+			Path: path.Join("/v1/metadata-saml"),
+			RawQuery: url.Values{
+				"idp": []string{identityProvider.Name},
+			}.Encode(),
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, u.String(), nil)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, statusCode)
+		}
+
+		var entityDescriptor saml.EntityDescriptor
+		err := xml.Unmarshal(w.Body.Bytes(), &entityDescriptor)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(entityDescriptor.SPSSODescriptors) != 1 {
+			t.Fatalf("expected one service provider descriptor, got %d", len(entityDescriptor.SPSSODescriptors))
+		}
+
+		assertionConsumerServices := entityDescriptor.SPSSODescriptors[0].AssertionConsumerServices
+		if len(assertionConsumerServices) == 0 || assertionConsumerServices[0].Location != "https://dockyards.dev/api/backend/v1/acs-saml" {
+			t.Fatalf("unexpected assertion consumer services %v", assertionConsumerServices)
+		}
+	})
+
+	t.Run("test invalid relay state", func(t *testing.T) {
+		form := url.Values{
+			"SAMLResponse": []string{"invalid"},
+			"RelayState":   []string{"invalid"},
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/acs-saml", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, statusCode)
+		}
+	})
+}
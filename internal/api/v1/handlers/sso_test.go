@@ -0,0 +1,213 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"log/slog"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/api/v1alpha3/index"
+	"golang.org/x/oauth2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAuthCodeOptions(t *testing.T) {
+	verifier := oauth2.GenerateVerifier()
+
+	testCases := []struct {
+		name         string
+		clientConfig dockyardsv1.OIDCClientConfig
+		verifier     string
+		expected     url.Values
+	}{
+		{
+			name: "test default prompt",
+			expected: url.Values{
+				"prompt": {"consent"},
+			},
+		},
+		{
+			name: "test empty prompt",
+			clientConfig: dockyardsv1.OIDCClientConfig{
+				Prompt: ptr.To(""),
+			},
+			expected: url.Values{},
+		},
+		{
+			name: "test custom prompt",
+			clientConfig: dockyardsv1.OIDCClientConfig{
+				Prompt: ptr.To("login"),
+			},
+			expected: url.Values{
+				"prompt": {"login"},
+			},
+		},
+		{
+			name: "test extra params",
+			clientConfig: dockyardsv1.OIDCClientConfig{
+				ExtraAuthParams: map[string]string{
+					"audience":   "dockyards",
+					"acr_values": "mfa",
+				},
+			},
+			expected: url.Values{
+				"prompt":     {"consent"},
+				"audience":   {"dockyards"},
+				"acr_values": {"mfa"},
+			},
+		},
+		{
+			name:     "test pkce",
+			verifier: verifier,
+			expected: url.Values{
+				"prompt":                {"consent"},
+				"code_challenge":        {oauth2.S256ChallengeFromVerifier(verifier)},
+				"code_challenge_method": {"S256"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := oauth2.Config{
+				Endpoint: oauth2.Endpoint{
+					AuthURL: "https://idp.dockyards.dev/authorize",
+				},
+			}
+
+			options := authCodeOptions(&tc.clientConfig, tc.verifier)
+
+			u, err := url.Parse(config.AuthCodeURL("state", options...))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := u.Query()
+
+			for _, key := range []string{"client_id", "response_type", "state"} {
+				actual.Del(key)
+			}
+
+			if !cmp.Equal(actual, tc.expected) {
+				t.Errorf("diff: %s", cmp.Diff(tc.expected, actual))
+			}
+		})
+	}
+}
+
+func TestGetOrCreateUser(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = dockyardsv1.AddToScheme(scheme)
+
+	existing := dockyardsv1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "sso-test",
+			Labels: map[string]string{
+				dockyardsv1.LabelProviderName: "sso",
+			},
+		},
+		Spec: dockyardsv1.UserSpec{
+			Email:      "test@dockyards.dev",
+			ProviderID: "sso://test",
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		claims   Claims
+		expected string
+	}{
+		{
+			name: "test existing user",
+			claims: Claims{
+				Username: "test",
+				Email:    "test@dockyards.dev",
+			},
+			expected: "sso-test",
+		},
+		{
+			name: "test email username",
+			claims: Claims{
+				Username: "Other.User@dockyards.dev",
+				Email:    "other.user@dockyards.dev",
+			},
+			expected: ssoUserName("sso", "Other.User@dockyards.dev"),
+		},
+		{
+			name: "test saml name id",
+			claims: Claims{
+				Username: "urn:oid:0.9.2342.19200300.100.1.1/Test_User",
+			},
+			expected: ssoUserName("sso", "urn:oid:0.9.2342.19200300.100.1.1/Test_User"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(&existing).
+				WithIndex(&dockyardsv1.User{}, index.ProviderIDField, index.ByProviderID).
+				Build()
+
+			h := handler{
+				Client: c,
+				logger: slog.New(slog.DiscardHandler),
+			}
+
+			user, err := h.getOrCreateUser(t.Context(), "sso", tc.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if user.Name != tc.expected {
+				t.Errorf("expected user name %s, got %s", tc.expected, user.Name)
+			}
+
+			errs := validation.IsDNS1123Subdomain(user.Name)
+			if len(errs) > 0 {
+				t.Errorf("expected valid user name, got %v", errs)
+			}
+
+			var actual dockyardsv1.User
+			err = c.Get(t.Context(), client.ObjectKey{Name: tc.expected}, &actual)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedProviderID := "sso://" + tc.claims.Username
+			if actual.Spec.ProviderID != expectedProviderID {
+				t.Errorf("expected provider id %s, got %s", expectedProviderID, actual.Spec.ProviderID)
+			}
+
+			again, err := h.getOrCreateUser(t.Context(), "sso", tc.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if again.Name != user.Name {
+				t.Errorf("expected user %s, got %s", user.Name, again.Name)
+			}
+		})
+	}
+}
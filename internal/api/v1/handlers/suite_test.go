@@ -17,6 +17,7 @@ package handlers_test
 import (
 	"context"
 	"crypto/ecdsa"
+	"crypto/rand"
 	"log/slog"
 	"net/http"
 	"os"
@@ -30,8 +31,11 @@ import (
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
 	"github.com/sudoswedenab/dockyards-backend/api/v1alpha3/index"
 	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/handlers"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	"github.com/sudoswedenab/dockyards-backend/internal/audit"
 	"github.com/sudoswedenab/dockyards-backend/pkg/testing/testingutil"
 	utiljwt "github.com/sudoswedenab/dockyards-backend/pkg/util/jwt"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -126,23 +130,43 @@ func TestMain(m *testing.M) {
 		}
 	}()
 
-	accessKey, refreshKey, err = utiljwt.GetOrGenerateKeys(ctx, c, testEnvironment.GetDockyardsNamespace())
+	keySet, err := utiljwt.GetOrGenerateKeySet(ctx, c, testEnvironment.GetDockyardsNamespace())
 	if err != nil {
 		slogr.Error(err, "error preparing test keys")
 
 		os.Exit(1)
 	}
 
+	accessKey = keySet.AccessTokenSigningKey()
+	refreshKey = keySet.RefreshTokenSigningKey()
+
 	fakeConfig := config.NewFakeConfigManager(map[config.Key]string{
 		config.KeyPublicNamespace: testEnvironment.GetPublicNamespace(),
 	})
 
+	auditRecorder, err := audit.NewRecorder(1000, audit.WithSinks(audit.NewResourceSink(c, 0)))
+	if err != nil {
+		slogr.Error(err, "error creating audit recorder")
+
+		os.Exit(1)
+	}
+
+	go func() {
+		err := auditRecorder.Start(ctx)
+		if err != nil {
+			slogr.Error(err, "error starting audit recorder")
+
+			os.Exit(1)
+		}
+	}()
+
 	handlerOptions := []handlers.HandlerOption{
 		handlers.WithManager(mgr),
 		handlers.WithSystemNamespace(testEnvironment.GetDockyardsNamespace()),
 		handlers.WithLogger(logger),
-		handlers.WithJWTPrivateKeys(accessKey, refreshKey),
+		handlers.WithJWTKeySet(keySet),
 		handlers.WithConfigManager(fakeConfig),
+		handlers.WithAuditRecorder(auditRecorder),
 	}
 
 	mux = http.NewServeMux()
@@ -192,10 +216,70 @@ func MustSignToken(t *testing.T, subject string) string {
 }
 
 func MustSignRefreshToken(t *testing.T, subject string) string {
-	signedToken, err := SignToken(subject, refreshKey)
+	session := MustCreateSession(t, subject)
+
+	signedToken, err := SignSessionToken(subject, session.Name, session.Status.TokenID, refreshKey)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	return signedToken
 }
+
+func SignSessionToken(subject, sessionName, tokenID string, key any) (string, error) {
+	claims := middleware.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute * 30)),
+		},
+		SessionID: sessionName,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	signedToken, err := token.SignedString(key)
+	if err != nil {
+		return "", err
+	}
+
+	return signedToken, nil
+}
+
+func MustCreateSession(t *testing.T, subject string) *dockyardsv1.Session {
+	c := testEnvironment.GetClient()
+
+	session := dockyardsv1.Session{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: subject + "-",
+			Labels: map[string]string{
+				dockyardsv1.LabelUserName: subject,
+			},
+		},
+		Spec: dockyardsv1.SessionSpec{
+			UserRef: corev1.TypedLocalObjectReference{
+				APIGroup: &dockyardsv1.GroupVersion.Group,
+				Kind:     dockyardsv1.UserKind,
+				Name:     subject,
+			},
+			Duration: &metav1.Duration{
+				Duration: time.Hour,
+			},
+		},
+	}
+
+	err := c.Create(ctx, &session)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patch := client.MergeFrom(session.DeepCopy())
+
+	session.Status.TokenID = rand.Text()
+
+	err = c.Status().Patch(ctx, &session, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &session
+}
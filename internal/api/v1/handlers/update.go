@@ -137,6 +137,21 @@ func UpdateClusterResource[T any](h *handler, resource string, f UpdateClusterRe
 			return
 		}
 
+		allowed, err = h.isClusterAllowed(ctx, subject, &cluster)
+		if err != nil {
+			logger.Error("error reviewing subject", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if !allowed {
+			logger.Debug("subject is not allowed to access cluster", "subject", subject, "cluster", cluster.Name)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
 		var request T
 		err = json.Unmarshal(b, &request)
 		if err != nil {
@@ -521,6 +536,12 @@ func UpdateNamelessResource[T any](_ *handler, resource string, f UpdateNameless
 			return
 		}
 
+		if apierrors.IsForbidden(err) {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
 		if apierrors.IsInvalid(err) {
 			statusError, ok := err.(*apierrors.StatusError)
 			if !ok {
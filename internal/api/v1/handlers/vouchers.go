@@ -0,0 +1,252 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/api/v1alpha3/index"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	"github.com/sudoswedenab/dockyards-backend/pkg/util/bubblebabble"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=dockyards.io,resources=organizationvouchers,verbs=create;get;list;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=organizationvouchers/status,verbs=patch
+// +kubebuilder:rbac:groups=dockyards.io,resources=organizationvoucherpools,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=features,verbs=create
+
+const (
+	organizationVoucherEntropy = 64
+)
+
+type OrganizationVoucherOptions struct {
+	PoolName string `json:"pool_name"`
+	Count    int    `json:"count"`
+}
+
+type OrganizationVoucher struct {
+	CreatedAt time.Time `json:"created_at"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	PoolName  string    `json:"pool_name"`
+}
+
+func invalidVoucherCode(code, detail string) error {
+	invalid := field.Invalid(field.NewPath("voucher_code"), code, detail)
+
+	return apierrors.NewInvalid(dockyardsv1.GroupVersion.WithKind(dockyardsv1.OrganizationKind).GroupKind(), "", field.ErrorList{invalid})
+}
+
+func organizationVoucherMaxRedemptions(organizationVoucherPool *dockyardsv1.OrganizationVoucherPool) int {
+	if organizationVoucherPool == nil || organizationVoucherPool.Spec.MaxRedemptions < 1 {
+		return 1
+	}
+
+	return int(organizationVoucherPool.Spec.MaxRedemptions)
+}
+
+// getRedeemableOrganizationVoucher returns the voucher with code together with its pool, vouchers not referencing an
+// organization voucher pool have no pool and can be redeemed once.
+func (h *handler) getRedeemableOrganizationVoucher(ctx context.Context, code string) (*dockyardsv1.OrganizationVoucher, *dockyardsv1.OrganizationVoucherPool, error) {
+	matchingFields := client.MatchingFields{
+		index.CodeField: code,
+	}
+
+	var organizationVoucherList dockyardsv1.OrganizationVoucherList
+	err := h.List(ctx, &organizationVoucherList, matchingFields, client.InNamespace(h.systemNamespace))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(organizationVoucherList.Items) != 1 {
+		return nil, nil, invalidVoucherCode(code, "voucher code is not valid")
+	}
+
+	organizationVoucher := organizationVoucherList.Items[0]
+
+	if organizationVoucher.Status.Redeemed {
+		return nil, nil, invalidVoucherCode(code, "voucher code has been redeemed")
+	}
+
+	poolRef := organizationVoucher.Spec.PoolRef
+	if poolRef == nil || poolRef.Kind != dockyardsv1.OrganizationVoucherPoolKind {
+		return &organizationVoucher, nil, nil
+	}
+
+	if poolRef.APIGroup != nil && *poolRef.APIGroup != dockyardsv1.GroupVersion.Group {
+		return &organizationVoucher, nil, nil
+	}
+
+	objectKey := client.ObjectKey{
+		Name:      poolRef.Name,
+		Namespace: organizationVoucher.Namespace,
+	}
+
+	var organizationVoucherPool dockyardsv1.OrganizationVoucherPool
+	err = h.Get(ctx, objectKey, &organizationVoucherPool)
+	if apierrors.IsNotFound(err) {
+		return nil, nil, invalidVoucherCode(code, "voucher code is not valid")
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+
+	if organizationVoucherPool.Spec.NotBefore != nil && now.Before(organizationVoucherPool.Spec.NotBefore.Time) {
+		return nil, nil, invalidVoucherCode(code, "voucher code is not valid yet")
+	}
+
+	if organizationVoucherPool.Spec.NotAfter != nil && now.After(organizationVoucherPool.Spec.NotAfter.Time) {
+		return nil, nil, invalidVoucherCode(code, "voucher code has expired")
+	}
+
+	if len(organizationVoucher.Status.Redemptions) >= organizationVoucherMaxRedemptions(&organizationVoucherPool) {
+		return nil, nil, invalidVoucherCode(code, "voucher code has been redeemed")
+	}
+
+	return &organizationVoucher, &organizationVoucherPool, nil
+}
+
+// redeemOrganizationVoucher records the redemption of the voucher by organization, the voucher is read directly from
+// the api server to avoid redeeming it more than the maximum number of times.
+func (h *handler) redeemOrganizationVoucher(ctx context.Context, organizationVoucher *dockyardsv1.OrganizationVoucher, organizationVoucherPool *dockyardsv1.OrganizationVoucherPool, organizationName string) error {
+	maxRedemptions := organizationVoucherMaxRedemptions(organizationVoucherPool)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var current dockyardsv1.OrganizationVoucher
+		err := h.apiReader.Get(ctx, client.ObjectKeyFromObject(organizationVoucher), &current)
+		if err != nil {
+			return err
+		}
+
+		if current.Status.Redeemed || len(current.Status.Redemptions) >= maxRedemptions {
+			return invalidVoucherCode(current.Spec.Code, "voucher code has been redeemed")
+		}
+
+		patch := client.MergeFromWithOptions(current.DeepCopy(), client.MergeFromWithOptimisticLock{})
+
+		current.Status.Redemptions = append(current.Status.Redemptions, dockyardsv1.OrganizationVoucherRedemption{
+			OrganizationRef: corev1.LocalObjectReference{
+				Name: organizationName,
+			},
+			Timestamp: metav1.Now(),
+		})
+
+		if len(current.Status.Redemptions) >= maxRedemptions {
+			current.Status.Redeemed = true
+		}
+
+		return h.Status().Patch(ctx, &current, patch)
+	})
+}
+
+func (h *handler) CreateGlobalOrganizationVouchers(ctx context.Context, request *OrganizationVoucherOptions) (*[]OrganizationVoucher, error) {
+	subject, err := middleware.SubjectFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceAttributes := authorizationv1.ResourceAttributes{
+		Group:     dockyardsv1.GroupVersion.Group,
+		Namespace: h.systemNamespace,
+		Resource:  "organizationvouchers",
+		Verb:      "create",
+	}
+
+	allowed, err := apiutil.IsSubjectAllowed(ctx, h.Client, subject, &resourceAttributes)
+	if err != nil {
+		return nil, err
+	}
+
+	if !allowed {
+		return nil, apierrors.NewUnauthorized("subject is not allowed to create organization vouchers")
+	}
+
+	objectKey := client.ObjectKey{
+		Name:      request.PoolName,
+		Namespace: h.systemNamespace,
+	}
+
+	var organizationVoucherPool dockyardsv1.OrganizationVoucherPool
+	err = h.Get(ctx, objectKey, &organizationVoucherPool)
+	if apierrors.IsNotFound(err) {
+		invalid := field.NotFound(field.NewPath("pool_name"), request.PoolName)
+
+		return nil, apierrors.NewInvalid(dockyardsv1.GroupVersion.WithKind(dockyardsv1.OrganizationVoucherKind).GroupKind(), "", field.ErrorList{invalid})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	organizationVouchers := []OrganizationVoucher{}
+
+	for range request.Count {
+		code, err := bubblebabble.RandomWithEntropyOfAtLeast(organizationVoucherEntropy)
+		if err != nil {
+			return nil, err
+		}
+
+		organizationVoucher := dockyardsv1.OrganizationVoucher{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: organizationVoucherPool.Name + "-",
+				Namespace:    organizationVoucherPool.Namespace,
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: dockyardsv1.GroupVersion.String(),
+						Kind:       dockyardsv1.OrganizationVoucherPoolKind,
+						Name:       organizationVoucherPool.Name,
+						UID:        organizationVoucherPool.UID,
+					},
+				},
+			},
+			Spec: dockyardsv1.OrganizationVoucherSpec{
+				Code: code,
+				PoolRef: &corev1.TypedObjectReference{
+					APIGroup: ptr.To(dockyardsv1.GroupVersion.Group),
+					Kind:     dockyardsv1.OrganizationVoucherPoolKind,
+					Name:     organizationVoucherPool.Name,
+				},
+			},
+		}
+
+		err = h.Create(ctx, &organizationVoucher)
+		if err != nil {
+			return nil, err
+		}
+
+		organizationVouchers = append(organizationVouchers, OrganizationVoucher{
+			CreatedAt: organizationVoucher.CreationTimestamp.Time,
+			Code:      organizationVoucher.Spec.Code,
+			Name:      organizationVoucher.Name,
+			PoolName:  organizationVoucherPool.Name,
+		})
+	}
+
+	return &organizationVouchers, nil
+}
@@ -0,0 +1,487 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	apitypes "github.com/sudoswedenab/dockyards-api/pkg/types"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/handlers"
+	"github.com/sudoswedenab/dockyards-backend/pkg/testing/testingutil"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestGlobalOrganizationVouchers_Create(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("no kubebuilder assets configured")
+	}
+
+	mgr := testEnvironment.GetManager()
+	c := testEnvironment.GetClient()
+
+	dockyardsNamespace := testEnvironment.GetDockyardsNamespace()
+
+	organization := testEnvironment.MustCreateOrganization(t)
+
+	superUser := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleSuperUser)
+	admin := testEnvironment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleReader)
+
+	superUserToken := MustSignToken(t, superUser.Name)
+	adminToken := MustSignToken(t, admin.Name)
+
+	role := rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    dockyardsNamespace,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{dockyardsv1.GroupVersion.Group},
+				Resources: []string{"organizationvouchers"},
+				Verbs:     []string{"create"},
+			},
+		},
+	}
+
+	err := c.Create(ctx, &role)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roleBinding := rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    dockyardsNamespace,
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     role.Name,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				APIGroup: rbacv1.GroupName,
+				Kind:     rbacv1.UserKind,
+				Name:     admin.Name,
+			},
+		},
+	}
+
+	err = c.Create(ctx, &roleBinding)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	organizationVoucherPool := dockyardsv1.OrganizationVoucherPool{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    dockyardsNamespace,
+		},
+		Spec: dockyardsv1.OrganizationVoucherPoolSpec{
+			MaxRedemptions: 2,
+		},
+	}
+
+	err = c.Create(ctx, &organizationVoucherPool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = testingutil.RetryUntilFound(ctx, mgr.GetClient(), &organizationVoucherPool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test as admin", func(t *testing.T) {
+		options := handlers.OrganizationVoucherOptions{
+			PoolName: organizationVoucherPool.Name,
+			Count:    3,
+		}
+
+		b, err := json.Marshal(&options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/organization-vouchers", bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+adminToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusCreated {
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+		}
+
+		b, err = io.ReadAll(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual []handlers.OrganizationVoucher
+		err = json.Unmarshal(b, &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(actual) != 3 {
+			t.Fatalf("expected 3 vouchers, got %d", len(actual))
+		}
+
+		codes := make(map[string]bool)
+
+		for _, item := range actual {
+			var organizationVoucher dockyardsv1.OrganizationVoucher
+			err := c.Get(ctx, client.ObjectKey{Name: item.Name, Namespace: dockyardsNamespace}, &organizationVoucher)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := dockyardsv1.OrganizationVoucherSpec{
+				Code: item.Code,
+				PoolRef: &corev1.TypedObjectReference{
+					APIGroup: ptr.To(dockyardsv1.GroupVersion.Group),
+					Kind:     dockyardsv1.OrganizationVoucherPoolKind,
+					Name:     organizationVoucherPool.Name,
+				},
+			}
+
+			if !cmp.Equal(organizationVoucher.Spec, expected) {
+				t.Errorf("diff: %s", cmp.Diff(expected, organizationVoucher.Spec))
+			}
+
+			codes[item.Code] = true
+		}
+
+		if len(codes) != 3 {
+			t.Errorf("expected 3 unique codes, got %d", len(codes))
+		}
+	})
+
+	t.Run("test as super user", func(t *testing.T) {
+		options := handlers.OrganizationVoucherOptions{
+			PoolName: organizationVoucherPool.Name,
+			Count:    1,
+		}
+
+		b, err := json.Marshal(&options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/organization-vouchers", bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+superUserToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, statusCode)
+		}
+	})
+
+	t.Run("test missing pool", func(t *testing.T) {
+		options := handlers.OrganizationVoucherOptions{
+			PoolName: "missing",
+			Count:    1,
+		}
+
+		b, err := json.Marshal(&options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/organization-vouchers", bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+adminToken)
+
+		mux.ServeHTTP(w, r)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnprocessableEntity, statusCode)
+		}
+	})
+}
+
+func TestGlobalOrganizations_CreateWithVoucherPool(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("no kubebuilder assets configured")
+	}
+
+	mgr := testEnvironment.GetManager()
+	c := testEnvironment.GetClient()
+
+	dockyardsNamespace := testEnvironment.GetDockyardsNamespace()
+
+	user := dockyardsv1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+		},
+	}
+
+	err := c.Create(ctx, &user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userToken := MustSignToken(t, user.Name)
+
+	err = testingutil.RetryUntilFound(ctx, mgr.GetClient(), &user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createOrganization := func(t *testing.T, code string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		request := apitypes.OrganizationOptions{
+			VoucherCode: &code,
+		}
+
+		b, err := json.Marshal(&request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/orgs", bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+userToken)
+
+		mux.ServeHTTP(w, r)
+
+		return w
+	}
+
+	createVoucher := func(t *testing.T, organizationVoucherPool *dockyardsv1.OrganizationVoucherPool, code string) *dockyardsv1.OrganizationVoucher {
+		t.Helper()
+
+		organizationVoucher := dockyardsv1.OrganizationVoucher{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    dockyardsNamespace,
+			},
+			Spec: dockyardsv1.OrganizationVoucherSpec{
+				Code: code,
+				PoolRef: &corev1.TypedObjectReference{
+					APIGroup: ptr.To(dockyardsv1.GroupVersion.Group),
+					Kind:     dockyardsv1.OrganizationVoucherPoolKind,
+					Name:     organizationVoucherPool.Name,
+				},
+			},
+		}
+
+		err := c.Create(ctx, &organizationVoucher)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = testingutil.RetryUntilFound(ctx, mgr.GetClient(), &organizationVoucher)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return &organizationVoucher
+	}
+
+	t.Run("test multiple redemptions", func(t *testing.T) {
+		organizationVoucherPool := dockyardsv1.OrganizationVoucherPool{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    dockyardsNamespace,
+			},
+			Spec: dockyardsv1.OrganizationVoucherPoolSpec{
+				MaxRedemptions: 2,
+				Duration: &metav1.Duration{
+					Duration: time.Hour * 24 * 30,
+				},
+				ResourceQuotas: corev1.ResourceList{
+					dockyardsv1.ResourceCluster: resource.MustParse("1"),
+				},
+				Features: []string{
+					"testing",
+				},
+			},
+		}
+
+		err := c.Create(ctx, &organizationVoucherPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = testingutil.RetryUntilFound(ctx, mgr.GetClient(), &organizationVoucherPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		organizationVoucher := createVoucher(t, &organizationVoucherPool, "TEST-POOL-MULTIPLE")
+
+		var organizationNames []string
+
+		for range 2 {
+			w := createOrganization(t, organizationVoucher.Spec.Code)
+
+			statusCode := w.Result().StatusCode
+			if statusCode != http.StatusCreated {
+				t.Fatalf("expected status code %d, got %d", http.StatusCreated, statusCode)
+			}
+
+			var response apitypes.Organization
+			err := json.NewDecoder(w.Result().Body).Decode(&response)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var organization dockyardsv1.Organization
+			err = c.Get(ctx, client.ObjectKey{Name: response.Name}, &organization)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !cmp.Equal(organization.Spec.Duration, organizationVoucherPool.Spec.Duration) {
+				t.Errorf("diff: %s", cmp.Diff(organizationVoucherPool.Spec.Duration, organization.Spec.Duration))
+			}
+
+			quantity := organization.Spec.ResourceQuotas[dockyardsv1.ResourceCluster]
+			if quantity.String() != "1" {
+				t.Errorf("expected cluster quota 1, got %s", quantity.String())
+			}
+
+			var feature dockyardsv1.Feature
+			err = c.Get(ctx, client.ObjectKey{Name: "testing", Namespace: organization.Spec.NamespaceRef.Name}, &feature)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			organizationNames = append(organizationNames, organization.Name)
+
+			err = testingutil.RetryUntilFound(ctx, mgr.GetClient(), &organization)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		w := createOrganization(t, organizationVoucher.Spec.Code)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnprocessableEntity, statusCode)
+		}
+
+		err = c.Get(ctx, client.ObjectKeyFromObject(organizationVoucher), organizationVoucher)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !organizationVoucher.Status.Redeemed {
+			t.Errorf("expected voucher to be redeemed")
+		}
+
+		var actual []string
+		for _, redemption := range organizationVoucher.Status.Redemptions {
+			actual = append(actual, redemption.OrganizationRef.Name)
+		}
+
+		if !cmp.Equal(actual, organizationNames) {
+			t.Errorf("diff: %s", cmp.Diff(organizationNames, actual))
+		}
+	})
+
+	t.Run("test expired pool", func(t *testing.T) {
+		organizationVoucherPool := dockyardsv1.OrganizationVoucherPool{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    dockyardsNamespace,
+			},
+			Spec: dockyardsv1.OrganizationVoucherPoolSpec{
+				NotAfter: &metav1.Time{
+					Time: time.Now().Add(-time.Hour),
+				},
+			},
+		}
+
+		err := c.Create(ctx, &organizationVoucherPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = testingutil.RetryUntilFound(ctx, mgr.GetClient(), &organizationVoucherPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		organizationVoucher := createVoucher(t, &organizationVoucherPool, "TEST-POOL-EXPIRED")
+
+		w := createOrganization(t, organizationVoucher.Spec.Code)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnprocessableEntity, statusCode)
+		}
+	})
+
+	t.Run("test pool not valid yet", func(t *testing.T) {
+		organizationVoucherPool := dockyardsv1.OrganizationVoucherPool{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    dockyardsNamespace,
+			},
+			Spec: dockyardsv1.OrganizationVoucherPoolSpec{
+				NotBefore: &metav1.Time{
+					Time: time.Now().Add(time.Hour),
+				},
+			},
+		}
+
+		err := c.Create(ctx, &organizationVoucherPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = testingutil.RetryUntilFound(ctx, mgr.GetClient(), &organizationVoucherPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		organizationVoucher := createVoucher(t, &organizationVoucherPool, "TEST-POOL-NOT-BEFORE")
+
+		w := createOrganization(t, organizationVoucher.Spec.Code)
+
+		statusCode := w.Result().StatusCode
+		if statusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnprocessableEntity, statusCode)
+		}
+	})
+}
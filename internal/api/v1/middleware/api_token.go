@@ -0,0 +1,229 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=dockyards.io,resources=apitokens,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=serviceaccounts,verbs=get;list;watch
+
+// HashAPIToken returns the hash stored for an api token.
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseAPIToken returns the name of the api token resource, tokens have the format prefix, name, a dot
+// and the secret.
+func ParseAPIToken(token string) (string, error) {
+	s, found := strings.CutPrefix(token, dockyardsv1.APITokenPrefix)
+	if !found {
+		return "", errors.New("missing api token prefix")
+	}
+
+	i := strings.LastIndex(s, ".")
+	if i < 1 || i == len(s)-1 {
+		return "", errors.New("malformed api token")
+	}
+
+	return s[:i], nil
+}
+
+func (a *RequireAuth) serveAPIToken(w http.ResponseWriter, r *http.Request, next http.Handler, bearerToken string) {
+	ctx := r.Context()
+
+	logger := LoggerFrom(ctx)
+
+	tokenName, err := ParseAPIToken(bearerToken)
+	if err != nil {
+		logger.Debug("error parsing api token", "err", err)
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	var apiToken dockyardsv1.APIToken
+	err = a.reader.Get(ctx, client.ObjectKey{Name: tokenName}, &apiToken)
+	if client.IgnoreNotFound(err) != nil {
+		logger.Error("error getting api token", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if err != nil {
+		logger.Debug("api token not found", "name", tokenName)
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	hash := HashAPIToken(bearerToken)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(apiToken.Spec.TokenHash)) != 1 {
+		logger.Debug("api token hash mismatch", "name", tokenName)
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	if !apiToken.DeletionTimestamp.IsZero() || apiutil.HasExpired(&apiToken) {
+		logger.Debug("api token expired", "name", tokenName)
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	if !isAllowedByScope(r, apiToken.Spec.Scope) {
+		logger.Debug("request not allowed by api token scope", "name", tokenName, "method", r.Method, "path", r.URL.Path)
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	if apiToken.Spec.ServiceAccountRef != nil {
+		ok, err := a.hasServiceAccount(ctx, apiToken.Spec.ServiceAccountRef)
+		if err != nil {
+			logger.Error("error getting service account", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if !ok {
+			logger.Debug("api token service account not found", "name", tokenName)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+	}
+
+	subject := apiToken.GetSubject()
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: subject,
+			ID:      apiToken.Name,
+		},
+		AuthenticationMethods: apiToken.Spec.AuthenticationMethods,
+	}
+
+	if !a.allowedWithoutMFA(w, r, subject, &claims) {
+		return
+	}
+
+	setAuditSubject(ctx, subject)
+
+	ctx = context.WithValue(ctx, sub, subject)
+	ctx = context.WithValue(ctx, clm, &claims)
+	ctx = context.WithValue(ctx, tkn, &apiToken)
+
+	r = r.Clone(ctx)
+
+	next.ServeHTTP(w, r)
+}
+
+// hasServiceAccount checks that the service account of an api token exists and is not being deleted, api tokens
+// are removed together with their service account but that happens asynchronously.
+func (a *RequireAuth) hasServiceAccount(ctx context.Context, ref *corev1.TypedObjectReference) (bool, error) {
+	key := client.ObjectKey{
+		Name:      ref.Name,
+		Namespace: ptr.Deref(ref.Namespace, ""),
+	}
+
+	var serviceAccount dockyardsv1.ServiceAccount
+	err := a.reader.Get(ctx, key, &serviceAccount)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return serviceAccount.DeletionTimestamp.IsZero(), nil
+}
+
+func verbFromMethod(method string) dockyardsv1.APITokenVerb {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return dockyardsv1.APITokenVerbGet
+	case http.MethodPost:
+		return dockyardsv1.APITokenVerbCreate
+	case http.MethodPut, http.MethodPatch:
+		return dockyardsv1.APITokenVerbUpdate
+	case http.MethodDelete:
+		return dockyardsv1.APITokenVerbDelete
+	}
+
+	return ""
+}
+
+// isAllowedByScope checks the request against the scope of the api token, requests scoped to organizations
+// may only read global resources.
+func isAllowedByScope(r *http.Request, scope *dockyardsv1.APITokenScope) bool {
+	if scope == nil {
+		return true
+	}
+
+	verb := verbFromMethod(r.Method)
+
+	if len(scope.Verbs) > 0 && !slices.Contains(scope.Verbs, verb) {
+		return false
+	}
+
+	if len(scope.Organizations) == 0 {
+		return true
+	}
+
+	organizationName := organizationNameFrom(r)
+	if organizationName == "" {
+		return verb == dockyardsv1.APITokenVerbGet
+	}
+
+	return slices.Contains(scope.Organizations, organizationName)
+}
+
+func ContextWithAPIToken(parent context.Context, apiToken *dockyardsv1.APIToken) context.Context {
+	return context.WithValue(parent, tkn, apiToken)
+}
+
+// APITokenFrom returns the api token used to authenticate the request, or nil if the request was
+// authenticated using a signed token.
+func APITokenFrom(ctx context.Context) *dockyardsv1.APIToken {
+	apiToken, ok := ctx.Value(tkn).(*dockyardsv1.APIToken)
+	if !ok {
+		return nil
+	}
+
+	return apiToken
+}
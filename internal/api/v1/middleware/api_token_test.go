@@ -0,0 +1,285 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware_test
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRequireAuthAPIToken(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = dockyardsv1.AddToScheme(scheme)
+
+	keyfunc := func(*jwt.Token) (any, error) {
+		return nil, errors.New("no keys")
+	}
+
+	serviceAccount := dockyardsv1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "automation",
+			Namespace: "testing",
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		apiToken dockyardsv1.APIToken
+		token    string
+		method   string
+		pattern  string
+		path     string
+		expected int
+	}{
+		{
+			name: "test valid token",
+			apiToken: dockyardsv1.APIToken{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-abcde",
+					CreationTimestamp: metav1.Now(),
+				},
+				Spec: dockyardsv1.APITokenSpec{
+					TokenHash: middleware.HashAPIToken("dyt_test-abcde.secret"),
+				},
+			},
+			token:    "dyt_test-abcde.secret",
+			method:   http.MethodGet,
+			pattern:  "GET /v1/whoami",
+			path:     "/v1/whoami",
+			expected: http.StatusOK,
+		},
+		{
+			name: "test invalid secret",
+			apiToken: dockyardsv1.APIToken{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-abcde",
+					CreationTimestamp: metav1.Now(),
+				},
+				Spec: dockyardsv1.APITokenSpec{
+					TokenHash: middleware.HashAPIToken("dyt_test-abcde.secret"),
+				},
+			},
+			token:    "dyt_test-abcde.invalid",
+			method:   http.MethodGet,
+			pattern:  "GET /v1/whoami",
+			path:     "/v1/whoami",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name: "test expired token",
+			apiToken: dockyardsv1.APIToken{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-abcde",
+					CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour * 2)),
+				},
+				Spec: dockyardsv1.APITokenSpec{
+					TokenHash: middleware.HashAPIToken("dyt_test-abcde.secret"),
+					Duration: &metav1.Duration{
+						Duration: time.Hour,
+					},
+				},
+			},
+			token:    "dyt_test-abcde.secret",
+			method:   http.MethodGet,
+			pattern:  "GET /v1/whoami",
+			path:     "/v1/whoami",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name: "test organization in scope",
+			apiToken: dockyardsv1.APIToken{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-abcde",
+					CreationTimestamp: metav1.Now(),
+				},
+				Spec: dockyardsv1.APITokenSpec{
+					TokenHash: middleware.HashAPIToken("dyt_test-abcde.secret"),
+					Scope: &dockyardsv1.APITokenScope{
+						Organizations: []string{
+							"test",
+						},
+					},
+				},
+			},
+			token:    "dyt_test-abcde.secret",
+			method:   http.MethodPost,
+			pattern:  "POST /v1/orgs/{organizationName}/clusters",
+			path:     "/v1/orgs/test/clusters",
+			expected: http.StatusOK,
+		},
+		{
+			name: "test organization out of scope",
+			apiToken: dockyardsv1.APIToken{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-abcde",
+					CreationTimestamp: metav1.Now(),
+				},
+				Spec: dockyardsv1.APITokenSpec{
+					TokenHash: middleware.HashAPIToken("dyt_test-abcde.secret"),
+					Scope: &dockyardsv1.APITokenScope{
+						Organizations: []string{
+							"test",
+						},
+					},
+				},
+			},
+			token:    "dyt_test-abcde.secret",
+			method:   http.MethodDelete,
+			pattern:  "DELETE /v1/orgs/{resourceName}",
+			path:     "/v1/orgs/other",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name: "test global create with organization scope",
+			apiToken: dockyardsv1.APIToken{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-abcde",
+					CreationTimestamp: metav1.Now(),
+				},
+				Spec: dockyardsv1.APITokenSpec{
+					TokenHash: middleware.HashAPIToken("dyt_test-abcde.secret"),
+					Scope: &dockyardsv1.APITokenScope{
+						Organizations: []string{
+							"test",
+						},
+					},
+				},
+			},
+			token:    "dyt_test-abcde.secret",
+			method:   http.MethodPost,
+			pattern:  "POST /v1/orgs",
+			path:     "/v1/orgs",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name: "test verb out of scope",
+			apiToken: dockyardsv1.APIToken{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-abcde",
+					CreationTimestamp: metav1.Now(),
+				},
+				Spec: dockyardsv1.APITokenSpec{
+					TokenHash: middleware.HashAPIToken("dyt_test-abcde.secret"),
+					Scope: &dockyardsv1.APITokenScope{
+						Verbs: []dockyardsv1.APITokenVerb{
+							dockyardsv1.APITokenVerbGet,
+						},
+					},
+				},
+			},
+			token:    "dyt_test-abcde.secret",
+			method:   http.MethodPost,
+			pattern:  "POST /v1/orgs/{organizationName}/clusters",
+			path:     "/v1/orgs/test/clusters",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name: "test service account token",
+			apiToken: dockyardsv1.APIToken{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "automation-abcde",
+					CreationTimestamp: metav1.Now(),
+				},
+				Spec: dockyardsv1.APITokenSpec{
+					ServiceAccountRef: &corev1.TypedObjectReference{
+						Kind:      dockyardsv1.ServiceAccountKind,
+						Name:      "automation",
+						Namespace: ptr.To("testing"),
+					},
+					TokenHash: middleware.HashAPIToken("dyt_automation-abcde.secret"),
+				},
+			},
+			token:    "dyt_automation-abcde.secret",
+			method:   http.MethodGet,
+			pattern:  "GET /v1/orgs/{organizationName}/clusters",
+			path:     "/v1/orgs/test/clusters",
+			expected: http.StatusOK,
+		},
+		{
+			name: "test missing service account",
+			apiToken: dockyardsv1.APIToken{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "deleted-abcde",
+					CreationTimestamp: metav1.Now(),
+				},
+				Spec: dockyardsv1.APITokenSpec{
+					ServiceAccountRef: &corev1.TypedObjectReference{
+						Kind:      dockyardsv1.ServiceAccountKind,
+						Name:      "deleted",
+						Namespace: ptr.To("testing"),
+					},
+					TokenHash: middleware.HashAPIToken("dyt_deleted-abcde.secret"),
+				},
+			},
+			token:    "dyt_deleted-abcde.secret",
+			method:   http.MethodGet,
+			pattern:  "GET /v1/orgs/{organizationName}/clusters",
+			path:     "/v1/orgs/test/clusters",
+			expected: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&tc.apiToken, &serviceAccount).Build()
+
+			logger := middleware.NewLogger(slog.New(slog.DiscardHandler)).Handler
+			requireAuth := middleware.NewRequireAuth(keyfunc, middleware.WithAPITokens(c)).Handler
+
+			mux := http.NewServeMux()
+			mux.Handle(tc.pattern, logger(requireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				subject, err := middleware.SubjectFrom(r.Context())
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if subject != tc.apiToken.GetSubject() {
+					t.Errorf("expected subject %s, got %s", tc.apiToken.GetSubject(), subject)
+				}
+
+				if middleware.APITokenFrom(r.Context()) == nil {
+					t.Error("expected api token in context")
+				}
+
+				w.WriteHeader(http.StatusOK)
+			}))))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tc.method, tc.path, nil)
+
+			r.Header.Add("Authorization", "Bearer "+tc.token)
+
+			mux.ServeHTTP(w, r)
+
+			statusCode := w.Result().StatusCode
+			if statusCode != tc.expected {
+				t.Errorf("expected status code %d, got %d", tc.expected, statusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sudoswedenab/dockyards-backend/internal/audit"
+)
+
+type Audit struct {
+	recorder       *audit.Recorder
+	withoutDigests bool
+}
+
+type AuditOption func(*Audit)
+
+// WithoutRequestDigest makes the middleware record events without the digest of the request body, routes with
+// bodies containing passwords, codes or tokens must not store digests since they can be compared to guesses.
+func WithoutRequestDigest() AuditOption {
+	return func(a *Audit) {
+		a.withoutDigests = true
+	}
+}
+
+var auditVerbs = map[string]string{
+	http.MethodPost:   "create",
+	http.MethodPut:    "update",
+	http.MethodPatch:  "patch",
+	http.MethodDelete: "delete",
+}
+
+// Handler records requests with mutating methods, the subject is set on the event by the authentication
+// middleware since it runs after the audit middleware.
+func (a *Audit) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verb, mutating := auditVerbs[r.Method]
+		if a.recorder == nil || !mutating {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		event := audit.Event{
+			Timestamp:    time.Now(),
+			Organization: organizationNameFrom(r),
+			Cluster:      r.PathValue("clusterName"),
+			Name:         r.PathValue("resourceName"),
+			Verb:         verb,
+			Method:       r.Method,
+			Path:         r.URL.Path,
+		}
+
+		event.Resource, event.Subresource = resourceFromPattern(r.Pattern)
+
+		if r.Body != nil && !a.withoutDigests {
+			b, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			if len(b) > 0 {
+				digest := sha256.Sum256(b)
+				event.RequestDigest = "sha256:" + hex.EncodeToString(digest[:])
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(b))
+		}
+
+		statusResponseWriter := StatusResponseWriter{
+			responseWriter: w,
+		}
+
+		ctx := context.WithValue(r.Context(), aud, &event)
+
+		r = r.Clone(ctx)
+
+		next.ServeHTTP(&statusResponseWriter, r)
+
+		event.StatusCode = statusResponseWriter.statusCode
+		if event.StatusCode == 0 {
+			event.StatusCode = http.StatusOK
+		}
+
+		a.recorder.Record(&event)
+	})
+}
+
+// AuditEventFrom returns the audit event of the request in the context, or nil when the request is not audited.
+func AuditEventFrom(ctx context.Context) *audit.Event {
+	event, ok := ctx.Value(aud).(*audit.Event)
+	if !ok {
+		return nil
+	}
+
+	return event
+}
+
+// setAuditSubject sets the subject of the audit event in the context, if any.
+func setAuditSubject(ctx context.Context, subject string) {
+	event := AuditEventFrom(ctx)
+	if event == nil {
+		return
+	}
+
+	event.Subject = subject
+}
+
+// resourceFromPattern returns the resource and subresource of a route pattern, the resource is the segment
+// before the resource name wildcard or the last segment when the pattern has no resource name.
+func resourceFromPattern(pattern string) (string, string) {
+	_, path, found := strings.Cut(pattern, " ")
+	if !found {
+		path = pattern
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, segment := range segments {
+		if segment != "{resourceName}" || i == 0 {
+			continue
+		}
+
+		return segments[i-1], strings.Join(segments[i+1:], "/")
+	}
+
+	for i := len(segments) - 1; i >= 0; i-- {
+		if !strings.HasPrefix(segments[i], "{") {
+			return segments[i], ""
+		}
+	}
+
+	return "", ""
+}
+
+func NewAudit(recorder *audit.Recorder, options ...AuditOption) *Audit {
+	a := Audit{
+		recorder: recorder,
+	}
+
+	for _, option := range options {
+		option(&a)
+	}
+
+	return &a
+}
@@ -0,0 +1,219 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	"github.com/sudoswedenab/dockyards-backend/internal/audit"
+)
+
+type collectingSink struct {
+	events []audit.Event
+}
+
+func (s *collectingSink) Write(_ context.Context, event *audit.Event) error {
+	s.events = append(s.events, *event)
+
+	return nil
+}
+
+func TestAudit(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyfunc := func(*jwt.Token) (any, error) {
+		return &privateKey.PublicKey, nil
+	}
+
+	claims := middleware.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "test",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, &claims).SignedString(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		method   string
+		pattern  string
+		path     string
+		body     string
+		options  []middleware.AuditOption
+		expected []audit.Event
+	}{
+		{
+			name:    "test create cluster resource",
+			method:  http.MethodPost,
+			pattern: "POST /v1/orgs/{organizationName}/clusters/{clusterName}/node-pools",
+			path:    "/v1/orgs/test-org/clusters/test-cluster/node-pools",
+			body:    `{"name":"test"}`,
+			expected: []audit.Event{
+				{
+					Subject:       "test",
+					Organization:  "test-org",
+					Cluster:       "test-cluster",
+					Resource:      "node-pools",
+					Verb:          "create",
+					Method:        http.MethodPost,
+					Path:          "/v1/orgs/test-org/clusters/test-cluster/node-pools",
+					RequestDigest: "sha256:7d9fd2051fc32b32feab10946fab6bb91426ab7e39aa5439289ed892864aa91d",
+					StatusCode:    http.StatusCreated,
+				},
+			},
+		},
+		{
+			name:    "test delete organization",
+			method:  http.MethodDelete,
+			pattern: "DELETE /v1/orgs/{resourceName}",
+			path:    "/v1/orgs/test-org",
+			expected: []audit.Event{
+				{
+					Subject:      "test",
+					Organization: "test-org",
+					Resource:     "orgs",
+					Name:         "test-org",
+					Verb:         "delete",
+					Method:       http.MethodDelete,
+					Path:         "/v1/orgs/test-org",
+					StatusCode:   http.StatusCreated,
+				},
+			},
+		},
+		{
+			name:    "test subresource",
+			method:  http.MethodPost,
+			pattern: "POST /v1/orgs/{organizationName}/invitations/{resourceName}/resend",
+			path:    "/v1/orgs/test-org/invitations/test-invitation/resend",
+			expected: []audit.Event{
+				{
+					Subject:      "test",
+					Organization: "test-org",
+					Resource:     "invitations",
+					Subresource:  "resend",
+					Name:         "test-invitation",
+					Verb:         "create",
+					Method:       http.MethodPost,
+					Path:         "/v1/orgs/test-org/invitations/test-invitation/resend",
+					StatusCode:   http.StatusCreated,
+				},
+			},
+		},
+		{
+			name:    "test without request digest",
+			method:  http.MethodPost,
+			pattern: "POST /v1/users/{resourceName}/password",
+			path:    "/v1/users/test/password",
+			body:    `{"password":"test"}`,
+			options: []middleware.AuditOption{
+				middleware.WithoutRequestDigest(),
+			},
+			expected: []audit.Event{
+				{
+					Subject:     "test",
+					Resource:    "users",
+					Subresource: "password",
+					Name:        "test",
+					Verb:        "create",
+					Method:      http.MethodPost,
+					Path:        "/v1/users/test/password",
+					StatusCode:  http.StatusCreated,
+				},
+			},
+		},
+		{
+			name:     "test get",
+			method:   http.MethodGet,
+			pattern:  "GET /v1/orgs/{organizationName}/clusters",
+			path:     "/v1/orgs/test-org/clusters",
+			expected: []audit.Event{},
+		},
+	}
+
+	ignoreTimestamp := cmpopts.IgnoreFields(audit.Event{}, "Timestamp")
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sink := collectingSink{
+				events: []audit.Event{},
+			}
+
+			recorder, err := audit.NewRecorder(10, audit.WithSinks(&sink))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			logger := middleware.NewLogger(slog.New(slog.DiscardHandler)).Handler
+			auditHandler := middleware.NewAudit(recorder, tc.options...).Handler
+			requireAuth := middleware.NewRequireAuth(keyfunc).Handler
+
+			var body string
+
+			mux := http.NewServeMux()
+			mux.Handle(tc.pattern, logger(auditHandler(requireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				body = string(b)
+
+				w.WriteHeader(http.StatusCreated)
+			})))))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+
+			r.Header.Add("Authorization", "Bearer "+token)
+
+			mux.ServeHTTP(w, r)
+
+			if body != tc.body {
+				t.Errorf("expected handler to read body %q, got %q", tc.body, body)
+			}
+
+			// Starting the recorder with a done context writes the queued events before returning.
+			ctx, cancel := context.WithCancel(t.Context())
+			cancel()
+
+			err = recorder.Start(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := sink.events
+			if !cmp.Equal(actual, tc.expected, ignoreTimestamp) {
+				t.Errorf("diff: %s", cmp.Diff(tc.expected, actual, ignoreTimestamp))
+			}
+		})
+	}
+}
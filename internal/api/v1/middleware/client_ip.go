@@ -0,0 +1,64 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type ClientIP struct {
+	trustForwardedFor bool
+}
+
+// Handler stores the address of the client in the context, when running behind a reverse proxy the last
+// address of the X-Forwarded-For header is used since any address before it can be set by the client.
+func (c *ClientIP) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			clientIP = r.RemoteAddr
+		}
+
+		forwardedFor := r.Header.Get("X-Forwarded-For")
+		if c.trustForwardedFor && forwardedFor != "" {
+			addresses := strings.Split(forwardedFor, ",")
+			clientIP = strings.TrimSpace(addresses[len(addresses)-1])
+		}
+
+		ctx := context.WithValue(r.Context(), cip, clientIP)
+
+		r = r.Clone(ctx)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClientIPFrom returns the address of the client, or an empty string if unknown.
+func ClientIPFrom(ctx context.Context) string {
+	clientIP, _ := ctx.Value(cip).(string)
+
+	return clientIP
+}
+
+func NewClientIP(trustForwardedFor bool) *ClientIP {
+	c := ClientIP{
+		trustForwardedFor: trustForwardedFor,
+	}
+
+	return &c
+}
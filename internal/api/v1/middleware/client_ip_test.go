@@ -0,0 +1,73 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+)
+
+func TestClientIP(t *testing.T) {
+	testCases := []struct {
+		name              string
+		trustForwardedFor bool
+		forwardedFor      string
+		expected          string
+	}{
+		{
+			name:     "test remote address",
+			expected: "192.0.2.1",
+		},
+		{
+			name:         "test untrusted forwarded for",
+			forwardedFor: "198.51.100.1",
+			expected:     "192.0.2.1",
+		},
+		{
+			name:              "test trusted forwarded for",
+			trustForwardedFor: true,
+			forwardedFor:      "203.0.113.1, 198.51.100.1",
+			expected:          "198.51.100.1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var actual string
+
+			clientIP := middleware.NewClientIP(tc.trustForwardedFor).Handler
+
+			h := clientIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				actual = middleware.ClientIPFrom(r.Context())
+			}))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/v1/login", nil)
+
+			if tc.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", tc.forwardedFor)
+			}
+
+			h.ServeHTTP(w, r)
+
+			if actual != tc.expected {
+				t.Errorf("expected client ip %s, got %s", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -19,4 +19,8 @@ type key int
 const (
 	sub key = iota
 	log
+	clm
+	tkn
+	cip
+	aud
 )
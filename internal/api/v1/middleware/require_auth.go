@@ -16,17 +16,60 @@ package middleware
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"errors"
-	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	AuthenticationMethodPassword  = "pwd"
+	AuthenticationMethodOTP       = "otp"
+	AuthenticationMethodFederated = "fed"
 )
 
 type RequireAuth struct {
-	publicKey *ecdsa.PublicKey
+	keyfunc   jwt.Keyfunc
+	reader    client.Reader
+	mfaReader client.Reader
+}
+
+type RequireAuthOption func(*RequireAuth)
+
+// WithAPITokens makes the middleware accept api tokens in addition to signed tokens.
+func WithAPITokens(reader client.Reader) RequireAuthOption {
+	return func(a *RequireAuth) {
+		a.reader = reader
+	}
+}
+
+// WithOrganizationMFA makes the middleware deny signed tokens and api tokens without multi-factor
+// authentication access to organizations that require it.
+func WithOrganizationMFA(reader client.Reader) RequireAuthOption {
+	return func(a *RequireAuth) {
+		a.mfaReader = reader
+	}
+}
+
+// Claims are the claims of tokens signed by dockyards, the session id is only set for tokens issued
+// as part of a session.
+type Claims struct {
+	jwt.RegisteredClaims
+
+	SessionID string `json:"sid,omitempty"`
+
+	// AuthenticationMethods are the methods used to authenticate the subject as described in RFC 8176.
+	AuthenticationMethods []string `json:"amr,omitempty"`
+}
+
+// HasMFA returns true if the subject authenticated using a second factor, federated logins are trusted to
+// have enforced it at the identity provider.
+func (c *Claims) HasMFA() bool {
+	return slices.Contains(c.AuthenticationMethods, AuthenticationMethodOTP) || slices.Contains(c.AuthenticationMethods, AuthenticationMethodFederated)
 }
 
 func (a *RequireAuth) Handler(next http.Handler) http.Handler {
@@ -43,14 +86,13 @@ func (a *RequireAuth) Handler(next http.Handler) http.Handler {
 
 		bearerToken := strings.TrimPrefix(authorizationHeader, "Bearer ")
 
-		token, err := jwt.ParseWithClaims(bearerToken, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-			_, ok := token.Method.(*jwt.SigningMethodECDSA)
-			if !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
+		if a.reader != nil && strings.HasPrefix(bearerToken, dockyardsv1.APITokenPrefix) {
+			a.serveAPIToken(w, r, next, bearerToken)
 
-			return a.publicKey, nil
-		})
+			return
+		}
+
+		token, err := jwt.ParseWithClaims(bearerToken, &Claims{}, a.keyfunc)
 		if err != nil {
 			logger.Error("error parsing bearer token", "err", err)
 			w.WriteHeader(http.StatusUnauthorized)
@@ -58,12 +100,21 @@ func (a *RequireAuth) Handler(next http.Handler) http.Handler {
 			return
 		}
 
-		claims, ok := token.Claims.(*jwt.RegisteredClaims)
+		claims, ok := token.Claims.(*Claims)
 		if !ok && !token.Valid {
 			logger.Debug("invalid claims")
 			w.WriteHeader(http.StatusUnauthorized)
 		}
 
+		// Tokens with an audience are signed for other purposes, such as single sign-on exchange codes, and
+		// are never accepted as bearer tokens.
+		if len(claims.Audience) > 0 {
+			logger.Debug("unexpected audience", "audience", claims.Audience)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
 		subject, err := claims.GetSubject()
 		if err != nil {
 			logger.Debug("error getting subject from claim", "err", err)
@@ -72,7 +123,14 @@ func (a *RequireAuth) Handler(next http.Handler) http.Handler {
 			return
 		}
 
+		if !a.allowedWithoutMFA(w, r, subject, claims) {
+			return
+		}
+
+		setAuditSubject(r.Context(), subject)
+
 		ctx := context.WithValue(r.Context(), sub, subject)
+		ctx = context.WithValue(ctx, clm, claims)
 
 		r = r.Clone(ctx)
 
@@ -80,6 +138,59 @@ func (a *RequireAuth) Handler(next http.Handler) http.Handler {
 	})
 }
 
+// allowedWithoutMFA returns true if the claims satisfy the multi-factor authentication requirement of the
+// organization the request is scoped to, otherwise a status code has been written to the response.
+func (a *RequireAuth) allowedWithoutMFA(w http.ResponseWriter, r *http.Request, subject string, claims *Claims) bool {
+	if a.mfaReader == nil || claims.HasMFA() {
+		return true
+	}
+
+	logger := LoggerFrom(r.Context())
+
+	required, err := a.isMFARequired(r)
+	if err != nil {
+		logger.Error("error checking organization mfa requirement", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return false
+	}
+
+	if required {
+		logger.Debug("organization requires multi-factor authentication", "subject", subject, "path", r.URL.Path)
+		w.WriteHeader(http.StatusForbidden)
+
+		return false
+	}
+
+	return true
+}
+
+func (a *RequireAuth) isMFARequired(r *http.Request) (bool, error) {
+	organizationName := organizationNameFrom(r)
+	if organizationName == "" {
+		return false, nil
+	}
+
+	var organization dockyardsv1.Organization
+	err := a.mfaReader.Get(r.Context(), client.ObjectKey{Name: organizationName}, &organization)
+	if err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	return organization.Spec.RequireMFA, nil
+}
+
+// organizationNameFrom returns the name of the organization a request is scoped to, or an empty string for
+// global requests.
+func organizationNameFrom(r *http.Request) string {
+	organizationName := r.PathValue("organizationName")
+	if organizationName == "" && strings.HasPrefix(r.URL.Path, "/v1/orgs/") {
+		organizationName = r.PathValue("resourceName")
+	}
+
+	return organizationName
+}
+
 func ContextWithSubject(parent context.Context, subject string) context.Context {
 	return context.WithValue(parent, sub, subject)
 }
@@ -98,9 +209,33 @@ func SubjectFrom(ctx context.Context) (string, error) {
 	return sub, nil
 }
 
-func NewRequireAuth(publicKey *ecdsa.PublicKey) *RequireAuth {
+func ContextWithClaims(parent context.Context, claims *Claims) context.Context {
+	return context.WithValue(parent, clm, claims)
+}
+
+func ClaimsFrom(ctx context.Context) (*Claims, error) {
+	v := ctx.Value(clm)
+	if v == nil {
+		return nil, errors.New("error fetching claims from context")
+	}
+
+	claims, ok := v.(*Claims)
+	if !ok {
+		return nil, errors.New("error during type conversion")
+	}
+
+	return claims, nil
+}
+
+// NewRequireAuth returns a middleware that requires a bearer token verified by the keyfunc, the keyfunc
+// is responsible for rejecting unexpected signing methods.
+func NewRequireAuth(keyfunc jwt.Keyfunc, options ...RequireAuthOption) *RequireAuth {
 	a := RequireAuth{
-		publicKey: publicKey,
+		keyfunc: keyfunc,
+	}
+
+	for _, option := range options {
+		option(&a)
 	}
 
 	return &a
@@ -39,9 +39,52 @@ import (
 #updateOrganization: types.#OrganizationOptions
 #updateOrganization: voucher_code?: _|_
 
+#createOrganizationVouchers: pool_name!: #_objectName
+#createOrganizationVouchers: count!:     int & >=1 & <=1000
+
 #createInvitation: types.#InvitationOptions
 #createInvitation: role!: "SuperUser" | "User" | "Reader"
 
+#acceptInvitation: code!:         string
+#acceptInvitation: display_name?: string
+#acceptInvitation: password?:     string
+
+#declineInvitation: code!: string
+
+#_memberRole: "SuperUser" | "User" | "Reader"
+
+#createMember: name?:  #_objectName
+#createMember: email?: string
+#createMember: role!:  #_memberRole | #_objectName
+
+#updateMember: role!: #_memberRole | #_objectName
+
+#loginMFA: mfa_token!:     string
+#loginMFA: code?:          =~"^[0-9]{6}$"
+#loginMFA: recovery_code?: string
+
+#mfaCode: code?:          =~"^[0-9]{6}$"
+#mfaCode: recovery_code?: string
+
+#loginSSO: code!: string
+
+#createDeviceCode: client_id?: string
+
+#deviceToken: device_code!: string
+#deviceToken: grant_type?:  "urn:ietf:params:oauth:grant-type:device_code"
+
+#verifyDevice: user_code!: string
+#verifyDevice: deny?:      bool
+
+#createAPIToken: display_name?:  string
+#createAPIToken: duration?:      string
+#createAPIToken: organizations?: [...#_objectName]
+#createAPIToken: verbs?: [..."get" | "create" | "update" | "delete"]
+
+#createServiceAccount: display_name?: string
+#createServiceAccount: name!:         #_objectName
+#createServiceAccount: role!:         "SuperUser" | "User" | "Reader"
+
 #verifyOptions: types.#VerifyOptions
 
 #passwordResetRequestOptions: types.#PasswordResetRequestOptions
@@ -128,6 +128,36 @@ func TestValidateJSON(t *testing.T) {
 			body:     `{"name":"test","quantity":1,"storage_resources":[{"name":"Test"}]}`,
 			expected: http.StatusUnprocessableEntity,
 		},
+		{
+			name:     "test accept invitation sign up",
+			schema:   "#acceptInvitation",
+			body:     `{"code":"test","password":"abc123","display_name":"Test"}`,
+			expected: http.StatusOK,
+		},
+		{
+			name:     "test accept invitation missing code",
+			schema:   "#acceptInvitation",
+			body:     `{"password":"abc123"}`,
+			expected: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "test create organization vouchers",
+			schema:   "#createOrganizationVouchers",
+			body:     `{"pool_name":"test","count":10}`,
+			expected: http.StatusOK,
+		},
+		{
+			name:     "test create organization vouchers zero count",
+			schema:   "#createOrganizationVouchers",
+			body:     `{"pool_name":"test","count":0}`,
+			expected: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "test create organization vouchers missing pool name",
+			schema:   "#createOrganizationVouchers",
+			body:     `{"count":10}`,
+			expected: http.StatusUnprocessableEntity,
+		},
 	}
 
 	for _, tc := range tt {
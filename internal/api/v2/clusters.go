@@ -0,0 +1,163 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/pkg/authorization"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var errClusterNotAllowed = errors.New("subject is not allowed to get the cluster of the resource")
+
+// clusterAccess limits subjects to the resources belonging to clusters they are allowed to get, members limited to
+// a subset of the clusters are granted the cluster resources in the whole organization. A nil clusterAccess allows
+// every resource.
+type clusterAccess struct {
+	client       client.Client
+	subject      string
+	namespace    string
+	allowedNames map[string]bool
+}
+
+// clusterAccessFor returns the cluster access of the subject to the resource, subjects allowed to get every cluster
+// in the namespace and resources not belonging to clusters are not limited.
+func (a *API) clusterAccessFor(ctx context.Context, subject string, resourceAttributes *authorizationv1.ResourceAttributes) (*clusterAccess, error) {
+	if resourceAttributes.Group != dockyardsv1.GroupVersion.Group {
+		return nil, nil
+	}
+
+	if !slices.Contains(authorization.ClusterResources, resourceAttributes.Resource) {
+		return nil, nil
+	}
+
+	clusterAttributes := authorizationv1.ResourceAttributes{
+		Group:     dockyardsv1.GroupVersion.Group,
+		Namespace: resourceAttributes.Namespace,
+		Resource:  "clusters",
+		Verb:      "get",
+	}
+
+	allowed, err := apiutil.IsSubjectAllowed(ctx, a, subject, &clusterAttributes)
+	if err != nil {
+		return nil, err
+	}
+
+	if allowed {
+		return nil, nil
+	}
+
+	c := clusterAccess{
+		client:       a,
+		subject:      subject,
+		namespace:    resourceAttributes.Namespace,
+		allowedNames: make(map[string]bool),
+	}
+
+	return &c, nil
+}
+
+// clusterNamesOf returns the names of the clusters the object belongs to by label or owner reference.
+func clusterNamesOf(o client.Object) []string {
+	var names []string
+
+	clusterName, hasLabel := o.GetLabels()[dockyardsv1.LabelClusterName]
+	if hasLabel {
+		names = append(names, clusterName)
+	}
+
+	for _, ownerReference := range o.GetOwnerReferences() {
+		groupVersion, err := schema.ParseGroupVersion(ownerReference.APIVersion)
+		if err != nil || groupVersion.Group != dockyardsv1.GroupVersion.Group {
+			continue
+		}
+
+		if ownerReference.Kind == dockyardsv1.ClusterKind && !slices.Contains(names, ownerReference.Name) {
+			names = append(names, ownerReference.Name)
+		}
+	}
+
+	return names
+}
+
+// allows returns true if the subject is allowed to get every cluster the object belongs to, objects not belonging
+// to any cluster are only allowed without limits.
+func (c *clusterAccess) allows(ctx context.Context, o client.Object) (bool, error) {
+	if c == nil {
+		return true, nil
+	}
+
+	names := clusterNamesOf(o)
+	if len(names) == 0 {
+		return false, nil
+	}
+
+	for _, name := range names {
+		allowed, found := c.allowedNames[name]
+		if !found {
+			resourceAttributes := authorizationv1.ResourceAttributes{
+				Group:     dockyardsv1.GroupVersion.Group,
+				Name:      name,
+				Namespace: c.namespace,
+				Resource:  "clusters",
+				Verb:      "get",
+			}
+
+			var err error
+
+			allowed, err = apiutil.IsSubjectAllowed(ctx, c.client, c.subject, &resourceAttributes)
+			if err != nil {
+				return false, err
+			}
+
+			c.allowedNames[name] = allowed
+		}
+
+		if !allowed {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// authorize returns a forbidden error unless the subject is allowed to get every cluster the objects belong to.
+func (c *clusterAccess) authorize(ctx context.Context, resourceAttributes *authorizationv1.ResourceAttributes, objects ...client.Object) error {
+	for _, o := range objects {
+		allowed, err := c.allows(ctx, o)
+		if err != nil {
+			return err
+		}
+
+		if !allowed {
+			groupResource := schema.GroupResource{
+				Group:    resourceAttributes.Group,
+				Resource: resourceAttributes.Resource,
+			}
+
+			return apierrors.NewForbidden(groupResource, o.GetName(), errClusterNotAllowed)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,295 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/pkg/authorization"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestClusterResources_Restricted(t *testing.T) {
+	ctx := t.Context()
+
+	organization := environment.MustCreateOrganization(t)
+	user := environment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleUser)
+
+	c := environment.GetClient()
+
+	allowedCluster := dockyardsv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "allowed",
+			Namespace: organization.Spec.NamespaceRef.Name,
+		},
+	}
+
+	otherCluster := dockyardsv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "other",
+			Namespace: organization.Spec.NamespaceRef.Name,
+		},
+	}
+
+	for _, cluster := range []*dockyardsv1.Cluster{&allowedCluster, &otherCluster} {
+		err := c.Create(ctx, cluster)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	allowedNodePool := dockyardsv1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "allowed-pool",
+			Namespace: organization.Spec.NamespaceRef.Name,
+			Labels: map[string]string{
+				dockyardsv1.LabelClusterName: allowedCluster.Name,
+			},
+		},
+	}
+
+	labelledNodePool := dockyardsv1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "other-pool",
+			Namespace: organization.Spec.NamespaceRef.Name,
+			Labels: map[string]string{
+				dockyardsv1.LabelClusterName: otherCluster.Name,
+			},
+		},
+	}
+
+	// The label claims the allowed cluster while the owner is the other cluster.
+	ownedNodePool := dockyardsv1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "owned-pool",
+			Namespace: organization.Spec.NamespaceRef.Name,
+			Labels: map[string]string{
+				dockyardsv1.LabelClusterName: allowedCluster.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: dockyardsv1.GroupVersion.String(),
+					Kind:       dockyardsv1.ClusterKind,
+					Name:       otherCluster.Name,
+					UID:        otherCluster.UID,
+				},
+			},
+		},
+	}
+
+	for _, nodePool := range []*dockyardsv1.NodePool{&allowedNodePool, &labelledNodePool, &ownedNodePool} {
+		err := c.Create(ctx, nodePool)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var member dockyardsv1.Member
+	err := c.Get(ctx, client.ObjectKey{Name: user.Name, Namespace: organization.Spec.NamespaceRef.Name}, &member)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patch := client.MergeFrom(member.DeepCopy())
+
+	member.Spec.Clusters = &dockyardsv1.MemberClusters{
+		Names: []string{
+			allowedCluster.Name,
+		},
+	}
+
+	err = c.Patch(ctx, &member, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = authorization.ReconcileMemberAuthorization(ctx, c, &member)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodePoolsPath, err := url.JoinPath("/v2",
+		"group", dockyardsv1.GroupVersion.Group,
+		"version", dockyardsv1.GroupVersion.Version,
+		"kind", "nodepools",
+		"namespace", organization.Spec.NamespaceRef.Name,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test list", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, nodePoolsPath, nil)
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(user))
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+
+		b, err := io.ReadAll(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual dockyardsv1.NodePoolList
+		err = json.Unmarshal(b, &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(actual.Items) != 1 || actual.Items[0].Name != allowedNodePool.Name {
+			t.Errorf("expected only node pool %s, got %v", allowedNodePool.Name, actual.Items)
+		}
+	})
+
+	t.Run("test get", func(t *testing.T) {
+		for _, tc := range []struct {
+			name     string
+			expected int
+		}{
+			{
+				name:     allowedNodePool.Name,
+				expected: http.StatusOK,
+			},
+			{
+				name:     labelledNodePool.Name,
+				expected: http.StatusForbidden,
+			},
+			{
+				name:     ownedNodePool.Name,
+				expected: http.StatusForbidden,
+			},
+		} {
+			target, err := url.JoinPath(nodePoolsPath, "name", tc.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, target, nil)
+
+			r.Header.Add("Authorization", "Bearer "+MustSignToken(user))
+
+			mux.ServeHTTP(w, r)
+
+			if w.Result().StatusCode != tc.expected {
+				t.Errorf("expected status code %d for %s, got %d", tc.expected, tc.name, w.Result().StatusCode)
+			}
+		}
+	})
+
+	t.Run("test create for other cluster", func(t *testing.T) {
+		nodePool := dockyardsv1.NodePool{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: dockyardsv1.GroupVersion.String(),
+				Kind:       dockyardsv1.NodePoolKind,
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "created-pool",
+				Labels: map[string]string{
+					dockyardsv1.LabelClusterName: otherCluster.Name,
+				},
+			},
+		}
+
+		b, err := json.Marshal(&nodePool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, nodePoolsPath, bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(user))
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusForbidden {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+	})
+
+	t.Run("test patch to other cluster", func(t *testing.T) {
+		target, err := url.JoinPath(nodePoolsPath, "name", allowedNodePool.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := `{"metadata":{"labels":{"` + dockyardsv1.LabelClusterName + `":"` + otherCluster.Name + `"}}}`
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPatch, target, bytes.NewBufferString(body))
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(user))
+		r.Header.Add("Content-Type", "application/merge-patch+json")
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusForbidden {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+
+		var actual dockyardsv1.NodePool
+		err = c.Get(ctx, client.ObjectKeyFromObject(&allowedNodePool), &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual.Labels[dockyardsv1.LabelClusterName] != allowedCluster.Name {
+			t.Errorf("expected node pool to remain in cluster %s", allowedCluster.Name)
+		}
+	})
+
+	t.Run("test delete", func(t *testing.T) {
+		target, err := url.JoinPath(nodePoolsPath, "name", labelledNodePool.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, target, nil)
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(user))
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusForbidden {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+
+		var actual dockyardsv1.NodePool
+		err = c.Get(ctx, client.ObjectKeyFromObject(&labelledNodePool), &actual)
+		if apierrors.IsNotFound(err) {
+			t.Fatal("expected node pool to not be deleted")
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
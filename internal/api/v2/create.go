@@ -0,0 +1,79 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"net/http"
+)
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
+
+func (a *API) CreateNamespacedResource(w http.ResponseWriter, r *http.Request) {
+	subject, err := a.subjectFrom(r)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	ctx := r.Context()
+
+	groupVersionKind, resourceAttributes, _, err := a.resourceFrom(r)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	u, err := readObject(r, groupVersionKind)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	resourceAttributes.Name = u.GetName()
+	resourceAttributes.Verb = "create"
+
+	err = a.authorize(ctx, subject, &resourceAttributes)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	access, err := a.clusterAccessFor(ctx, subject, &resourceAttributes)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	err = access.authorize(ctx, &resourceAttributes, u)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	err = a.Create(ctx, u)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, u.Object)
+}
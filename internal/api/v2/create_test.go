@@ -0,0 +1,160 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestNamespacedResource_Create(t *testing.T) {
+	ctx := t.Context()
+
+	organization := environment.MustCreateOrganization(t)
+	user := environment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleUser)
+	reader := environment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleReader)
+
+	c := environment.GetClient()
+
+	target, err := url.JoinPath("/v2",
+		"group", dockyardsv1.GroupVersion.Group,
+		"version", dockyardsv1.GroupVersion.Version,
+		"kind", "clusters",
+		"namespace", organization.Spec.NamespaceRef.Name,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test as user", func(t *testing.T) {
+		cluster := dockyardsv1.Cluster{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       dockyardsv1.ClusterKind,
+				APIVersion: dockyardsv1.GroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test-user",
+			},
+		}
+
+		b, err := json.Marshal(&cluster)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, target, bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(user))
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusCreated {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+
+		key := client.ObjectKey{
+			Name:      cluster.Name,
+			Namespace: organization.Spec.NamespaceRef.Name,
+		}
+
+		var actual dockyardsv1.Cluster
+		err = c.Get(ctx, key, &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("test as reader", func(t *testing.T) {
+		cluster := dockyardsv1.Cluster{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       dockyardsv1.ClusterKind,
+				APIVersion: dockyardsv1.GroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test-reader",
+			},
+		}
+
+		b, err := json.Marshal(&cluster)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, target, bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(reader))
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusForbidden {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+
+		b, err = io.ReadAll(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var status metav1.Status
+		err = json.Unmarshal(b, &status)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if status.Reason != metav1.StatusReasonUnauthorized {
+			t.Errorf("expected reason %s, got %s", metav1.StatusReasonUnauthorized, status.Reason)
+		}
+	})
+
+	t.Run("test other namespace", func(t *testing.T) {
+		cluster := dockyardsv1.Cluster{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       dockyardsv1.ClusterKind,
+				APIVersion: dockyardsv1.GroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-namespace",
+				Namespace: environment.GetDockyardsNamespace(),
+			},
+		}
+
+		b, err := json.Marshal(&cluster)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, target, bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(user))
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusBadRequest {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+	})
+}
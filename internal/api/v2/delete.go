@@ -0,0 +1,108 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"net/http"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
+
+func (a *API) DeleteNamespacedResource(w http.ResponseWriter, r *http.Request) {
+	subject, err := a.subjectFrom(r)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	ctx := r.Context()
+
+	groupVersionKind, resourceAttributes, _, err := a.resourceFrom(r)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	resourceAttributes.Verb = "delete"
+
+	err = a.authorize(ctx, subject, &resourceAttributes)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	var u unstructured.Unstructured
+	u.SetGroupVersionKind(groupVersionKind)
+	u.SetName(resourceAttributes.Name)
+	u.SetNamespace(resourceAttributes.Namespace)
+
+	access, err := a.clusterAccessFor(ctx, subject, &resourceAttributes)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	var opts []client.DeleteOption
+
+	if access != nil {
+		err = a.Get(ctx, client.ObjectKeyFromObject(&u), &u)
+		if err != nil {
+			writeStatus(w, err)
+
+			return
+		}
+
+		err = access.authorize(ctx, &resourceAttributes, &u)
+		if err != nil {
+			writeStatus(w, err)
+
+			return
+		}
+
+		opts = append(opts, client.Preconditions{UID: ptr.To(u.GetUID())})
+	}
+
+	err = a.Delete(ctx, &u, opts...)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	status := metav1.Status{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Status",
+			APIVersion: "v1",
+		},
+		Status: metav1.StatusSuccess,
+		Code:   http.StatusOK,
+		Details: &metav1.StatusDetails{
+			Name:  resourceAttributes.Name,
+			Group: resourceAttributes.Group,
+			Kind:  resourceAttributes.Resource,
+		},
+	}
+
+	writeJSON(w, http.StatusOK, &status)
+}
@@ -0,0 +1,177 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/audit"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestNamespacedResource_Delete(t *testing.T) {
+	ctx := t.Context()
+
+	organization := environment.MustCreateOrganization(t)
+	user := environment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleUser)
+	reader := environment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleReader)
+
+	c := environment.GetClient()
+
+	t.Run("test as reader", func(t *testing.T) {
+		cluster := dockyardsv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    organization.Spec.NamespaceRef.Name,
+			},
+		}
+
+		err := c.Create(ctx, &cluster)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		target, err := url.JoinPath("/v2",
+			"group", dockyardsv1.GroupVersion.Group,
+			"version", dockyardsv1.GroupVersion.Version,
+			"kind", "clusters",
+			"namespace", cluster.Namespace,
+			"name", cluster.Name,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, target, nil)
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(reader))
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusForbidden {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+
+		filter := audit.Filter{
+			Organization: organization.Name,
+			Subject:      reader.Name,
+		}
+
+		expected := []audit.Event{
+			{
+				Subject:      reader.Name,
+				Organization: organization.Name,
+				Resource:     "clusters",
+				Name:         cluster.Name,
+				Verb:         "delete",
+				Method:       http.MethodDelete,
+				Path:         target,
+				StatusCode:   http.StatusForbidden,
+			},
+		}
+
+		ignoreTimestamp := cmpopts.IgnoreFields(audit.Event{}, "Timestamp")
+
+		var actual []audit.Event
+		err = wait.PollUntilContextTimeout(ctx, time.Millisecond*200, time.Second*5, true, func(ctx context.Context) (bool, error) {
+			var auditEventList dockyardsv1.AuditEventList
+			err := c.List(ctx, &auditEventList, client.InNamespace(organization.Spec.NamespaceRef.Name))
+			if err != nil {
+				return false, err
+			}
+
+			events := make([]audit.Event, len(auditEventList.Items))
+			for i := range auditEventList.Items {
+				events[i] = audit.EventFromResource(organization.Name, &auditEventList.Items[i])
+			}
+
+			actual = filter.Apply(events)
+
+			return len(actual) > 0, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !cmp.Equal(actual, expected, ignoreTimestamp) {
+			t.Errorf("diff: %s", cmp.Diff(expected, actual, ignoreTimestamp))
+		}
+	})
+
+	t.Run("test as user", func(t *testing.T) {
+		cluster := dockyardsv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    organization.Spec.NamespaceRef.Name,
+			},
+		}
+
+		err := c.Create(ctx, &cluster)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		target, err := url.JoinPath("/v2",
+			"group", dockyardsv1.GroupVersion.Group,
+			"version", dockyardsv1.GroupVersion.Version,
+			"kind", "clusters",
+			"namespace", cluster.Namespace,
+			"name", cluster.Name,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, target, nil)
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(user))
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+
+		var actual dockyardsv1.Cluster
+		err = c.Get(ctx, client.ObjectKeyFromObject(&cluster), &actual)
+		if !apierrors.IsNotFound(err) {
+			t.Fatalf("expected not found error, got %v", err)
+		}
+
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodDelete, target, nil)
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(user))
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusNotFound {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+	})
+}
@@ -15,16 +15,9 @@
 package v2
 
 import (
-	"encoding/json"
 	"net/http"
 
-	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
-	authorizationv1 "k8s.io/api/authorization/v1"
-	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -33,56 +26,25 @@ import (
 func (a *API) GetNamespacedResource(w http.ResponseWriter, r *http.Request) {
 	subject, err := a.subjectFrom(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeStatus(w, err)
 
 		return
 	}
 
 	ctx := r.Context()
 
-	group := r.PathValue("group")
-	version := r.PathValue("version")
-	kind := r.PathValue("kind")
-
-	key := client.ObjectKey{
-		Name: kind + "." + group,
-	}
-
-	var customResourceDefinition apiextensionsv1.CustomResourceDefinition
-	err = a.Get(ctx, key, &customResourceDefinition)
+	groupVersionKind, resourceAttributes, columns, err := a.resourceFrom(r)
 	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
+		writeStatus(w, err)
 
 		return
 	}
 
-	namespace := r.PathValue("namespace")
-	name := r.PathValue("name")
+	resourceAttributes.Verb = "get"
 
-	groupVersionKind := schema.GroupVersionKind{
-		Group:   group,
-		Version: version,
-		Kind:    customResourceDefinition.Spec.Names.Kind,
-	}
-
-	resourceAttributes := authorizationv1.ResourceAttributes{
-		Group:     group,
-		Name:      name,
-		Namespace: namespace,
-		Resource:  customResourceDefinition.Spec.Names.Plural,
-		Verb:      "list",
-		Version:   version,
-	}
-
-	allowed, err := apiutil.IsSubjectAllowed(ctx, a, subject, &resourceAttributes)
+	err = a.authorize(ctx, subject, &resourceAttributes)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-
-		return
-	}
-
-	if !allowed {
-		w.WriteHeader(http.StatusUnauthorized)
+		writeStatus(w, err)
 
 		return
 	}
@@ -90,36 +52,31 @@ func (a *API) GetNamespacedResource(w http.ResponseWriter, r *http.Request) {
 	var u unstructured.Unstructured
 	u.SetGroupVersionKind(groupVersionKind)
 
-	key = client.ObjectKey{
-		Name:      name,
-		Namespace: namespace,
+	key := client.ObjectKey{
+		Name:      resourceAttributes.Name,
+		Namespace: resourceAttributes.Namespace,
 	}
 
 	err = a.Get(ctx, key, &u)
-	if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
-		w.WriteHeader(http.StatusNotFound)
-
-		return
-	}
-
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeStatus(w, err)
 
 		return
 	}
 
-	b, err := json.Marshal(u.Object)
+	access, err := a.clusterAccessFor(ctx, subject, &resourceAttributes)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeStatus(w, err)
 
 		return
 	}
 
-	w.Header().Add("Content-Type", "application/json")
-	_, err = w.Write(b)
+	err = access.authorize(ctx, &resourceAttributes, &u)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeStatus(w, err)
 
 		return
 	}
+
+	writeObject(w, r, columns, &u)
 }
@@ -15,13 +15,14 @@
 package v2
 
 import (
-	"encoding/json"
+	"context"
 	"net/http"
+	"slices"
 
 	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
 	authorizationv1 "k8s.io/api/authorization/v1"
-	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
-	"k8s.io/apimachinery/pkg/api/meta"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -29,105 +30,291 @@ import (
 )
 
 // +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=members,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=organizations,verbs=get;list;watch
+
+// listNamespace returns the resources in the namespace of the resource attributes matching the selectors, subjects
+// allowed to list resources without being allowed to get all of them, e.g. members limited to a subset of the
+// clusters in an organization, only receive the resources they are allowed to get and the cluster resources
+// belonging to clusters they are allowed to get.
+func (a *API) listNamespace(ctx context.Context, subject string, groupVersionKind schema.GroupVersionKind, resourceAttributes authorizationv1.ResourceAttributes, selector labels.Selector, matchesFields fieldMatcher) ([]unstructured.Unstructured, error) {
+	opts := []client.ListOption{
+		client.InNamespace(resourceAttributes.Namespace),
+	}
+
+	if !selector.Empty() {
+		matchingLabelsSelector := client.MatchingLabelsSelector{
+			Selector: selector,
+		}
+
+		opts = append(opts, matchingLabelsSelector)
+	}
+
+	var u unstructured.UnstructuredList
+	u.SetGroupVersionKind(groupVersionKind)
+
+	err := a.List(ctx, &u, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Items = slices.DeleteFunc(u.Items, func(item unstructured.Unstructured) bool {
+		return !matchesFields(&item)
+	})
+
+	access, err := a.clusterAccessFor(ctx, subject, &resourceAttributes)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceAttributes.Verb = "get"
+
+	allowedAll, err := apiutil.IsSubjectAllowed(ctx, a, subject, &resourceAttributes)
+	if err != nil {
+		return nil, err
+	}
+
+	if allowedAll && access == nil {
+		return u.Items, nil
+	}
+
+	items := []unstructured.Unstructured{}
+
+	for _, item := range u.Items {
+		allowed := allowedAll
+
+		if !allowed {
+			itemAttributes := resourceAttributes
+			itemAttributes.Name = item.GetName()
+
+			allowed, err = apiutil.IsSubjectAllowed(ctx, a, subject, &itemAttributes)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if allowed {
+			allowed, err = access.allows(ctx, &item)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if allowed {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
 
 func (a *API) ListNamespacedResource(w http.ResponseWriter, r *http.Request) {
 	subject, err := a.subjectFrom(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeStatus(w, err)
 
 		return
 	}
 
 	ctx := r.Context()
 
-	group := r.PathValue("group")
-	version := r.PathValue("version")
-	kind := r.PathValue("kind")
-	namespace := r.PathValue("namespace")
+	groupVersionKind, resourceAttributes, columns, err := a.resourceFrom(r)
+	if err != nil {
+		writeStatus(w, err)
 
-	key := client.ObjectKey{
-		Name: kind + "." + group,
+		return
 	}
 
-	var customResourceDefinition apiextensionsv1.CustomResourceDefinition
-	err = a.Get(ctx, key, &customResourceDefinition)
+	if isWatch(r) {
+		a.watchNamespacedResource(w, r, subject, groupVersionKind, resourceAttributes)
+
+		return
+	}
+
+	resourceAttributes.Verb = "list"
+
+	err = a.authorize(ctx, subject, &resourceAttributes)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeStatus(w, err)
 
 		return
 	}
 
-	groupVersionKind := schema.GroupVersionKind{
-		Group:   group,
-		Version: version,
-		Kind:    customResourceDefinition.Spec.Names.Kind,
+	selector, err := labelSelectorFrom(r)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
 	}
 
-	resourceAttributes := authorizationv1.ResourceAttributes{
-		Group:     group,
-		Namespace: namespace,
-		Resource:  customResourceDefinition.Spec.Names.Plural,
-		Verb:      "list",
-		Version:   version,
+	matchesFields, err := a.fieldSelectorFrom(r)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
 	}
 
-	allowed, err := apiutil.IsSubjectAllowed(ctx, a, subject, &resourceAttributes)
+	order, err := listOrderFrom(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeStatus(w, err)
 
 		return
 	}
 
-	if !allowed {
-		w.WriteHeader(http.StatusUnauthorized)
+	items, err := a.listNamespace(ctx, subject, groupVersionKind, resourceAttributes, selector, matchesFields)
+	if err != nil {
+		writeStatus(w, err)
 
 		return
 	}
 
-	opts := []client.ListOption{
-		client.InNamespace(namespace),
+	var u unstructured.UnstructuredList
+	u.SetGroupVersionKind(groupVersionKind)
+	u.Items = items
+
+	err = order.paginate(r, &u)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
 	}
 
-	labelSelector := r.URL.Query().Get("labelSelector")
-	if labelSelector != "" {
-		set, err := labels.ConvertSelectorToLabelsMap(labelSelector)
-		if err != nil {
-			panic(err)
-		}
+	writeObject(w, r, columns, &u)
+}
 
-		matchingLabelsSelector := client.MatchingLabelsSelector{
-			Selector: set.AsSelector(),
-		}
+// ListResource lists the resources in the namespaces of every organization the subject is a member of, each item is
+// annotated with the name of its organization.
+func (a *API) ListResource(w http.ResponseWriter, r *http.Request) {
+	claims, err := a.claimsFrom(r)
+	if err != nil {
+		writeStatus(w, err)
 
-		opts = append(opts, matchingLabelsSelector)
+		return
 	}
 
-	var u unstructured.UnstructuredList
-	u.SetGroupVersionKind(groupVersionKind)
+	subject := claims.Subject
+
+	ctx := r.Context()
+
+	groupVersionKind, resourceAttributes, columns, err := a.resourceFrom(r)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	if isWatch(r) {
+		writeStatus(w, apierrors.NewBadRequest("watch requires a namespace"))
 
-	err = a.List(ctx, &u, opts...)
-	if meta.IsNoMatchError(err) {
-		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	selector, err := labelSelectorFrom(r)
+	if err != nil {
+		writeStatus(w, err)
 
 		return
 	}
 
+	matchesFields, err := a.fieldSelectorFrom(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeStatus(w, err)
 
 		return
 	}
 
-	b, err := json.Marshal(&u)
+	order, err := listOrderFrom(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeStatus(w, err)
 
 		return
 	}
 
-	w.Header().Add("Content-Type", "application/json")
-	_, err = w.Write(b)
+	matchingLabels := client.MatchingLabels{
+		dockyardsv1.LabelUserName: subject,
+	}
+
+	var memberList dockyardsv1.MemberList
+	err = a.List(ctx, &memberList, matchingLabels)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	var u unstructured.UnstructuredList
+	u.SetGroupVersionKind(groupVersionKind)
+	u.Items = []unstructured.Unstructured{}
+
+	for _, member := range memberList.Items {
+		organizationName, hasLabel := member.Labels[dockyardsv1.LabelOrganizationName]
+		if !hasLabel {
+			continue
+		}
+
+		var organization dockyardsv1.Organization
+		err := a.Get(ctx, client.ObjectKey{Name: organizationName}, &organization)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+
+		if err != nil {
+			writeStatus(w, err)
+
+			return
+		}
+
+		if organization.Spec.NamespaceRef == nil {
+			continue
+		}
+
+		if organization.Spec.RequireMFA && !claims.HasMFA() {
+			continue
+		}
+
+		namespaceAttributes := resourceAttributes
+		namespaceAttributes.Namespace = organization.Spec.NamespaceRef.Name
+		namespaceAttributes.Verb = "list"
+
+		// Organizations where the subject is not allowed to list the resources are left out rather than failing
+		// the whole request.
+		allowed, err := apiutil.IsSubjectAllowed(ctx, a, subject, &namespaceAttributes)
+		if err != nil {
+			writeStatus(w, err)
+
+			return
+		}
+
+		if !allowed {
+			continue
+		}
+
+		items, err := a.listNamespace(ctx, subject, groupVersionKind, namespaceAttributes, selector, matchesFields)
+		if err != nil {
+			writeStatus(w, err)
+
+			return
+		}
+
+		for _, item := range items {
+			annotations := item.GetAnnotations()
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+
+			annotations[dockyardsv1.AnnotationOrganizationName] = organization.Name
+			item.SetAnnotations(annotations)
+
+			u.Items = append(u.Items, item)
+		}
+	}
+
+	err = order.paginate(r, &u)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeStatus(w, err)
+
+		return
 	}
+
+	writeObject(w, r, columns, &u)
 }
@@ -25,7 +25,11 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	"github.com/sudoswedenab/dockyards-backend/pkg/authorization"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func TestNamespacedResource_List(t *testing.T) {
@@ -158,7 +162,7 @@ func TestNamespacedResource_List(t *testing.T) {
 
 		mux.ServeHTTP(w, r)
 
-		if w.Result().StatusCode != http.StatusUnauthorized {
+		if w.Result().StatusCode != http.StatusForbidden {
 			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
 		}
 	})
@@ -288,3 +292,374 @@ func TestNamespacedResource_List(t *testing.T) {
 		}
 	})
 }
+
+func TestNamespacedResource_ListQuery(t *testing.T) {
+	ctx := t.Context()
+
+	organization := environment.MustCreateOrganization(t)
+	superUser := environment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleSuperUser)
+
+	c := environment.GetClient()
+
+	clusters := []dockyardsv1.Cluster{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-c",
+				Namespace: organization.Spec.NamespaceRef.Name,
+				Labels: map[string]string{
+					"environment": "production",
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-a",
+				Namespace: organization.Spec.NamespaceRef.Name,
+				Labels: map[string]string{
+					"environment": "staging",
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-b",
+				Namespace: organization.Spec.NamespaceRef.Name,
+			},
+		},
+	}
+
+	for i := range clusters {
+		err := c.Create(ctx, &clusters[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	target, err := url.JoinPath("/v2",
+		"group", dockyardsv1.GroupVersion.Group,
+		"version", dockyardsv1.GroupVersion.Version,
+		"kind", "clusters",
+		"namespace", organization.Spec.NamespaceRef.Name,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listClusters := func(t *testing.T, query url.Values) (int, *dockyardsv1.ClusterList) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, target+"?"+query.Encode(), nil)
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(superUser))
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusOK {
+			return w.Result().StatusCode, nil
+		}
+
+		b, err := io.ReadAll(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual dockyardsv1.ClusterList
+		err = json.Unmarshal(b, &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return w.Result().StatusCode, &actual
+	}
+
+	clusterNames := func(clusterList *dockyardsv1.ClusterList) []string {
+		names := make([]string, len(clusterList.Items))
+		for i, item := range clusterList.Items {
+			names[i] = item.Name
+		}
+
+		return names
+	}
+
+	t.Run("test set based label selector", func(t *testing.T) {
+		query := url.Values{
+			"labelSelector": []string{"environment in (production,staging)"},
+		}
+
+		statusCode, actual := listClusters(t, query)
+		if statusCode != http.StatusOK {
+			t.Fatalf("unexpected status code %d", statusCode)
+		}
+
+		expected := []string{"test-a", "test-c"}
+
+		if !cmp.Equal(clusterNames(actual), expected) {
+			t.Errorf("diff: %s", cmp.Diff(expected, clusterNames(actual)))
+		}
+	})
+
+	t.Run("test invalid label selector", func(t *testing.T) {
+		query := url.Values{
+			"labelSelector": []string{"environment in (production"},
+		}
+
+		statusCode, _ := listClusters(t, query)
+		if statusCode != http.StatusBadRequest {
+			t.Fatalf("unexpected status code %d", statusCode)
+		}
+	})
+
+	t.Run("test field selector", func(t *testing.T) {
+		query := url.Values{
+			"fieldSelector": []string{"metadata.uid=" + string(clusters[2].UID)},
+		}
+
+		statusCode, actual := listClusters(t, query)
+		if statusCode != http.StatusOK {
+			t.Fatalf("unexpected status code %d", statusCode)
+		}
+
+		expected := []string{"test-b"}
+
+		if !cmp.Equal(clusterNames(actual), expected) {
+			t.Errorf("diff: %s", cmp.Diff(expected, clusterNames(actual)))
+		}
+	})
+
+	t.Run("test unsupported field selector", func(t *testing.T) {
+		query := url.Values{
+			"fieldSelector": []string{"spec.version=v1.2.3"},
+		}
+
+		statusCode, _ := listClusters(t, query)
+		if statusCode != http.StatusBadRequest {
+			t.Fatalf("unexpected status code %d", statusCode)
+		}
+	})
+
+	t.Run("test sort by name descending", func(t *testing.T) {
+		query := url.Values{
+			"sortBy": []string{"-name"},
+		}
+
+		statusCode, actual := listClusters(t, query)
+		if statusCode != http.StatusOK {
+			t.Fatalf("unexpected status code %d", statusCode)
+		}
+
+		expected := []string{"test-c", "test-b", "test-a"}
+
+		if !cmp.Equal(clusterNames(actual), expected) {
+			t.Errorf("diff: %s", cmp.Diff(expected, clusterNames(actual)))
+		}
+	})
+
+	t.Run("test limit and continue", func(t *testing.T) {
+		query := url.Values{
+			"limit": []string{"2"},
+		}
+
+		statusCode, actual := listClusters(t, query)
+		if statusCode != http.StatusOK {
+			t.Fatalf("unexpected status code %d", statusCode)
+		}
+
+		expected := []string{"test-a", "test-b"}
+
+		if !cmp.Equal(clusterNames(actual), expected) {
+			t.Errorf("diff: %s", cmp.Diff(expected, clusterNames(actual)))
+		}
+
+		if actual.Continue == "" {
+			t.Fatal("expected continue token")
+		}
+
+		query.Set("continue", actual.Continue)
+
+		statusCode, actual = listClusters(t, query)
+		if statusCode != http.StatusOK {
+			t.Fatalf("unexpected status code %d", statusCode)
+		}
+
+		expected = []string{"test-c"}
+
+		if !cmp.Equal(clusterNames(actual), expected) {
+			t.Errorf("diff: %s", cmp.Diff(expected, clusterNames(actual)))
+		}
+
+		if actual.Continue != "" {
+			t.Errorf("expected no continue token, got %s", actual.Continue)
+		}
+	})
+
+	t.Run("test invalid continue", func(t *testing.T) {
+		query := url.Values{
+			"continue": []string{"invalid"},
+		}
+
+		statusCode, _ := listClusters(t, query)
+		if statusCode != http.StatusBadRequest {
+			t.Fatalf("unexpected status code %d", statusCode)
+		}
+	})
+}
+
+func TestResource_List(t *testing.T) {
+	ctx := t.Context()
+
+	organization := environment.MustCreateOrganization(t)
+	otherOrganization := environment.MustCreateOrganization(t)
+	unrelatedOrganization := environment.MustCreateOrganization(t)
+
+	superUser := environment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleSuperUser)
+
+	c := environment.GetClient()
+
+	member := dockyardsv1.Member{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				dockyardsv1.LabelRoleName:         string(dockyardsv1.RoleReader),
+				dockyardsv1.LabelUserName:         superUser.Name,
+				dockyardsv1.LabelOrganizationName: otherOrganization.Name,
+			},
+			Name:      superUser.Name,
+			Namespace: otherOrganization.Spec.NamespaceRef.Name,
+		},
+		Spec: dockyardsv1.MemberSpec{
+			Role: dockyardsv1.RoleReader,
+			UserRef: corev1.TypedLocalObjectReference{
+				APIGroup: &dockyardsv1.GroupVersion.Group,
+				Kind:     dockyardsv1.UserKind,
+				Name:     superUser.Name,
+			},
+		},
+	}
+
+	err := c.Create(ctx, &member)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = authorization.ReconcileMemberAuthorization(ctx, c, &member)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, o := range []*dockyardsv1.Organization{organization, otherOrganization, unrelatedOrganization} {
+		cluster := dockyardsv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      o.Name,
+				Namespace: o.Spec.NamespaceRef.Name,
+			},
+		}
+
+		err := c.Create(ctx, &cluster)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("test clusters across organizations", func(t *testing.T) {
+		target, err := url.JoinPath("/v2",
+			"group", dockyardsv1.GroupVersion.Group,
+			"version", dockyardsv1.GroupVersion.Version,
+			"kind", "clusters",
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(superUser))
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+
+		b, err := io.ReadAll(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual dockyardsv1.ClusterList
+		err = json.Unmarshal(b, &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := map[string]string{
+			organization.Name:      organization.Name,
+			otherOrganization.Name: otherOrganization.Name,
+		}
+
+		organizationNames := make(map[string]string)
+		for _, item := range actual.Items {
+			organizationNames[item.Name] = item.Annotations[dockyardsv1.AnnotationOrganizationName]
+		}
+
+		if !cmp.Equal(organizationNames, expected) {
+			t.Errorf("diff: %s", cmp.Diff(expected, organizationNames))
+		}
+	})
+}
+
+func TestNamespacedResource_ListRequireMFA(t *testing.T) {
+	ctx := t.Context()
+
+	organization := environment.MustCreateOrganization(t)
+	reader := environment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleReader)
+
+	c := environment.GetClient()
+
+	patch := client.MergeFrom(organization.DeepCopy())
+
+	organization.Spec.RequireMFA = true
+
+	err := c.Patch(ctx, organization, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	environment.GetManager().GetCache().WaitForCacheSync(ctx)
+
+	target, err := url.JoinPath("/v2",
+		"group", dockyardsv1.GroupVersion.Group,
+		"version", dockyardsv1.GroupVersion.Version,
+		"kind", "clusters",
+		"namespace", organization.Spec.NamespaceRef.Name,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test without mfa", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(reader))
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusForbidden {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+	})
+
+	t.Run("test with mfa", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+
+		token := MustSignTokenWithAuthenticationMethods(reader, middleware.AuthenticationMethodPassword, middleware.AuthenticationMethodOTP)
+
+		r.Header.Add("Authorization", "Bearer "+token)
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+	})
+}
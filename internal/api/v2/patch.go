@@ -0,0 +1,155 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
+
+func patchTypeFrom(r *http.Request) (types.PatchType, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return "", apierrors.NewBadRequest(err.Error())
+	}
+
+	switch types.PatchType(mediaType) {
+	case types.JSONPatchType, types.MergePatchType:
+		return types.PatchType(mediaType), nil
+	}
+
+	return "", &apierrors.StatusError{
+		ErrStatus: metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusUnsupportedMediaType,
+			Reason:  metav1.StatusReasonUnsupportedMediaType,
+			Message: fmt.Sprintf("the body of the request was in an unknown format, accepted media types include: %s, %s", types.JSONPatchType, types.MergePatchType),
+		},
+	}
+}
+
+func (a *API) PatchNamespacedResource(w http.ResponseWriter, r *http.Request) {
+	subject, err := a.subjectFrom(r)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	ctx := r.Context()
+
+	groupVersionKind, resourceAttributes, _, err := a.resourceFrom(r)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	patchType, err := patchTypeFrom(r)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	resourceAttributes.Verb = "patch"
+
+	err = a.authorize(ctx, subject, &resourceAttributes)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeStatus(w, apierrors.NewBadRequest(err.Error()))
+
+		return
+	}
+
+	var u unstructured.Unstructured
+	u.SetGroupVersionKind(groupVersionKind)
+	u.SetName(resourceAttributes.Name)
+	u.SetNamespace(resourceAttributes.Namespace)
+
+	access, err := a.clusterAccessFor(ctx, subject, &resourceAttributes)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	if access != nil {
+		err = a.patchClusterResource(ctx, access, &resourceAttributes, &u, client.RawPatch(patchType, b))
+		if err != nil {
+			writeStatus(w, err)
+
+			return
+		}
+
+		writeJSON(w, http.StatusOK, u.Object)
+
+		return
+	}
+
+	err = a.Patch(ctx, &u, client.RawPatch(patchType, b))
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, u.Object)
+}
+
+// patchClusterResource patches a resource belonging to a cluster for subjects limited to a subset of the clusters,
+// the patch is applied as a dry run to check the clusters of the result before it is written using the resource
+// version of the dry run.
+func (a *API) patchClusterResource(ctx context.Context, access *clusterAccess, resourceAttributes *authorizationv1.ResourceAttributes, u *unstructured.Unstructured, patch client.Patch) error {
+	err := a.Get(ctx, client.ObjectKeyFromObject(u), u)
+	if err != nil {
+		return err
+	}
+
+	err = access.authorize(ctx, resourceAttributes, u)
+	if err != nil {
+		return err
+	}
+
+	err = a.Patch(ctx, u, patch, client.DryRunAll)
+	if err != nil {
+		return err
+	}
+
+	err = access.authorize(ctx, resourceAttributes, u)
+	if err != nil {
+		return err
+	}
+
+	return a.Update(ctx, u)
+}
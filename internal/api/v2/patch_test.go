@@ -0,0 +1,144 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestNamespacedResource_Patch(t *testing.T) {
+	ctx := t.Context()
+
+	organization := environment.MustCreateOrganization(t)
+	user := environment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleUser)
+	reader := environment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleReader)
+
+	c := environment.GetClient()
+
+	cluster := dockyardsv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    organization.Spec.NamespaceRef.Name,
+		},
+	}
+
+	err := c.Create(ctx, &cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target, err := url.JoinPath("/v2",
+		"group", dockyardsv1.GroupVersion.Group,
+		"version", dockyardsv1.GroupVersion.Version,
+		"kind", "clusters",
+		"namespace", cluster.Namespace,
+		"name", cluster.Name,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test merge patch", func(t *testing.T) {
+		b := []byte(`{"metadata":{"labels":{"test":"merge"}}}`)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPatch, target, bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(user))
+		r.Header.Add("Content-Type", "application/merge-patch+json")
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+
+		var actual dockyardsv1.Cluster
+		err := c.Get(ctx, client.ObjectKeyFromObject(&cluster), &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual.Labels["test"] != "merge" {
+			t.Errorf("expected label test to be merge, got %q", actual.Labels["test"])
+		}
+	})
+
+	t.Run("test json patch", func(t *testing.T) {
+		b := []byte(`[{"op":"replace","path":"/metadata/labels/test","value":"json"}]`)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPatch, target, bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(user))
+		r.Header.Add("Content-Type", "application/json-patch+json")
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+
+		var actual dockyardsv1.Cluster
+		err := c.Get(ctx, client.ObjectKeyFromObject(&cluster), &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual.Labels["test"] != "json" {
+			t.Errorf("expected label test to be json, got %q", actual.Labels["test"])
+		}
+	})
+
+	t.Run("test unsupported media type", func(t *testing.T) {
+		b := []byte(`{"metadata":{"labels":{"test":"json"}}}`)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPatch, target, bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(user))
+		r.Header.Add("Content-Type", "application/json")
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusUnsupportedMediaType {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+	})
+
+	t.Run("test as reader", func(t *testing.T) {
+		b := []byte(`{"metadata":{"labels":{"test":"reader"}}}`)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPatch, target, bytes.NewBuffer(b))
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(reader))
+		r.Header.Add("Content-Type", "application/merge-patch+json")
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusForbidden {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+	})
+}
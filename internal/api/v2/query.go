@@ -0,0 +1,275 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sudoswedenab/dockyards-backend/api/v1alpha3/index"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	sortByName              = "name"
+	sortByCreationTimestamp = "creationTimestamp"
+)
+
+// labelSelectorFrom returns the selector of the labelSelector query parameter, requests without the parameter select
+// everything.
+func labelSelectorFrom(r *http.Request) (labels.Selector, error) {
+	selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	return selector, nil
+}
+
+// fieldMatcher returns true if the object matches the field selector of the request.
+type fieldMatcher func(*unstructured.Unstructured) bool
+
+// fieldSelectorFrom returns a matcher for the fieldSelector query parameter. Besides the name and namespace of an
+// object the fields indexed by the manager are supported, e.g. spec.email, and the objects are matched against the
+// values of the same index functions.
+func (a *API) fieldSelectorFrom(r *http.Request) (fieldMatcher, error) {
+	selector, err := fields.ParseSelector(r.URL.Query().Get("fieldSelector"))
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	requirements := selector.Requirements()
+
+	indexerFuncs := make([]client.IndexerFunc, len(requirements))
+
+	for i, requirement := range requirements {
+		switch requirement.Field {
+		case "metadata.name":
+			indexerFuncs[i] = func(obj client.Object) []string {
+				return []string{obj.GetName()}
+			}
+		case "metadata.namespace":
+			indexerFuncs[i] = func(obj client.Object) []string {
+				return []string{obj.GetNamespace()}
+			}
+		default:
+			indexerFunc, found := index.IndexerFuncs["."+requirement.Field]
+			if !found {
+				return nil, apierrors.NewBadRequest(fmt.Sprintf("field label not supported: %s", requirement.Field))
+			}
+
+			indexerFuncs[i] = indexerFunc
+		}
+	}
+
+	matcher := func(u *unstructured.Unstructured) bool {
+		if len(requirements) == 0 {
+			return true
+		}
+
+		var obj client.Object = u
+
+		// The index functions expect typed objects, kinds unknown to the scheme are matched as unstructured.
+		typed, err := a.Scheme().New(u.GroupVersionKind())
+		if err == nil {
+			err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, typed)
+			if err == nil {
+				obj = typed.(client.Object)
+			}
+		}
+
+		for i, requirement := range requirements {
+			contains := slices.Contains(indexerFuncs[i](obj), requirement.Value)
+
+			if requirement.Operator == selection.NotEquals && contains {
+				return false
+			}
+
+			if requirement.Operator != selection.NotEquals && !contains {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return matcher, nil
+}
+
+// listOrder sorts and paginates the items of a list.
+type listOrder struct {
+	sortBy     string
+	descending bool
+}
+
+func listOrderFrom(r *http.Request) (*listOrder, error) {
+	sortBy := r.URL.Query().Get("sortBy")
+
+	order := listOrder{
+		sortBy: strings.TrimPrefix(sortBy, "-"),
+	}
+
+	order.descending = order.sortBy != sortBy
+
+	switch order.sortBy {
+	case "":
+		order.sortBy = sortByName
+	case sortByName, sortByCreationTimestamp:
+	default:
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("unsupported sort by %s", sortBy))
+	}
+
+	return &order, nil
+}
+
+func (o *listOrder) compare(a, b *unstructured.Unstructured) int {
+	result := 0
+
+	if o.sortBy == sortByCreationTimestamp {
+		result = a.GetCreationTimestamp().Compare(b.GetCreationTimestamp().Time)
+	}
+
+	if result == 0 {
+		result = strings.Compare(a.GetName(), b.GetName())
+	}
+
+	if result == 0 {
+		result = strings.Compare(a.GetNamespace(), b.GetNamespace())
+	}
+
+	if o.descending {
+		return -result
+	}
+
+	return result
+}
+
+// continueToken is the position of the last item of a page, the next page starts with the first item ordered after
+// it which keeps the pages stable while items are created and deleted.
+type continueToken struct {
+	SortBy            string      `json:"sortBy"`
+	Descending        bool        `json:"descending,omitempty"`
+	Name              string      `json:"name"`
+	Namespace         string      `json:"namespace,omitempty"`
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+}
+
+func (o *listOrder) encodeContinue(u *unstructured.Unstructured) (string, error) {
+	token := continueToken{
+		SortBy:            o.sortBy,
+		Descending:        o.descending,
+		Name:              u.GetName(),
+		Namespace:         u.GetNamespace(),
+		CreationTimestamp: u.GetCreationTimestamp(),
+	}
+
+	b, err := json.Marshal(&token)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (o *listOrder) decodeContinue(s string) (*unstructured.Unstructured, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, apierrors.NewBadRequest("invalid continue token")
+	}
+
+	var token continueToken
+	err = json.Unmarshal(b, &token)
+	if err != nil {
+		return nil, apierrors.NewBadRequest("invalid continue token")
+	}
+
+	if token.SortBy != o.sortBy || token.Descending != o.descending {
+		return nil, apierrors.NewBadRequest("continue token does not match the sort order of the request")
+	}
+
+	var u unstructured.Unstructured
+	u.SetName(token.Name)
+	u.SetNamespace(token.Namespace)
+	u.SetCreationTimestamp(token.CreationTimestamp)
+
+	return &u, nil
+}
+
+// paginate sorts the list and keeps the page of items selected by the limit and continue query parameters.
+func (o *listOrder) paginate(r *http.Request, list *unstructured.UnstructuredList) error {
+	slices.SortFunc(list.Items, func(a, b unstructured.Unstructured) int {
+		return o.compare(&a, &b)
+	})
+
+	query := r.URL.Query()
+
+	if query.Get("continue") != "" {
+		last, err := o.decodeContinue(query.Get("continue"))
+		if err != nil {
+			return err
+		}
+
+		start := len(list.Items)
+
+		for i := range list.Items {
+			if o.compare(&list.Items[i], last) > 0 {
+				start = i
+
+				break
+			}
+		}
+
+		list.Items = list.Items[start:]
+	}
+
+	if query.Get("limit") == "" {
+		return nil
+	}
+
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 {
+		return apierrors.NewBadRequest(fmt.Sprintf("invalid limit %s", query.Get("limit")))
+	}
+
+	if len(list.Items) <= limit {
+		return nil
+	}
+
+	remaining := int64(len(list.Items) - limit)
+
+	list.Items = list.Items[:limit]
+
+	token, err := o.encodeContinue(&list.Items[limit-1])
+	if err != nil {
+		return err
+	}
+
+	list.SetContinue(token)
+	list.SetRemainingItemCount(&remaining)
+
+	return nil
+}
@@ -27,7 +27,9 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/golang-jwt/jwt/v5"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
 	v2 "github.com/sudoswedenab/dockyards-backend/internal/api/v2"
+	"github.com/sudoswedenab/dockyards-backend/internal/audit"
 	"github.com/sudoswedenab/dockyards-backend/pkg/testing/testingutil"
 	utiljwt "github.com/sudoswedenab/dockyards-backend/pkg/util/jwt"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -68,16 +70,34 @@ func TestMain(m *testing.M) {
 		}
 	}()
 
-	accessKey, _, err = utiljwt.GetOrGenerateKeys(ctx, c, environment.GetDockyardsNamespace())
+	keySet, err := utiljwt.GetOrGenerateKeySet(ctx, c, environment.GetDockyardsNamespace())
 	if err != nil {
 		slogr.Error(err, "error preparing test keys")
 
 		os.Exit(1)
 	}
 
+	accessKey = keySet.AccessTokenSigningKey()
+
+	auditRecorder, err := audit.NewRecorder(100, audit.WithSinks(audit.NewResourceSink(c, 0)))
+	if err != nil {
+		slogr.Error(err, "error creating audit recorder")
+
+		os.Exit(1)
+	}
+
+	go func() {
+		err := auditRecorder.Start(ctx)
+		if err != nil {
+			slogr.Error(err, "error starting audit recorder")
+
+			os.Exit(1)
+		}
+	}()
+
 	mux = http.NewServeMux()
 
-	a := v2.NewAPI(mgr, &accessKey.PublicKey)
+	a := v2.NewAPI(mgr, keySet.AccessTokenKeyfunc, v2.WithAuditRecorder(auditRecorder))
 	a.RegisterRoutes(mux)
 
 	code := m.Run()
@@ -107,3 +127,21 @@ func MustSignToken(user *dockyardsv1.User) string {
 
 	return signed
 }
+
+func MustSignTokenWithAuthenticationMethods(user *dockyardsv1.User, authenticationMethods ...string) string {
+	claims := middleware.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			Subject:   user.Name,
+		},
+		AuthenticationMethods: authenticationMethods,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, &claims)
+	signed, err := token.SignedString(accessKey)
+	if err != nil {
+		panic(err)
+	}
+
+	return signed
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/registry/customresource/tableconvertor"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const tableContentType = "application/json;as=Table;g=meta.k8s.io;v=v1"
+
+// isTable returns true if the request accepts the Kubernetes table format.
+func isTable(r *http.Request) bool {
+	for accept := range strings.SplitSeq(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(accept)
+		if err != nil {
+			continue
+		}
+
+		if mediaType == "application/json" && params["as"] == "Table" && params["g"] == metav1.GroupName && params["v"] == "v1" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func includeObjectFrom(r *http.Request) (metav1.IncludeObjectPolicy, error) {
+	includeObject := metav1.IncludeObjectPolicy(r.URL.Query().Get("includeObject"))
+
+	switch includeObject {
+	case "":
+		return metav1.IncludeMetadata, nil
+	case metav1.IncludeNone, metav1.IncludeMetadata, metav1.IncludeObject:
+		return includeObject, nil
+	}
+
+	return "", apierrors.NewBadRequest(fmt.Sprintf("unsupported include object policy %s", includeObject))
+}
+
+// printerColumnsOf returns the additional printer columns of the version of the custom resource, versions without
+// any columns get the age column like when served by the api server.
+func printerColumnsOf(customResourceDefinition *apiextensionsv1.CustomResourceDefinition, versionName string) []apiextensionsv1.CustomResourceColumnDefinition {
+	for _, version := range customResourceDefinition.Spec.Versions {
+		if version.Name != versionName {
+			continue
+		}
+
+		if len(version.AdditionalPrinterColumns) == 0 {
+			break
+		}
+
+		return version.AdditionalPrinterColumns
+	}
+
+	columns := []apiextensionsv1.CustomResourceColumnDefinition{
+		{
+			Name:     "Age",
+			Type:     "date",
+			JSONPath: ".metadata.creationTimestamp",
+		},
+	}
+
+	return columns
+}
+
+// convertToTable converts an object or a list to a table with a row for each object, the objects in the rows are
+// included according to the includeObject query parameter.
+func convertToTable(r *http.Request, columns []apiextensionsv1.CustomResourceColumnDefinition, obj runtime.Object) (*metav1.Table, error) {
+	includeObject, err := includeObjectFrom(r)
+	if err != nil {
+		return nil, err
+	}
+
+	convertor, err := tableconvertor.New(columns)
+	if err != nil {
+		return nil, apierrors.NewInternalError(err)
+	}
+
+	tableOptions := metav1.TableOptions{
+		IncludeObject: includeObject,
+	}
+
+	table, err := convertor.ConvertToTable(r.Context(), obj, &tableOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	table.Kind = "Table"
+	table.APIVersion = metav1.SchemeGroupVersion.String()
+
+	for i, row := range table.Rows {
+		switch includeObject {
+		case metav1.IncludeNone:
+			table.Rows[i].Object = runtime.RawExtension{}
+		case metav1.IncludeMetadata:
+			u, ok := row.Object.Object.(runtime.Unstructured)
+			if !ok {
+				continue
+			}
+
+			metadata, _ := u.UnstructuredContent()["metadata"].(map[string]any)
+
+			partialObjectMetadata := metav1.PartialObjectMetadata{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "PartialObjectMetadata",
+					APIVersion: metav1.SchemeGroupVersion.String(),
+				},
+			}
+
+			err := runtime.DefaultUnstructuredConverter.FromUnstructured(metadata, &partialObjectMetadata.ObjectMeta)
+			if err != nil {
+				return nil, err
+			}
+
+			table.Rows[i].Object = runtime.RawExtension{Object: &partialObjectMetadata}
+		}
+	}
+
+	return table, nil
+}
+
+// writeObject writes the object as JSON, or as a table of the printer columns of the resource when the request
+// accepts the table format.
+func writeObject(w http.ResponseWriter, r *http.Request, columns []apiextensionsv1.CustomResourceColumnDefinition, obj runtime.Object) {
+	if !isTable(r) {
+		writeJSON(w, http.StatusOK, obj)
+
+		return
+	}
+
+	table, err := convertToTable(r, columns, obj)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	writeContent(w, tableContentType, http.StatusOK, table)
+}
@@ -0,0 +1,151 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNamespacedResource_Table(t *testing.T) {
+	ctx := t.Context()
+
+	organization := environment.MustCreateOrganization(t)
+	superUser := environment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleSuperUser)
+
+	c := environment.GetClient()
+
+	cluster := dockyardsv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    organization.Spec.NamespaceRef.Name,
+		},
+	}
+
+	err := c.Create(ctx, &cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columnNames := func(table *metav1.Table) []string {
+		names := make([]string, len(table.ColumnDefinitions))
+		for i, columnDefinition := range table.ColumnDefinitions {
+			names[i] = columnDefinition.Name
+		}
+
+		return names
+	}
+
+	t.Run("test list", func(t *testing.T) {
+		target, err := url.JoinPath("/v2",
+			"group", dockyardsv1.GroupVersion.Group,
+			"version", dockyardsv1.GroupVersion.Version,
+			"kind", "clusters",
+			"namespace", organization.Spec.NamespaceRef.Name,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(superUser))
+		r.Header.Add("Accept", "application/json;as=Table;g=meta.k8s.io;v=v1")
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+
+		b, err := io.ReadAll(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual metav1.Table
+		err = json.Unmarshal(b, &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{"Name", "Ready", "Reason", "Version", "Age", "Duration"}
+
+		if !cmp.Equal(columnNames(&actual), expected) {
+			t.Errorf("diff: %s", cmp.Diff(expected, columnNames(&actual)))
+		}
+
+		if len(actual.Rows) != 1 {
+			t.Fatalf("expected 1 row, got %d", len(actual.Rows))
+		}
+
+		if actual.Rows[0].Cells[0] != cluster.Name {
+			t.Errorf("expected name %s, got %v", cluster.Name, actual.Rows[0].Cells[0])
+		}
+	})
+
+	t.Run("test get without object", func(t *testing.T) {
+		target, err := url.JoinPath("/v2",
+			"group", dockyardsv1.GroupVersion.Group,
+			"version", dockyardsv1.GroupVersion.Version,
+			"kind", "clusters",
+			"namespace", cluster.Namespace,
+			"name", cluster.Name,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, target+"?includeObject=None", nil)
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(superUser))
+		r.Header.Add("Accept", "application/json;as=Table;g=meta.k8s.io;v=v1")
+
+		mux.ServeHTTP(w, r)
+
+		if w.Result().StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status code %d", w.Result().StatusCode)
+		}
+
+		b, err := io.ReadAll(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual metav1.Table
+		err = json.Unmarshal(b, &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(actual.Rows) != 1 {
+			t.Fatalf("expected 1 row, got %d", len(actual.Rows))
+		}
+
+		if actual.Rows[0].Object.Raw != nil && string(actual.Rows[0].Object.Raw) != "null" {
+			t.Errorf("expected no object, got %s", actual.Rows[0].Object.Raw)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
+
+func (a *API) UpdateNamespacedResource(w http.ResponseWriter, r *http.Request) {
+	subject, err := a.subjectFrom(r)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	ctx := r.Context()
+
+	groupVersionKind, resourceAttributes, _, err := a.resourceFrom(r)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	u, err := readObject(r, groupVersionKind)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	if u.GetName() == "" {
+		u.SetName(resourceAttributes.Name)
+	}
+
+	if u.GetName() != resourceAttributes.Name {
+		writeStatus(w, apierrors.NewBadRequest("the name of the provided object does not match the name on the url"))
+
+		return
+	}
+
+	resourceAttributes.Verb = "update"
+
+	err = a.authorize(ctx, subject, &resourceAttributes)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	access, err := a.clusterAccessFor(ctx, subject, &resourceAttributes)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	// Both the existing and the updated resource must belong to clusters the subject is allowed to get, otherwise
+	// a resource could be moved to or from another cluster.
+	if access != nil {
+		var existing unstructured.Unstructured
+		existing.SetGroupVersionKind(groupVersionKind)
+
+		err = a.Get(ctx, client.ObjectKeyFromObject(u), &existing)
+		if err != nil {
+			writeStatus(w, err)
+
+			return
+		}
+
+		err = access.authorize(ctx, &resourceAttributes, &existing, u)
+		if err != nil {
+			writeStatus(w, err)
+
+			return
+		}
+	}
+
+	err = a.Update(ctx, u)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, u.Object)
+}
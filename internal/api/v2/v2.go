@@ -15,60 +15,285 @@
 package v2
 
 import (
-	"crypto"
-	"crypto/ecdsa"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/middleware"
+	"github.com/sudoswedenab/dockyards-backend/internal/audit"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
+var errMFARequired = errors.New("organization requires multi-factor authentication")
+
 type API struct {
 	client.Client
 	*http.ServeMux
 
-	accessKey crypto.PublicKey
+	cache         cache.Cache
+	keyfunc       jwt.Keyfunc
+	auditRecorder *audit.Recorder
+}
+
+type APIOption func(*API)
+
+// WithAuditRecorder makes the API record requests with mutating methods as audit events.
+func WithAuditRecorder(auditRecorder *audit.Recorder) APIOption {
+	return func(a *API) {
+		a.auditRecorder = auditRecorder
+	}
 }
 
-func NewAPI(mgr manager.Manager, accessKey crypto.PublicKey) *API {
+func NewAPI(mgr manager.Manager, keyfunc jwt.Keyfunc, options ...APIOption) *API {
 	mux := http.NewServeMux()
 
 	api := API{
-		Client:    mgr.GetClient(),
-		ServeMux:  mux,
-		accessKey: accessKey,
+		Client:   mgr.GetClient(),
+		ServeMux: mux,
+		cache:    mgr.GetCache(),
+		keyfunc:  keyfunc,
+	}
+
+	for _, option := range options {
+		option(&api)
 	}
 
 	return &api
 }
 
 func (a *API) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/v2/group/{group}/version/{version}/kind/{kind}/namespace/{namespace}", a.ListNamespacedResource)
-	mux.HandleFunc("/v2/group/{group}/version/{version}/kind/{kind}/namespace/{namespace}/name/{name}", a.GetNamespacedResource)
+	auditHandler := middleware.NewAudit(a.auditRecorder).Handler
+
+	mux.HandleFunc("GET /v2/group/{group}/version/{version}/kind/{kind}", a.ListResource)
+	mux.HandleFunc("GET /v2/group/{group}/version/{version}/kind/{kind}/namespace/{namespace}", a.ListNamespacedResource)
+	mux.Handle("POST /v2/group/{group}/version/{version}/kind/{kind}/namespace/{namespace}", auditHandler(http.HandlerFunc(a.CreateNamespacedResource)))
+	mux.HandleFunc("GET /v2/group/{group}/version/{version}/kind/{kind}/namespace/{namespace}/name/{name}", a.GetNamespacedResource)
+	mux.Handle("PUT /v2/group/{group}/version/{version}/kind/{kind}/namespace/{namespace}/name/{name}", auditHandler(http.HandlerFunc(a.UpdateNamespacedResource)))
+	mux.Handle("PATCH /v2/group/{group}/version/{version}/kind/{kind}/namespace/{namespace}/name/{name}", auditHandler(http.HandlerFunc(a.PatchNamespacedResource)))
+	mux.Handle("DELETE /v2/group/{group}/version/{version}/kind/{kind}/namespace/{namespace}/name/{name}", auditHandler(http.HandlerFunc(a.DeleteNamespacedResource)))
 }
 
-func (a *API) subjectFrom(r *http.Request) (string, error) {
+// claimsFrom returns the claims of the bearer token of the request.
+func (a *API) claimsFrom(r *http.Request) (*middleware.Claims, error) {
 	header := r.Header.Get("Authorization")
 	tokenString := strings.TrimPrefix(header, "Bearer ")
 
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(t *jwt.Token) (any, error) {
-		_, ok := t.Method.(*jwt.SigningMethodECDSA)
-		if !ok {
-			return nil, jwt.ErrTokenSignatureInvalid
-		}
+	var claims middleware.Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, a.keyfunc)
+	if err != nil {
+		return nil, apierrors.NewUnauthorized(err.Error())
+	}
 
-		return a.accessKey.(*ecdsa.PublicKey), nil
-	})
+	if claims.Subject == "" {
+		return nil, apierrors.NewUnauthorized("token has no subject")
+	}
+
+	return &claims, nil
+}
+
+// subjectFrom returns the subject of the bearer token of the request, subjects without multi-factor authentication
+// are denied access to namespaces of organizations that require it.
+func (a *API) subjectFrom(r *http.Request) (string, error) {
+	claims, err := a.claimsFrom(r)
 	if err != nil {
 		return "", err
 	}
 
-	subject, err := token.Claims.GetSubject()
+	namespace := r.PathValue("namespace")
+	if namespace == "" || claims.HasMFA() {
+		return claims.Subject, nil
+	}
+
+	organization, err := apiutil.GetOrganizationByNamespaceRef(r.Context(), a, namespace)
+	if apierrors.IsNotFound(err) {
+		return claims.Subject, nil
+	}
+
 	if err != nil {
 		return "", err
 	}
 
-	return subject, nil
+	if organization.Spec.RequireMFA {
+		groupResource := dockyardsv1.GroupVersion.WithResource("organizations").GroupResource()
+
+		return "", apierrors.NewForbidden(groupResource, organization.Name, errMFARequired)
+	}
+
+	return claims.Subject, nil
+}
+
+// resourceFrom returns the kind, resource attributes and printer columns of the custom resource addressed by the
+// request path, the verb of the attributes is left for the caller to set.
+func (a *API) resourceFrom(r *http.Request) (schema.GroupVersionKind, authorizationv1.ResourceAttributes, []apiextensionsv1.CustomResourceColumnDefinition, error) {
+	group := r.PathValue("group")
+	version := r.PathValue("version")
+	kind := r.PathValue("kind")
+
+	key := client.ObjectKey{
+		Name: kind + "." + group,
+	}
+
+	var customResourceDefinition apiextensionsv1.CustomResourceDefinition
+	err := a.Get(r.Context(), key, &customResourceDefinition)
+	if apierrors.IsNotFound(err) {
+		groupResource := schema.GroupResource{
+			Group:    group,
+			Resource: kind,
+		}
+
+		return schema.GroupVersionKind{}, authorizationv1.ResourceAttributes{}, nil, apierrors.NewNotFound(groupResource, "")
+	}
+
+	if err != nil {
+		return schema.GroupVersionKind{}, authorizationv1.ResourceAttributes{}, nil, err
+	}
+
+	groupVersionKind := schema.GroupVersionKind{
+		Group:   group,
+		Version: version,
+		Kind:    customResourceDefinition.Spec.Names.Kind,
+	}
+
+	resourceAttributes := authorizationv1.ResourceAttributes{
+		Group:     group,
+		Name:      r.PathValue("name"),
+		Namespace: r.PathValue("namespace"),
+		Resource:  customResourceDefinition.Spec.Names.Plural,
+		Version:   version,
+	}
+
+	return groupVersionKind, resourceAttributes, printerColumnsOf(&customResourceDefinition, version), nil
+}
+
+// authorize returns a forbidden error unless the subject is allowed to perform the verb on the resource, the
+// subject and resource are set on the audit event of mutating requests.
+func (a *API) authorize(ctx context.Context, subject string, resourceAttributes *authorizationv1.ResourceAttributes) error {
+	a.setAuditEvent(ctx, subject, resourceAttributes)
+
+	allowed, err := apiutil.IsSubjectAllowed(ctx, a, subject, resourceAttributes)
+	if err != nil {
+		return err
+	}
+
+	if !allowed {
+		groupResource := schema.GroupResource{
+			Group:    resourceAttributes.Group,
+			Resource: resourceAttributes.Resource,
+		}
+
+		return apierrors.NewForbidden(groupResource, resourceAttributes.Name, fmt.Errorf("subject is not allowed to %s", resourceAttributes.Verb))
+	}
+
+	return nil
+}
+
+// setAuditEvent sets the subject, organization, resource and name of the audit event in the context, if any.
+func (a *API) setAuditEvent(ctx context.Context, subject string, resourceAttributes *authorizationv1.ResourceAttributes) {
+	event := middleware.AuditEventFrom(ctx)
+	if event == nil {
+		return
+	}
+
+	event.Subject = subject
+	event.Resource = resourceAttributes.Resource
+	event.Name = resourceAttributes.Name
+
+	organization, err := apiutil.GetOrganizationByNamespaceRef(ctx, a, resourceAttributes.Namespace)
+	if err == nil {
+		event.Organization = organization.Name
+	}
+}
+
+// writeStatus writes the error as a Kubernetes status, errors without a status are written as internal errors.
+func writeStatus(w http.ResponseWriter, err error) {
+	if meta.IsNoMatchError(err) {
+		err = &apierrors.StatusError{
+			ErrStatus: metav1.Status{
+				Status:  metav1.StatusFailure,
+				Code:    http.StatusNotFound,
+				Reason:  metav1.StatusReasonNotFound,
+				Message: err.Error(),
+			},
+		}
+	}
+
+	var apiStatus apierrors.APIStatus
+	if !errors.As(err, &apiStatus) {
+		apiStatus = apierrors.NewInternalError(err)
+	}
+
+	status := apiStatus.Status()
+	status.Kind = "Status"
+	status.APIVersion = "v1"
+
+	if status.Code == 0 {
+		status.Code = http.StatusInternalServerError
+	}
+
+	writeJSON(w, int(status.Code), &status)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	writeContent(w, "application/json", statusCode, v)
+}
+
+func writeContent(w http.ResponseWriter, contentType string, statusCode int, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Add("Content-Type", contentType)
+	w.WriteHeader(statusCode)
+
+	_, _ = w.Write(b)
+}
+
+// readObject decodes the request body as an object of the kind addressed by the request path, objects without a
+// namespace are placed in the namespace of the path and objects with a different namespace are rejected.
+func readObject(r *http.Request, groupVersionKind schema.GroupVersionKind) (*unstructured.Unstructured, error) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	var u unstructured.Unstructured
+	err = u.UnmarshalJSON(b)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	if u.GroupVersionKind() != groupVersionKind {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("the kind of the provided object (%s) does not match the kind on the url (%s)", u.GroupVersionKind(), groupVersionKind))
+	}
+
+	namespace := r.PathValue("namespace")
+
+	if u.GetNamespace() == "" {
+		u.SetNamespace(namespace)
+	}
+
+	if u.GetNamespace() != namespace {
+		return nil, apierrors.NewBadRequest("the namespace of the provided object does not match the namespace on the url")
+	}
+
+	return &u, nil
 }
@@ -0,0 +1,392 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// watchableKinds are the kinds that can be watched, every watched kind keeps an informer running for the lifetime of
+// the process so watches are limited to the dockyards kinds clients follow.
+var watchableKinds = []string{
+	dockyardsv1.ClusterKind,
+	dockyardsv1.InvitationKind,
+	dockyardsv1.MemberKind,
+	dockyardsv1.NodeKind,
+	dockyardsv1.NodePoolKind,
+	dockyardsv1.WorkloadKind,
+	dockyardsv1.WorkloadInventoryKind,
+}
+
+// isWatchable returns true if the kind is a dockyards kind in the allow-list of watchable kinds.
+func isWatchable(groupVersionKind schema.GroupVersionKind) bool {
+	if groupVersionKind.GroupVersion() != dockyardsv1.GroupVersion {
+		return false
+	}
+
+	return slices.Contains(watchableKinds, groupVersionKind.Kind)
+}
+
+// watchHeartbeatInterval is how often a bookmark event is written to idle watches, proxies close connections that
+// have not transferred any data for a while.
+const watchHeartbeatInterval = 30 * time.Second
+
+func isWatch(r *http.Request) bool {
+	value := r.URL.Query().Get("watch")
+
+	return value == "true" || value == "1"
+}
+
+// watchResourceVersionFrom returns the resource version to resume the watch from, server-sent events clients
+// reconnecting on their own send the id of the last event they received.
+func watchResourceVersionFrom(r *http.Request) (uint64, error) {
+	resourceVersion := r.URL.Query().Get("resourceVersion")
+	if resourceVersion == "" {
+		resourceVersion = r.Header.Get("Last-Event-ID")
+	}
+
+	if resourceVersion == "" {
+		return 0, nil
+	}
+
+	parsed, err := strconv.ParseUint(resourceVersion, 10, 64)
+	if err != nil {
+		return 0, apierrors.NewBadRequest(fmt.Sprintf("invalid resource version %s", resourceVersion))
+	}
+
+	return parsed, nil
+}
+
+// isNewerThan returns true unless the object has a resource version not newer than the one given.
+func isNewerThan(u *unstructured.Unstructured, resourceVersion uint64) bool {
+	parsed, err := strconv.ParseUint(u.GetResourceVersion(), 10, 64)
+	if err != nil {
+		return true
+	}
+
+	return parsed > resourceVersion
+}
+
+type watchEncoder func(w io.Writer, event *metav1.WatchEvent, resourceVersion string) error
+
+func encodeJSONWatchEvent(w io.Writer, event *metav1.WatchEvent, _ string) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	b = append(b, '\n')
+
+	_, err = w.Write(b)
+
+	return err
+}
+
+func encodeServerSentWatchEvent(w io.Writer, event *metav1.WatchEvent, resourceVersion string) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", resourceVersion, event.Type, b)
+
+	return err
+}
+
+// watchNamespacedResource streams the changes to the resources in a namespace as they are observed by the informer
+// cache. A watch without a resource version starts with an added event for every existing resource, a watch
+// resuming from a resource version only receives added events for resources changed after that version. The cache
+// does not keep history, resources deleted while the client was disconnected are not sent as deleted events.
+func (a *API) watchNamespacedResource(w http.ResponseWriter, r *http.Request, subject string, groupVersionKind schema.GroupVersionKind, resourceAttributes authorizationv1.ResourceAttributes) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	if !isWatchable(groupVersionKind) {
+		groupResource := schema.GroupResource{
+			Group:    resourceAttributes.Group,
+			Resource: resourceAttributes.Resource,
+		}
+
+		writeStatus(w, apierrors.NewMethodNotSupported(groupResource, "watch"))
+
+		return
+	}
+
+	resourceAttributes.Verb = "watch"
+
+	err := a.authorize(ctx, subject, &resourceAttributes)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	selector, err := labelSelectorFrom(r)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	matchesFields, err := a.fieldSelectorFrom(r)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	resourceVersion, err := watchResourceVersionFrom(r)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	access, err := a.clusterAccessFor(ctx, subject, &resourceAttributes)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	// Subjects allowed to watch resources without being allowed to get all of them only receive events for the
+	// resources they are allowed to get, the decision for each resource is kept for the duration of the watch.
+	resourceAttributes.Verb = "get"
+
+	allowedAll, err := apiutil.IsSubjectAllowed(ctx, a, subject, &resourceAttributes)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	allowedNames := make(map[string]bool)
+
+	isAllowed := func(name string) (bool, error) {
+		if allowedAll {
+			return true, nil
+		}
+
+		allowed, found := allowedNames[name]
+		if found {
+			return allowed, nil
+		}
+
+		itemAttributes := resourceAttributes
+		itemAttributes.Name = name
+
+		allowed, err := apiutil.IsSubjectAllowed(ctx, a, subject, &itemAttributes)
+		if err != nil {
+			return false, err
+		}
+
+		allowedNames[name] = allowed
+
+		return allowed, nil
+	}
+
+	var u unstructured.Unstructured
+	u.SetGroupVersionKind(groupVersionKind)
+
+	informer, err := a.cache.GetInformer(ctx, &u)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	events := make(chan watch.Event)
+
+	send := func(eventType watch.EventType, obj any) {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return
+		}
+
+		if u.GetNamespace() != resourceAttributes.Namespace || !selector.Matches(labels.Set(u.GetLabels())) {
+			return
+		}
+
+		if !matchesFields(u) {
+			return
+		}
+
+		// Each event handler is called from its own goroutine, blocking here only holds back events for this
+		// watch.
+		select {
+		case events <- watch.Event{Type: eventType, Object: u.DeepCopy()}:
+		case <-ctx.Done():
+		}
+	}
+
+	handler := toolscache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj any, isInInitialList bool) {
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				return
+			}
+
+			if isInInitialList && !isNewerThan(u, resourceVersion) {
+				return
+			}
+
+			send(watch.Added, obj)
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			oldUnstructured, ok := oldObj.(*unstructured.Unstructured)
+			if !ok {
+				return
+			}
+
+			newUnstructured, ok := newObj.(*unstructured.Unstructured)
+			if !ok {
+				return
+			}
+
+			if oldUnstructured.GetResourceVersion() == newUnstructured.GetResourceVersion() {
+				return
+			}
+
+			send(watch.Modified, newObj)
+		},
+		DeleteFunc: func(obj any) {
+			tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown)
+			if ok {
+				obj = tombstone.Obj
+			}
+
+			send(watch.Deleted, obj)
+		},
+	}
+
+	registration, err := informer.AddEventHandler(handler)
+	if err != nil {
+		writeStatus(w, err)
+
+		return
+	}
+
+	defer func() {
+		_ = informer.RemoveEventHandler(registration)
+	}()
+
+	var encode watchEncoder = encodeJSONWatchEvent
+	contentType := "application/x-ndjson"
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		encode = encodeServerSentWatchEvent
+		contentType = "text/event-stream"
+	}
+
+	w.Header().Add("Content-Type", contentType)
+	w.Header().Add("Cache-Control", "no-cache")
+	w.Header().Add("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	responseController := http.NewResponseController(w)
+
+	err = responseController.Flush()
+	if err != nil {
+		return
+	}
+
+	lastResourceVersion := ""
+	if resourceVersion != 0 {
+		lastResourceVersion = strconv.FormatUint(resourceVersion, 10)
+	}
+
+	ticker := time.NewTicker(watchHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		var event watch.Event
+
+		select {
+		case <-ctx.Done():
+			return
+		case event = <-events:
+		case <-ticker.C:
+			var bookmark unstructured.Unstructured
+			bookmark.SetGroupVersionKind(groupVersionKind)
+			bookmark.SetResourceVersion(lastResourceVersion)
+
+			event = watch.Event{Type: watch.Bookmark, Object: &bookmark}
+		}
+
+		u := event.Object.(*unstructured.Unstructured)
+
+		if event.Type != watch.Bookmark {
+			allowed, err := isAllowed(u.GetName())
+			if err != nil {
+				return
+			}
+
+			// The clusters of a resource are read from its labels and owner references which may change, the
+			// decision is made for every event.
+			if allowed {
+				allowed, err = access.allows(ctx, u)
+				if err != nil {
+					return
+				}
+			}
+
+			if !allowed {
+				continue
+			}
+
+			lastResourceVersion = u.GetResourceVersion()
+		}
+
+		b, err := u.MarshalJSON()
+		if err != nil {
+			return
+		}
+
+		watchEvent := metav1.WatchEvent{
+			Type:   string(event.Type),
+			Object: runtime.RawExtension{Raw: b},
+		}
+
+		err = encode(w, &watchEvent, lastResourceVersion)
+		if err != nil {
+			return
+		}
+
+		err = responseController.Flush()
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestNamespacedResource_Watch(t *testing.T) {
+	ctx := t.Context()
+
+	organization := environment.MustCreateOrganization(t)
+	superUser := environment.MustGetOrganizationUser(t, organization, dockyardsv1.RoleSuperUser)
+
+	c := environment.GetClient()
+
+	cluster := dockyardsv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    organization.Spec.NamespaceRef.Name,
+		},
+	}
+
+	err := c.Create(ctx, &cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	target, err := url.JoinPath(server.URL, "/v2",
+		"group", dockyardsv1.GroupVersion.Group,
+		"version", dockyardsv1.GroupVersion.Version,
+		"kind", "clusters",
+		"namespace", organization.Spec.NamespaceRef.Name,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	watchEvents := func(t *testing.T, query url.Values, user *dockyardsv1.User) (*http.Response, *json.Decoder) {
+		ctx, cancel := context.WithTimeout(t.Context(), time.Second*10)
+		t.Cleanup(cancel)
+
+		r, err := http.NewRequestWithContext(ctx, http.MethodGet, target+"?"+query.Encode(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(user))
+
+		response, err := http.DefaultClient.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() {
+			response.Body.Close()
+		})
+
+		return response, json.NewDecoder(bufio.NewReader(response.Body))
+	}
+
+	t.Run("test initial events", func(t *testing.T) {
+		query := url.Values{
+			"watch": []string{"true"},
+		}
+
+		response, decoder := watchEvents(t, query, superUser)
+
+		if response.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status code %d", response.StatusCode)
+		}
+
+		var event struct {
+			Type   watch.EventType     `json:"type"`
+			Object dockyardsv1.Cluster `json:"object"`
+		}
+
+		err := decoder.Decode(&event)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if event.Type != watch.Added {
+			t.Errorf("expected event type %s, got %s", watch.Added, event.Type)
+		}
+
+		if event.Object.Name != cluster.Name {
+			t.Errorf("expected cluster %s, got %s", cluster.Name, event.Object.Name)
+		}
+	})
+
+	t.Run("test resource version", func(t *testing.T) {
+		query := url.Values{
+			"watch":           []string{"true"},
+			"resourceVersion": []string{cluster.ResourceVersion},
+		}
+
+		response, decoder := watchEvents(t, query, superUser)
+
+		if response.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status code %d", response.StatusCode)
+		}
+
+		patch := client.MergeFrom(cluster.DeepCopy())
+
+		cluster.Labels = map[string]string{
+			"test": "watch",
+		}
+
+		err := c.Patch(ctx, &cluster, patch)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var event struct {
+			Type   watch.EventType     `json:"type"`
+			Object dockyardsv1.Cluster `json:"object"`
+		}
+
+		err = decoder.Decode(&event)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if event.Type != watch.Modified {
+			t.Errorf("expected event type %s, got %s", watch.Modified, event.Type)
+		}
+
+		if event.Object.Labels["test"] != "watch" {
+			t.Errorf("expected label test to be watch, got %q", event.Object.Labels["test"])
+		}
+	})
+
+	t.Run("test other user", func(t *testing.T) {
+		otherOrganization := environment.MustCreateOrganization(t)
+		otherUser := environment.MustGetOrganizationUser(t, otherOrganization, dockyardsv1.RoleSuperUser)
+
+		query := url.Values{
+			"watch": []string{"true"},
+		}
+
+		response, _ := watchEvents(t, query, otherUser)
+
+		if response.StatusCode != http.StatusForbidden {
+			t.Fatalf("unexpected status code %d", response.StatusCode)
+		}
+	})
+
+	t.Run("test kind not watchable", func(t *testing.T) {
+		featuresTarget, err := url.JoinPath(server.URL, "/v2",
+			"group", dockyardsv1.GroupVersion.Group,
+			"version", dockyardsv1.GroupVersion.Version,
+			"kind", "features",
+			"namespace", organization.Spec.NamespaceRef.Name,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := http.NewRequestWithContext(t.Context(), http.MethodGet, featuresTarget+"?watch=true", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r.Header.Add("Authorization", "Bearer "+MustSignToken(superUser))
+
+		response, err := http.DefaultClient.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusMethodNotAllowed {
+			t.Fatalf("unexpected status code %d", response.StatusCode)
+		}
+	})
+}
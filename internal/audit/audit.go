@@ -0,0 +1,203 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit records mutating API requests and writes them to one or more sinks.
+package audit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// Event is a single mutating request.
+type Event struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Subject       string    `json:"subject,omitempty"`
+	Organization  string    `json:"organization,omitempty"`
+	Cluster       string    `json:"cluster,omitempty"`
+	Resource      string    `json:"resource,omitempty"`
+	Subresource   string    `json:"subresource,omitempty"`
+	Name          string    `json:"name,omitempty"`
+	Verb          string    `json:"verb"`
+	Method        string    `json:"method"`
+	Path          string    `json:"path"`
+	RequestDigest string    `json:"requestDigest,omitempty"`
+	StatusCode    int       `json:"statusCode"`
+}
+
+// Sink writes events to a destination outside of the process.
+type Sink interface {
+	Write(context.Context, *Event) error
+}
+
+// Filter selects events, empty fields match any event.
+type Filter struct {
+	Organization string
+	Subject      string
+	Resource     string
+	Verb         string
+	Since        time.Time
+	Until        time.Time
+	Limit        int
+}
+
+func (f *Filter) matches(event *Event) bool {
+	if f.Organization != "" && event.Organization != f.Organization {
+		return false
+	}
+
+	if f.Subject != "" && event.Subject != f.Subject {
+		return false
+	}
+
+	if f.Resource != "" && event.Resource != f.Resource {
+		return false
+	}
+
+	if f.Verb != "" && event.Verb != f.Verb {
+		return false
+	}
+
+	if !f.Since.IsZero() && event.Timestamp.Before(f.Since) {
+		return false
+	}
+
+	if !f.Until.IsZero() && event.Timestamp.After(f.Until) {
+		return false
+	}
+
+	return true
+}
+
+// Apply returns the events matching the filter ordered by timestamp, oldest first. When the filter has a limit
+// only the most recent events are returned.
+func (f *Filter) Apply(events []Event) []Event {
+	matching := []Event{}
+	for i := range events {
+		if f.matches(&events[i]) {
+			matching = append(matching, events[i])
+		}
+	}
+
+	slices.SortStableFunc(matching, func(a, b Event) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+
+	if f.Limit > 0 && len(matching) > f.Limit {
+		matching = matching[len(matching)-f.Limit:]
+	}
+
+	return matching
+}
+
+// drainTimeout bounds how long queued events are written to sinks when the recorder is stopped.
+const drainTimeout = time.Second * 10
+
+// Recorder queues events and writes them to all sinks in the background, keeping slow sinks off the request
+// path.
+type Recorder struct {
+	sinks  []Sink
+	logger *slog.Logger
+	queue  chan Event
+}
+
+type RecorderOption func(*Recorder)
+
+func WithSinks(sinks ...Sink) RecorderOption {
+	return func(r *Recorder) {
+		r.sinks = append(r.sinks, sinks...)
+	}
+}
+
+func WithLogger(logger *slog.Logger) RecorderOption {
+	return func(r *Recorder) {
+		r.logger = logger
+	}
+}
+
+// NewRecorder returns a recorder that queues up to size events waiting to be written to the sinks.
+func NewRecorder(size int, recorderOptions ...RecorderOption) (*Recorder, error) {
+	if size < 1 {
+		return nil, errors.New("size must be at least 1")
+	}
+
+	r := Recorder{
+		queue:  make(chan Event, size),
+		logger: slog.New(slog.DiscardHandler),
+	}
+
+	for _, recorderOption := range recorderOptions {
+		recorderOption(&r)
+	}
+
+	return &r, nil
+}
+
+// Record queues the event to be written to the sinks, the event is dropped and logged when the queue is full
+// since recording must not block the request.
+func (r *Recorder) Record(event *Event) {
+	if r == nil {
+		return
+	}
+
+	select {
+	case r.queue <- *event:
+	default:
+		r.logger.Error("dropping audit event since queue is full", "verb", event.Verb, "path", event.Path)
+	}
+}
+
+// Start writes queued events to the sinks until the context is done, events still queued are then written
+// before returning. Errors from sinks are logged since a failing sink must not stop the recorder.
+func (r *Recorder) Start(ctx context.Context) error {
+	for {
+		select {
+		case event := <-r.queue:
+			r.write(ctx, &event)
+		case <-ctx.Done():
+			return r.drain(ctx)
+		}
+	}
+}
+
+// NeedLeaderElection makes every replica write the events recorded by itself.
+func (r *Recorder) NeedLeaderElection() bool {
+	return false
+}
+
+func (r *Recorder) drain(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), drainTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case event := <-r.queue:
+			r.write(ctx, &event)
+		default:
+			return nil
+		}
+	}
+}
+
+func (r *Recorder) write(ctx context.Context, event *Event) {
+	for _, sink := range r.sinks {
+		err := sink.Write(ctx, event)
+		if err != nil {
+			r.logger.Error("error writing audit event", "err", err)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sudoswedenab/dockyards-backend/internal/audit"
+)
+
+type fakeSink struct {
+	events chan audit.Event
+}
+
+func (s *fakeSink) Write(_ context.Context, event *audit.Event) error {
+	s.events <- *event
+
+	return nil
+}
+
+func TestRecorder(t *testing.T) {
+	events := []audit.Event{
+		{
+			Subject:  "alice",
+			Resource: "clusters",
+			Verb:     "create",
+		},
+		{
+			Subject:  "bob",
+			Resource: "clusters",
+			Verb:     "delete",
+		},
+		{
+			Subject:  "alice",
+			Resource: "members",
+			Verb:     "create",
+		},
+	}
+
+	t.Run("test write", func(t *testing.T) {
+		sink := fakeSink{
+			events: make(chan audit.Event, len(events)),
+		}
+
+		recorder, err := audit.NewRecorder(len(events), audit.WithSinks(&sink))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range events {
+			recorder.Record(&events[i])
+		}
+
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+
+		go recorder.Start(ctx)
+
+		var actual []audit.Event
+		for range events {
+			select {
+			case event := <-sink.events:
+				actual = append(actual, event)
+			case <-time.After(time.Second * 5):
+				t.Fatal("timeout waiting for sink")
+			}
+		}
+
+		if !cmp.Equal(actual, events) {
+			t.Errorf("diff: %s", cmp.Diff(events, actual))
+		}
+	})
+
+	t.Run("test full queue", func(t *testing.T) {
+		sink := fakeSink{
+			events: make(chan audit.Event, len(events)),
+		}
+
+		recorder, err := audit.NewRecorder(1, audit.WithSinks(&sink))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range events {
+			recorder.Record(&events[i])
+		}
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		err = recorder.Start(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		close(sink.events)
+
+		var actual []audit.Event
+		for event := range sink.events {
+			actual = append(actual, event)
+		}
+
+		expected := events[:1]
+
+		if !cmp.Equal(actual, expected) {
+			t.Errorf("diff: %s", cmp.Diff(expected, actual))
+		}
+	})
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	events := []audit.Event{
+		{
+			Timestamp:    now.Add(-time.Minute * 2),
+			Subject:      "bob",
+			Organization: "test",
+			Resource:     "clusters",
+			Verb:         "delete",
+		},
+		{
+			Timestamp:    now.Add(-time.Minute * 3),
+			Subject:      "alice",
+			Organization: "test",
+			Resource:     "clusters",
+			Verb:         "create",
+		},
+		{
+			Timestamp:    now,
+			Subject:      "alice",
+			Organization: "test",
+			Resource:     "invitations",
+			Verb:         "create",
+		},
+		{
+			Timestamp:    now.Add(-time.Minute),
+			Subject:      "alice",
+			Organization: "other",
+			Resource:     "members",
+			Verb:         "create",
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		filter   audit.Filter
+		expected []audit.Event
+	}{
+		{
+			name:     "test all",
+			expected: []audit.Event{events[1], events[0], events[3], events[2]},
+		},
+		{
+			name: "test organization",
+			filter: audit.Filter{
+				Organization: "test",
+			},
+			expected: []audit.Event{events[1], events[0], events[2]},
+		},
+		{
+			name: "test subject",
+			filter: audit.Filter{
+				Subject: "alice",
+			},
+			expected: []audit.Event{events[1], events[3], events[2]},
+		},
+		{
+			name: "test resource and verb",
+			filter: audit.Filter{
+				Resource: "clusters",
+				Verb:     "delete",
+			},
+			expected: []audit.Event{events[0]},
+		},
+		{
+			name: "test since and until",
+			filter: audit.Filter{
+				Since: now.Add(-time.Minute * 2),
+				Until: now.Add(-time.Minute),
+			},
+			expected: []audit.Event{events[0], events[3]},
+		},
+		{
+			name: "test limit",
+			filter: audit.Filter{
+				Limit: 2,
+			},
+			expected: []audit.Event{events[3], events[2]},
+		},
+		{
+			name: "test no match",
+			filter: audit.Filter{
+				Organization: "missing",
+			},
+			expected: []audit.Event{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.filter.Apply(events)
+			if !cmp.Equal(actual, tc.expected) {
+				t.Errorf("diff: %s", cmp.Diff(tc.expected, actual))
+			}
+		})
+	}
+}
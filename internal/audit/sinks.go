@@ -0,0 +1,296 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WriterSink writes events as JSON lines, e.g. to stdout.
+type WriterSink struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+func NewWriterSink(writer io.Writer) *WriterSink {
+	return &WriterSink{writer: writer}
+}
+
+func (s *WriterSink) Write(_ context.Context, event *Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err = s.writer.Write(append(b, '\n'))
+
+	return err
+}
+
+// FileSink writes events as JSON lines to a file, the file is rotated when it would exceed the max size and at
+// most max backups rotated files are kept with the suffixes .1, .2 and so on.
+type FileSink struct {
+	mutex      sync.Mutex
+	name       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func NewFileSink(name string, maxSize int64, maxBackups int) (*FileSink, error) {
+	s := FileSink{
+		name:       name,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+
+	err := os.MkdirAll(filepath.Dir(name), 0o700)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+
+		return err
+	}
+
+	s.file = file
+	s.size = info.Size()
+
+	return nil
+}
+
+func (s *FileSink) rotate() error {
+	err := s.file.Close()
+	if err != nil {
+		return err
+	}
+
+	if s.maxBackups < 1 {
+		err := os.Remove(s.name)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return s.open()
+	}
+
+	for i := s.maxBackups - 1; i > 0; i-- {
+		err := os.Rename(s.name+"."+strconv.Itoa(i), s.name+"."+strconv.Itoa(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	err = os.Rename(s.name, s.name+".1")
+	if err != nil {
+		return err
+	}
+
+	return s.open()
+}
+
+func (s *FileSink) Write(_ context.Context, event *Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	b = append(b, '\n')
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(b)) > s.maxSize {
+		err := s.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(b)
+	s.size += int64(n)
+
+	return err
+}
+
+func (s *FileSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.file.Close()
+}
+
+// WebhookSink posts every event as JSON to a URL.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookSink(url string, client *http.Client) *WebhookSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &WebhookSink{
+		url:    url,
+		client: client,
+	}
+}
+
+func (s *WebhookSink) Write(ctx context.Context, event *Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d from webhook", response.StatusCode)
+	}
+
+	return nil
+}
+
+// +kubebuilder:rbac:groups=dockyards.io,resources=auditevents,verbs=create
+// +kubebuilder:rbac:groups=dockyards.io,resources=organizations,verbs=get;list;watch
+
+// ResourceSink creates an audit event resource in the namespace of the organization of every event, events
+// without an organization are not written. The resources are shared by all replicas and are listed by the API.
+type ResourceSink struct {
+	client    client.Client
+	retention time.Duration
+}
+
+// NewResourceSink returns a sink creating audit event resources that are retained for the duration, a zero
+// duration retains them until deleted.
+func NewResourceSink(c client.Client, retention time.Duration) *ResourceSink {
+	return &ResourceSink{
+		client:    c,
+		retention: retention,
+	}
+}
+
+func (s *ResourceSink) Write(ctx context.Context, event *Event) error {
+	if event.Organization == "" {
+		return nil
+	}
+
+	var organization dockyardsv1.Organization
+	err := s.client.Get(ctx, client.ObjectKey{Name: event.Organization}, &organization)
+	if err != nil {
+		return err
+	}
+
+	if organization.Spec.NamespaceRef == nil {
+		return fmt.Errorf("organization %s has no namespace reference", organization.Name)
+	}
+
+	auditEvent := dockyardsv1.AuditEvent{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "audit-",
+			Namespace:    organization.Spec.NamespaceRef.Name,
+			Labels: map[string]string{
+				dockyardsv1.LabelOrganizationName: organization.Name,
+			},
+		},
+		Spec: dockyardsv1.AuditEventSpec{
+			Timestamp:     metav1.NewTime(event.Timestamp),
+			Subject:       event.Subject,
+			Cluster:       event.Cluster,
+			Resource:      event.Resource,
+			Subresource:   event.Subresource,
+			Name:          event.Name,
+			Verb:          event.Verb,
+			Method:        event.Method,
+			Path:          event.Path,
+			RequestDigest: event.RequestDigest,
+			StatusCode:    event.StatusCode,
+		},
+	}
+
+	if s.retention > 0 {
+		auditEvent.Spec.Duration = &metav1.Duration{Duration: s.retention}
+	}
+
+	return s.client.Create(ctx, &auditEvent)
+}
+
+// EventFromResource returns the event of an audit event resource of the organization.
+func EventFromResource(organizationName string, auditEvent *dockyardsv1.AuditEvent) Event {
+	return Event{
+		Timestamp:     auditEvent.Spec.Timestamp.Time,
+		Subject:       auditEvent.Spec.Subject,
+		Organization:  organizationName,
+		Cluster:       auditEvent.Spec.Cluster,
+		Resource:      auditEvent.Spec.Resource,
+		Subresource:   auditEvent.Spec.Subresource,
+		Name:          auditEvent.Spec.Name,
+		Verb:          auditEvent.Spec.Verb,
+		Method:        auditEvent.Spec.Method,
+		Path:          auditEvent.Spec.Path,
+		RequestDigest: auditEvent.Spec.RequestDigest,
+		StatusCode:    auditEvent.Spec.StatusCode,
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/audit"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestWriterSink(t *testing.T) {
+	var buffer bytes.Buffer
+
+	sink := audit.NewWriterSink(&buffer)
+
+	event := audit.Event{
+		Subject: "test",
+		Verb:    "create",
+	}
+
+	err := sink.Write(t.Context(), &event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"timestamp":"0001-01-01T00:00:00Z","subject":"test","verb":"create","method":"","path":"","statusCode":0}` + "\n"
+
+	if buffer.String() != expected {
+		t.Errorf("expected %s, got %s", expected, buffer.String())
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	name := path.Join(t.TempDir(), "audit", "audit.log")
+
+	event := audit.Event{
+		Subject: "test",
+		Verb:    "create",
+	}
+
+	b, err := json.Marshal(&event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line := string(b) + "\n"
+
+	// Room for two lines per file.
+	sink, err := audit.NewFileSink(name, int64(len(line)*2), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = sink.Close()
+	})
+
+	for range 7 {
+		err := sink.Write(t.Context(), &event)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string]string{
+		name:        line,
+		name + ".1": strings.Repeat(line, 2),
+		name + ".2": strings.Repeat(line, 2),
+	}
+
+	actual := map[string]string{}
+
+	for file := range expected {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual[file] = string(b)
+	}
+
+	if !cmp.Equal(actual, expected) {
+		t.Errorf("diff: %s", cmp.Diff(expected, actual))
+	}
+
+	_, err = os.Stat(name + ".3")
+	if !os.IsNotExist(err) {
+		t.Errorf("expected only two backups, got error %v", err)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var actual audit.Event
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+
+			return
+		}
+
+		err := json.NewDecoder(r.Body).Decode(&actual)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		if actual.Subject == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(server.Close)
+
+	sink := audit.NewWebhookSink(server.URL, server.Client())
+
+	expected := audit.Event{
+		Subject:      "test",
+		Organization: "test",
+		Verb:         "delete",
+		StatusCode:   http.StatusAccepted,
+	}
+
+	err := sink.Write(t.Context(), &expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !cmp.Equal(actual, expected) {
+		t.Errorf("diff: %s", cmp.Diff(expected, actual))
+	}
+
+	event := audit.Event{
+		Subject: "fail",
+	}
+
+	err = sink.Write(t.Context(), &event)
+	if err == nil {
+		t.Error("expected error from webhook")
+	}
+}
+
+func TestResourceSink(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = dockyardsv1.AddToScheme(scheme)
+
+	organization := dockyardsv1.Organization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+		},
+		Spec: dockyardsv1.OrganizationSpec{
+			NamespaceRef: &corev1.LocalObjectReference{
+				Name: "testing",
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&organization).Build()
+
+	sink := audit.NewResourceSink(c, time.Hour)
+
+	expected := audit.Event{
+		Timestamp:     time.Now().Truncate(time.Second),
+		Subject:       "alice",
+		Organization:  organization.Name,
+		Cluster:       "test-cluster",
+		Resource:      "clusters",
+		Name:          "test-cluster",
+		Verb:          "delete",
+		Method:        http.MethodDelete,
+		Path:          "/v1/orgs/test/clusters/test-cluster",
+		RequestDigest: "sha256:test",
+		StatusCode:    http.StatusAccepted,
+	}
+
+	err := sink.Write(t.Context(), &expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := audit.Event{
+		Subject: "bob",
+		Verb:    "create",
+	}
+
+	err = sink.Write(t.Context(), &event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var auditEventList dockyardsv1.AuditEventList
+	err = c.List(t.Context(), &auditEventList)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(auditEventList.Items) != 1 {
+		t.Fatalf("expected 1 audit event, got %d", len(auditEventList.Items))
+	}
+
+	auditEvent := auditEventList.Items[0]
+
+	if auditEvent.Namespace != "testing" {
+		t.Errorf("expected namespace testing, got %s", auditEvent.Namespace)
+	}
+
+	if auditEvent.Labels[dockyardsv1.LabelOrganizationName] != organization.Name {
+		t.Errorf("expected organization label %s, got %s", organization.Name, auditEvent.Labels[dockyardsv1.LabelOrganizationName])
+	}
+
+	if auditEvent.Spec.Duration == nil || auditEvent.Spec.Duration.Duration != time.Hour {
+		t.Errorf("expected duration %s, got %v", time.Hour, auditEvent.Spec.Duration)
+	}
+
+	actual := audit.EventFromResource(organization.Name, &auditEvent)
+
+	if !cmp.Equal(actual, expected) {
+		t.Errorf("diff: %s", cmp.Diff(expected, actual))
+	}
+
+	event = audit.Event{
+		Organization: "missing",
+		Verb:         "create",
+	}
+
+	err = sink.Write(t.Context(), &event)
+	if err == nil {
+		t.Error("expected error from missing organization")
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=dockyards.io,resources=auditevents,verbs=delete;get;list;watch
+
+// AuditEventReconciler garbage collects audit events once they have been retained for their duration.
+type AuditEventReconciler struct {
+	client.Client
+}
+
+func (r *AuditEventReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var auditEvent dockyardsv1.AuditEvent
+	err := r.Get(ctx, req.NamespacedName, &auditEvent)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !auditEvent.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	if apiutil.HasExpired(&auditEvent) {
+		err := r.Delete(ctx, &auditEvent)
+		if err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	expiration := auditEvent.GetExpiration()
+	if expiration != nil {
+		requeueAfter := time.Until(expiration.Time)
+
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *AuditEventReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	scheme := mgr.GetScheme()
+
+	_ = dockyardsv1.AddToScheme(scheme)
+
+	err := ctrl.NewControllerManagedBy(mgr).For(&dockyardsv1.AuditEvent{}).Complete(r)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
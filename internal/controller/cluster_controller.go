@@ -16,6 +16,8 @@ package controller
 
 import (
 	"context"
+	"slices"
+	"strings"
 	"time"
 
 	semverv4 "github.com/blang/semver/v4"
@@ -28,6 +30,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -35,10 +38,16 @@ import (
 
 // +kubebuilder:rbac:groups=dockyards.io,resources=clusters,verbs=get;delete;list;patch;watch
 // +kubebuilder:rbac:groups=dockyards.io,resources=releases,verbs=get;list;watch
+// +kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
+
+const (
+	clusterUpgradesAvailableReason = "UpgradesAvailable"
+)
 
 type ClusterReconciler struct {
 	client.Client
 	DockyardsNamespace string
+	Recorder           events.EventRecorder
 }
 
 func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, reterr error) {
@@ -53,6 +62,8 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (re
 	if apiutil.HasExpired(&cluster) && !cluster.Spec.BlockDeletion {
 		logger.Info("deleting expired cluster")
 
+		r.Recorder.Eventf(&cluster, nil, corev1.EventTypeNormal, dockyardsv1.ExpiredReason, "Delete", "Deleting expired cluster")
+
 		err := r.Delete(ctx, &cluster, client.PropagationPolicy(metav1.DeletePropagationForeground))
 		if err != nil {
 			return ctrl.Result{}, err
@@ -119,6 +130,7 @@ func (r *ClusterReconciler) reconcileClusterUpgrades(ctx context.Context, dockya
 	currentVersion, err := semverv4.ParseTolerant(dockyardsCluster.Spec.Version)
 	if err != nil {
 		logger.Error(err, "error parsing semver")
+		r.Recorder.Eventf(dockyardsCluster, nil, corev1.EventTypeWarning, dockyardsv1.ClusterUpgradesReconcileFailedReason, "Reconcile", "Unable to parse version %s: %s", dockyardsCluster.Spec.Version, err)
 		conditions.MarkFalse(dockyardsCluster, dockyardsv1.ClusterUpgradesReadyCondition, dockyardsv1.ClusterUpgradesReconcileFailedReason, "%s", err)
 
 		return ctrl.Result{}, nil
@@ -178,6 +190,15 @@ func (r *ClusterReconciler) reconcileClusterUpgrades(ctx context.Context, dockya
 		})
 	}
 
+	if len(upgrades) > 0 && !slices.Equal(dockyardsCluster.Spec.Upgrades, upgrades) {
+		versions := make([]string, len(upgrades))
+		for i, upgrade := range upgrades {
+			versions[i] = upgrade.To
+		}
+
+		r.Recorder.Eventf(dockyardsCluster, nil, corev1.EventTypeNormal, clusterUpgradesAvailableReason, "Reconcile", "Upgrades available to %s", strings.Join(versions, ", "))
+	}
+
 	dockyardsCluster.Spec.Upgrades = upgrades
 
 	conditions.MarkTrue(dockyardsCluster, dockyardsv1.ClusterUpgradesReadyCondition, dockyardsv1.ReadyReason, "")
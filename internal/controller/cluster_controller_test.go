@@ -29,6 +29,7 @@ import (
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
 	"github.com/sudoswedenab/dockyards-backend/internal/controller"
 	"github.com/sudoswedenab/dockyards-backend/pkg/testing/testingutil"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -108,6 +109,7 @@ func TestClusterController_Upgrades(t *testing.T) {
 	err = (&controller.ClusterReconciler{
 		Client:             mgr.GetClient(),
 		DockyardsNamespace: testEnvironment.GetDockyardsNamespace(),
+		Recorder:           mgr.GetEventRecorder("test"),
 	}).SetupWithManager(mgr)
 	if err != nil {
 		t.Fatal(err)
@@ -449,6 +451,7 @@ func TestClusterController_DNSZones(t *testing.T) {
 	err = (&controller.ClusterReconciler{
 		Client:             mgr.GetClient(),
 		DockyardsNamespace: testEnvironment.GetDockyardsNamespace(),
+		Recorder:           mgr.GetEventRecorder("test"),
 	}).SetupWithManager(mgr)
 	if err != nil {
 		t.Fatal(err)
@@ -597,3 +600,97 @@ func TestClusterController_DNSZones(t *testing.T) {
 		}
 	})
 }
+
+func TestClusterController_Events(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("no kubebuilder assets configured")
+	}
+
+	handler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})
+	slogr := logr.FromSlogHandler(handler)
+	ctrl.SetLogger(slogr)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	testEnvironment, err := testingutil.NewTestEnvironment(ctx, []string{path.Join("../../config/crd")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		cancel()
+		testEnvironment.GetEnvironment().Stop()
+	})
+
+	mgr := testEnvironment.GetManager()
+	c := testEnvironment.GetClient()
+
+	organization := testEnvironment.MustCreateOrganization(t)
+
+	err = (&controller.ClusterReconciler{
+		Client:             mgr.GetClient(),
+		DockyardsNamespace: testEnvironment.GetDockyardsNamespace(),
+		Recorder:           mgr.GetEventRecorder("test"),
+	}).SetupWithManager(mgr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		err := mgr.Start(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	if !mgr.GetCache().WaitForCacheSync(ctx) {
+		t.Fatal("unable to wait for cache sync")
+	}
+
+	t.Run("test expired cluster", func(t *testing.T) {
+		cluster := dockyardsv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    organization.Spec.NamespaceRef.Name,
+			},
+			Spec: dockyardsv1.ClusterSpec{
+				Duration: &metav1.Duration{
+					Duration: time.Second,
+				},
+			},
+		}
+
+		err := c.Create(ctx, &cluster)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		matchingFields := client.MatchingFields{
+			"involvedObject.uid": string(cluster.UID),
+		}
+
+		var eventList corev1.EventList
+
+		err = wait.PollUntilContextTimeout(ctx, time.Millisecond*200, time.Second*10, true, func(ctx context.Context) (bool, error) {
+			err := c.List(ctx, &eventList, matchingFields, client.InNamespace(cluster.Namespace))
+			if err != nil {
+				return true, err
+			}
+
+			return len(eventList.Items) > 0, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual := eventList.Items[0]
+
+		if actual.Reason != dockyardsv1.ExpiredReason {
+			t.Errorf("expected reason %s, got %s", dockyardsv1.ExpiredReason, actual.Reason)
+		}
+
+		if actual.Type != corev1.EventTypeNormal {
+			t.Errorf("expected type %s, got %s", corev1.EventTypeNormal, actual.Type)
+		}
+	})
+}
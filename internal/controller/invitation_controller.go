@@ -16,21 +16,39 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/fluxcd/pkg/runtime/conditions"
 	"github.com/fluxcd/pkg/runtime/patch"
 	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
+	"github.com/sudoswedenab/dockyards-backend/api/config"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/pkg/util/bubblebabble"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 )
 
+// invitationRetention is how long an accepted, declined or expired invitation is kept before it is deleted.
+const invitationRetention = time.Hour * 24
+
 // +kubebuilder:rbac:groups=dockyards.io,resources=invitations,verbs=delete;get;list;patch;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=invitations/status,verbs=patch
+// +kubebuilder:rbac:groups=dockyards.io,resources=verificationrequests,verbs=create;delete;get;list;patch;watch
 
 type InvitationReconciler struct {
 	client.Client
+	Config   *config.ConfigManager
+	Recorder events.EventRecorder
 }
 
 func (r *InvitationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, reterr error) {
@@ -40,11 +58,8 @@ func (r *InvitationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	if apiutil.HasExpired(&invitation) {
-		err := r.Delete(ctx, &invitation, client.PropagationPolicy(metav1.DeletePropagationForeground))
-		if err != nil {
-			return ctrl.Result{}, err
-		}
+	if !invitation.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
 	}
 
 	patchHelper, err := patch.NewHelper(&invitation, r)
@@ -63,6 +78,22 @@ func (r *InvitationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	expiration := invitation.GetExpiration()
 	invitation.Status.ExpirationTimestamp = expiration
 
+	if invitation.IsPending() && apiutil.HasExpired(&invitation) {
+		r.Recorder.Eventf(&invitation, nil, corev1.EventTypeNormal, dockyardsv1.InvitationExpiredReason, "Reconcile", "Invitation of %s has expired", invitation.Spec.Email)
+		conditions.MarkFalse(&invitation, dockyardsv1.InvitationAcceptedCondition, dockyardsv1.InvitationExpiredReason, "")
+	}
+
+	if !invitation.IsPending() {
+		return r.reconcileCompletedInvitation(ctx, &invitation)
+	}
+
+	conditions.MarkUnknown(&invitation, dockyardsv1.InvitationAcceptedCondition, dockyardsv1.InvitationPendingReason, "")
+
+	err = r.reconcileVerificationRequest(ctx, &invitation)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	if expiration != nil {
 		requeueAfter := time.Until(expiration.Time)
 
@@ -72,12 +103,157 @@ func (r *InvitationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	return ctrl.Result{}, nil
 }
 
+func (r *InvitationReconciler) reconcileCompletedInvitation(ctx context.Context, invitation *dockyardsv1.Invitation) (ctrl.Result, error) {
+	verificationRequest := dockyardsv1.VerificationRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: invitation.GetVerificationRequestName(),
+		},
+	}
+
+	err := r.Delete(ctx, &verificationRequest)
+	if client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, err
+	}
+
+	condition := conditions.Get(invitation, dockyardsv1.InvitationAcceptedCondition)
+
+	deleteAfter := condition.LastTransitionTime.Add(invitationRetention)
+	if time.Now().Before(deleteAfter) {
+		return ctrl.Result{RequeueAfter: time.Until(deleteAfter)}, nil
+	}
+
+	err = r.Delete(ctx, invitation, client.PropagationPolicy(metav1.DeletePropagationForeground))
+	if client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *InvitationReconciler) reconcileVerificationRequest(ctx context.Context, invitation *dockyardsv1.Invitation) error {
+	logger := ctrl.LoggerFrom(ctx)
+
+	organization, err := apiutil.GetOwnerOrganization(ctx, r, invitation)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if organization.Spec.NamespaceRef == nil {
+		return nil
+	}
+
+	verificationRequest := dockyardsv1.VerificationRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: invitation.GetVerificationRequestName(),
+		},
+	}
+
+	operationResult, err := controllerutil.CreateOrPatch(ctx, r.Client, &verificationRequest, func() error {
+		verificationRequest.Labels = map[string]string{
+			dockyardsv1.LabelOrganizationName: organization.Name,
+		}
+
+		verificationRequest.OwnerReferences = []metav1.OwnerReference{
+			{
+				APIVersion: dockyardsv1.GroupVersion.String(),
+				Kind:       dockyardsv1.OrganizationKind,
+				Name:       organization.Name,
+				UID:        organization.UID,
+			},
+		}
+
+		verificationRequest.Spec.Subject = "Invitation"
+		verificationRequest.Spec.Email = invitation.Spec.Email
+		verificationRequest.Spec.UserRef = corev1.TypedLocalObjectReference{
+			APIGroup: &dockyardsv1.GroupVersion.Group,
+			Kind:     dockyardsv1.InvitationKind,
+			Name:     invitation.Name,
+		}
+
+		if verificationRequest.Spec.Code == "" {
+			code, err := bubblebabble.RandomWithEntropyOfAtLeast(32)
+			if err != nil {
+				return err
+			}
+
+			verificationRequest.Spec.Code = code
+		}
+
+		organizationName := organization.Name
+		if organization.Spec.DisplayName != "" {
+			organizationName = organization.Spec.DisplayName
+		}
+
+		bodyText := fmt.Sprintf("You have been invited to join the organization %s.\nHere is your invitation code: %s.\n", organizationName, verificationRequest.Spec.Code)
+
+		externalURL := r.Config.GetValueOrDefault(config.KeyExternalURL, "")
+		if externalURL != "" {
+			acceptURL := strings.TrimSuffix(externalURL, "/") + "/invitations/accept?" + url.Values{"code": []string{verificationRequest.Spec.Code}}.Encode()
+			bodyText += fmt.Sprintf("You can accept the invitation at %s.\n", acceptURL)
+		}
+
+		verificationRequest.Spec.BodyText = bodyText + "If you were not expecting an invitation, you can ignore this email."
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if operationResult != controllerutil.OperationResultNone {
+		logger.Info("reconciled invitation verification request", "verificationRequestName", verificationRequest.Name, "operationResult", operationResult)
+	}
+
+	return nil
+}
+
+func (r *InvitationReconciler) verificationRequestToInvitations(ctx context.Context, obj client.Object) []ctrl.Request {
+	verificationRequest, ok := obj.(*dockyardsv1.VerificationRequest)
+	if !ok {
+		return nil
+	}
+
+	if verificationRequest.Spec.UserRef.Kind != dockyardsv1.InvitationKind {
+		return nil
+	}
+
+	organizationName, has := verificationRequest.Labels[dockyardsv1.LabelOrganizationName]
+	if !has {
+		return nil
+	}
+
+	var organization dockyardsv1.Organization
+	err := r.Get(ctx, client.ObjectKey{Name: organizationName}, &organization)
+	if err != nil {
+		return nil
+	}
+
+	if organization.Spec.NamespaceRef == nil {
+		return nil
+	}
+
+	return []ctrl.Request{
+		{
+			NamespacedName: types.NamespacedName{
+				Name:      verificationRequest.Spec.UserRef.Name,
+				Namespace: organization.Spec.NamespaceRef.Name,
+			},
+		},
+	}
+}
+
 func (r *InvitationReconciler) SetupWithManger(mgr ctrl.Manager) error {
 	scheme := mgr.GetScheme()
 
 	_ = dockyardsv1.AddToScheme(scheme)
 
-	err := ctrl.NewControllerManagedBy(mgr).For(&dockyardsv1.Invitation{}).Complete(r)
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&dockyardsv1.Invitation{}).
+		Watches(
+			&dockyardsv1.VerificationRequest{},
+			handler.EnqueueRequestsFromMapFunc(r.verificationRequestToInvitations),
+		).
+		Complete(r)
 	if err != nil {
 		return err
 	}
@@ -22,11 +22,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fluxcd/pkg/runtime/conditions"
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
 	"github.com/sudoswedenab/dockyards-backend/internal/controller"
 	"github.com/sudoswedenab/dockyards-backend/pkg/testing/testingutil"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -59,7 +62,8 @@ func TestInvitationController_ReconcileExpiration(t *testing.T) {
 	c := testEnvironment.GetClient()
 
 	err = (&controller.InvitationReconciler{
-		Client: mgr.GetClient(),
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorder("test"),
 	}).SetupWithManger(mgr)
 	if err != nil {
 		t.Fatal(err)
@@ -76,6 +80,8 @@ func TestInvitationController_ReconcileExpiration(t *testing.T) {
 		t.Fatal("unable to wait for cache sync")
 	}
 
+	ignoreLastTransitionTime := cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")
+
 	t.Run("test invitation", func(t *testing.T) {
 		invitation := dockyardsv1.Invitation{
 			ObjectMeta: metav1.ObjectMeta{
@@ -100,7 +106,7 @@ func TestInvitationController_ReconcileExpiration(t *testing.T) {
 				return true, err
 			}
 
-			return invitation.Status.ExpirationTimestamp == nil, nil
+			return conditions.Has(&actual, dockyardsv1.InvitationAcceptedCondition), nil
 		})
 		if err != nil {
 			t.Fatal(err)
@@ -109,11 +115,20 @@ func TestInvitationController_ReconcileExpiration(t *testing.T) {
 		expected := dockyardsv1.Invitation{
 			ObjectMeta: actual.ObjectMeta,
 			Spec:       actual.Spec,
-			Status:     dockyardsv1.InvitationStatus{},
+			Status: dockyardsv1.InvitationStatus{
+				Conditions: []metav1.Condition{
+					{
+						Type:               dockyardsv1.InvitationAcceptedCondition,
+						Status:             metav1.ConditionUnknown,
+						Reason:             dockyardsv1.InvitationPendingReason,
+						ObservedGeneration: actual.Generation,
+					},
+				},
+			},
 		}
 
-		if !cmp.Equal(actual, expected) {
-			t.Errorf("diff: %s", cmp.Diff(expected, actual))
+		if !cmp.Equal(actual, expected, ignoreLastTransitionTime) {
+			t.Errorf("diff: %s", cmp.Diff(expected, actual, ignoreLastTransitionTime))
 		}
 	})
 
@@ -157,11 +172,19 @@ func TestInvitationController_ReconcileExpiration(t *testing.T) {
 				ExpirationTimestamp: &metav1.Time{
 					Time: invitation.CreationTimestamp.Add(invitation.Spec.Duration.Duration),
 				},
+				Conditions: []metav1.Condition{
+					{
+						Type:               dockyardsv1.InvitationAcceptedCondition,
+						Status:             metav1.ConditionUnknown,
+						Reason:             dockyardsv1.InvitationPendingReason,
+						ObservedGeneration: actual.Generation,
+					},
+				},
 			},
 		}
 
-		if !cmp.Equal(actual, expected) {
-			t.Errorf("diff: %s", cmp.Diff(expected, actual))
+		if !cmp.Equal(actual, expected, ignoreLastTransitionTime) {
+			t.Errorf("diff: %s", cmp.Diff(expected, actual, ignoreLastTransitionTime))
 		}
 	})
 
@@ -195,10 +218,73 @@ func TestInvitationController_ReconcileExpiration(t *testing.T) {
 				return true, err
 			}
 
-			return !actual.DeletionTimestamp.IsZero(), nil
+			return !actual.IsPending(), nil
 		})
 		if err != nil {
 			t.Fatal(err)
 		}
+
+		condition := conditions.Get(&actual, dockyardsv1.InvitationAcceptedCondition)
+		if condition.Reason != dockyardsv1.InvitationExpiredReason {
+			t.Errorf("expected reason %s, got %s", dockyardsv1.InvitationExpiredReason, condition.Reason)
+		}
+	})
+
+	t.Run("test verification request", func(t *testing.T) {
+		invitation := dockyardsv1.Invitation{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    organization.Spec.NamespaceRef.Name,
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: dockyardsv1.GroupVersion.String(),
+						Kind:       dockyardsv1.OrganizationKind,
+						Name:       organization.Name,
+						UID:        organization.UID,
+					},
+				},
+			},
+			Spec: dockyardsv1.InvitationSpec{
+				Email: "invited@dockyards.dev",
+				Role:  dockyardsv1.RoleReader,
+			},
+		}
+
+		err := c.Create(ctx, &invitation)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual dockyardsv1.VerificationRequest
+
+		err = wait.PollUntilContextTimeout(ctx, time.Millisecond*200, time.Second*5, true, func(ctx context.Context) (bool, error) {
+			err := c.Get(ctx, client.ObjectKey{Name: invitation.GetVerificationRequestName()}, &actual)
+			if err != nil {
+				return false, client.IgnoreNotFound(err)
+			}
+
+			return true, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual.Spec.Code == "" {
+			t.Error("expected verification request code, got empty")
+		}
+
+		expected := corev1.TypedLocalObjectReference{
+			APIGroup: &dockyardsv1.GroupVersion.Group,
+			Kind:     dockyardsv1.InvitationKind,
+			Name:     invitation.Name,
+		}
+
+		if !cmp.Equal(actual.Spec.UserRef, expected) {
+			t.Errorf("diff: %s", cmp.Diff(expected, actual.Spec.UserRef))
+		}
+
+		if actual.Spec.Email != invitation.Spec.Email {
+			t.Errorf("expected email %s, got %s", invitation.Spec.Email, actual.Spec.Email)
+		}
 	})
 }
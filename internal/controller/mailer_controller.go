@@ -0,0 +1,204 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	htmltemplate "html/template"
+	"time"
+
+	"github.com/fluxcd/pkg/runtime/conditions"
+	"github.com/fluxcd/pkg/runtime/patch"
+	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
+	"github.com/sudoswedenab/dockyards-backend/api/config"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/pkg/util/mailer"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	mailerInitialBackoff = time.Second * 5
+	mailerMaxBackoff     = time.Hour
+)
+
+// +kubebuilder:rbac:groups=dockyards.io,resources=verificationrequests,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=verificationrequests/status,verbs=patch
+// +kubebuilder:rbac:groups=dockyards.io,resources=users,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+
+// MailerReconciler delivers verification requests using the provider, the subject and bodies are rendered from the
+// templates in the templates config map when it exists.
+type MailerReconciler struct {
+	client.Client
+	Config             *config.ConfigManager
+	Provider           mailer.Provider
+	From               string
+	TemplatesConfigMap client.ObjectKey
+}
+
+func (r *MailerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, reterr error) {
+	logger := ctrl.LoggerFrom(ctx)
+
+	var verificationRequest dockyardsv1.VerificationRequest
+	err := r.Get(ctx, req.NamespacedName, &verificationRequest)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !verificationRequest.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Requests with a provider id have been delivered, possibly by another component.
+	if verificationRequest.Status.ProviderID != "" || conditions.IsTrue(&verificationRequest, dockyardsv1.SentCondition) {
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(&verificationRequest, r)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		err := patchHelper.Patch(ctx, &verificationRequest)
+		if err != nil {
+			result = ctrl.Result{}
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	if apiutil.HasExpired(&verificationRequest) {
+		conditions.MarkFalse(&verificationRequest, dockyardsv1.SentCondition, dockyardsv1.ExpiredReason, "")
+
+		return ctrl.Result{}, nil
+	}
+
+	recipient, err := r.getRecipient(ctx, &verificationRequest)
+	if apierrors.IsNotFound(err) {
+		conditions.MarkFalse(&verificationRequest, dockyardsv1.SentCondition, dockyardsv1.RecipientNotFoundReason, "%s", err)
+
+		return ctrl.Result{}, nil
+	}
+
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	message, err := r.renderMessage(ctx, &verificationRequest, recipient)
+	if err != nil {
+		conditions.MarkFalse(&verificationRequest, dockyardsv1.SentCondition, dockyardsv1.RenderFailedReason, "%s", err)
+
+		return ctrl.Result{}, err
+	}
+
+	providerID, err := r.Provider.Send(ctx, message)
+	if err != nil {
+		logger.Error(err, "error sending verification request")
+
+		verificationRequest.Status.DeliveryAttempts++
+
+		conditions.MarkFalse(&verificationRequest, dockyardsv1.SentCondition, dockyardsv1.SendFailedReason, "%s", err)
+
+		return ctrl.Result{RequeueAfter: mailerBackoff(verificationRequest.Status.DeliveryAttempts)}, nil
+	}
+
+	verificationRequest.Status.ProviderID = providerID
+
+	conditions.MarkTrue(&verificationRequest, dockyardsv1.SentCondition, dockyardsv1.SentReason, "")
+
+	return ctrl.Result{}, nil
+}
+
+// getRecipient returns the email of the referenced user unless the request has an email.
+func (r *MailerReconciler) getRecipient(ctx context.Context, verificationRequest *dockyardsv1.VerificationRequest) (string, error) {
+	if verificationRequest.Spec.Email != "" {
+		return verificationRequest.Spec.Email, nil
+	}
+
+	if verificationRequest.Spec.UserRef.Kind != dockyardsv1.UserKind {
+		return "", apierrors.NewNotFound(dockyardsv1.GroupVersion.WithResource("users").GroupResource(), verificationRequest.Spec.UserRef.Name)
+	}
+
+	var user dockyardsv1.User
+	err := r.Get(ctx, client.ObjectKey{Name: verificationRequest.Spec.UserRef.Name}, &user)
+	if err != nil {
+		return "", err
+	}
+
+	return user.Spec.Email, nil
+}
+
+func (r *MailerReconciler) renderMessage(ctx context.Context, verificationRequest *dockyardsv1.VerificationRequest, recipient string) (*mailer.Message, error) {
+	var configMap corev1.ConfigMap
+	err := r.Get(ctx, r.TemplatesConfigMap, &configMap)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, err
+	}
+
+	data := mailer.TemplateData{
+		EnvironmentName: r.Config.GetValueOrDefault(config.KeyEnvironmentName, ""),
+		ExternalURL:     r.Config.GetValueOrDefault(config.KeyExternalURL, ""),
+		Recipient:       recipient,
+		Code:            verificationRequest.Spec.Code,
+		Subject:         verificationRequest.Spec.Subject,
+		BodyText:        verificationRequest.Spec.BodyText,
+		BodyHTML:        htmltemplate.HTML(verificationRequest.Spec.BodyHTML), //nolint:gosec
+	}
+
+	message, err := mailer.Render(configMap.Data, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	message.From = r.From
+
+	return message, nil
+}
+
+func mailerBackoff(attempts int) time.Duration {
+	backoff := mailerInitialBackoff
+
+	for range attempts - 1 {
+		backoff *= 2
+
+		if backoff >= mailerMaxBackoff {
+			return mailerMaxBackoff
+		}
+	}
+
+	return backoff
+}
+
+func (r *MailerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	scheme := mgr.GetScheme()
+
+	_ = dockyardsv1.AddToScheme(scheme)
+
+	err := ctrl.NewControllerManagedBy(mgr).
+		Named("mailer").
+		For(&dockyardsv1.VerificationRequest{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/pkg/runtime/conditions"
+	"github.com/go-logr/logr"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/internal/controller"
+	"github.com/sudoswedenab/dockyards-backend/pkg/testing/testingutil"
+	"github.com/sudoswedenab/dockyards-backend/pkg/util/mailer"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type fakeProvider struct {
+	mutex    sync.Mutex
+	messages []mailer.Message
+	failures int
+}
+
+func (p *fakeProvider) Send(_ context.Context, message *mailer.Message) (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.failures > 0 {
+		p.failures--
+
+		return "", errors.New("temporary failure")
+	}
+
+	p.messages = append(p.messages, *message)
+
+	return "fake-" + message.To, nil
+}
+
+func (p *fakeProvider) getMessages() []mailer.Message {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return append([]mailer.Message{}, p.messages...)
+}
+
+func TestMailerController(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("no kubebuilder assets configured")
+	}
+
+	ctx := t.Context()
+
+	handler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})
+	slogr := logr.FromSlogHandler(handler)
+	ctrl.SetLogger(slogr)
+
+	testEnvironment, err := testingutil.NewTestEnvironment(ctx, []string{path.Join("../../config/crd")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		testEnvironment.GetEnvironment().Stop()
+	})
+
+	mgr := testEnvironment.GetManager()
+	c := testEnvironment.GetClient()
+
+	templates := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mail-templates",
+			Namespace: testEnvironment.GetDockyardsNamespace(),
+		},
+		Data: map[string]string{
+			mailer.TemplateKeySubject: "[testing] {{ .Subject }}",
+		},
+	}
+
+	err = c.Create(ctx, &templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := fakeProvider{
+		failures: 1,
+	}
+
+	err = (&controller.MailerReconciler{
+		Client:             mgr.GetClient(),
+		Provider:           &provider,
+		From:               "noreply@dockyards.dev",
+		TemplatesConfigMap: client.ObjectKeyFromObject(&templates),
+	}).SetupWithManager(mgr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		err := mgr.Start(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	if !mgr.GetCache().WaitForCacheSync(ctx) {
+		t.Fatal("unable to wait for cache sync")
+	}
+
+	t.Run("test email", func(t *testing.T) {
+		verificationRequest := dockyardsv1.VerificationRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+			},
+			Spec: dockyardsv1.VerificationRequestSpec{
+				Code:     "abc",
+				Subject:  "Invitation",
+				BodyText: "hello",
+				UserRef: corev1.TypedLocalObjectReference{
+					APIGroup: &dockyardsv1.GroupVersion.Group,
+					Kind:     dockyardsv1.InvitationKind,
+					Name:     "test",
+				},
+				Email: "test@dockyards.dev",
+			},
+		}
+
+		err := c.Create(ctx, &verificationRequest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual dockyardsv1.VerificationRequest
+
+		err = wait.PollUntilContextTimeout(ctx, time.Millisecond*200, time.Second*15, true, func(ctx context.Context) (bool, error) {
+			err := c.Get(ctx, client.ObjectKeyFromObject(&verificationRequest), &actual)
+			if err != nil {
+				return true, err
+			}
+
+			return conditions.IsTrue(&actual, dockyardsv1.SentCondition), nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual.Status.ProviderID != "fake-test@dockyards.dev" {
+			t.Errorf("expected provider id, got %s", actual.Status.ProviderID)
+		}
+
+		if actual.Status.DeliveryAttempts != 1 {
+			t.Errorf("expected 1 failed delivery attempt, got %d", actual.Status.DeliveryAttempts)
+		}
+
+		messages := provider.getMessages()
+		if len(messages) != 1 {
+			t.Fatalf("expected 1 message, got %d", len(messages))
+		}
+
+		if messages[0].Subject != "[testing] Invitation" {
+			t.Errorf("expected rendered subject, got %s", messages[0].Subject)
+		}
+
+		if messages[0].From != "noreply@dockyards.dev" {
+			t.Errorf("expected from address, got %s", messages[0].From)
+		}
+	})
+
+	t.Run("test user not found", func(t *testing.T) {
+		verificationRequest := dockyardsv1.VerificationRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+			},
+			Spec: dockyardsv1.VerificationRequestSpec{
+				Code:     "abc",
+				Subject:  "Verification",
+				BodyText: "hello",
+				UserRef: corev1.TypedLocalObjectReference{
+					APIGroup: &dockyardsv1.GroupVersion.Group,
+					Kind:     dockyardsv1.UserKind,
+					Name:     "missing",
+				},
+			},
+		}
+
+		err := c.Create(ctx, &verificationRequest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual dockyardsv1.VerificationRequest
+
+		err = wait.PollUntilContextTimeout(ctx, time.Millisecond*200, time.Second*5, true, func(ctx context.Context) (bool, error) {
+			err := c.Get(ctx, client.ObjectKeyFromObject(&verificationRequest), &actual)
+			if err != nil {
+				return true, err
+			}
+
+			return conditions.Has(&actual, dockyardsv1.SentCondition), nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if conditions.GetReason(&actual, dockyardsv1.SentCondition) != dockyardsv1.RecipientNotFoundReason {
+			t.Errorf("expected reason %s, got %s", dockyardsv1.RecipientNotFoundReason, conditions.GetReason(&actual, dockyardsv1.SentCondition))
+		}
+	})
+}
@@ -23,17 +23,23 @@ import (
 	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
 	"github.com/sudoswedenab/dockyards-backend/pkg/authorization"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 )
 
+// +kubebuilder:rbac:groups=dockyards.io,resources=clusters,verbs=get;list;watch
 // +kubebuilder:rbac:groups=dockyards.io,resources=members/status,verbs=patch
 // +kubebuilder:rbac:groups=dockyards.io,resources=members,verbs=get;list;patch;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=serviceaccounts,verbs=get;list;watch
 // +kubebuilder:rbac:groups=dockyards.io,resources=users,verbs=get;list;watch
 
 type MemberReconciler struct {
 	client.Client
+	Recorder events.EventRecorder
 }
 
 func (r *MemberReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, reterr error) {
@@ -76,6 +82,7 @@ func (r *MemberReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 func (r *MemberReconciler) reconcileAuthorization(ctx context.Context, member *dockyardsv1.Member) (ctrl.Result, error) {
 	err := authorization.ReconcileMemberAuthorization(ctx, r, member)
 	if err != nil {
+		r.Recorder.Eventf(member, nil, corev1.EventTypeWarning, dockyardsv1.MemberAuthorizationInternalErrorReason, "Reconcile", "Unable to reconcile role bindings: %s", err)
 		conditions.MarkFalse(member, dockyardsv1.MemberAuthorizationReadyCondition, dockyardsv1.MemberAuthorizationInternalErrorReason, "%s", err)
 
 		return ctrl.Result{}, nil
@@ -96,9 +103,14 @@ func (r *MemberReconciler) reconcileInfo(ctx context.Context, member *dockyardsv
 		member.Labels = map[string]string{}
 	}
 	member.Labels[dockyardsv1.LabelRoleName] = string(member.Spec.Role)
-	member.Labels[dockyardsv1.LabelUserName] = member.Spec.UserRef.Name
 	member.Labels[dockyardsv1.LabelOrganizationName] = organization.Name
 
+	if member.Spec.UserRef.Kind == dockyardsv1.ServiceAccountKind {
+		return r.reconcileServiceAccountInfo(ctx, member)
+	}
+
+	member.Labels[dockyardsv1.LabelUserName] = member.Spec.UserRef.Name
+
 	key := client.ObjectKey{
 		Name: member.Spec.UserRef.Name,
 	}
@@ -127,12 +139,70 @@ func (r *MemberReconciler) reconcileInfo(ctx context.Context, member *dockyardsv
 	return ctrl.Result{}, nil
 }
 
+func (r *MemberReconciler) reconcileServiceAccountInfo(ctx context.Context, member *dockyardsv1.Member) (ctrl.Result, error) {
+	delete(member.Labels, dockyardsv1.LabelUserName)
+	member.Labels[dockyardsv1.LabelServiceAccountName] = member.Spec.UserRef.Name
+
+	key := client.ObjectKey{
+		Name:      member.Spec.UserRef.Name,
+		Namespace: member.Namespace,
+	}
+	var serviceAccount dockyardsv1.ServiceAccount
+	err := r.Get(ctx, key, &serviceAccount)
+	if errors.IsNotFound(err) {
+		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	member.Status.Email = nil
+
+	if serviceAccount.Spec.DisplayName == "" {
+		member.Status.DisplayName = nil
+	} else {
+		member.Status.DisplayName = &serviceAccount.Spec.DisplayName
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// clusterToMembers enqueues the members limited to a subset of the clusters, the clusters matching their selectors
+// may change when a cluster is created, deleted or relabelled.
+func (r *MemberReconciler) clusterToMembers(ctx context.Context, obj client.Object) []ctrl.Request {
+	var memberList dockyardsv1.MemberList
+	err := r.List(ctx, &memberList, client.InNamespace(obj.GetNamespace()))
+	if err != nil {
+		return nil
+	}
+
+	var requests []ctrl.Request
+
+	for _, member := range memberList.Items {
+		if member.Spec.Clusters == nil || member.Spec.Clusters.Selector == nil {
+			continue
+		}
+
+		requests = append(requests, ctrl.Request{
+			NamespacedName: client.ObjectKeyFromObject(&member),
+		})
+	}
+
+	return requests
+}
+
 func (r *MemberReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	scheme := mgr.GetScheme()
 
 	_ = dockyardsv1.AddToScheme(scheme)
 
-	err := ctrl.NewControllerManagedBy(mgr).For(&dockyardsv1.Member{}).Complete(r)
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&dockyardsv1.Member{}).
+		Watches(
+			&dockyardsv1.Cluster{},
+			handler.EnqueueRequestsFromMapFunc(r.clusterToMembers),
+		).
+		Complete(r)
 	if err != nil {
 		return err
 	}
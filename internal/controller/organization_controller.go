@@ -24,13 +24,16 @@ import (
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
 	"github.com/sudoswedenab/dockyards-backend/api/v1alpha3/index"
 	"github.com/sudoswedenab/dockyards-backend/pkg/authorization"
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 )
 
 const (
@@ -39,6 +42,7 @@ const (
 
 type OrganizationReconciler struct {
 	client.Client
+	Recorder events.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=dockyards.io,resources=*,verbs=*
@@ -63,6 +67,8 @@ func (r *OrganizationReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	if apiutil.HasExpired(&organization) {
 		logger.Info("organization has expired")
 
+		r.Recorder.Eventf(&organization, nil, corev1.EventTypeNormal, dockyardsv1.ExpiredReason, "Delete", "Deleting expired organization")
+
 		err := r.Delete(ctx, &organization, client.PropagationPolicy(metav1.DeletePropagationForeground))
 		if apiutil.IgnoreInternalError(err) != nil {
 			return ctrl.Result{}, err
@@ -105,6 +111,11 @@ func (r *OrganizationReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return result, err
 	}
 
+	result, err = r.reconcileResourceUsage(ctx, &organization)
+	if err != nil {
+		return result, err
+	}
+
 	expiration := organization.GetExpiration()
 	organization.Status.ExpirationTimestamp = expiration
 
@@ -122,6 +133,7 @@ func (r *OrganizationReconciler) Reconcile(ctx context.Context, req ctrl.Request
 func (r *OrganizationReconciler) reconcileRoleBindings(ctx context.Context, organization *dockyardsv1.Organization) (ctrl.Result, error) {
 	err := authorization.ReconcileOrganizationAuthorization(ctx, r.Client, organization)
 	if err != nil {
+		r.Recorder.Eventf(organization, nil, corev1.EventTypeWarning, dockyardsv1.RoleBindingReconcileFailedReason, "Reconcile", "Unable to reconcile role bindings: %s", err)
 		conditions.MarkFalse(organization, dockyardsv1.RoleBindingsReadyCondition, dockyardsv1.RoleBindingReconcileFailedReason, "%s", err)
 
 		return ctrl.Result{}, err
@@ -132,6 +144,32 @@ func (r *OrganizationReconciler) reconcileRoleBindings(ctx context.Context, orga
 	return ctrl.Result{}, nil
 }
 
+func (r *OrganizationReconciler) reconcileResourceUsage(ctx context.Context, organization *dockyardsv1.Organization) (ctrl.Result, error) {
+	usage, err := apiutil.GetOrganizationResourceUsage(ctx, r.Client, organization)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	organization.Status.ResourceUsage = usage
+
+	return ctrl.Result{}, nil
+}
+
+func (r *OrganizationReconciler) nodePoolToOrganization(_ context.Context, obj client.Object) []ctrl.Request {
+	organizationName := obj.GetLabels()[dockyardsv1.LabelOrganizationName]
+	if organizationName == "" {
+		return nil
+	}
+
+	return []ctrl.Request{
+		{
+			NamespacedName: client.ObjectKey{
+				Name: organizationName,
+			},
+		},
+	}
+}
+
 func (r *OrganizationReconciler) reconcileDelete(ctx context.Context, organization *dockyardsv1.Organization) (ctrl.Result, error) {
 	logger := ctrl.LoggerFrom(ctx)
 
@@ -177,5 +215,9 @@ func (r *OrganizationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&dockyardsv1.Organization{}).
 		Owns(&dockyardsv1.Cluster{}).
 		Owns(&rbacv1.RoleBinding{}).
+		Watches(
+			&dockyardsv1.NodePool{},
+			handler.EnqueueRequestsFromMapFunc(r.nodePoolToOrganization),
+		).
 		Complete(r)
 }
@@ -0,0 +1,86 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+
+	"github.com/fluxcd/pkg/runtime/conditions"
+	"github.com/fluxcd/pkg/runtime/patch"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	"github.com/sudoswedenab/dockyards-backend/pkg/authorization"
+	rbacv1 "k8s.io/api/rbac/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=dockyards.io,resources=organizationroles/status,verbs=patch
+// +kubebuilder:rbac:groups=dockyards.io,resources=organizationroles,verbs=get;list;watch
+
+type OrganizationRoleReconciler struct {
+	client.Client
+}
+
+func (r *OrganizationRoleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, reterr error) {
+	var organizationRole dockyardsv1.OrganizationRole
+	err := r.Get(ctx, req.NamespacedName, &organizationRole)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !organizationRole.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(&organizationRole, r)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		err := patchHelper.Patch(ctx, &organizationRole)
+		if err != nil {
+			result = ctrl.Result{}
+			reterr = err
+		}
+	}()
+
+	err = authorization.ReconcileOrganizationRoleAuthorization(ctx, r, &organizationRole)
+	if err != nil {
+		conditions.MarkFalse(&organizationRole, dockyardsv1.OrganizationRoleAuthorizationReadyCondition, dockyardsv1.OrganizationRoleAuthorizationInternalErrorReason, "%s", err)
+
+		return ctrl.Result{}, err
+	}
+
+	conditions.MarkTrue(&organizationRole, dockyardsv1.OrganizationRoleAuthorizationReadyCondition, dockyardsv1.ReadyReason, "")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *OrganizationRoleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	scheme := mgr.GetScheme()
+
+	_ = dockyardsv1.AddToScheme(scheme)
+
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&dockyardsv1.OrganizationRole{}).
+		Owns(&rbacv1.Role{}).
+		Complete(r)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
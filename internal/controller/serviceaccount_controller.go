@@ -0,0 +1,153 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+
+	"github.com/fluxcd/pkg/runtime/conditions"
+	"github.com/fluxcd/pkg/runtime/patch"
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	ServiceAccountFinalizer = "dockyards.io/backend-controller"
+)
+
+// +kubebuilder:rbac:groups=dockyards.io,resources=apitokens,verbs=delete;deletecollection;list;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=members,verbs=create;get;list;patch;watch
+// +kubebuilder:rbac:groups=dockyards.io,resources=serviceaccounts/status,verbs=patch
+// +kubebuilder:rbac:groups=dockyards.io,resources=serviceaccounts,verbs=get;list;patch;watch
+
+// ServiceAccountReconciler makes service accounts members of their organization, api tokens are cluster scoped
+// and cannot be owned by a service account so they are removed using a finalizer.
+type ServiceAccountReconciler struct {
+	client.Client
+}
+
+func (r *ServiceAccountReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, reterr error) {
+	var serviceAccount dockyardsv1.ServiceAccount
+	err := r.Get(ctx, req.NamespacedName, &serviceAccount)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !serviceAccount.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, &serviceAccount)
+	}
+
+	patchHelper, err := patch.NewHelper(&serviceAccount, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		err := patchHelper.Patch(ctx, &serviceAccount)
+		if err != nil {
+			result = ctrl.Result{}
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	if controllerutil.AddFinalizer(&serviceAccount, ServiceAccountFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	result, err = r.reconcileMember(ctx, &serviceAccount)
+	if err != nil {
+		return result, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *ServiceAccountReconciler) reconcileMember(ctx context.Context, serviceAccount *dockyardsv1.ServiceAccount) (ctrl.Result, error) {
+	member := dockyardsv1.Member{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "serviceaccount-" + serviceAccount.Name,
+			Namespace: serviceAccount.Namespace,
+		},
+	}
+
+	_, err := controllerutil.CreateOrPatch(ctx, r.Client, &member, func() error {
+		if member.Labels == nil {
+			member.Labels = make(map[string]string)
+		}
+
+		member.Labels[dockyardsv1.LabelServiceAccountName] = serviceAccount.Name
+		member.Labels[dockyardsv1.LabelRoleName] = string(serviceAccount.Spec.Role)
+
+		member.Spec.Role = serviceAccount.Spec.Role
+		member.Spec.UserRef.APIGroup = &dockyardsv1.GroupVersion.Group
+		member.Spec.UserRef.Kind = dockyardsv1.ServiceAccountKind
+		member.Spec.UserRef.Name = serviceAccount.Name
+
+		return controllerutil.SetControllerReference(serviceAccount, &member, r.Scheme())
+	})
+	if err != nil {
+		conditions.MarkFalse(serviceAccount, dockyardsv1.ReadyCondition, dockyardsv1.ServiceAccountMemberReconcileFailedReason, "%s", err)
+
+		return ctrl.Result{}, err
+	}
+
+	conditions.MarkTrue(serviceAccount, dockyardsv1.ReadyCondition, dockyardsv1.ReadyReason, "")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *ServiceAccountReconciler) reconcileDelete(ctx context.Context, serviceAccount *dockyardsv1.ServiceAccount) (ctrl.Result, error) {
+	logger := ctrl.LoggerFrom(ctx)
+
+	matchingLabels := client.MatchingLabels{
+		dockyardsv1.LabelServiceAccountName: serviceAccount.Name,
+		dockyardsv1.LabelNamespaceName:      serviceAccount.Namespace,
+	}
+
+	err := r.DeleteAllOf(ctx, &dockyardsv1.APIToken{}, matchingLabels)
+	if err != nil {
+		logger.Error(err, "error deleting api tokens")
+
+		return ctrl.Result{}, err
+	}
+
+	patch := client.MergeFrom(serviceAccount.DeepCopy())
+
+	controllerutil.RemoveFinalizer(serviceAccount, ServiceAccountFinalizer)
+
+	err = r.Patch(ctx, serviceAccount, patch)
+	if err != nil {
+		logger.Error(err, "error removing finalizer")
+
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *ServiceAccountReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	scheme := mgr.GetScheme()
+
+	_ = dockyardsv1.AddToScheme(scheme)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dockyardsv1.ServiceAccount{}).
+		Owns(&dockyardsv1.Member{}).
+		Complete(r)
+}
@@ -30,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -38,7 +39,8 @@ import (
 
 type UserReconciler struct {
 	client.Client
-	Config *config.ConfigManager
+	Config   *config.ConfigManager
+	Recorder events.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=dockyards.io,resources=users,verbs=get;list;watch
@@ -112,6 +114,8 @@ func (r *UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	if readyCondition.Status == metav1.ConditionTrue {
 		err = authorization.ReconcileUserAuthorization(ctx, r, user)
 		if err != nil {
+			r.Recorder.Eventf(&user, nil, corev1.EventTypeWarning, dockyardsv1.UserAuthorizationInternalErrorReason, "Reconcile", "Unable to reconcile authorization: %s", err)
+
 			patch := client.MergeFrom(user.DeepCopy())
 
 			meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
@@ -176,7 +180,7 @@ func (r *UserReconciler) reconcileNonDockyardsProvidedUser(ctx context.Context,
 		Type:               dockyardsv1.ReadyCondition,
 		Status:             metav1.ConditionTrue,
 		Reason:             dockyardsv1.VerificationReasonVerified,
-		Message:            "Verified by OIDC",
+		Message:            "Verified by identity provider",
 		LastTransitionTime: metav1.Now(),
 	})
 	logger.Info("user did not have a ready condition, adding it", "userName", user.Name, "condition", dockyardsv1.ReadyCondition, "status", metav1.ConditionFalse)
@@ -219,6 +223,9 @@ func (r *UserReconciler) reconcileUserVerification(ctx context.Context, user *do
 		if err != nil {
 			return err
 		}
+
+		r.Recorder.Eventf(user, nil, corev1.EventTypeNormal, dockyardsv1.VerificationReasonVerified, "Verify", "User %s has been verified", user.Spec.Email)
+
 		logger.Info("user verification request was verified, so we set the user condition to ready", "userName", user.Name, "condition", dockyardsv1.ReadyCondition, "status", metav1.ConditionTrue)
 	}
 
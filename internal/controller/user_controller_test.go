@@ -31,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -81,8 +82,9 @@ func TestUserReconciler_Reconcile(t *testing.T) {
 	})
 
 	reconciler := controller.UserReconciler{
-		Client: c,
-		Config: config,
+		Client:   c,
+		Config:   config,
+		Recorder: &events.FakeRecorder{},
 	}
 
 	reconcileUserCalls := 0
@@ -36,6 +36,8 @@ type PrometheusMetrics struct {
 	credentialMetric   *prometheus.GaugeVec
 	controllerClient   client.Client
 	clusterMetric      *prometheus.GaugeVec
+	loginFailureMetric *prometheus.CounterVec
+	lockoutMetric      prometheus.Counter
 }
 
 type PrometheusMetricsOption func(*PrometheusMetrics)
@@ -99,11 +101,28 @@ func NewPrometheusMetrics(prometheusMetricsOptions ...PrometheusMetricsOption) (
 		},
 	)
 
+	loginFailureMetric := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dockyards_backend_login_failures_total",
+		},
+		[]string{
+			"reason",
+		},
+	)
+
+	lockoutMetric := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "dockyards_backend_user_lockouts_total",
+		},
+	)
+
 	m := PrometheusMetrics{
 		organizationMetric: organizationMetric,
 		userMetric:         userMetric,
 		credentialMetric:   credentialMetric,
 		clusterMetric:      clusterMetric,
+		loginFailureMetric: loginFailureMetric,
+		lockoutMetric:      lockoutMetric,
 	}
 
 	for _, PrometheusMetricsOption := range prometheusMetricsOptions {
@@ -114,6 +133,8 @@ func NewPrometheusMetrics(prometheusMetricsOptions ...PrometheusMetricsOption) (
 	m.registry.MustRegister(m.userMetric)
 	m.registry.MustRegister(m.credentialMetric)
 	m.registry.MustRegister(m.clusterMetric)
+	m.registry.MustRegister(m.loginFailureMetric)
+	m.registry.MustRegister(m.lockoutMetric)
 
 	buildInfo, ok := debug.ReadBuildInfo()
 	if ok {
@@ -147,6 +168,24 @@ func NewPrometheusMetrics(prometheusMetricsOptions ...PrometheusMetricsOption) (
 	return &m, nil
 }
 
+// IncLoginFailure counts a failed login, it is safe to call on a nil receiver.
+func (m *PrometheusMetrics) IncLoginFailure(reason string) {
+	if m == nil {
+		return
+	}
+
+	m.loginFailureMetric.WithLabelValues(reason).Inc()
+}
+
+// IncLockout counts a user being locked out, it is safe to call on a nil receiver.
+func (m *PrometheusMetrics) IncLockout() {
+	if m == nil {
+		return
+	}
+
+	m.lockoutMetric.Inc()
+}
+
 func (m *PrometheusMetrics) CollectMetrics() error {
 	ctx := context.Background()
 
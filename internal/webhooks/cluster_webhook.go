@@ -20,7 +20,9 @@ import (
 
 	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -79,8 +81,13 @@ func (webhook *DockyardsCluster) Default(ctx context.Context, cluster *dockyards
 	return nil
 }
 
-func (webhook *DockyardsCluster) ValidateCreate(_ context.Context, cluster *dockyardsv1.Cluster) (admission.Warnings, error) {
-	return nil, webhook.validate(cluster)
+func (webhook *DockyardsCluster) ValidateCreate(ctx context.Context, cluster *dockyardsv1.Cluster) (admission.Warnings, error) {
+	err := webhook.validate(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, webhook.validateResourceQuotas(ctx, cluster)
 }
 
 func (webhook *DockyardsCluster) ValidateDelete(_ context.Context, cluster *dockyardsv1.Cluster) (admission.Warnings, error) {
@@ -130,6 +137,59 @@ func (webhook *DockyardsCluster) ValidateUpdate(_ context.Context, oldCluster *d
 	return nil, webhook.validate(newCluster)
 }
 
+// validateResourceQuotas rejects clusters that would exceed the cluster quota of the organization referencing the
+// namespace of the cluster, clusters in namespaces not referenced by an organization are rejected.
+func (webhook *DockyardsCluster) validateResourceQuotas(ctx context.Context, cluster *dockyardsv1.Cluster) error {
+	qualifiedKind := dockyardsv1.GroupVersion.WithKind(dockyardsv1.ClusterKind).GroupKind()
+
+	organization, err := apiutil.GetOrganizationByNamespaceRef(ctx, webhook.Client, cluster.Namespace)
+	if apierrors.IsNotFound(err) {
+		return apierrors.NewInvalid(
+			qualifiedKind,
+			cluster.Name,
+			field.ErrorList{
+				namespaceWithoutOrganization(cluster.Namespace),
+			},
+		)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	limit, hasQuota := organization.Spec.ResourceQuotas[dockyardsv1.ResourceCluster]
+	if !hasQuota {
+		return nil
+	}
+
+	usage, err := apiutil.GetOrganizationResourceUsage(ctx, webhook.Client, organization)
+	if err != nil {
+		return err
+	}
+
+	apiutil.AddResourceList(usage, corev1.ResourceList{
+		dockyardsv1.ResourceCluster: *resource.NewQuantity(1, resource.DecimalSI),
+	})
+
+	used := usage[dockyardsv1.ResourceCluster]
+	if used.Cmp(limit) <= 0 {
+		return nil
+	}
+
+	forbidden := field.Forbidden(
+		field.NewPath("metadata", "namespace"),
+		exceededResourceQuotaDetail(organization, dockyardsv1.ResourceCluster, usage),
+	)
+
+	return apierrors.NewInvalid(
+		qualifiedKind,
+		cluster.Name,
+		field.ErrorList{
+			forbidden,
+		},
+	)
+}
+
 func (webhook *DockyardsCluster) validate(dockyardsCluster *dockyardsv1.Cluster) error {
 	hasOrganizationOwner := false
 	for _, ownerReference := range dockyardsCluster.OwnerReferences {
@@ -22,7 +22,9 @@ import (
 	"github.com/google/go-cmp/cmp"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
 	"github.com/sudoswedenab/dockyards-backend/internal/webhooks"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -52,6 +54,33 @@ func TestDockyardsClusterValidateCreate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "test namespace without organization",
+			dockyardsCluster: dockyardsv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "without-organization",
+					Namespace: "other",
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: dockyardsv1.GroupVersion.String(),
+							Kind:       dockyardsv1.OrganizationKind,
+							Name:       "testing",
+							UID:        "8004bcb8-146c-445d-a95d-0ab7842184d8",
+						},
+					},
+				},
+			},
+			expected: apierrors.NewInvalid(
+				dockyardsv1.GroupVersion.WithKind(dockyardsv1.ClusterKind).GroupKind(),
+				"without-organization",
+				field.ErrorList{
+					field.Forbidden(
+						field.NewPath("metadata", "namespace"),
+						"namespace other is not referenced by an organization",
+					),
+				},
+			),
+		},
 		{
 			name: "test cluster without organization owner",
 			dockyardsCluster: dockyardsv1.Cluster{
@@ -75,7 +104,8 @@ func TestDockyardsClusterValidateCreate(t *testing.T) {
 			name: "test with internal ip allocation",
 			dockyardsCluster: dockyardsv1.Cluster{
 				ObjectMeta: metav1.ObjectMeta{
-					Name: "test-with-internal-ip-allocation",
+					Name:      "test-with-internal-ip-allocation",
+					Namespace: "testing",
 					OwnerReferences: []metav1.OwnerReference{
 						{
 							APIVersion: dockyardsv1.GroupVersion.String(),
@@ -94,7 +124,8 @@ func TestDockyardsClusterValidateCreate(t *testing.T) {
 			name: "test custom pod subnets",
 			dockyardsCluster: dockyardsv1.Cluster{
 				ObjectMeta: metav1.ObjectMeta{
-					Name: "custom-pod-subnets",
+					Name:      "custom-pod-subnets",
+					Namespace: "testing",
 					OwnerReferences: []metav1.OwnerReference{
 						{
 							APIVersion: dockyardsv1.GroupVersion.String(),
@@ -154,7 +185,8 @@ func TestDockyardsClusterValidateCreate(t *testing.T) {
 			name: "test custom service subnets",
 			dockyardsCluster: dockyardsv1.Cluster{
 				ObjectMeta: metav1.ObjectMeta{
-					Name: "custom-service-subnets",
+					Name:      "custom-service-subnets",
+					Namespace: "testing",
 					OwnerReferences: []metav1.OwnerReference{
 						{
 							APIVersion: dockyardsv1.GroupVersion.String(),
@@ -247,9 +279,32 @@ func TestDockyardsClusterValidateCreate(t *testing.T) {
 		},
 	}
 
+	organization := dockyardsv1.Organization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "testing",
+		},
+		Spec: dockyardsv1.OrganizationSpec{
+			NamespaceRef: &corev1.LocalObjectReference{
+				Name: "testing",
+			},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+
+	_ = dockyardsv1.AddToScheme(scheme)
+
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			webhook := webhooks.DockyardsCluster{}
+			c := fake.
+				NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(&organization).
+				Build()
+
+			webhook := webhooks.DockyardsCluster{
+				Client: c,
+			}
 
 			_, actual := webhook.ValidateCreate(context.Background(), &tc.dockyardsCluster)
 			if !cmp.Equal(actual, tc.expected) {
@@ -520,3 +575,123 @@ func TestDockyardsClusterDefault(t *testing.T) {
 		})
 	}
 }
+
+func TestDockyardsClusterResourceQuotas(t *testing.T) {
+	organization := dockyardsv1.Organization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+		},
+		Spec: dockyardsv1.OrganizationSpec{
+			NamespaceRef: &corev1.LocalObjectReference{
+				Name: "testing",
+			},
+			ResourceQuotas: corev1.ResourceList{
+				dockyardsv1.ResourceCluster: resource.MustParse("2"),
+			},
+		},
+	}
+
+	ownerReferences := []metav1.OwnerReference{
+		{
+			APIVersion: dockyardsv1.GroupVersion.String(),
+			Kind:       dockyardsv1.OrganizationKind,
+			Name:       organization.Name,
+			UID:        "8004bcb8-146c-445d-a95d-0ab7842184d8",
+		},
+	}
+
+	existingCluster := dockyardsv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "existing",
+			Namespace:       "testing",
+			OwnerReferences: ownerReferences,
+		},
+	}
+
+	deletedCluster := dockyardsv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "deleted",
+			Namespace:         "testing",
+			OwnerReferences:   ownerReferences,
+			DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			Finalizers: []string{
+				"testing",
+			},
+		},
+	}
+
+	tt := []struct {
+		name     string
+		clusters []dockyardsv1.Cluster
+		expected error
+	}{
+		{
+			name: "test below quota",
+			clusters: []dockyardsv1.Cluster{
+				deletedCluster,
+			},
+		},
+		{
+			name: "test at quota",
+			clusters: []dockyardsv1.Cluster{
+				existingCluster,
+				deletedCluster,
+			},
+		},
+		{
+			name: "test above quota",
+			clusters: []dockyardsv1.Cluster{
+				existingCluster,
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "another",
+						Namespace:       "testing",
+						OwnerReferences: ownerReferences,
+					},
+				},
+			},
+			expected: apierrors.NewInvalid(
+				dockyardsv1.GroupVersion.WithKind(dockyardsv1.ClusterKind).GroupKind(),
+				"test",
+				field.ErrorList{
+					field.Forbidden(
+						field.NewPath("metadata", "namespace"),
+						"exceeds quota of organization test for resource cluster, requested usage 3 exceeds limit 2",
+					),
+				},
+			),
+		},
+	}
+
+	scheme := runtime.NewScheme()
+
+	_ = dockyardsv1.AddToScheme(scheme)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c := fake.
+				NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(&organization).
+				WithLists(&dockyardsv1.ClusterList{Items: tc.clusters}).
+				Build()
+
+			webhook := webhooks.DockyardsCluster{
+				Client: c,
+			}
+
+			cluster := dockyardsv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "test",
+					Namespace:       "testing",
+					OwnerReferences: ownerReferences,
+				},
+			}
+
+			_, actual := webhook.ValidateCreate(t.Context(), &cluster)
+			if !cmp.Equal(actual, tc.expected) {
+				t.Errorf("diff: %s", cmp.Diff(tc.expected, actual))
+			}
+		})
+	}
+}
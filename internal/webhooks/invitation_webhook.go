@@ -103,7 +103,11 @@ func (webhook *DockyardsInvitation) validate(ctx context.Context, invitation *do
 		return nil, err
 	}
 
-	if len(invitationList.Items) != 0 {
+	for _, item := range invitationList.Items {
+		if item.Name == invitation.Name || !item.IsPending() {
+			continue
+		}
+
 		invalid := field.Invalid(field.NewPath("spec", "email"), invitation.Spec.Email, "address already invited")
 		errs := field.ErrorList{invalid}
 
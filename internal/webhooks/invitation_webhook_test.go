@@ -15,11 +15,14 @@
 package webhooks_test
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"path"
 	"testing"
+	"time"
 
+	"github.com/fluxcd/pkg/runtime/conditions"
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
@@ -31,6 +34,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/wait"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
@@ -228,6 +232,62 @@ func TestDockyardsInvitationWebhook_Create(t *testing.T) {
 		}
 	})
 
+	t.Run("test existing declined invitation", func(t *testing.T) {
+		existing := dockyardsv1.Invitation{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    namespace.Name,
+			},
+			Spec: dockyardsv1.InvitationSpec{
+				Email: "declined@dockyards.dev",
+				Role:  dockyardsv1.RoleReader,
+			},
+		}
+
+		err := c.Create(ctx, &existing)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		patch := client.MergeFrom(existing.DeepCopy())
+
+		conditions.MarkFalse(&existing, dockyardsv1.InvitationAcceptedCondition, dockyardsv1.InvitationDeclinedReason, "")
+
+		err = c.Status().Patch(ctx, &existing, patch)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = wait.PollUntilContextTimeout(ctx, time.Millisecond*200, time.Second*5, true, func(ctx context.Context) (bool, error) {
+			var actual dockyardsv1.Invitation
+			err := mgr.GetClient().Get(ctx, client.ObjectKeyFromObject(&existing), &actual)
+			if err != nil {
+				return false, client.IgnoreNotFound(err)
+			}
+
+			return !actual.IsPending(), nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		invitation := dockyardsv1.Invitation{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test",
+				Namespace: namespace.Name,
+			},
+			Spec: dockyardsv1.InvitationSpec{
+				Email: "declined@dockyards.dev",
+				Role:  dockyardsv1.RoleUser,
+			},
+		}
+
+		_, err = webhook.ValidateCreate(ctx, &invitation)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
 	t.Run("test existing user", func(t *testing.T) {
 		invitation := dockyardsv1.Invitation{
 			ObjectMeta: metav1.ObjectMeta{
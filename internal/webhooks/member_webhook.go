@@ -21,14 +21,16 @@ import (
 
 	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
-// +kubebuilder:webhook:groups=dockyards.io,resources=members,verbs=create;update,path=/validate-dockyards-io-v1alpha3-member,mutating=false,failurePolicy=fail,sideEffects=none,admissionReviewVersions=v1,name=validation.member.dockyards.io,versions=v1alpha3,serviceName=dockyards-backend
+// +kubebuilder:webhook:groups=dockyards.io,resources=members,verbs=create;update;delete,path=/validate-dockyards-io-v1alpha3-member,mutating=false,failurePolicy=fail,sideEffects=none,admissionReviewVersions=v1,name=validation.member.dockyards.io,versions=v1alpha3,serviceName=dockyards-backend
 
 // +kubebuilder:webhook:groups=dockyards.io,resources=members,verbs=create,path=/mutate-dockyards-io-v1alpha3-member,mutating=true,failurePolicy=fail,sideEffects=none,admissionReviewVersions=v1,name=default.member.dockyards.io,versions=v1alpha3,serviceName=dockyards-backend
 
@@ -55,6 +57,13 @@ func (webhook *DockyardsMember) Default(ctx context.Context, member *dockyardsv1
 
 	member.Labels[dockyardsv1.LabelOrganizationName] = organizationName
 	member.Labels[dockyardsv1.LabelRoleName] = roleName
+
+	if member.Spec.UserRef.Kind == dockyardsv1.ServiceAccountKind {
+		member.Labels[dockyardsv1.LabelServiceAccountName] = userName
+
+		return nil
+	}
+
 	member.Labels[dockyardsv1.LabelUserName] = userName
 
 	return nil
@@ -86,14 +95,49 @@ func (webhook *DockyardsMember) ValidateUpdate(ctx context.Context, oldMember, n
 		)
 	}
 
+	if oldMember.Spec.Role == dockyardsv1.RoleSuperUser && newMember.Spec.Role != dockyardsv1.RoleSuperUser {
+		err := apiutil.EnsureSuperUserRemains(ctx, webhook.Client, oldMember)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return webhook.validate(ctx, newMember)
 }
 
-func (webhook *DockyardsMember) ValidateDelete(_ context.Context, _ *dockyardsv1.Member) (admission.Warnings, error) {
+func (webhook *DockyardsMember) ValidateDelete(ctx context.Context, member *dockyardsv1.Member) (admission.Warnings, error) {
+	if member.Spec.Role != dockyardsv1.RoleSuperUser {
+		return nil, nil
+	}
+
+	var namespace corev1.Namespace
+	err := webhook.Client.Get(ctx, client.ObjectKey{Name: member.Namespace}, &namespace)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, err
+	}
+
+	if apierrors.IsNotFound(err) || !namespace.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	organization, err := apiutil.GetOrganizationByNamespaceRef(ctx, webhook.Client, member.Namespace)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, err
+	}
+
+	if apierrors.IsNotFound(err) || !organization.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	err = apiutil.EnsureSuperUserRemains(ctx, webhook.Client, member)
+	if err != nil {
+		return nil, err
+	}
+
 	return nil, nil
 }
 
-func (webhook *DockyardsMember) validate(_ context.Context, newMember *dockyardsv1.Member) (admission.Warnings, error) {
+func (webhook *DockyardsMember) validate(ctx context.Context, newMember *dockyardsv1.Member) (admission.Warnings, error) {
 	var warnings admission.Warnings
 	var errorList field.ErrorList
 
@@ -110,19 +154,57 @@ func (webhook *DockyardsMember) validate(_ context.Context, newMember *dockyards
 		invalid := field.Invalid(
 			field.NewPath("spec", "userRef", "kind"),
 			newMember,
-			fmt.Sprintf("invalid userRef kind, expected %s", dockyardsv1.UserKind),
+			fmt.Sprintf("invalid userRef kind, expected %s or %s", dockyardsv1.UserKind, dockyardsv1.ServiceAccountKind),
 		)
 		errorList = append(errorList, invalid)
 	}
 
+	if !dockyardsv1.IsBuiltinRole(newMember.Spec.Role) {
+		objectKey := client.ObjectKey{
+			Name:      string(newMember.Spec.Role),
+			Namespace: newMember.Namespace,
+		}
+
+		var organizationRole dockyardsv1.OrganizationRole
+		err := webhook.Client.Get(ctx, objectKey, &organizationRole)
+		if client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+
+		if apierrors.IsNotFound(err) {
+			notFound := field.NotFound(field.NewPath("spec", "role"), newMember.Spec.Role)
+			errorList = append(errorList, notFound)
+		}
+	}
+
+	if newMember.Spec.Clusters != nil {
+		if newMember.Spec.Role != dockyardsv1.RoleUser && newMember.Spec.Role != dockyardsv1.RoleReader {
+			forbidden := field.Forbidden(field.NewPath("spec", "clusters"), "clusters are only supported for the User and Reader roles")
+			errorList = append(errorList, forbidden)
+		}
+
+		if newMember.Spec.Clusters.Selector != nil {
+			_, err := metav1.LabelSelectorAsSelector(newMember.Spec.Clusters.Selector)
+			if err != nil {
+				invalid := field.Invalid(field.NewPath("spec", "clusters", "selector"), newMember.Spec.Clusters.Selector, err.Error())
+				errorList = append(errorList, invalid)
+			}
+		}
+	}
+
+	labelName := dockyardsv1.LabelUserName
+	if newMember.Spec.UserRef.Kind == dockyardsv1.ServiceAccountKind {
+		labelName = dockyardsv1.LabelServiceAccountName
+	}
+
 	if newMember.Labels[dockyardsv1.LabelRoleName] != string(newMember.Spec.Role) {
 		warnings = append(warnings, fmt.Sprintf("label '%s' should match the role defined in '%s'", dockyardsv1.LabelRoleName, field.NewPath("spec", "role")))
 		newMember.Labels[dockyardsv1.LabelRoleName] = string(newMember.Spec.Role)
 	}
 
-	if newMember.Labels[dockyardsv1.LabelUserName] != string(newMember.Spec.UserRef.Name) {
-		warnings = append(warnings, fmt.Sprintf("label '%s' should match the role defined in '%s'", dockyardsv1.LabelUserName, field.NewPath("spec", "userRef", "name")))
-		newMember.Labels[dockyardsv1.LabelUserName] = string(newMember.Spec.UserRef.Name)
+	if newMember.Labels[labelName] != string(newMember.Spec.UserRef.Name) {
+		warnings = append(warnings, fmt.Sprintf("label '%s' should match the role defined in '%s'", labelName, field.NewPath("spec", "userRef", "name")))
+		newMember.Labels[labelName] = string(newMember.Spec.UserRef.Name)
 	}
 
 	if len(errorList) > 0 {
@@ -17,8 +17,10 @@ package webhooks_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/sudoswedenab/dockyards-backend/api/apiutil"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
 	"github.com/sudoswedenab/dockyards-backend/internal/webhooks"
 	corev1 "k8s.io/api/core/v1"
@@ -212,4 +214,288 @@ func TestDockyardsMemberDefault(t *testing.T) {
 			t.Errorf("diff: %s", cmp.Diff(expected, member.Labels))
 		}
 	})
+	t.Run("test service account labels", func(t *testing.T) {
+		organizationList := dockyardsv1.OrganizationList{
+			Items: []dockyardsv1.Organization{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+					Spec: dockyardsv1.OrganizationSpec{
+						NamespaceRef: &corev1.LocalObjectReference{
+							Name: "testing",
+						},
+					},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithLists(&organizationList).
+			Build()
+
+		member := dockyardsv1.Member{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test",
+				Namespace: "testing",
+			},
+			Spec: dockyardsv1.MemberSpec{
+				Role: dockyardsv1.RoleReader,
+				UserRef: corev1.TypedLocalObjectReference{
+					APIGroup: &dockyardsv1.GroupVersion.Group,
+					Kind:     dockyardsv1.ServiceAccountKind,
+					Name:     "automation",
+				},
+			},
+		}
+
+		webhook := webhooks.DockyardsMember{
+			Client: c,
+		}
+
+		err := webhook.Default(ctx, &member)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := map[string]string{
+			dockyardsv1.LabelOrganizationName:   "test",
+			dockyardsv1.LabelRoleName:           "Reader",
+			dockyardsv1.LabelServiceAccountName: "automation",
+		}
+
+		if !cmp.Equal(member.Labels, expected) {
+			t.Errorf("diff: %s", cmp.Diff(expected, member.Labels))
+		}
+	})
+}
+
+func TestDockyardsMemberLastSuperUser(t *testing.T) {
+	ctx := t.Context()
+	scheme := runtime.NewScheme()
+
+	_ = corev1.AddToScheme(scheme)
+	_ = dockyardsv1.AddToScheme(scheme)
+
+	organization := dockyardsv1.Organization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+		},
+		Spec: dockyardsv1.OrganizationSpec{
+			NamespaceRef: &corev1.LocalObjectReference{
+				Name: "testing",
+			},
+		},
+	}
+
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "testing",
+		},
+	}
+
+	superUser := newTestMember("super-user", "super-user", validMemberLabels("super-user"))
+	superUser.Namespace = "testing"
+	superUser.Spec.Role = dockyardsv1.RoleSuperUser
+
+	user := newTestMember("user", "user", validMemberLabels("user"))
+	user.Namespace = "testing"
+
+	groupResource := dockyardsv1.GroupVersion.WithResource("members").GroupResource()
+	forbidden := apierrors.NewForbidden(groupResource, "super-user", apiutil.ErrLastSuperUser)
+
+	t.Run("test deleting last super user", func(t *testing.T) {
+		c := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(&organization, &namespace, superUser.DeepCopy(), user.DeepCopy()).
+			Build()
+
+		webhook := webhooks.DockyardsMember{
+			Client: c,
+		}
+
+		_, actual := webhook.ValidateDelete(ctx, superUser.DeepCopy())
+		if !cmp.Equal(actual, forbidden) {
+			t.Errorf("diff: %s", cmp.Diff(forbidden, actual))
+		}
+	})
+
+	t.Run("test demoting last super user", func(t *testing.T) {
+		c := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(&organization, &namespace, superUser.DeepCopy(), user.DeepCopy()).
+			Build()
+
+		webhook := webhooks.DockyardsMember{
+			Client: c,
+		}
+
+		newMember := superUser.DeepCopy()
+		newMember.Spec.Role = dockyardsv1.RoleReader
+
+		_, actual := webhook.ValidateUpdate(ctx, superUser.DeepCopy(), newMember)
+		if !cmp.Equal(actual, forbidden) {
+			t.Errorf("diff: %s", cmp.Diff(forbidden, actual))
+		}
+	})
+
+	t.Run("test deleting super user with other super user", func(t *testing.T) {
+		otherSuperUser := newTestMember("other", "other", validMemberLabels("other"))
+		otherSuperUser.Namespace = "testing"
+		otherSuperUser.Spec.Role = dockyardsv1.RoleSuperUser
+
+		c := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(&organization, &namespace, superUser.DeepCopy(), &otherSuperUser).
+			Build()
+
+		webhook := webhooks.DockyardsMember{
+			Client: c,
+		}
+
+		_, err := webhook.ValidateDelete(ctx, superUser.DeepCopy())
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("test deleting last super user in terminating namespace", func(t *testing.T) {
+		terminating := namespace.DeepCopy()
+		terminating.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		terminating.Finalizers = []string{"kubernetes"}
+
+		c := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(&organization, terminating, superUser.DeepCopy()).
+			Build()
+
+		webhook := webhooks.DockyardsMember{
+			Client: c,
+		}
+
+		_, err := webhook.ValidateDelete(ctx, superUser.DeepCopy())
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestDockyardsMemberOrganizationRole(t *testing.T) {
+	ctx := t.Context()
+	scheme := runtime.NewScheme()
+
+	_ = dockyardsv1.AddToScheme(scheme)
+
+	organizationRole := dockyardsv1.OrganizationRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "workload-manager",
+			Namespace: "testing",
+		},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(&organizationRole).
+		Build()
+
+	webhook := webhooks.DockyardsMember{
+		Client: c,
+	}
+
+	t.Run("test existing role", func(t *testing.T) {
+		member := newTestMember("test", "test", validMemberLabels("test"))
+		member.Namespace = "testing"
+		member.Spec.Role = "workload-manager"
+		member.Labels[dockyardsv1.LabelRoleName] = "workload-manager"
+
+		_, err := webhook.ValidateCreate(ctx, &member)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("test missing role", func(t *testing.T) {
+		member := newTestMember("test", "test", validMemberLabels("test"))
+		member.Namespace = "testing"
+		member.Spec.Role = "cluster-manager"
+		member.Labels[dockyardsv1.LabelRoleName] = "cluster-manager"
+
+		expected := apierrors.NewInvalid(
+			dockyardsv1.GroupVersion.WithKind(dockyardsv1.MemberKind).GroupKind(),
+			member.Name,
+			field.ErrorList{
+				field.NotFound(field.NewPath("spec", "role"), member.Spec.Role),
+			},
+		)
+
+		_, actual := webhook.ValidateCreate(ctx, &member)
+		if !cmp.Equal(actual, expected) {
+			t.Errorf("diff: %s", cmp.Diff(expected, actual))
+		}
+	})
+}
+
+func TestDockyardsMemberClusters(t *testing.T) {
+	ctx := t.Context()
+	webhook := webhooks.DockyardsMember{}
+
+	t.Run("test user with clusters", func(t *testing.T) {
+		member := newTestMember("test", "test", validMemberLabels("test"))
+		member.Spec.Clusters = &dockyardsv1.MemberClusters{
+			Names: []string{
+				"production",
+			},
+		}
+
+		_, err := webhook.ValidateCreate(ctx, &member)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("test super user with clusters", func(t *testing.T) {
+		labels := validMemberLabels("test")
+		labels[dockyardsv1.LabelRoleName] = dockyardsv1.RoleSuperUser
+
+		member := newTestMember("test", "test", labels)
+		member.Spec.Role = dockyardsv1.RoleSuperUser
+		member.Spec.Clusters = &dockyardsv1.MemberClusters{
+			Names: []string{
+				"production",
+			},
+		}
+
+		expected := apierrors.NewInvalid(
+			dockyardsv1.GroupVersion.WithKind(dockyardsv1.MemberKind).GroupKind(),
+			member.Name,
+			field.ErrorList{
+				field.Forbidden(field.NewPath("spec", "clusters"), "clusters are only supported for the User and Reader roles"),
+			},
+		)
+
+		_, actual := webhook.ValidateCreate(ctx, &member)
+		if !cmp.Equal(actual, expected) {
+			t.Errorf("diff: %s", cmp.Diff(expected, actual))
+		}
+	})
+
+	t.Run("test invalid selector", func(t *testing.T) {
+		member := newTestMember("test", "test", validMemberLabels("test"))
+		member.Spec.Clusters = &dockyardsv1.MemberClusters{
+			Selector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "environment",
+						Operator: "Unknown",
+					},
+				},
+			},
+		}
+
+		_, err := webhook.ValidateCreate(ctx, &member)
+		if !apierrors.IsInvalid(err) {
+			t.Errorf("expected invalid error, got %v", err)
+		}
+	})
 }
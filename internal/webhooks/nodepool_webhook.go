@@ -24,6 +24,7 @@ import (
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
 	"github.com/sudoswedenab/dockyards-backend/pkg/util/name"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -169,6 +170,13 @@ func (webhook *DockyardsNodePool) validate(ctx context.Context, oldNodePool, new
 		}
 	}
 
+	quotaErrors, err := webhook.validateResourceQuotas(ctx, oldNodePool, newNodePool)
+	if err != nil {
+		return err
+	}
+
+	errorList = append(errorList, quotaErrors...)
+
 	if len(errorList) > 0 {
 		qualifiedKind := dockyardsv1.GroupVersion.WithKind(dockyardsv1.NodePoolKind).GroupKind()
 
@@ -177,3 +185,70 @@ func (webhook *DockyardsNodePool) validate(ctx context.Context, oldNodePool, new
 
 	return nil
 }
+
+// validateResourceQuotas rejects node pools that would increase the usage of a resource above the quota of the
+// organization, updates that do not increase the usage are allowed even if the organization is above quota. The
+// organization is the one referencing the namespace of the node pool, node pools in other namespaces are rejected.
+// Deleting node pools and updates that do not change the usage are not validated so finalizers can be removed.
+func (webhook *DockyardsNodePool) validateResourceQuotas(ctx context.Context, oldNodePool, newNodePool *dockyardsv1.NodePool) (field.ErrorList, error) {
+	if !newNodePool.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	oldUsage := corev1.ResourceList{}
+	if oldNodePool != nil && oldNodePool.DeletionTimestamp.IsZero() {
+		oldUsage = apiutil.GetNodePoolResourceUsage(oldNodePool)
+	}
+
+	newUsage := apiutil.GetNodePoolResourceUsage(newNodePool)
+
+	if oldNodePool != nil && equality.Semantic.DeepEqual(oldUsage, newUsage) {
+		return nil, nil
+	}
+
+	organization, err := apiutil.GetOrganizationByNamespaceRef(ctx, webhook.Client, newNodePool.Namespace)
+	if apierrors.IsNotFound(err) {
+		return field.ErrorList{namespaceWithoutOrganization(newNodePool.Namespace)}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(organization.Spec.ResourceQuotas) == 0 {
+		return nil, nil
+	}
+
+	usage, err := apiutil.GetOrganizationResourceUsage(ctx, webhook.Client, organization)
+	if err != nil {
+		return nil, err
+	}
+
+	apiutil.SubtractResourceList(usage, oldUsage)
+	apiutil.AddResourceList(usage, newUsage)
+
+	var errorList field.ErrorList
+
+	for _, resourceName := range apiutil.ExceededResourceQuotas(organization.Spec.ResourceQuotas, usage) {
+		newQuantity := newUsage[resourceName]
+
+		oldQuantity, hasQuantity := oldUsage[resourceName]
+		if hasQuantity && newQuantity.Cmp(oldQuantity) <= 0 {
+			continue
+		}
+
+		path := field.NewPath("spec", "resources").Key(string(resourceName))
+
+		switch {
+		case resourceName == dockyardsv1.ResourceNodePool:
+			path = field.NewPath("metadata", "namespace")
+		case resourceName == corev1.ResourceStorage && len(newNodePool.Spec.StorageResources) > 0:
+			path = field.NewPath("spec", "storageResources")
+		}
+
+		forbidden := field.Forbidden(path, exceededResourceQuotaDetail(organization, resourceName, usage))
+		errorList = append(errorList, forbidden)
+	}
+
+	return errorList, nil
+}
@@ -40,6 +40,17 @@ func TestDockyardsNodePoolValidateCreate(t *testing.T) {
 		dockyardsv1.LabelClusterName:      "c",
 	}
 
+	organization := dockyardsv1.Organization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "o",
+		},
+		Spec: dockyardsv1.OrganizationSpec{
+			NamespaceRef: &corev1.LocalObjectReference{
+				Name: namespace,
+			},
+		},
+	}
+
 	tt := []struct {
 		name              string
 		dockyardsNodePool dockyardsv1.NodePool
@@ -64,6 +75,23 @@ func TestDockyardsNodePoolValidateCreate(t *testing.T) {
 				},
 			),
 		},
+		{
+			name: "test namespace without organization",
+			dockyardsNodePool: dockyardsv1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "without-organization",
+					Namespace: "other",
+					Labels:    labels,
+				},
+			},
+			expected: apierrors.NewInvalid(
+				dockyardsv1.GroupVersion.WithKind(dockyardsv1.NodePoolKind).GroupKind(),
+				"without-organization",
+				field.ErrorList{
+					field.Forbidden(field.NewPath("metadata", "namespace"), "namespace other is not referenced by an organization"),
+				},
+			),
+		},
 		{
 			name: "test storage role disabled",
 			dockyardsNodePool: dockyardsv1.NodePool{
@@ -337,6 +365,7 @@ func TestDockyardsNodePoolValidateCreate(t *testing.T) {
 			c := fake.
 				NewClientBuilder().
 				WithScheme(scheme).
+				WithObjects(&organization).
 				WithLists(&tc.features).
 				Build()
 
@@ -359,6 +388,17 @@ func TestDockyardsNodePoolValidateUpdate(t *testing.T) {
 		dockyardsv1.LabelClusterName:      "c",
 	}
 
+	organization := dockyardsv1.Organization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "o",
+		},
+		Spec: dockyardsv1.OrganizationSpec{
+			NamespaceRef: &corev1.LocalObjectReference{
+				Name: namespace,
+			},
+		},
+	}
+
 	tt := []struct {
 		name        string
 		oldNodePool dockyardsv1.NodePool
@@ -634,6 +674,7 @@ func TestDockyardsNodePoolValidateUpdate(t *testing.T) {
 			c := fake.
 				NewClientBuilder().
 				WithScheme(scheme).
+				WithObjects(&organization).
 				WithLists(&tc.features).
 				Build()
 
@@ -648,3 +689,310 @@ func TestDockyardsNodePoolValidateUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestDockyardsNodePoolResourceQuotas(t *testing.T) {
+	namespace := "testing"
+	now := metav1.Now()
+	labels := map[string]string{
+		dockyardsv1.LabelOrganizationName: "test",
+		dockyardsv1.LabelClusterName:      "test",
+	}
+
+	organization := dockyardsv1.Organization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+		},
+		Spec: dockyardsv1.OrganizationSpec{
+			NamespaceRef: &corev1.LocalObjectReference{
+				Name: namespace,
+			},
+			ResourceQuotas: corev1.ResourceList{
+				dockyardsv1.ResourceNodePool: resource.MustParse("2"),
+				corev1.ResourceCPU:           resource.MustParse("8"),
+				corev1.ResourceMemory:        resource.MustParse("16Gi"),
+			},
+		},
+	}
+
+	existingNodePool := dockyardsv1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-existing",
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: dockyardsv1.NodePoolSpec{
+			Replicas: ptr.To(int32(2)),
+			Resources: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("2"),
+				corev1.ResourceMemory: resource.MustParse("4Gi"),
+			},
+		},
+	}
+
+	tt := []struct {
+		name        string
+		oldNodePool *dockyardsv1.NodePool
+		newNodePool dockyardsv1.NodePool
+		nodePools   []dockyardsv1.NodePool
+		expected    error
+	}{
+		{
+			name: "test create within quota",
+			newNodePool: dockyardsv1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-create",
+					Namespace: namespace,
+					Labels:    labels,
+				},
+				Spec: dockyardsv1.NodePoolSpec{
+					Replicas: ptr.To(int32(2)),
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("2"),
+						corev1.ResourceMemory: resource.MustParse("4Gi"),
+					},
+				},
+			},
+			nodePools: []dockyardsv1.NodePool{
+				existingNodePool,
+			},
+		},
+		{
+			name: "test create above quota",
+			newNodePool: dockyardsv1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-create",
+					Namespace: namespace,
+					Labels:    labels,
+				},
+				Spec: dockyardsv1.NodePoolSpec{
+					Replicas: ptr.To(int32(3)),
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("2"),
+						corev1.ResourceMemory: resource.MustParse("4Gi"),
+					},
+				},
+			},
+			nodePools: []dockyardsv1.NodePool{
+				existingNodePool,
+			},
+			expected: apierrors.NewInvalid(
+				dockyardsv1.GroupVersion.WithKind(dockyardsv1.NodePoolKind).GroupKind(),
+				"test-create",
+				field.ErrorList{
+					field.Forbidden(
+						field.NewPath("spec", "resources").Key(string(corev1.ResourceCPU)),
+						"exceeds quota of organization test for resource cpu, requested usage 10 exceeds limit 8",
+					),
+					field.Forbidden(
+						field.NewPath("spec", "resources").Key(string(corev1.ResourceMemory)),
+						"exceeds quota of organization test for resource memory, requested usage 20Gi exceeds limit 16Gi",
+					),
+				},
+			),
+		},
+		{
+			name: "test create above node pool quota",
+			newNodePool: dockyardsv1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-create",
+					Namespace: namespace,
+					Labels:    labels,
+				},
+			},
+			nodePools: []dockyardsv1.NodePool{
+				existingNodePool,
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-another",
+						Namespace: namespace,
+						Labels:    labels,
+					},
+				},
+			},
+			expected: apierrors.NewInvalid(
+				dockyardsv1.GroupVersion.WithKind(dockyardsv1.NodePoolKind).GroupKind(),
+				"test-create",
+				field.ErrorList{
+					field.Forbidden(
+						field.NewPath("metadata", "namespace"),
+						"exceeds quota of organization test for resource nodepool, requested usage 3 exceeds limit 2",
+					),
+				},
+			),
+		},
+		{
+			name:        "test update above quota",
+			oldNodePool: &existingNodePool,
+			newNodePool: dockyardsv1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-existing",
+					Namespace: namespace,
+					Labels:    labels,
+				},
+				Spec: dockyardsv1.NodePoolSpec{
+					Replicas: ptr.To(int32(5)),
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("2"),
+						corev1.ResourceMemory: resource.MustParse("2Gi"),
+					},
+				},
+			},
+			nodePools: []dockyardsv1.NodePool{
+				existingNodePool,
+			},
+			expected: apierrors.NewInvalid(
+				dockyardsv1.GroupVersion.WithKind(dockyardsv1.NodePoolKind).GroupKind(),
+				"test-existing",
+				field.ErrorList{
+					field.Forbidden(
+						field.NewPath("spec", "resources").Key(string(corev1.ResourceCPU)),
+						"exceeds quota of organization test for resource cpu, requested usage 10 exceeds limit 8",
+					),
+				},
+			),
+		},
+		{
+			name: "test update decreasing usage above quota",
+			oldNodePool: &dockyardsv1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-large",
+					Namespace: namespace,
+					Labels:    labels,
+				},
+				Spec: dockyardsv1.NodePoolSpec{
+					Replicas: ptr.To(int32(6)),
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2"),
+					},
+				},
+			},
+			newNodePool: dockyardsv1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-large",
+					Namespace: namespace,
+					Labels:    labels,
+				},
+				Spec: dockyardsv1.NodePoolSpec{
+					Replicas: ptr.To(int32(5)),
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2"),
+					},
+				},
+			},
+			nodePools: []dockyardsv1.NodePool{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-large",
+						Namespace: namespace,
+						Labels:    labels,
+					},
+					Spec: dockyardsv1.NodePoolSpec{
+						Replicas: ptr.To(int32(6)),
+						Resources: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("2"),
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "test update unchanged usage above quota",
+			oldNodePool: &dockyardsv1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-large",
+					Namespace: namespace,
+					Labels:    labels,
+				},
+				Spec: dockyardsv1.NodePoolSpec{
+					Replicas: ptr.To(int32(6)),
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2"),
+					},
+				},
+			},
+			newNodePool: dockyardsv1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-large",
+					Namespace: namespace,
+					Labels:    labels,
+					Annotations: map[string]string{
+						"test": "test",
+					},
+				},
+				Spec: dockyardsv1.NodePoolSpec{
+					Replicas: ptr.To(int32(6)),
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2000m"),
+					},
+				},
+			},
+			nodePools: []dockyardsv1.NodePool{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-large",
+						Namespace: namespace,
+						Labels:    labels,
+					},
+					Spec: dockyardsv1.NodePoolSpec{
+						Replicas: ptr.To(int32(6)),
+						Resources: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("2"),
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "test update deleting node pool without organization",
+			oldNodePool: &dockyardsv1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-deleting",
+					Namespace:         "deleted",
+					Labels:            labels,
+					DeletionTimestamp: &now,
+					Finalizers: []string{
+						"test",
+					},
+				},
+			},
+			newNodePool: dockyardsv1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-deleting",
+					Namespace:         "deleted",
+					Labels:            labels,
+					DeletionTimestamp: &now,
+				},
+			},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+
+	_ = dockyardsv1.AddToScheme(scheme)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c := fake.
+				NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(&organization).
+				WithLists(&dockyardsv1.NodePoolList{Items: tc.nodePools}).
+				Build()
+
+			webhook := webhooks.DockyardsNodePool{
+				Client: c,
+			}
+
+			var actual error
+			if tc.oldNodePool == nil {
+				_, actual = webhook.ValidateCreate(t.Context(), &tc.newNodePool)
+			} else {
+				_, actual = webhook.ValidateUpdate(t.Context(), tc.oldNodePool, &tc.newNodePool)
+			}
+
+			if !cmp.Equal(actual, tc.expected) {
+				t.Errorf("diff: %s", cmp.Diff(tc.expected, actual))
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"fmt"
+
+	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// namespaceWithoutOrganization returns the error of resources in namespaces not referenced by an organization, the
+// quotas of such resources cannot be validated so they are rejected.
+func namespaceWithoutOrganization(namespace string) *field.Error {
+	return field.Forbidden(field.NewPath("metadata", "namespace"), fmt.Sprintf("namespace %s is not referenced by an organization", namespace))
+}
+
+func exceededResourceQuotaDetail(organization *dockyardsv1.Organization, resourceName corev1.ResourceName, usage corev1.ResourceList) string {
+	limit := organization.Spec.ResourceQuotas[resourceName]
+	used := usage[resourceName]
+
+	return fmt.Sprintf("exceeds quota of organization %s for resource %s, requested usage %s exceeds limit %s", organization.Name, resourceName, used.String(), limit.String())
+}
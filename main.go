@@ -16,6 +16,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -32,11 +33,13 @@ import (
 	"github.com/sudoswedenab/dockyards-backend/api/v1alpha3/index"
 	"github.com/sudoswedenab/dockyards-backend/internal/api/v1/handlers"
 	"github.com/sudoswedenab/dockyards-backend/internal/api/v2"
+	"github.com/sudoswedenab/dockyards-backend/internal/audit"
 	"github.com/sudoswedenab/dockyards-backend/internal/controller"
 	"github.com/sudoswedenab/dockyards-backend/internal/metrics"
 	"github.com/sudoswedenab/dockyards-backend/internal/webhooks"
 	"github.com/sudoswedenab/dockyards-backend/pkg/authorization"
 	"github.com/sudoswedenab/dockyards-backend/pkg/util/jwt"
+	"github.com/sudoswedenab/dockyards-backend/pkg/util/mailer"
 	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -116,6 +119,75 @@ func setupWebhooks(mgr ctrl.Manager, allowedDomains []string) error {
 	return nil
 }
 
+func newMailerProvider(ctx context.Context, c client.Client, namespace, provider, directory, address, secretName string) (mailer.Provider, error) {
+	switch provider {
+	case "file":
+		if directory == "" {
+			return nil, errors.New("file provider requires mailer directory")
+		}
+
+		return &mailer.FileProvider{Directory: directory}, nil
+	case "smtp":
+		if address == "" {
+			return nil, errors.New("smtp provider requires smtp address")
+		}
+
+		smtpProvider := mailer.SMTPProvider{
+			Address: address,
+		}
+
+		if secretName != "" {
+			var secret corev1.Secret
+			err := c.Get(ctx, client.ObjectKey{Name: secretName, Namespace: namespace}, &secret)
+			if err != nil {
+				return nil, err
+			}
+
+			smtpProvider.Username = string(secret.Data["username"])
+			smtpProvider.Password = string(secret.Data["password"])
+		}
+
+		return &smtpProvider, nil
+	default:
+		return nil, fmt.Errorf("unsupported mailer provider %s", provider)
+	}
+}
+
+func newAuditRecorder(c client.Client, logger *slog.Logger, size int, retention time.Duration, sinks []string, file string, maxSize int64, maxBackups int, webhookURL string) (*audit.Recorder, error) {
+	recorderOptions := []audit.RecorderOption{
+		audit.WithLogger(logger),
+		audit.WithSinks(audit.NewResourceSink(c, retention)),
+	}
+
+	for _, sink := range sinks {
+		switch sink {
+		case "stdout":
+			recorderOptions = append(recorderOptions, audit.WithSinks(audit.NewWriterSink(os.Stdout)))
+		case "file":
+			fileSink, err := audit.NewFileSink(file, maxSize, maxBackups)
+			if err != nil {
+				return nil, err
+			}
+
+			recorderOptions = append(recorderOptions, audit.WithSinks(fileSink))
+		case "webhook":
+			if webhookURL == "" {
+				return nil, errors.New("webhook sink requires audit webhook url")
+			}
+
+			webhookClient := http.Client{
+				Timeout: time.Second * 5,
+			}
+
+			recorderOptions = append(recorderOptions, audit.WithSinks(audit.NewWebhookSink(webhookURL, &webhookClient)))
+		default:
+			return nil, fmt.Errorf("unsupported audit sink %s", sink)
+		}
+	}
+
+	return audit.NewRecorder(size, recorderOptions...)
+}
+
 func main() {
 	var logLevel string
 	var configMap string
@@ -125,6 +197,22 @@ func main() {
 	var allowedOrigins []string
 	var dockyardsSystemNamespace string
 	var allowedDomains []string
+	var jwtRotationInterval time.Duration
+	var jwtRotationGracePeriod time.Duration
+	var trustForwardedFor bool
+	var mailerProvider string
+	var mailerFrom string
+	var mailerDirectory string
+	var mailerTemplates string
+	var smtpAddress string
+	var smtpSecret string
+	var auditSinks []string
+	var auditFile string
+	var auditFileMaxSize int64
+	var auditFileMaxBackups int
+	var auditWebhookURL string
+	var auditBufferSize int
+	var auditEventRetention time.Duration
 	pflag.StringVar(&logLevel, "log-level", "info", "log level")
 	pflag.StringVar(&configMap, "config-map", "dockyards-system", "ConfigMap name")
 	pflag.IntVar(&collectMetricsInterval, "collect-metrics-interval", 30, "collect metrics interval seconds")
@@ -133,6 +221,22 @@ func main() {
 	pflag.StringSliceVar(&allowedOrigins, "allow-origin", []string{"http://localhost", "http://localhost:8000"}, "allow origin")
 	pflag.StringVar(&dockyardsSystemNamespace, "dockyards-namespace", "dockyards-system", "dockyards namespace")
 	pflag.StringSliceVar(&allowedDomains, "allow-domain", nil, "allow domain")
+	pflag.DurationVar(&jwtRotationInterval, "jwt-rotation-interval", time.Hour*24*30, "jwt signing key rotation interval, zero disables rotation")
+	pflag.DurationVar(&jwtRotationGracePeriod, "jwt-rotation-grace-period", time.Hour*24, "duration rotated jwt keys are still accepted")
+	pflag.BoolVar(&trustForwardedFor, "trust-forwarded-for", false, "trust the X-Forwarded-For header set by a reverse proxy")
+	pflag.StringVar(&mailerProvider, "mailer-provider", "", "mailer provider used to deliver verification requests, one of smtp or file, empty disables delivery")
+	pflag.StringVar(&mailerFrom, "mailer-from", "", "mailer from address")
+	pflag.StringVar(&mailerDirectory, "mailer-directory", "", "mailer maildir directory used by the file provider")
+	pflag.StringVar(&mailerTemplates, "mailer-templates-config-map", "dockyards-mail-templates", "mailer templates ConfigMap name")
+	pflag.StringVar(&smtpAddress, "smtp-address", "", "smtp server address used by the smtp provider")
+	pflag.StringVar(&smtpSecret, "smtp-secret", "", "name of the Secret with smtp username and password")
+	pflag.StringSliceVar(&auditSinks, "audit-sink", nil, "audit sinks mutating requests are written to, any of stdout, file or webhook")
+	pflag.StringVar(&auditFile, "audit-file", "/var/log/dockyards/audit.log", "audit file used by the file sink")
+	pflag.Int64Var(&auditFileMaxSize, "audit-file-max-size", 100*1024*1024, "audit file size in bytes before it is rotated")
+	pflag.IntVar(&auditFileMaxBackups, "audit-file-max-backups", 5, "number of rotated audit files to keep")
+	pflag.StringVar(&auditWebhookURL, "audit-webhook-url", "", "audit webhook url used by the webhook sink")
+	pflag.IntVar(&auditBufferSize, "audit-buffer-size", 1000, "number of audit events queued by each replica before they are written to the sinks, events are dropped when the queue is full")
+	pflag.DurationVar(&auditEventRetention, "audit-event-retention", time.Hour*24*90, "duration audit event resources listed by the api are retained, zero retains them until deleted")
 	pflag.Parse()
 
 	logger, err := newLogger(logLevel)
@@ -235,13 +339,31 @@ func main() {
 		}
 	}()
 
-	accessKey, refreshKey, err := jwt.GetOrGenerateKeys(ctx, controllerClient, dockyardsSystemNamespace)
+	keySet, err := jwt.GetOrGenerateKeySet(ctx, controllerClient, dockyardsSystemNamespace)
 	if err != nil {
 		logger.Error("error getting private keys for jwt", "err", err)
 
 		os.Exit(1)
 	}
 
+	keyRotator := jwt.KeyRotator{
+		Client:    controllerClient,
+		Namespace: dockyardsSystemNamespace,
+		KeySet:    keySet,
+		Options: jwt.RotationOptions{
+			Interval:    jwtRotationInterval,
+			GracePeriod: jwtRotationGracePeriod,
+		},
+		Logger: logger,
+	}
+
+	err = mgr.Add(&keyRotator)
+	if err != nil {
+		logger.Error("error adding jwt key rotator", "err", err)
+
+		os.Exit(1)
+	}
+
 	configManagerOptions := []dyconfig.ConfigManagerOption{
 		dyconfig.WithLogger(logger),
 	}
@@ -252,12 +374,30 @@ func main() {
 		os.Exit(1)
 	}
 
+	auditRecorder, err := newAuditRecorder(mgr.GetClient(), logger, auditBufferSize, auditEventRetention, auditSinks, auditFile, auditFileMaxSize, auditFileMaxBackups, auditWebhookURL)
+	if err != nil {
+		logger.Error("error creating audit recorder", "err", err)
+
+		os.Exit(1)
+	}
+
+	err = mgr.Add(auditRecorder)
+	if err != nil {
+		logger.Error("error adding audit recorder", "err", err)
+
+		os.Exit(1)
+	}
+
 	handlerOptions := []handlers.HandlerOption{
 		handlers.WithManager(mgr),
 		handlers.WithSystemNamespace(dockyardsSystemNamespace),
-		handlers.WithJWTPrivateKeys(accessKey, refreshKey),
+		handlers.WithJWTKeySet(keySet),
 		handlers.WithLogger(logger),
 		handlers.WithConfigManager(dockyardsConfig),
+		handlers.WithPrometheusMetrics(prometheusMetrics),
+		handlers.WithTrustForwardedFor(trustForwardedFor),
+		handlers.WithAllowedOrigins(allowedOrigins),
+		handlers.WithAuditRecorder(auditRecorder),
 	}
 
 	publicMux := http.NewServeMux()
@@ -280,7 +420,7 @@ func main() {
 
 	publicHandler := corsHandler.Handler(publicMux)
 
-	v2API := v2.NewAPI(mgr, &accessKey.PublicKey)
+	v2API := v2.NewAPI(mgr, keySet.AccessTokenKeyfunc, v2.WithAuditRecorder(auditRecorder))
 	v2API.RegisterRoutes(publicMux)
 
 	publicServer := &http.Server{
@@ -322,7 +462,8 @@ func main() {
 	}()
 
 	err = (&controller.OrganizationReconciler{
-		Client: mgr.GetClient(),
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorder("dockyards-organization-controller"),
 	}).SetupWithManager(mgr)
 	if err != nil {
 		logger.Error("error creating new organization reconciler", "err", err)
@@ -333,6 +474,7 @@ func main() {
 	err = (&controller.ClusterReconciler{
 		Client:             mgr.GetClient(),
 		DockyardsNamespace: dockyardsSystemNamespace,
+		Recorder:           mgr.GetEventRecorder("dockyards-cluster-controller"),
 	}).SetupWithManager(mgr)
 	if err != nil {
 		logger.Error("error creating new cluster reconciler", "err", err)
@@ -350,8 +492,9 @@ func main() {
 	}
 
 	err = (&controller.UserReconciler{
-		Client: mgr.GetClient(),
-		Config: dockyardsConfig,
+		Client:   mgr.GetClient(),
+		Config:   dockyardsConfig,
+		Recorder: mgr.GetEventRecorder("dockyards-user-controller"),
 	}).SetupWithManager(mgr)
 	if err != nil {
 		logger.Error("error creating new verificationrequest reconciler", "err", err)
@@ -360,7 +503,9 @@ func main() {
 	}
 
 	err = (&controller.InvitationReconciler{
-		Client: mgr.GetClient(),
+		Client:   mgr.GetClient(),
+		Config:   dockyardsConfig,
+		Recorder: mgr.GetEventRecorder("dockyards-invitation-controller"),
 	}).SetupWithManger(mgr)
 	if err != nil {
 		logger.Error("error creating new invitation reconciler", "err", err)
@@ -368,15 +513,86 @@ func main() {
 		os.Exit(1)
 	}
 
-	err = (&controller.MemberReconciler{
+	err = (&controller.SessionReconciler{
+		Client: mgr.GetClient(),
+	}).SetupWithManager(mgr)
+	if err != nil {
+		logger.Error("error creating new session reconciler", "err", err)
+
+		os.Exit(1)
+	}
+
+	err = (&controller.DeviceCodeReconciler{
+		Client: mgr.GetClient(),
+	}).SetupWithManager(mgr)
+	if err != nil {
+		logger.Error("error creating new device code reconciler", "err", err)
+
+		os.Exit(1)
+	}
+
+	err = (&controller.AuditEventReconciler{
 		Client: mgr.GetClient(),
 	}).SetupWithManager(mgr)
+	if err != nil {
+		logger.Error("error creating new audit event reconciler", "err", err)
+
+		os.Exit(1)
+	}
+
+	err = (&controller.MemberReconciler{
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorder("dockyards-member-controller"),
+	}).SetupWithManager(mgr)
 	if err != nil {
 		logger.Error("error creating new member reconciler", "err", err)
 
 		os.Exit(1)
 	}
 
+	err = (&controller.OrganizationRoleReconciler{
+		Client: mgr.GetClient(),
+	}).SetupWithManager(mgr)
+	if err != nil {
+		logger.Error("error creating new organization role reconciler", "err", err)
+
+		os.Exit(1)
+	}
+
+	err = (&controller.ServiceAccountReconciler{
+		Client: mgr.GetClient(),
+	}).SetupWithManager(mgr)
+	if err != nil {
+		logger.Error("error creating new service account reconciler", "err", err)
+
+		os.Exit(1)
+	}
+
+	if mailerProvider != "" {
+		provider, err := newMailerProvider(ctx, controllerClient, dockyardsSystemNamespace, mailerProvider, mailerDirectory, smtpAddress, smtpSecret)
+		if err != nil {
+			logger.Error("error creating mailer provider", "err", err)
+
+			os.Exit(1)
+		}
+
+		err = (&controller.MailerReconciler{
+			Client:   mgr.GetClient(),
+			Config:   dockyardsConfig,
+			Provider: provider,
+			From:     mailerFrom,
+			TemplatesConfigMap: client.ObjectKey{
+				Name:      mailerTemplates,
+				Namespace: dockyardsSystemNamespace,
+			},
+		}).SetupWithManager(mgr)
+		if err != nil {
+			logger.Error("error creating new mailer reconciler", "err", err)
+
+			os.Exit(1)
+		}
+	}
+
 	if enableWebhooks {
 		logger.Info("enabling webhooks", "domains", allowedDomains)
 
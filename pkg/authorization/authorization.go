@@ -16,10 +16,12 @@ package authorization
 
 import (
 	"context"
+	"slices"
 
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -30,6 +32,15 @@ const (
 	LabelAuthenticatedAggregateRole = "rbac.dockyards.io/aggregate-to-authenticated"
 )
 
+// ClusterResources are the resources belonging to a cluster. Members limited to a subset of the clusters are
+// granted these in the whole organization, the APIs only allow access to the ones belonging to clusters the
+// member is allowed to get.
+var ClusterResources = []string{
+	"nodepools",
+	"nodes",
+	"workloads",
+}
+
 func ReconcileOrganizationSuperUserClusterRole(ctx context.Context, c client.Client, organization *dockyardsv1.Organization) error {
 	clusterRole := rbacv1.ClusterRole{
 		ObjectMeta: metav1.ObjectMeta{
@@ -119,7 +130,7 @@ func ReconcileOrganizationSuperUserClusterRole(ctx context.Context, c client.Cli
 			subjects = append(subjects, rbacv1.Subject{
 				APIGroup: rbacv1.GroupName,
 				Kind:     rbacv1.UserKind,
-				Name:     member.Spec.UserRef.Name,
+				Name:     member.GetSubject(),
 			})
 		}
 
@@ -217,7 +228,7 @@ func ReconcileOrganizationReaderClusterRole(ctx context.Context, c client.Client
 			subjects[i] = rbacv1.Subject{
 				APIGroup: rbacv1.GroupName,
 				Kind:     rbacv1.UserKind,
-				Name:     member.Spec.UserRef.Name,
+				Name:     member.GetSubject(),
 			}
 		}
 
@@ -388,6 +399,8 @@ func ReconcileClusterAuthorization(ctx context.Context, client client.Client) er
 					"members",
 					"nodepools",
 					"nodes",
+					"organizationroles",
+					"serviceaccounts",
 					"workloads",
 				},
 			},
@@ -444,6 +457,7 @@ func ReconcileClusterAuthorization(ctx context.Context, client client.Client) er
 				Verbs: []string{
 					"create",
 					"delete",
+					"patch",
 				},
 				APIGroups: []string{
 					dockyardsv1.GroupVersion.Group,
@@ -454,6 +468,33 @@ func ReconcileClusterAuthorization(ctx context.Context, client client.Client) er
 			},
 			{
 				Verbs: []string{
+					"create",
+					"delete",
+				},
+				APIGroups: []string{
+					dockyardsv1.GroupVersion.Group,
+				},
+				Resources: []string{
+					"serviceaccounts",
+				},
+			},
+			{
+				Verbs: []string{
+					"create",
+					"delete",
+					"patch",
+					"update",
+				},
+				APIGroups: []string{
+					dockyardsv1.GroupVersion.Group,
+				},
+				Resources: []string{
+					"organizationroles",
+				},
+			},
+			{
+				Verbs: []string{
+					"create",
 					"delete",
 					"patch",
 					"update",
@@ -465,6 +506,81 @@ func ReconcileClusterAuthorization(ctx context.Context, client client.Client) er
 					"members",
 				},
 			},
+			{
+				Verbs: []string{
+					"list",
+				},
+				APIGroups: []string{
+					dockyardsv1.GroupVersion.Group,
+				},
+				Resources: []string{
+					"auditevents",
+				},
+			},
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	clusterRole = rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dockyards:restricted-reader",
+		},
+	}
+
+	_, err = controllerutil.CreateOrPatch(ctx, client, &clusterRole, func() error {
+		clusterRole.Rules = []rbacv1.PolicyRule{
+			{
+				Verbs: []string{
+					"get",
+					"list",
+					"watch",
+				},
+				APIGroups: []string{
+					dockyardsv1.GroupVersion.Group,
+				},
+				Resources: []string{
+					"dnszones",
+					"invitations",
+					"members",
+					"nodepools",
+					"nodes",
+					"organizationroles",
+					"serviceaccounts",
+					"workloads",
+				},
+			},
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	clusterRole = rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dockyards:restricted-user",
+		},
+	}
+
+	_, err = controllerutil.CreateOrPatch(ctx, client, &clusterRole, func() error {
+		clusterRole.Rules = []rbacv1.PolicyRule{
+			{
+				Verbs: []string{
+					"create",
+					"delete",
+					"patch",
+					"update",
+				},
+				APIGroups: []string{
+					dockyardsv1.GroupVersion.Group,
+				},
+				Resources: ClusterResources,
+			},
 		}
 
 		return nil
@@ -553,7 +669,7 @@ func ReconcileMemberAuthorization(ctx context.Context, client client.Client, mem
 			{
 				APIGroup: rbacv1.SchemeGroupVersion.Group,
 				Kind:     rbacv1.UserKind,
-				Name:     member.Spec.UserRef.Name,
+				Name:     member.GetSubject(),
 			},
 		}
 
@@ -563,14 +679,117 @@ func ReconcileMemberAuthorization(ctx context.Context, client client.Client, mem
 		return err
 	}
 
+	readerClusterRoleName := "dockyards:reader"
+	userClusterRoleName := "dockyards:user"
+
+	// Members limited to a subset of the clusters are bound to cluster roles without any cluster permissions, the
+	// permissions for the clusters are instead granted by name using a role for the member.
+	restricted := IsRestrictedMember(member)
+	if restricted {
+		readerClusterRoleName = "dockyards:restricted-reader"
+		userClusterRoleName = "dockyards:restricted-user"
+	}
+
+	roleRefs := map[string]rbacv1.RoleRef{
+		"reader": {
+			APIGroup: rbacv1.SchemeGroupVersion.Group,
+			Kind:     "ClusterRole",
+			Name:     readerClusterRoleName,
+		},
+	}
+
+	userRoleRef := rbacv1.RoleRef{
+		APIGroup: rbacv1.SchemeGroupVersion.Group,
+		Kind:     "ClusterRole",
+		Name:     userClusterRoleName,
+	}
+
+	switch {
+	case member.Spec.Role == dockyardsv1.RoleSuperUser:
+		roleRefs["user"] = userRoleRef
+		roleRefs["super-user"] = rbacv1.RoleRef{
+			APIGroup: rbacv1.SchemeGroupVersion.Group,
+			Kind:     "ClusterRole",
+			Name:     "dockyards:super-user",
+		}
+	case member.Spec.Role == dockyardsv1.RoleUser:
+		roleRefs["user"] = userRoleRef
+	case !dockyardsv1.IsBuiltinRole(member.Spec.Role):
+		roleRefs["role"] = rbacv1.RoleRef{
+			APIGroup: rbacv1.SchemeGroupVersion.Group,
+			Kind:     "Role",
+			Name:     OrganizationRoleName(string(member.Spec.Role)),
+		}
+	}
+
+	if restricted {
+		err := reconcileMemberClustersRole(ctx, client, member)
+		if err != nil {
+			return err
+		}
+
+		roleRefs["clusters"] = rbacv1.RoleRef{
+			APIGroup: rbacv1.SchemeGroupVersion.Group,
+			Kind:     "Role",
+			Name:     member.Name + ":clusters",
+		}
+	}
+
+	if !restricted {
+		err := deleteMemberClustersRole(ctx, client, member)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, suffix := range []string{"reader", "user", "super-user", "role", "clusters"} {
+		roleRef, hasRoleRef := roleRefs[suffix]
+		if !hasRoleRef {
+			err := deleteMemberRoleBinding(ctx, client, member, suffix)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		err := reconcileMemberRoleBinding(ctx, client, member, suffix, roleRef)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func reconcileMemberRoleBinding(ctx context.Context, c client.Client, member *dockyardsv1.Member, suffix string, roleRef rbacv1.RoleRef) error {
+	objectKey := client.ObjectKey{
+		Name:      member.Name + ":" + suffix,
+		Namespace: member.Namespace,
+	}
+
+	var roleBinding rbacv1.RoleBinding
+	err := c.Get(ctx, objectKey, &roleBinding)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+
+	// The role reference of a role binding is immutable, the binding is recreated when the role of the member changes.
+	if err == nil && roleBinding.RoleRef != roleRef {
+		err := c.Delete(ctx, &roleBinding)
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
 	roleBinding = rbacv1.RoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      member.Name + ":reader",
-			Namespace: member.Namespace,
+			Name:      objectKey.Name,
+			Namespace: objectKey.Namespace,
 		},
 	}
 
-	_, err = controllerutil.CreateOrPatch(ctx, client, &roleBinding, func() error {
+	_, err = controllerutil.CreateOrPatch(ctx, c, &roleBinding, func() error {
 		roleBinding.Labels = map[string]string{
 			dockyardsv1.LabelMemberName: member.Name,
 		}
@@ -584,17 +803,13 @@ func ReconcileMemberAuthorization(ctx context.Context, client client.Client, mem
 			},
 		}
 
-		roleBinding.RoleRef = rbacv1.RoleRef{
-			APIGroup: rbacv1.SchemeGroupVersion.Group,
-			Kind:     "ClusterRole",
-			Name:     "dockyards:reader",
-		}
+		roleBinding.RoleRef = roleRef
 
 		roleBinding.Subjects = []rbacv1.Subject{
 			{
 				APIGroup: rbacv1.SchemeGroupVersion.Group,
 				Kind:     rbacv1.UserKind,
-				Name:     member.Spec.UserRef.Name,
+				Name:     member.GetSubject(),
 			},
 		}
 
@@ -604,23 +819,82 @@ func ReconcileMemberAuthorization(ctx context.Context, client client.Client, mem
 		return err
 	}
 
-	if member.Spec.Role == dockyardsv1.RoleReader {
-		return nil
+	return nil
+}
+
+func deleteMemberRoleBinding(ctx context.Context, c client.Client, member *dockyardsv1.Member, suffix string) error {
+	roleBinding := rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      member.Name + ":" + suffix,
+			Namespace: member.Namespace,
+		},
 	}
 
-	roleBinding = rbacv1.RoleBinding{
+	err := c.Delete(ctx, &roleBinding)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IsRestrictedMember returns true if the member is limited to a subset of the clusters in the organization.
+func IsRestrictedMember(member *dockyardsv1.Member) bool {
+	if member.Spec.Clusters == nil {
+		return false
+	}
+
+	return member.Spec.Role == dockyardsv1.RoleUser || member.Spec.Role == dockyardsv1.RoleReader
+}
+
+// MemberClusterNames returns the sorted names of the clusters the member is limited to, clusters listed by name are
+// included even if they do not exist yet.
+func MemberClusterNames(ctx context.Context, c client.Client, member *dockyardsv1.Member) ([]string, error) {
+	if member.Spec.Clusters == nil {
+		return nil, nil
+	}
+
+	clusterNames := sets.New(member.Spec.Clusters.Names...)
+
+	if member.Spec.Clusters.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(member.Spec.Clusters.Selector)
+		if err != nil {
+			return nil, err
+		}
+
+		var clusterList dockyardsv1.ClusterList
+		err = c.List(ctx, &clusterList, client.InNamespace(member.Namespace), client.MatchingLabelsSelector{Selector: selector})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cluster := range clusterList.Items {
+			clusterNames.Insert(cluster.Name)
+		}
+	}
+
+	return sets.List(clusterNames), nil
+}
+
+func reconcileMemberClustersRole(ctx context.Context, c client.Client, member *dockyardsv1.Member) error {
+	clusterNames, err := MemberClusterNames(ctx, c, member)
+	if err != nil {
+		return err
+	}
+
+	role := rbacv1.Role{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      member.Name + ":user",
+			Name:      member.Name + ":clusters",
 			Namespace: member.Namespace,
 		},
 	}
 
-	_, err = controllerutil.CreateOrPatch(ctx, client, &roleBinding, func() error {
-		roleBinding.Labels = map[string]string{
+	_, err = controllerutil.CreateOrPatch(ctx, c, &role, func() error {
+		role.Labels = map[string]string{
 			dockyardsv1.LabelMemberName: member.Name,
 		}
 
-		roleBinding.OwnerReferences = []metav1.OwnerReference{
+		role.OwnerReferences = []metav1.OwnerReference{
 			{
 				APIVersion: dockyardsv1.GroupVersion.String(),
 				Kind:       dockyardsv1.MemberKind,
@@ -629,65 +903,130 @@ func ReconcileMemberAuthorization(ctx context.Context, client client.Client, mem
 			},
 		}
 
-		roleBinding.RoleRef = rbacv1.RoleRef{
-			APIGroup: rbacv1.SchemeGroupVersion.Group,
-			Kind:     "ClusterRole",
-			Name:     "dockyards:user",
+		listVerbs := []string{
+			"list",
+			"watch",
 		}
 
-		roleBinding.Subjects = []rbacv1.Subject{
+		namedVerbs := []string{
+			"get",
+		}
+
+		if member.Spec.Role == dockyardsv1.RoleUser {
+			listVerbs = append(listVerbs, "create")
+			namedVerbs = append(namedVerbs, "delete", "patch", "update")
+		}
+
+		role.Rules = []rbacv1.PolicyRule{
 			{
-				APIGroup: rbacv1.SchemeGroupVersion.Group,
-				Kind:     rbacv1.UserKind,
-				Name:     member.Spec.UserRef.Name,
+				Verbs: listVerbs,
+				APIGroups: []string{
+					dockyardsv1.GroupVersion.Group,
+				},
+				Resources: []string{
+					"clusters",
+				},
 			},
 		}
 
+		// An empty list of resource names allows every name and must not be used.
+		if len(clusterNames) > 0 {
+			role.Rules = append(role.Rules, rbacv1.PolicyRule{
+				Verbs: namedVerbs,
+				APIGroups: []string{
+					dockyardsv1.GroupVersion.Group,
+				},
+				Resources: []string{
+					"clusters",
+				},
+				ResourceNames: clusterNames,
+			})
+		}
+
 		return nil
 	})
 	if err != nil {
 		return err
 	}
 
-	if member.Spec.Role == dockyardsv1.RoleUser {
-		return nil
-	}
+	return nil
+}
 
-	roleBinding = rbacv1.RoleBinding{
+func deleteMemberClustersRole(ctx context.Context, c client.Client, member *dockyardsv1.Member) error {
+	role := rbacv1.Role{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      member.Name + ":super-user",
+			Name:      member.Name + ":clusters",
 			Namespace: member.Namespace,
 		},
 	}
 
-	_, err = controllerutil.CreateOrPatch(ctx, client, &roleBinding, func() error {
-		roleBinding.Labels = map[string]string{
-			dockyardsv1.LabelMemberName: member.Name,
+	err := c.Delete(ctx, &role)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+
+	return nil
+}
+
+// OrganizationRoleName returns the name of the role created for an organization role.
+func OrganizationRoleName(name string) string {
+	return "dockyards:role:" + name
+}
+
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles,verbs=bind;create;delete;escalate;get;list;patch;watch
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=create;delete;get;list;patch;watch
+
+func ReconcileOrganizationRoleAuthorization(ctx context.Context, c client.Client, organizationRole *dockyardsv1.OrganizationRole) error {
+	role := rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      OrganizationRoleName(organizationRole.Name),
+			Namespace: organizationRole.Namespace,
+		},
+	}
+
+	_, err := controllerutil.CreateOrPatch(ctx, c, &role, func() error {
+		role.Labels = map[string]string{
+			dockyardsv1.LabelRoleName: organizationRole.Name,
 		}
 
-		roleBinding.OwnerReferences = []metav1.OwnerReference{
+		role.OwnerReferences = []metav1.OwnerReference{
 			{
 				APIVersion: dockyardsv1.GroupVersion.String(),
-				Kind:       dockyardsv1.MemberKind,
-				Name:       member.Name,
-				UID:        member.UID,
+				Kind:       dockyardsv1.OrganizationRoleKind,
+				Name:       organizationRole.Name,
+				UID:        organizationRole.UID,
 			},
 		}
 
-		roleBinding.RoleRef = rbacv1.RoleRef{
-			APIGroup: rbacv1.SchemeGroupVersion.Group,
-			Kind:     "ClusterRole",
-			Name:     "dockyards:super-user",
-		}
+		var rules []rbacv1.PolicyRule
 
-		roleBinding.Subjects = []rbacv1.Subject{
-			{
-				APIGroup: rbacv1.SchemeGroupVersion.Group,
-				Kind:     rbacv1.UserKind,
-				Name:     member.Spec.UserRef.Name,
-			},
+		for _, rule := range organizationRole.Spec.Rules {
+			// Members are never granted by custom roles as members could otherwise give themselves any role,
+			// roles created before members were disallowed by validation are filtered here.
+			resources := slices.DeleteFunc(slices.Clone(rule.Resources), func(resource string) bool {
+				return resource == "members"
+			})
+
+			if len(resources) == 0 {
+				continue
+			}
+
+			verbs := make([]string, len(rule.Verbs))
+			for j, verb := range rule.Verbs {
+				verbs[j] = string(verb)
+			}
+
+			rules = append(rules, rbacv1.PolicyRule{
+				Verbs: verbs,
+				APIGroups: []string{
+					dockyardsv1.GroupVersion.Group,
+				},
+				Resources: resources,
+			})
 		}
 
+		role.Rules = rules
+
 		return nil
 	})
 	if err != nil {
@@ -18,6 +18,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	dockyardsv1 "github.com/sudoswedenab/dockyards-backend/api/v1alpha3"
 	"github.com/sudoswedenab/dockyards-backend/pkg/authorization"
 	authorizationv1 "k8s.io/api/authorization/v1"
@@ -26,6 +27,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 )
 
@@ -1042,3 +1044,361 @@ func TestMemberAuthorization(t *testing.T) {
 		}
 	})
 }
+
+func TestOrganizationRoleAuthorization(t *testing.T) {
+	ctx := t.Context()
+
+	err := authorization.ReconcileClusterAuthorization(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := dockyardsv1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "role-",
+		},
+	}
+
+	err = c.Create(ctx, &user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "role-",
+		},
+	}
+
+	err = c.Create(ctx, &namespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	organizationRole := dockyardsv1.OrganizationRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "workload-manager",
+			Namespace: namespace.Name,
+		},
+		Spec: dockyardsv1.OrganizationRoleSpec{
+			Rules: []dockyardsv1.OrganizationRoleRule{
+				{
+					Resources: []string{
+						"workloads",
+					},
+					Verbs: []dockyardsv1.Verb{
+						"create",
+						"delete",
+					},
+				},
+			},
+		},
+	}
+
+	err = c.Create(ctx, &organizationRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = authorization.ReconcileOrganizationRoleAuthorization(ctx, c, &organizationRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	member := dockyardsv1.Member{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      user.Name,
+			Namespace: namespace.Name,
+		},
+		Spec: dockyardsv1.MemberSpec{
+			Role: dockyardsv1.Role(organizationRole.Name),
+			UserRef: corev1.TypedLocalObjectReference{
+				Name: user.Name,
+			},
+		},
+	}
+
+	err = c.Create(ctx, &member)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = authorization.ReconcileMemberAuthorization(ctx, c, &member)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	isAllowed := func(t *testing.T, resource, verb string) bool {
+		subjectAccessReview := authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User: user.Name,
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Group:     dockyardsv1.GroupVersion.Group,
+					Namespace: namespace.Name,
+					Resource:  resource,
+					Verb:      verb,
+				},
+			},
+		}
+
+		err := c.Create(ctx, &subjectAccessReview)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return subjectAccessReview.Status.Allowed
+	}
+
+	t.Run("test deleting workloads", func(t *testing.T) {
+		allowed := isAllowed(t, "workloads", "delete")
+		if !allowed {
+			t.Errorf("expected allowed, got %t", allowed)
+		}
+	})
+
+	t.Run("test deleting clusters", func(t *testing.T) {
+		allowed := isAllowed(t, "clusters", "delete")
+		if allowed {
+			t.Errorf("expected not allowed, got %t", allowed)
+		}
+	})
+
+	t.Run("test getting clusters", func(t *testing.T) {
+		allowed := isAllowed(t, "clusters", "get")
+		if !allowed {
+			t.Errorf("expected allowed, got %t", allowed)
+		}
+	})
+
+	t.Run("test changing role to reader", func(t *testing.T) {
+		patch := client.MergeFrom(member.DeepCopy())
+
+		member.Spec.Role = dockyardsv1.RoleReader
+
+		err := c.Patch(ctx, &member, patch)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = authorization.ReconcileMemberAuthorization(ctx, c, &member)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		allowed := isAllowed(t, "workloads", "delete")
+		if allowed {
+			t.Errorf("expected not allowed, got %t", allowed)
+		}
+	})
+}
+
+func TestRestrictedMemberAuthorization(t *testing.T) {
+	ctx := t.Context()
+
+	err := authorization.ReconcileClusterAuthorization(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := dockyardsv1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "restricted-",
+		},
+	}
+
+	err = c.Create(ctx, &user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "restricted-",
+		},
+	}
+
+	err = c.Create(ctx, &namespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cluster := dockyardsv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "production",
+			Namespace: namespace.Name,
+			Labels: map[string]string{
+				"environment": "production",
+			},
+		},
+	}
+
+	err = c.Create(ctx, &cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	member := dockyardsv1.Member{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      user.Name,
+			Namespace: namespace.Name,
+		},
+		Spec: dockyardsv1.MemberSpec{
+			Role: dockyardsv1.RoleUser,
+			UserRef: corev1.TypedLocalObjectReference{
+				Name: user.Name,
+			},
+			Clusters: &dockyardsv1.MemberClusters{
+				Names: []string{
+					"staging",
+				},
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"environment": "production",
+					},
+				},
+			},
+		},
+	}
+
+	err = c.Create(ctx, &member)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = authorization.ReconcileMemberAuthorization(ctx, c, &member)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	isAllowed := func(t *testing.T, name, verb string) bool {
+		subjectAccessReview := authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User: user.Name,
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Group:     dockyardsv1.GroupVersion.Group,
+					Name:      name,
+					Namespace: namespace.Name,
+					Resource:  "clusters",
+					Verb:      verb,
+				},
+			},
+		}
+
+		err := c.Create(ctx, &subjectAccessReview)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return subjectAccessReview.Status.Allowed
+	}
+
+	tt := []struct {
+		name     string
+		cluster  string
+		verb     string
+		expected bool
+	}{
+		{
+			name:     "test getting cluster by name",
+			cluster:  "staging",
+			verb:     "get",
+			expected: true,
+		},
+		{
+			name:     "test deleting cluster by selector",
+			cluster:  "production",
+			verb:     "delete",
+			expected: true,
+		},
+		{
+			name:     "test getting other cluster",
+			cluster:  "development",
+			verb:     "get",
+			expected: false,
+		},
+		{
+			name:     "test getting all clusters",
+			verb:     "get",
+			expected: false,
+		},
+		{
+			name:     "test listing clusters",
+			verb:     "list",
+			expected: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			allowed := isAllowed(t, tc.cluster, tc.verb)
+			if allowed != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, allowed)
+			}
+		})
+	}
+}
+
+func TestOrganizationRoleAuthorizationMembers(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = dockyardsv1.AddToScheme(scheme)
+	_ = rbacv1.AddToScheme(scheme)
+
+	organizationRole := dockyardsv1.OrganizationRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "member-manager",
+			Namespace: "testing",
+		},
+		Spec: dockyardsv1.OrganizationRoleSpec{
+			Rules: []dockyardsv1.OrganizationRoleRule{
+				{
+					Resources: []string{
+						"members",
+					},
+					Verbs: []dockyardsv1.Verb{
+						"patch",
+					},
+				},
+				{
+					Resources: []string{
+						"members",
+						"workloads",
+					},
+					Verbs: []dockyardsv1.Verb{
+						"create",
+					},
+				},
+			},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&organizationRole).Build()
+
+	err := authorization.ReconcileOrganizationRoleAuthorization(t.Context(), fakeClient, &organizationRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var role rbacv1.Role
+	err = fakeClient.Get(t.Context(), client.ObjectKey{Name: authorization.OrganizationRoleName(organizationRole.Name), Namespace: "testing"}, &role)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []rbacv1.PolicyRule{
+		{
+			Verbs: []string{
+				"create",
+			},
+			APIGroups: []string{
+				dockyardsv1.GroupVersion.Group,
+			},
+			Resources: []string{
+				"workloads",
+			},
+		},
+	}
+
+	if !cmp.Equal(role.Rules, expected) {
+		t.Errorf("diff: %s", cmp.Diff(expected, role.Rules))
+	}
+}
@@ -22,9 +22,13 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
+	"slices"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
@@ -36,130 +40,345 @@ const (
 	AccessTokenPrivateKeyKey  = "accessTokenPrivateKey"
 	RefreshTokenPrivateKeyKey = "refreshTokenPrivateKey"
 	AccessTokenPublicKeyKey   = "accessTokenPublicKey"
+
+	// CreatedHeader is the PEM header recording when a key was generated, keys without the header are
+	// assumed to have been generated together with the secret.
+	CreatedHeader = "Created"
 )
 
+const (
+	secretName    = "dockyards-backend-jwt"
+	configMapName = "dockyards-backend-jwt"
+)
+
+// RotationOptions controls how keys are rotated, the zero value never rotates nor retires keys.
+type RotationOptions struct {
+	// Interval is how long a key is used for signing before a new key is generated, the new key is only
+	// used for signing once it has been published for a short while.
+	Interval time.Duration
+	// GracePeriod is how long a key is still accepted for verification after being replaced.
+	GracePeriod time.Duration
+}
+
+// GetOrGenerateKeys returns the current access and refresh token signing keys, generating them if missing.
 func GetOrGenerateKeys(ctx context.Context, c client.Client, namespace string) (*ecdsa.PrivateKey, *ecdsa.PrivateKey, error) {
-	var (
-		accessTokenPrivateKeyPEM  []byte
-		refreshTokenPrivateKeyPEM []byte
-		has                       bool
-	)
+	keySet, err := GetOrGenerateKeySet(ctx, c, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	secret := corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "dockyards-backend-jwt",
-			Namespace: namespace,
-		},
+	return keySet.AccessTokenSigningKey(), keySet.RefreshTokenSigningKey(), nil
+}
+
+// GetOrGenerateKeySet returns a key set with all keys stored in the secret, without rotating any keys.
+func GetOrGenerateKeySet(ctx context.Context, c client.Client, namespace string) (*KeySet, error) {
+	var keySet KeySet
+
+	err := keySet.Sync(ctx, c, namespace, RotationOptions{})
+	if err != nil {
+		return nil, err
 	}
 
-	_, err := controllerutil.CreateOrPatch(ctx, c, &secret, func() error {
-		if secret.Data == nil {
-			secret.Data = make(map[string][]byte)
-		}
+	return &keySet, nil
+}
 
-		accessTokenPrivateKeyPEM, has = secret.Data[AccessTokenPrivateKeyKey]
-		if !has {
-			accessTokenPrivateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-			if err != nil {
-				return err
-			}
+// Sync loads the keys from the secret into the key set, generating a new signing key when the current
+// one is older than the rotation interval and dropping keys that have been replaced for longer than the
+// grace period.
+func (s *KeySet) Sync(ctx context.Context, c client.Client, namespace string, options RotationOptions) error {
+	var accessKeys, refreshKeys []Key
 
-			b, err := x509.MarshalECPrivateKey(accessTokenPrivateKey)
-			if err != nil {
-				return err
-			}
+	retriable := func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}
 
-			block := pem.Block{
-				Type:  "EC PRIVATE KEY",
-				Bytes: b,
-			}
+	err := retry.OnError(retry.DefaultRetry, retriable, func() error {
+		var err error
 
-			accessTokenPrivateKeyPEM = pem.EncodeToMemory(&block)
+		accessKeys, refreshKeys, err = syncSecret(ctx, c, namespace, options)
 
-			secret.Data[AccessTokenPrivateKeyKey] = accessTokenPrivateKeyPEM
-		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
 
-		refreshTokenPrivateKeyPEM, has = secret.Data[RefreshTokenPrivateKeyKey]
-		if !has {
-			refreshTokenPrivateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-			if err != nil {
-				return err
-			}
+	err = syncConfigMap(ctx, c, namespace, accessKeys)
+	if err != nil {
+		return err
+	}
 
-			b, err := x509.MarshalECPrivateKey(refreshTokenPrivateKey)
-			if err != nil {
-				return err
-			}
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-			block := pem.Block{
-				Type:  "EC PRIVATE KEY",
-				Bytes: b,
-			}
+	s.accessKeys = accessKeys
+	s.refreshKeys = refreshKeys
 
-			refreshTokenPrivateKeyPEM = pem.EncodeToMemory(&block)
+	return nil
+}
 
-			secret.Data[RefreshTokenPrivateKeyKey] = refreshTokenPrivateKeyPEM
-		}
+func syncSecret(ctx context.Context, c client.Client, namespace string, options RotationOptions) ([]Key, []Key, error) {
+	var secret corev1.Secret
+	err := c.Get(ctx, client.ObjectKey{Name: secretName, Namespace: namespace}, &secret)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, nil, err
+	}
 
-		return nil
-	})
+	isNotFound := apierrors.IsNotFound(err)
+
+	now := time.Now().Truncate(time.Second)
+
+	accessKeys, err := decodeKeys(secret.Data[AccessTokenPrivateKeyKey], secret.CreationTimestamp.Time)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshKeys, err := decodeKeys(secret.Data[RefreshTokenPrivateKeyKey], secret.CreationTimestamp.Time)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accessKeys, accessKeysChanged, err := rotateKeys(accessKeys, now, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshKeys, refreshKeysChanged, err := rotateKeys(refreshKeys, now, options)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	accessTokenPrivateKeyDER, _ := pem.Decode(accessTokenPrivateKeyPEM)
-	if accessTokenPrivateKeyDER == nil || accessTokenPrivateKeyDER.Type != "EC PRIVATE KEY" {
-		return nil, nil, errors.New("invalid access private key")
+	if !accessKeysChanged && !refreshKeysChanged {
+		return accessKeys, refreshKeys, nil
+	}
+
+	accessKeysPEM, err := encodeKeys(accessKeys)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	accessTokenPrivateKey, err := x509.ParseECPrivateKey(accessTokenPrivateKeyDER.Bytes)
+	refreshKeysPEM, err := encodeKeys(refreshKeys)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	refreshTokenPrivateKeyDER, _ := pem.Decode(refreshTokenPrivateKeyPEM)
-	if refreshTokenPrivateKeyDER == nil || refreshTokenPrivateKeyDER.Type != "EC PRIVATE KEY" {
-		return nil, nil, errors.New("invalid refresh private key")
+	if isNotFound {
+		secret = corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: namespace,
+			},
+			Data: map[string][]byte{
+				AccessTokenPrivateKeyKey:  accessKeysPEM,
+				RefreshTokenPrivateKeyKey: refreshKeysPEM,
+			},
+		}
+
+		err := c.Create(ctx, &secret)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return accessKeys, refreshKeys, nil
+	}
+
+	// The optimistic lock makes sure that concurrent rotations never overwrite keys that might already
+	// have been used for signing.
+	patch := client.MergeFromWithOptions(secret.DeepCopy(), client.MergeFromWithOptimisticLock{})
+
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
 	}
 
-	refreshTokenPrivateKey, err := x509.ParseECPrivateKey(refreshTokenPrivateKeyDER.Bytes)
+	secret.Data[AccessTokenPrivateKeyKey] = accessKeysPEM
+	secret.Data[RefreshTokenPrivateKeyKey] = refreshKeysPEM
+
+	err = c.Patch(ctx, &secret, patch)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	return accessKeys, refreshKeys, nil
+}
+
+// syncConfigMap publishes the public part of every access token key, starting with the current signing key
+// so that consumers reading a single key keep verifying new tokens.
+func syncConfigMap(ctx context.Context, c client.Client, namespace string, accessKeys []Key) error {
+	i := signingKeyIndex(accessKeys, time.Now())
+
+	publicKeys := []*ecdsa.PublicKey{
+		&accessKeys[i].PrivateKey.PublicKey,
+	}
+
+	for j, key := range accessKeys {
+		if j != i {
+			publicKeys = append(publicKeys, &key.PrivateKey.PublicKey)
+		}
+	}
+
 	configMap := corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "dockyards-backend-jwt",
+			Name:      configMapName,
 			Namespace: namespace,
 		},
 	}
 
-	_, err = controllerutil.CreateOrPatch(ctx, c, &configMap, func() error {
+	_, err := controllerutil.CreateOrPatch(ctx, c, &configMap, func() error {
 		if configMap.Data == nil {
 			configMap.Data = make(map[string]string)
 		}
 
-		accessTokenPublicKey := accessTokenPrivateKey.PublicKey
+		var accessTokenPublicKeyPEM []byte
 
-		b, err := x509.MarshalPKIXPublicKey(&accessTokenPublicKey)
-		if err != nil {
-			return err
-		}
+		for _, publicKey := range publicKeys {
+			b, err := x509.MarshalPKIXPublicKey(publicKey)
+			if err != nil {
+				return err
+			}
 
-		block := pem.Block{
-			Type:  "PUBLIC KEY",
-			Bytes: b,
-		}
+			block := pem.Block{
+				Type:  "PUBLIC KEY",
+				Bytes: b,
+			}
 
-		accessTokenPublicKeyPEM := pem.EncodeToMemory(&block)
+			accessTokenPublicKeyPEM = append(accessTokenPublicKeyPEM, pem.EncodeToMemory(&block)...)
+		}
 
 		configMap.Data[AccessTokenPublicKeyKey] = string(accessTokenPublicKeyPEM)
 
 		return nil
 	})
 	if err != nil {
-		return nil, nil, err
+		return err
+	}
+
+	return nil
+}
+
+// rotateKeys returns the keys sorted from newest to oldest, with a new key added when there are no keys
+// or the newest key is due for rotation.
+func rotateKeys(keys []Key, now time.Time, options RotationOptions) ([]Key, bool, error) {
+	changed := false
+
+	if len(keys) == 0 || options.Interval > 0 && now.Sub(keys[0].CreationTimestamp) >= options.Interval {
+		key, err := generateKey(now)
+		if err != nil {
+			return nil, false, err
+		}
+
+		keys = append([]Key{*key}, keys...)
+		changed = true
+	}
+
+	if options.Interval == 0 {
+		return keys, changed, nil
+	}
+
+	for i := 1; i < len(keys); i++ {
+		// A key keeps signing until its replacement is activated.
+		replacedAt := keys[i-1].CreationTimestamp.Add(keyActivationDelay)
+
+		if now.Sub(replacedAt) > options.GracePeriod {
+			keys = keys[:i]
+			changed = true
+
+			break
+		}
+	}
+
+	return keys, changed, nil
+}
+
+func generateKey(now time.Time) (*Key, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	keyID, err := thumbprint(&privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key := Key{
+		ID:                keyID,
+		CreationTimestamp: now,
+		PrivateKey:        privateKey,
+	}
+
+	return &key, nil
+}
+
+func decodeKeys(data []byte, defaultCreationTimestamp time.Time) ([]Key, error) {
+	var keys []Key
+
+	for {
+		var block *pem.Block
+
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "EC PRIVATE KEY" {
+			return nil, errors.New("invalid private key")
+		}
+
+		privateKey, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		keyID, err := thumbprint(&privateKey.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		creationTimestamp := defaultCreationTimestamp
+
+		created, has := block.Headers[CreatedHeader]
+		if has {
+			creationTimestamp, err = time.Parse(time.RFC3339, created)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		key := Key{
+			ID:                keyID,
+			CreationTimestamp: creationTimestamp,
+			PrivateKey:        privateKey,
+		}
+
+		keys = append(keys, key)
+	}
+
+	slices.SortStableFunc(keys, func(a, b Key) int {
+		return b.CreationTimestamp.Compare(a.CreationTimestamp)
+	})
+
+	return keys, nil
+}
+
+func encodeKeys(keys []Key) ([]byte, error) {
+	var data []byte
+
+	for _, key := range keys {
+		b, err := x509.MarshalECPrivateKey(key.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+
+		block := pem.Block{
+			Type: "EC PRIVATE KEY",
+			Headers: map[string]string{
+				CreatedHeader: key.CreationTimestamp.UTC().Format(time.RFC3339),
+			},
+			Bytes: b,
+		}
+
+		data = append(data, pem.EncodeToMemory(&block)...)
 	}
 
-	return accessTokenPrivateKey, refreshTokenPrivateKey, nil
+	return data, nil
 }
@@ -21,6 +21,7 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"log/slog"
 	"os"
 	"testing"
@@ -79,6 +80,31 @@ func TestGetOrGenerateKeys(t *testing.T) {
 		return pem.EncodeToMemory(&block), nil
 	}
 
+	encodeKeyWithHeaders := func(privateKey *ecdsa.PrivateKey, data []byte) ([]byte, error) {
+		actual, _ := pem.Decode(data)
+		if actual == nil {
+			return nil, errors.New("missing pem block")
+		}
+
+		_, has := actual.Headers[jwt.CreatedHeader]
+		if !has {
+			return nil, errors.New("missing created header")
+		}
+
+		b, err := x509.MarshalECPrivateKey(privateKey)
+		if err != nil {
+			return nil, err
+		}
+
+		block := pem.Block{
+			Type:    "EC PRIVATE KEY",
+			Headers: actual.Headers,
+			Bytes:   b,
+		}
+
+		return pem.EncodeToMemory(&block), nil
+	}
+
 	generateKey := func() (*ecdsa.PrivateKey, error) {
 		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 		if err != nil {
@@ -105,18 +131,18 @@ func TestGetOrGenerateKeys(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		accessTokenPEM, err := encodeKey(accessTokenPrivateKey)
+		var actual corev1.Secret
+		err = c.Get(ctx, client.ObjectKey{Name: "dockyards-backend-jwt", Namespace: namespace.Name}, &actual)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		refreshTokenPEM, err := encodeKey(refreshTokenPrivateKey)
+		accessTokenPEM, err := encodeKeyWithHeaders(accessTokenPrivateKey, actual.Data[jwt.AccessTokenPrivateKeyKey])
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		var actual corev1.Secret
-		err = c.Get(ctx, client.ObjectKey{Name: "dockyards-backend-jwt", Namespace: namespace.Name}, &actual)
+		refreshTokenPEM, err := encodeKeyWithHeaders(refreshTokenPrivateKey, actual.Data[jwt.RefreshTokenPrivateKeyKey])
 		if err != nil {
 			t.Fatal(err)
 		}
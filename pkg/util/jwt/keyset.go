@@ -0,0 +1,222 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type Key struct {
+	ID                string
+	CreationTimestamp time.Time
+	PrivateKey        *ecdsa.PrivateKey
+}
+
+// KeySet holds the keys used for access and refresh tokens, the newest active key of each kind is used
+// for signing while every key in the set is accepted for verification.
+type KeySet struct {
+	mu          sync.RWMutex
+	accessKeys  []Key
+	refreshKeys []Key
+}
+
+// JSONWebKey is the public part of a signing key as described in RFC 7517.
+type JSONWebKey struct {
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv"`
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+func (s *KeySet) AccessTokenSigningKey() *ecdsa.PrivateKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.accessKeys) == 0 {
+		return nil
+	}
+
+	return s.accessKeys[signingKeyIndex(s.accessKeys, time.Now())].PrivateKey
+}
+
+func (s *KeySet) RefreshTokenSigningKey() *ecdsa.PrivateKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.refreshKeys) == 0 {
+		return nil
+	}
+
+	return s.refreshKeys[signingKeyIndex(s.refreshKeys, time.Now())].PrivateKey
+}
+
+func (s *KeySet) SignAccessToken(claims jwt.Claims) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return sign(s.accessKeys, claims)
+}
+
+func (s *KeySet) SignRefreshToken(claims jwt.Claims) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return sign(s.refreshKeys, claims)
+}
+
+// AccessTokenKeyfunc is a jwt.Keyfunc verifying access tokens against the keys in the set.
+func (s *KeySet) AccessTokenKeyfunc(token *jwt.Token) (any, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return keyfunc(s.accessKeys, token)
+}
+
+// RefreshTokenKeyfunc is a jwt.Keyfunc verifying refresh tokens against the keys in the set.
+func (s *KeySet) RefreshTokenKeyfunc(token *jwt.Token) (any, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return keyfunc(s.refreshKeys, token)
+}
+
+// AccessTokenJWKS returns the public access token keys, refresh tokens are only ever verified by dockyards
+// itself and their keys are never published.
+func (s *KeySet) AccessTokenJWKS() (*JSONWebKeySet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jwks := JSONWebKeySet{
+		Keys: make([]JSONWebKey, len(s.accessKeys)),
+	}
+
+	for i, key := range s.accessKeys {
+		jwk, err := newJSONWebKey(key.ID, &key.PrivateKey.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		jwks.Keys[i] = *jwk
+	}
+
+	return &jwks, nil
+}
+
+// signingKeyIndex returns the index of the newest key that has been published for at least the activation
+// delay, falling back to the oldest key when every key is newer. The keys must be sorted from newest to
+// oldest.
+func signingKeyIndex(keys []Key, now time.Time) int {
+	for i, key := range keys {
+		if now.Sub(key.CreationTimestamp) >= keyActivationDelay {
+			return i
+		}
+	}
+
+	return len(keys) - 1
+}
+
+func sign(keys []Key, claims jwt.Claims) (string, error) {
+	if len(keys) == 0 {
+		return "", errors.New("no signing key")
+	}
+
+	key := keys[signingKeyIndex(keys, time.Now())]
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.PrivateKey)
+}
+
+func keyfunc(keys []Key, token *jwt.Token) (any, error) {
+	_, ok := token.Method.(*jwt.SigningMethodECDSA)
+	if !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	keyID, has := token.Header["kid"]
+	if has {
+		for _, key := range keys {
+			if key.ID == keyID {
+				return &key.PrivateKey.PublicKey, nil
+			}
+		}
+
+		return nil, fmt.Errorf("unknown key id: %v", keyID)
+	}
+
+	// Tokens signed before key ids were introduced are verified against every key in the set.
+	verificationKeySet := jwt.VerificationKeySet{
+		Keys: make([]jwt.VerificationKey, len(keys)),
+	}
+
+	for i, key := range keys {
+		verificationKeySet.Keys[i] = &key.PrivateKey.PublicKey
+	}
+
+	return verificationKeySet, nil
+}
+
+func newJSONWebKey(keyID string, publicKey *ecdsa.PublicKey) (*JSONWebKey, error) {
+	ecdhPublicKey, err := publicKey.ECDH()
+	if err != nil {
+		return nil, err
+	}
+
+	// The uncompressed point is encoded as 0x04 followed by the x and y coordinates.
+	b := ecdhPublicKey.Bytes()
+	size := (len(b) - 1) / 2
+
+	jwk := JSONWebKey{
+		Algorithm: jwt.SigningMethodES256.Alg(),
+		Curve:     publicKey.Curve.Params().Name,
+		KeyID:     keyID,
+		KeyType:   "EC",
+		Use:       "sig",
+		X:         base64.RawURLEncoding.EncodeToString(b[1 : 1+size]),
+		Y:         base64.RawURLEncoding.EncodeToString(b[1+size:]),
+	}
+
+	return &jwk, nil
+}
+
+// thumbprint returns the RFC 7638 thumbprint of the public key, which is used as key id.
+func thumbprint(publicKey *ecdsa.PublicKey) (string, error) {
+	jwk, err := newJSONWebKey("", publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	s := fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s","y":"%s"}`, jwk.Curve, jwk.KeyType, jwk.X, jwk.Y)
+
+	sum := sha256.Sum256([]byte(s))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,328 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	utiljwt "github.com/sudoswedenab/dockyards-backend/pkg/util/jwt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func mustEncodeKeys(t *testing.T, creationTimestamps ...time.Time) ([]*ecdsa.PrivateKey, []byte) {
+	var keys []*ecdsa.PrivateKey
+	var data []byte
+
+	for _, creationTimestamp := range creationTimestamps {
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := x509.MarshalECPrivateKey(privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		block := pem.Block{
+			Type: "EC PRIVATE KEY",
+			Headers: map[string]string{
+				utiljwt.CreatedHeader: creationTimestamp.UTC().Format(time.RFC3339),
+			},
+			Bytes: b,
+		}
+
+		keys = append(keys, privateKey)
+		data = append(data, pem.EncodeToMemory(&block)...)
+	}
+
+	return keys, data
+}
+
+func TestKeySetSync(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	ctx := context.Background()
+	now := time.Now()
+
+	options := utiljwt.RotationOptions{
+		Interval:    time.Hour * 24,
+		GracePeriod: time.Hour,
+	}
+
+	t.Run("test rotation", func(t *testing.T) {
+		accessKeys, accessKeysPEM := mustEncodeKeys(t, now.Add(-time.Hour*25))
+		refreshKeys, refreshKeysPEM := mustEncodeKeys(t, now.Add(-time.Hour*25))
+
+		secret := corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dockyards-backend-jwt",
+				Namespace: "testing",
+			},
+			Data: map[string][]byte{
+				utiljwt.AccessTokenPrivateKeyKey:  accessKeysPEM,
+				utiljwt.RefreshTokenPrivateKeyKey: refreshKeysPEM,
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&secret).Build()
+
+		var keySet utiljwt.KeySet
+
+		err := keySet.Sync(ctx, c, "testing", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !keySet.AccessTokenSigningKey().Equal(accessKeys[0]) {
+			t.Error("expected access token signing key to be kept until the new key is activated")
+		}
+
+		if !keySet.RefreshTokenSigningKey().Equal(refreshKeys[0]) {
+			t.Error("expected refresh token signing key to be kept until the new key is activated")
+		}
+
+		claims := jwt.RegisteredClaims{
+			Subject:   "test",
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		}
+
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(accessKeys[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = jwt.Parse(signed, keySet.AccessTokenKeyfunc)
+		if err != nil {
+			t.Errorf("expected token signed by rotated key to be valid during grace period, got %s", err)
+		}
+
+		jwks, err := keySet.AccessTokenJWKS()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(jwks.Keys) != 2 {
+			t.Errorf("expected 2 keys, got %d", len(jwks.Keys))
+		}
+
+		var configMap corev1.ConfigMap
+		err = c.Get(ctx, client.ObjectKey{Name: "dockyards-backend-jwt", Namespace: "testing"}, &configMap)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var publicKeys []any
+
+		data := []byte(configMap.Data[utiljwt.AccessTokenPublicKeyKey])
+		for {
+			var block *pem.Block
+
+			block, data = pem.Decode(data)
+			if block == nil {
+				break
+			}
+
+			publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			publicKeys = append(publicKeys, publicKey)
+		}
+
+		if len(publicKeys) != 2 {
+			t.Fatalf("expected 2 published public keys, got %d", len(publicKeys))
+		}
+
+		if !accessKeys[0].PublicKey.Equal(publicKeys[0]) {
+			t.Error("expected signing key to be published first")
+		}
+
+		var expected utiljwt.KeySet
+
+		err = expected.Sync(ctx, c, "testing", utiljwt.RotationOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !expected.AccessTokenSigningKey().Equal(keySet.AccessTokenSigningKey()) {
+			t.Error("expected rotated access token signing key to be stored in secret")
+		}
+	})
+
+	t.Run("test grace period", func(t *testing.T) {
+		accessKeys, accessKeysPEM := mustEncodeKeys(t, now.Add(-time.Hour*2), now.Add(-time.Hour*26))
+		_, refreshKeysPEM := mustEncodeKeys(t, now.Add(-time.Hour*2), now.Add(-time.Hour*26))
+
+		secret := corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dockyards-backend-jwt",
+				Namespace: "testing",
+			},
+			Data: map[string][]byte{
+				utiljwt.AccessTokenPrivateKeyKey:  accessKeysPEM,
+				utiljwt.RefreshTokenPrivateKeyKey: refreshKeysPEM,
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&secret).Build()
+
+		var keySet utiljwt.KeySet
+
+		err := keySet.Sync(ctx, c, "testing", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !keySet.AccessTokenSigningKey().Equal(accessKeys[0]) {
+			t.Error("expected access token signing key to be kept")
+		}
+
+		claims := jwt.RegisteredClaims{
+			Subject:   "test",
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		}
+
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(accessKeys[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = jwt.Parse(signed, keySet.AccessTokenKeyfunc)
+		if err == nil {
+			t.Error("expected token signed by retired key to be invalid")
+		}
+
+		jwks, err := keySet.AccessTokenJWKS()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(jwks.Keys) != 1 {
+			t.Errorf("expected 1 key, got %d", len(jwks.Keys))
+		}
+	})
+
+	t.Run("test activation", func(t *testing.T) {
+		accessKeys, accessKeysPEM := mustEncodeKeys(t, now.Add(-time.Minute), now.Add(-time.Hour*25))
+		_, refreshKeysPEM := mustEncodeKeys(t, now.Add(-time.Minute), now.Add(-time.Hour*25))
+
+		secret := corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dockyards-backend-jwt",
+				Namespace: "testing",
+			},
+			Data: map[string][]byte{
+				utiljwt.AccessTokenPrivateKeyKey:  accessKeysPEM,
+				utiljwt.RefreshTokenPrivateKeyKey: refreshKeysPEM,
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&secret).Build()
+
+		var keySet utiljwt.KeySet
+
+		err := keySet.Sync(ctx, c, "testing", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !keySet.AccessTokenSigningKey().Equal(accessKeys[1]) {
+			t.Error("expected previous key to sign until the new key is activated")
+		}
+
+		claims := jwt.RegisteredClaims{
+			Subject:   "test",
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		}
+
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(accessKeys[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = jwt.Parse(signed, keySet.AccessTokenKeyfunc)
+		if err != nil {
+			t.Errorf("expected token signed by published key to be valid, got %s", err)
+		}
+
+		accessKeys, accessKeysPEM = mustEncodeKeys(t, now.Add(-time.Minute*3), now.Add(-time.Hour*25))
+
+		secret.Data[utiljwt.AccessTokenPrivateKeyKey] = accessKeysPEM
+
+		c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(&secret).Build()
+
+		err = keySet.Sync(ctx, c, "testing", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !keySet.AccessTokenSigningKey().Equal(accessKeys[0]) {
+			t.Error("expected new key to sign once activated")
+		}
+	})
+
+	t.Run("test key id", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+		keySet, err := utiljwt.GetOrGenerateKeySet(ctx, c, "testing")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		claims := jwt.RegisteredClaims{
+			Subject:   "test",
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		}
+
+		signed, err := keySet.SignAccessToken(&claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		token, err := jwt.Parse(signed, keySet.AccessTokenKeyfunc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		jwks, err := keySet.AccessTokenJWKS()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if token.Header["kid"] != jwks.Keys[0].KeyID {
+			t.Errorf("expected key id %s, got %v", jwks.Keys[0].KeyID, token.Header["kid"])
+		}
+
+		_, err = jwt.Parse(signed, keySet.RefreshTokenKeyfunc)
+		if err == nil {
+			t.Error("expected access token to be invalid as refresh token")
+		}
+	})
+}
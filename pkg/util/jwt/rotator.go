@@ -0,0 +1,63 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	syncInterval = time.Minute
+
+	// keyActivationDelay is how long a new key is published before it is used for signing, giving every
+	// replica time to pick it up for verification.
+	keyActivationDelay = 2 * syncInterval
+)
+
+// KeyRotator periodically syncs a key set with the secret, rotating keys according to the options. It runs
+// on every replica so that keys generated by another replica are picked up within the sync interval, which
+// is well before any replica starts signing with them.
+type KeyRotator struct {
+	Client    client.Client
+	Namespace string
+	KeySet    *KeySet
+	Options   RotationOptions
+	Logger    *slog.Logger
+}
+
+func (r *KeyRotator) Start(ctx context.Context) error {
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			err := r.KeySet.Sync(ctx, r.Client, r.Namespace, r.Options)
+			if err != nil {
+				r.Logger.Error("error syncing jwt keys", "err", err)
+			}
+		}
+	}
+}
+
+func (r *KeyRotator) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"time"
+)
+
+// FileProvider delivers messages to a maildir, it is intended for local development and testing.
+type FileProvider struct {
+	Directory string
+}
+
+var _ Provider = &FileProvider{}
+
+func (p *FileProvider) Send(_ context.Context, message *Message) (string, error) {
+	for _, subdirectory := range []string{"tmp", "new", "cur"} {
+		err := os.MkdirAll(path.Join(p.Directory, subdirectory), 0o700)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	b, _, err := message.Bytes()
+	if err != nil {
+		return "", err
+	}
+
+	random := make([]byte, 8)
+
+	_, err = rand.Read(random)
+	if err != nil {
+		return "", err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+
+	name := fmt.Sprintf("%d.%s.%s", time.Now().UnixNano(), hex.EncodeToString(random), hostname)
+
+	// Messages are written to tmp and then moved to new to never expose partially written messages to readers.
+	tmp := path.Join(p.Directory, "tmp", name)
+
+	err = os.WriteFile(tmp, b, 0o600)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.Rename(tmp, path.Join(p.Directory, "new", name))
+	if err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mailer_test
+
+import (
+	"bytes"
+	"net/mail"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/sudoswedenab/dockyards-backend/pkg/util/mailer"
+)
+
+func TestFileProvider(t *testing.T) {
+	provider := mailer.FileProvider{
+		Directory: t.TempDir(),
+	}
+
+	message := mailer.Message{
+		From:     "noreply@dockyards.dev",
+		To:       "test@dockyards.dev",
+		Subject:  "Test",
+		BodyText: "test",
+	}
+
+	name, err := provider.Send(t.Context(), &message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path.Join(provider.Directory, "new", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := mail.ReadMessage(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual.Header.Get("To") != "<test@dockyards.dev>" {
+		t.Errorf("expected recipient <test@dockyards.dev>, got %s", actual.Header.Get("To"))
+	}
+
+	entries, err := os.ReadDir(path.Join(provider.Directory, "tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 0 {
+		t.Errorf("expected empty tmp directory, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mailer delivers email messages using pluggable providers.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message is an email message with a plain text body and an optional HTML alternative.
+type Message struct {
+	From     string
+	To       string
+	Subject  string
+	BodyText string
+	BodyHTML string
+}
+
+// Provider delivers messages, the returned identifier references the delivered message within the provider.
+type Provider interface {
+	Send(ctx context.Context, message *Message) (string, error)
+}
+
+// Bytes returns the message formatted as described in RFC 5322 together with the generated message id.
+func (m *Message) Bytes() ([]byte, string, error) {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, "", err
+	}
+
+	to, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return nil, "", err
+	}
+
+	messageID, err := generateMessageID(from.Address)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var buf bytes.Buffer
+
+	headers := []struct {
+		key   string
+		value string
+	}{
+		{key: "From", value: from.String()},
+		{key: "To", value: to.String()},
+		{key: "Subject", value: mime.QEncoding.Encode("utf-8", m.Subject)},
+		{key: "Date", value: time.Now().Format(time.RFC1123Z)},
+		{key: "Message-ID", value: messageID},
+		{key: "MIME-Version", value: "1.0"},
+	}
+
+	for _, header := range headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", header.key, header.value)
+	}
+
+	if m.BodyHTML == "" {
+		fmt.Fprintf(&buf, "Content-Type: text/plain; charset=utf-8\r\n")
+		fmt.Fprintf(&buf, "Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+		err := writeQuotedPrintable(&buf, m.BodyText)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return buf.Bytes(), messageID, nil
+	}
+
+	writer := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{contentType: "text/plain; charset=utf-8", body: m.BodyText},
+		{contentType: "text/html; charset=utf-8", body: m.BodyHTML},
+	}
+
+	for _, part := range parts {
+		header := textproto.MIMEHeader{
+			"Content-Type":              []string{part.contentType},
+			"Content-Transfer-Encoding": []string{"quoted-printable"},
+		}
+
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+
+		err = writeQuotedPrintable(w, part.body)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), messageID, nil
+}
+
+func writeQuotedPrintable(w io.Writer, s string) error {
+	qp := quotedprintable.NewWriter(w)
+
+	_, err := qp.Write([]byte(s))
+	if err != nil {
+		return err
+	}
+
+	return qp.Close()
+}
+
+func generateMessageID(address string) (string, error) {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	_, domain, found := strings.Cut(address, "@")
+	if !found {
+		domain = "localhost"
+	}
+
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">", nil
+}
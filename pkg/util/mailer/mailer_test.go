@@ -0,0 +1,141 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mailer_test
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/sudoswedenab/dockyards-backend/pkg/util/mailer"
+)
+
+func TestMessageBytes(t *testing.T) {
+	t.Run("test text", func(t *testing.T) {
+		message := mailer.Message{
+			From:     "Dockyards <noreply@dockyards.dev>",
+			To:       "test@dockyards.dev",
+			Subject:  "Välkommen",
+			BodyText: "Here is your code: abc-def.\nBye.",
+		}
+
+		b, messageID, err := message.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := mail.ReadMessage(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual.Header.Get("Message-ID") != messageID {
+			t.Errorf("expected message id %s, got %s", messageID, actual.Header.Get("Message-ID"))
+		}
+
+		if !strings.HasSuffix(messageID, "@dockyards.dev>") {
+			t.Errorf("expected message id with sender domain, got %s", messageID)
+		}
+
+		subject, err := new(mime.WordDecoder).DecodeHeader(actual.Header.Get("Subject"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if subject != message.Subject {
+			t.Errorf("expected subject %s, got %s", message.Subject, subject)
+		}
+
+		body, err := io.ReadAll(quotedprintable.NewReader(actual.Body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "Here is your code: abc-def.\r\nBye."
+		if string(body) != expected {
+			t.Errorf("expected body %q, got %q", expected, string(body))
+		}
+	})
+
+	t.Run("test html", func(t *testing.T) {
+		message := mailer.Message{
+			From:     "noreply@dockyards.dev",
+			To:       "test@dockyards.dev",
+			Subject:  "Test",
+			BodyText: "text",
+			BodyHTML: "<p>html</p>",
+		}
+
+		b, _, err := message.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := mail.ReadMessage(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mediaType, params, err := mime.ParseMediaType(actual.Header.Get("Content-Type"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if mediaType != "multipart/alternative" {
+			t.Fatalf("expected media type multipart/alternative, got %s", mediaType)
+		}
+
+		reader := multipart.NewReader(actual.Body, params["boundary"])
+
+		expected := []string{
+			"text",
+			"<p>html</p>",
+		}
+
+		for _, e := range expected {
+			part, err := reader.NextPart()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The multipart reader decodes quoted-printable parts transparently.
+			body, err := io.ReadAll(part)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(body) != e {
+				t.Errorf("expected part %q, got %q", e, string(body))
+			}
+		}
+	})
+
+	t.Run("test invalid recipient", func(t *testing.T) {
+		message := mailer.Message{
+			From: "noreply@dockyards.dev",
+			To:   "invalid",
+		}
+
+		_, _, err := message.Bytes()
+		if err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
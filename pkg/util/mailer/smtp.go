@@ -0,0 +1,154 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// SMTPProvider delivers messages to an SMTP server, STARTTLS is used when supported by the server.
+type SMTPProvider struct {
+	// Address is the host and port of the server.
+	Address  string
+	Username string
+	Password string
+}
+
+var _ Provider = &SMTPProvider{}
+
+func (p *SMTPProvider) Send(ctx context.Context, message *Message) (string, error) {
+	host, _, err := net.SplitHostPort(p.Address)
+	if err != nil {
+		return "", err
+	}
+
+	var auth smtp.Auth
+	if p.Username != "" {
+		auth = smtp.PlainAuth("", p.Username, p.Password, host)
+	}
+
+	from, err := mail.ParseAddress(message.From)
+	if err != nil {
+		return "", err
+	}
+
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return "", err
+	}
+
+	b, messageID, err := message.Bytes()
+	if err != nil {
+		return "", err
+	}
+
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", p.Address)
+	if err != nil {
+		return "", err
+	}
+
+	defer conn.Close()
+
+	deadline, hasDeadline := ctx.Deadline()
+	if hasDeadline {
+		err := conn.SetDeadline(deadline)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Cancelling the context interrupts any pending read or write on the connection.
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Unix(1, 0))
+	})
+
+	defer stop()
+
+	err = p.send(conn, host, auth, from.Address, to.Address, b)
+	if err != nil {
+		return "", errors.Join(err, ctx.Err())
+	}
+
+	return messageID, nil
+}
+
+// send delivers the message on the connection like smtp.SendMail.
+func (p *SMTPProvider) send(conn net.Conn, host string, auth smtp.Auth, from, to string, b []byte) error {
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+
+	defer c.Close()
+
+	hasStartTLS, _ := c.Extension("STARTTLS")
+	if hasStartTLS {
+		tlsConfig := tls.Config{
+			ServerName: host,
+		}
+
+		err := c.StartTLS(&tlsConfig)
+		if err != nil {
+			return err
+		}
+	}
+
+	if auth != nil {
+		hasAuth, _ := c.Extension("AUTH")
+		if !hasAuth {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+
+		err := c.Auth(auth)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.Mail(from)
+	if err != nil {
+		return err
+	}
+
+	err = c.Rcpt(to)
+	if err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	if err != nil {
+		return err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mailer_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sudoswedenab/dockyards-backend/pkg/util/mailer"
+)
+
+// serveSMTP accepts a single connection and answers with just enough of RFC 5321 to receive one message.
+func serveSMTP(t *testing.T, listener net.Listener, received chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Error(err)
+
+		return
+	}
+
+	defer conn.Close()
+
+	text := textproto.NewConn(conn)
+
+	_ = text.PrintfLine("220 localhost ESMTP")
+
+	var data strings.Builder
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		command, _, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(command) {
+		case "EHLO", "HELO":
+			_ = text.PrintfLine("250 localhost")
+		case "MAIL", "RCPT":
+			_ = text.PrintfLine("250 OK")
+		case "DATA":
+			_ = text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+
+			lines, err := text.ReadDotLines()
+			if err != nil {
+				return
+			}
+
+			data.WriteString(strings.Join(lines, "\n"))
+
+			_ = text.PrintfLine("250 OK")
+		case "QUIT":
+			_ = text.PrintfLine("221 Bye")
+			received <- data.String()
+
+			return
+		default:
+			_ = text.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPProvider(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer listener.Close()
+
+	received := make(chan string, 1)
+
+	go serveSMTP(t, listener, received)
+
+	provider := mailer.SMTPProvider{
+		Address: listener.Addr().String(),
+	}
+
+	message := mailer.Message{
+		From:     "noreply@dockyards.dev",
+		To:       "test@dockyards.dev",
+		Subject:  "Test",
+		BodyText: "test",
+	}
+
+	messageID, err := provider.Send(t.Context(), &message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := <-received
+
+	reader := textproto.NewReader(bufio.NewReader(strings.NewReader(actual + "\n")))
+
+	header, err := reader.ReadMIMEHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if header.Get("Message-ID") != messageID {
+		t.Errorf("expected message id %s, got %s", messageID, header.Get("Message-ID"))
+	}
+}
+
+func TestSMTPProviderContext(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer listener.Close()
+
+	// The server accepts the connection but never greets the client.
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		_, _ = io.Copy(io.Discard, conn)
+	}()
+
+	provider := mailer.SMTPProvider{
+		Address: listener.Addr().String(),
+	}
+
+	message := mailer.Message{
+		From:     "noreply@dockyards.dev",
+		To:       "test@dockyards.dev",
+		Subject:  "Test",
+		BodyText: "test",
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Millisecond*100)
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		_, err := provider.Send(ctx, &message)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected send to return when the context is done")
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mailer
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+// Keys of the templates in the data of the templates config map.
+const (
+	TemplateKeySubject  = "subject"
+	TemplateKeyBodyText = "bodyText"
+	TemplateKeyBodyHTML = "bodyHTML"
+)
+
+// TemplateData is the data available when rendering templates, the subject and bodies are the unrendered values
+// of the verification request.
+type TemplateData struct {
+	EnvironmentName string
+	ExternalURL     string
+	Recipient       string
+	Code            string
+	Subject         string
+	BodyText        string
+	BodyHTML        htmltemplate.HTML
+}
+
+// Render returns a message with the subject and bodies rendered from the templates, the unrendered values are used
+// when there is no template for a key.
+func Render(templates map[string]string, data *TemplateData) (*Message, error) {
+	message := Message{
+		To:       data.Recipient,
+		Subject:  data.Subject,
+		BodyText: data.BodyText,
+		BodyHTML: string(data.BodyHTML),
+	}
+
+	text := []struct {
+		key    string
+		output *string
+	}{
+		{key: TemplateKeySubject, output: &message.Subject},
+		{key: TemplateKeyBodyText, output: &message.BodyText},
+	}
+
+	for _, t := range text {
+		s, has := templates[t.key]
+		if !has {
+			continue
+		}
+
+		tmpl, err := texttemplate.New(t.key).Parse(s)
+		if err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+
+		err = tmpl.Execute(&buf, data)
+		if err != nil {
+			return nil, err
+		}
+
+		*t.output = buf.String()
+	}
+
+	s, has := templates[TemplateKeyBodyHTML]
+	if has {
+		tmpl, err := htmltemplate.New(TemplateKeyBodyHTML).Parse(s)
+		if err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+
+		err = tmpl.Execute(&buf, data)
+		if err != nil {
+			return nil, err
+		}
+
+		message.BodyHTML = buf.String()
+	}
+
+	return &message, nil
+}
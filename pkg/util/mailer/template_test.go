@@ -0,0 +1,86 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mailer_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sudoswedenab/dockyards-backend/pkg/util/mailer"
+)
+
+func TestRender(t *testing.T) {
+	data := mailer.TemplateData{
+		EnvironmentName: "staging",
+		ExternalURL:     "https://dockyards.dev",
+		Recipient:       "test@dockyards.dev",
+		Code:            "abc-def",
+		Subject:         "Email Verification",
+		BodyText:        "Here is your code: abc-def.",
+	}
+
+	testCases := []struct {
+		name      string
+		templates map[string]string
+		expected  mailer.Message
+	}{
+		{
+			name: "test without templates",
+			expected: mailer.Message{
+				To:       "test@dockyards.dev",
+				Subject:  "Email Verification",
+				BodyText: "Here is your code: abc-def.",
+			},
+		},
+		{
+			name: "test branding",
+			templates: map[string]string{
+				mailer.TemplateKeySubject:  "[{{ .EnvironmentName }}] {{ .Subject }}",
+				mailer.TemplateKeyBodyText: "{{ .BodyText }}\n\n{{ .ExternalURL }}",
+				mailer.TemplateKeyBodyHTML: "<p>{{ .BodyText }}</p><a href=\"{{ .ExternalURL }}\">{{ .EnvironmentName }}</a>",
+			},
+			expected: mailer.Message{
+				To:       "test@dockyards.dev",
+				Subject:  "[staging] Email Verification",
+				BodyText: "Here is your code: abc-def.\n\nhttps://dockyards.dev",
+				BodyHTML: "<p>Here is your code: abc-def.</p><a href=\"https://dockyards.dev\">staging</a>",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := mailer.Render(tc.templates, &data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !cmp.Equal(*actual, tc.expected) {
+				t.Errorf("diff: %s", cmp.Diff(tc.expected, *actual))
+			}
+		})
+	}
+
+	t.Run("test invalid template", func(t *testing.T) {
+		templates := map[string]string{
+			mailer.TemplateKeySubject: "{{ .Subject",
+		}
+
+		_, err := mailer.Render(templates, &data)
+		if err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
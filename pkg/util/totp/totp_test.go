@@ -0,0 +1,120 @@
+// Copyright 2025 Sudo Sweden AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package totp_test
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/sudoswedenab/dockyards-backend/pkg/util/totp"
+)
+
+func TestGenerateCode(t *testing.T) {
+	// Test vectors from RFC 6238 appendix B, truncated to six digits.
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	testCases := []struct {
+		name     string
+		time     time.Time
+		expected string
+	}{
+		{
+			name:     "test 59",
+			time:     time.Unix(59, 0),
+			expected: "287082",
+		},
+		{
+			name:     "test 1111111109",
+			time:     time.Unix(1111111109, 0),
+			expected: "081804",
+		},
+		{
+			name:     "test 1234567890",
+			time:     time.Unix(1234567890, 0),
+			expected: "005924",
+		},
+		{
+			name:     "test 20000000000",
+			time:     time.Unix(20000000000, 0),
+			expected: "353130",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := totp.GenerateCode(secret, totp.Counter(tc.time))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual != tc.expected {
+				t.Errorf("expected code %s, got %s", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret := totp.GenerateSecret()
+	now := time.Now()
+
+	t.Run("test current code", func(t *testing.T) {
+		code, err := totp.GenerateCode(secret, totp.Counter(now))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		counter, ok := totp.Validate(secret, code, now)
+		if !ok {
+			t.Fatal("expected code to be valid")
+		}
+
+		if counter != totp.Counter(now) {
+			t.Errorf("expected counter %d, got %d", totp.Counter(now), counter)
+		}
+	})
+
+	t.Run("test previous code", func(t *testing.T) {
+		code, err := totp.GenerateCode(secret, totp.Counter(now.Add(-totp.Period)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, ok := totp.Validate(secret, code, now)
+		if !ok {
+			t.Error("expected code within skew to be valid")
+		}
+	})
+
+	t.Run("test old code", func(t *testing.T) {
+		code, err := totp.GenerateCode(secret, totp.Counter(now.Add(-totp.Period*5)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, ok := totp.Validate(secret, code, now)
+		if ok {
+			t.Error("expected code outside skew to be invalid")
+		}
+	})
+
+	t.Run("test malformed code", func(t *testing.T) {
+		_, ok := totp.Validate(secret, "12345", now)
+		if ok {
+			t.Error("expected malformed code to be invalid")
+		}
+	})
+}